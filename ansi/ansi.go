@@ -0,0 +1,86 @@
+// Package ansi styles fragments of log messages and attribute values with
+// ANSI escape sequences, so that applications need not write raw escape
+// codes, which would leak into log files:
+//
+//	logger.Info("deployed " + ansi.Bold(version))
+//	logger.Info("checked", ansi.String("status", ansi.Color256(2, "passing")))
+//
+// The [l4g.SimpleHandler] writes the styles when it colors its output and
+// removes them otherwise; the [l4g.JSONHandler] always removes them from
+// the message and from the attributes built with [String].
+package ansi
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"go-slim.dev/l4g"
+)
+
+// reset ends the styles of a fragment.
+const reset = "\x1b[0m"
+
+// Color256 returns s in the given color of the 256-color palette, as
+// described in [l4g.ColorAttr].
+func Color256(color uint8, s string) string {
+	return "\x1b[38;5;" + strconv.Itoa(int(color)) + "m" + s + reset
+}
+
+// RGB returns s in the given 24-bit color.
+func RGB(r, g, b uint8, s string) string {
+	return "\x1b[38;2;" + strconv.Itoa(int(r)) + ";" + strconv.Itoa(int(g)) + ";" + strconv.Itoa(int(b)) + "m" + s + reset
+}
+
+// Faint returns s dimmed.
+func Faint(s string) string {
+	return "\x1b[2m" + s + reset
+}
+
+// Bold returns s in bold.
+func Bold(s string) string {
+	return "\x1b[1m" + s + reset
+}
+
+// Strip returns s without its ANSI escape sequences.
+func Strip(s string) string {
+	i := strings.IndexByte(s, '\x1b')
+	if i < 0 {
+		return s
+	}
+	var b strings.Builder
+	for ; i >= 0; i = strings.IndexByte(s, '\x1b') {
+		b.WriteString(s[:i])
+		s = s[i+1:]
+		if strings.HasPrefix(s, "[") {
+			j := 1
+			for j < len(s) && (s[j] < 0x40 || s[j] > 0x7e) {
+				j++
+			}
+			s = s[min(j+1, len(s)):]
+		}
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// Text is a string styled with ANSI escape sequences. Its LogValue is the
+// string without them, so that handlers other than a coloring
+// SimpleHandler write it unstyled.
+type Text string
+
+// LogValue implements [slog.LogValuer], returning the text unstyled.
+func (t Text) LogValue() slog.Value {
+	return slog.StringValue(Strip(string(t)))
+}
+
+// ANSIString returns the styled text.
+func (t Text) ANSIString() string {
+	return string(t)
+}
+
+// String returns an [l4g.Attr] for the styled string s, written with its
+// styles only by a SimpleHandler coloring its output.
+func String(key, s string) l4g.Attr {
+	return l4g.Any(key, Text(s))
+}
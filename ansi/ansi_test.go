@@ -0,0 +1,54 @@
+package ansi
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go-slim.dev/l4g"
+)
+
+func TestStyles(t *testing.T) {
+	tests := []struct {
+		got, want string
+	}{
+		{Color256(2, "ok"), "\x1b[38;5;2mok\x1b[0m"},
+		{RGB(255, 0, 10, "hot"), "\x1b[38;2;255;0;10mhot\x1b[0m"},
+		{Faint("dim"), "\x1b[2mdim\x1b[0m"},
+		{Bold("big"), "\x1b[1mbig\x1b[0m"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("got %q, want %q", tt.got, tt.want)
+		}
+		if got := Strip("a " + tt.got + " b"); strings.Contains(got, "\x1b") || !strings.HasPrefix(got, "a ") || !strings.HasSuffix(got, " b") {
+			t.Errorf("Strip(%q) = %q", tt.got, got)
+		}
+	}
+	if got := Strip("plain"); got != "plain" {
+		t.Errorf("Strip(plain) = %q", got)
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	msg := "deployed " + Bold("v1.2")
+	attr := String("status", Color256(2, "passing"))
+
+	var buf bytes.Buffer
+	l4g.New(l4g.Options{Output: &buf}).Info(msg, attr)
+	if got := buf.String(); !strings.Contains(got, "deployed \x1b[1mv1.2\x1b[0m") || !strings.Contains(got, "\x1b[38;5;2mpassing\x1b[0m") {
+		t.Errorf("colored output = %q, want the styles", got)
+	}
+
+	for _, opts := range []l4g.Options{
+		{Output: &buf, NoColor: true},
+		{Output: &buf, NewHandlerFunc: l4g.NewJSONHandler},
+	} {
+		buf.Reset()
+		l4g.New(opts).Info(msg, attr)
+		got := buf.String()
+		if strings.Contains(got, "\x1b") || strings.Contains(got, `\u001b`) || !strings.Contains(got, "deployed v1.2") || !strings.Contains(got, "passing") {
+			t.Errorf("uncolored output = %q, want no escape codes", got)
+		}
+	}
+}
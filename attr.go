@@ -71,6 +71,7 @@ func Any(key string, value any) Attr {
 type colorValue struct {
 	slog.Value
 	Color uint8
+	Tone  Tone // if set, overrides Color with the color of the tone
 }
 
 // LogValue implements the [slog.LogValuer] interface.
@@ -91,18 +92,156 @@ func (v colorValue) LogValue() slog.Value {
 //
 // See https://en.wikipedia.org/wiki/ANSI_escape_code#8-bit
 func ColorAttr(color uint8, attr Attr) Attr {
-	attr.Value = slog.AnyValue(colorValue{attr.Value, color})
+	attr.Value = slog.AnyValue(colorValue{Value: attr.Value, Color: color})
 	return attr
 }
 
+// A Tone is the semantic role of an attribute emphasized with [Highlight],
+// [Warning] or [OK], written by the [SimpleHandler] in the color given to
+// the tone by [HandlerOptions.ToneColors].
+type Tone uint8
+
+// Tones of the attributes.
+const (
+	// ToneHighlight draws attention to a value. Default color: 13 (bright magenta).
+	ToneHighlight Tone = iota + 1
+	// ToneWarning marks a worrying value. Default color: 11 (bright yellow).
+	ToneWarning
+	// ToneOK marks a healthy value. Default color: 10 (bright green).
+	ToneOK
+)
+
+// toneColor returns the default color of t.
+func toneColor(t Tone) uint8 {
+	switch t {
+	case ToneWarning:
+		return 11
+	case ToneOK:
+		return 10
+	default:
+		return 13
+	}
+}
+
+// ToneAttr returns attr written in the color of tone by the [SimpleHandler].
+// With any other [Handler], it behaves as a plain [Attr].
+func ToneAttr(tone Tone, attr Attr) Attr {
+	attr.Value = slog.AnyValue(colorValue{Value: attr.Value, Tone: tone})
+	return attr
+}
+
+// Highlight returns attr emphasized with [ToneHighlight], as in
+//
+//	l.Info("deployed", l4g.Highlight(l4g.String("version", v)))
+func Highlight(attr Attr) Attr {
+	return ToneAttr(ToneHighlight, attr)
+}
+
+// Warning returns attr marked with [ToneWarning], such as a disk usage
+// close to its limit.
+func Warning(attr Attr) Attr {
+	return ToneAttr(ToneWarning, attr)
+}
+
+// OK returns attr marked with [ToneOK], such as a passing health check.
+func OK(attr Attr) Attr {
+	return ToneAttr(ToneOK, attr)
+}
+
 // Err returns a tinted (colorized) [Attr] that will be written in red color
 // by the [Handler]. When used with any other [Handler], it behaves as
 //
 //	Any("error", err)
+//
+// With [HandlerOptions.ErrorTree], errors wrapping other errors, such as
+// those of errors.Join, are written as a group holding their message and
+// their causes.
 func Err(err error) Attr {
 	return ColorAttr(9, Any(errorKey, err))
 }
 
+// Keys of the members of the group an error is rendered as when
+// [HandlerOptions.ErrorTree] is set.
+const (
+	// ErrorMsgKey is the key of the message of the error.
+	ErrorMsgKey = "msg"
+	// ErrorCausesKey is the key of the list of the errors it wraps,
+	// each rendered as an object holding its message and its causes.
+	ErrorCausesKey = "causes"
+)
+
+// errorNode is an error of a tree rendered by errorTreeValue.
+type errorNode struct {
+	Msg    string      `json:"msg"`
+	Causes []errorNode `json:"causes,omitempty"`
+}
+
+// String returns the message of the error.
+func (n errorNode) String() string { return n.Msg }
+
+// newErrorNode returns the tree of the errors wrapped by err, as reported
+// by their Unwrap methods.
+func newErrorNode(err error) errorNode {
+	n := errorNode{Msg: err.Error()}
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		if cause := u.Unwrap(); cause != nil {
+			n.Causes = []errorNode{newErrorNode(cause)}
+		}
+	case interface{ Unwrap() []error }:
+		for _, cause := range u.Unwrap() {
+			if cause != nil {
+				n.Causes = append(n.Causes, newErrorNode(cause))
+			}
+		}
+	}
+	return n
+}
+
+// errorTreeValue returns v, if it holds an error wrapping other errors, as
+// a group holding the message of the error and the tree of its causes.
+// Other values are returned unchanged.
+func errorTreeValue(v slog.Value) slog.Value {
+	if v.Kind() != slog.KindAny {
+		return v
+	}
+	err, ok := v.Any().(error)
+	if !ok {
+		return v
+	}
+	n := newErrorNode(err)
+	if len(n.Causes) == 0 {
+		return v
+	}
+	return slog.GroupValue(String(ErrorMsgKey, n.Msg), Any(ErrorCausesKey, n.Causes))
+}
+
+// levelOverride is the value of the attribute returned by OverrideLevel.
+type levelOverride Level
+
+// levelOverrideKey is the key of the attribute returned by OverrideLevel.
+const levelOverrideKey = "!LEVEL"
+
+// OverrideLevel returns an [Attr] that makes the built-in handlers render
+// the record carrying it at the given level, instead of the level it was
+// logged at. The attribute itself is not written.
+//
+// It is intended for adapters that receive the severity as data: the
+// logger still decides whether the record is enabled using the level of
+// the log call.
+func OverrideLevel(level Level) Attr {
+	return slog.Any(levelOverrideKey, levelOverride(level))
+}
+
+// isLevelOverride reports whether v is the value of an OverrideLevel attribute.
+func isLevelOverride(v slog.Value) bool {
+	if v.Kind() != slog.KindAny {
+		return false
+	}
+	_, ok := v.Any().(levelOverride)
+	return ok
+}
+
 func argsToAttrSlice(args []any) []Attr {
 	if len(args) == 0 {
 		return nil
@@ -120,22 +259,27 @@ func argsToAttrSlice(args []any) []Attr {
 	return attrs
 }
 
-func splitAttrs(args []any) ([]Attr, []any) {
-	if len(args) == 0 {
-		return nil, nil
+// nonAttrs returns the values of args that are not Attrs. It returns
+// args itself when it holds no Attr, so as not to allocate in the common
+// case of a format with plain arguments.
+func nonAttrs(args []any) []any {
+	n := 0
+	for _, arg := range args {
+		if _, ok := arg.(Attr); ok {
+			n++
+		}
 	}
-	// Pre-allocate with full capacity to avoid reallocation
-	// In worst case, all items go into one slice
-	attrs := make([]Attr, 0, len(args))
-	remaining := make([]any, 0, len(args))
-
+	switch n {
+	case 0:
+		return args
+	case len(args):
+		return nil
+	}
+	anies := make([]any, 0, len(args)-n)
 	for _, arg := range args {
-		if attr, ok := arg.(Attr); ok {
-			attrs = append(attrs, attr)
-		} else {
-			remaining = append(remaining, arg)
+		if _, ok := arg.(Attr); !ok {
+			anies = append(anies, arg)
 		}
 	}
-
-	return attrs, remaining
+	return anies
 }
@@ -1,8 +1,10 @@
 package l4g
 
 import (
+	"bytes"
 	"errors"
 	"log/slog"
+	"strings"
 	"testing"
 	"time"
 )
@@ -359,27 +361,28 @@ func TestArgsToAttrSlice(t *testing.T) {
 	}
 }
 
-func TestSplitAttrs(t *testing.T) {
+func TestNonAttrs(t *testing.T) {
 	tests := []struct {
 		name      string
 		args      []any
-		wantAttrs int
 		wantAnies int
 	}{
-		{"empty", []any{}, 0, 0},
-		{"only attrs", []any{String("a", "1"), Int("b", 2)}, 2, 0},
-		{"only anies", []any{"a", 1, 2.5}, 0, 3},
-		{"mixed", []any{String("x", "y"), "a", 1, Int("z", 3)}, 2, 2},
+		{"empty", []any{}, 0},
+		{"only attrs", []any{String("a", "1"), Int("b", 2)}, 0},
+		{"only anies", []any{"a", 1, 2.5}, 3},
+		{"mixed", []any{String("x", "y"), "a", 1, Int("z", 3)}, 2},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			attrs, anies := splitAttrs(tt.args)
-			if len(attrs) != tt.wantAttrs {
-				t.Errorf("splitAttrs() attrs length = %v, want %v", len(attrs), tt.wantAttrs)
-			}
+			anies := nonAttrs(tt.args)
 			if len(anies) != tt.wantAnies {
-				t.Errorf("splitAttrs() anies length = %v, want %v", len(anies), tt.wantAnies)
+				t.Errorf("nonAttrs() length = %v, want %v", len(anies), tt.wantAnies)
+			}
+			for _, a := range anies {
+				if _, ok := a.(Attr); ok {
+					t.Errorf("nonAttrs() kept Attr %v", a)
+				}
 			}
 		})
 	}
@@ -471,3 +474,25 @@ type customError struct {
 func (e *customError) Error() string {
 	return e.msg
 }
+
+func TestOverrideLevel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true})
+
+	logger.Info("from adapter", OverrideLevel(LevelError), String("k", "v"))
+	output := buf.String()
+
+	if !strings.Contains(output, "ERROR from adapter k=v") {
+		t.Errorf("output = %q, want the record rendered at ERROR", output)
+	}
+	if strings.Contains(output, levelOverrideKey) {
+		t.Errorf("output = %q, the override attribute should not be written", output)
+	}
+
+	buf.Reset()
+	jl := New(Options{Output: buf, NewHandlerFunc: NewJSONHandler})
+	jl.Info("from adapter", OverrideLevel(LevelWarn))
+	if !strings.Contains(buf.String(), `"level":"WARN","msg":"from adapter"}`) {
+		t.Errorf("JSON output = %q, want the record rendered at WARN", buf.String())
+	}
+}
@@ -0,0 +1,54 @@
+package l4g
+
+import (
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// AppKey is the key of the attribute naming the application in the
+// records written by [Banner].
+const AppKey = "app"
+
+// Banner logs with l, at LevelInfo, a "starting" record holding the name
+// of the application, highlighted, its version and VCS revision from the
+// build information, the Go version, the process ID and attrs, such as a
+// summary of the configuration and the listen addresses, so that every
+// service prints the same record at boot. If l is nil, the default logger
+// is used.
+//
+// It returns a function logging the matching "stopped" record with the
+// uptime, once however many times it is called, typically deferred:
+//
+//	defer l4g.Banner(logger, "billing", l4g.String("listen", addr))()
+func Banner(l *Logger, app string, attrs ...Attr) (stop func()) {
+	if l == nil {
+		l = Default()
+	}
+	start := time.Now()
+	args := []any{Highlight(String(AppKey, app))}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if bi.Main.Version != "" {
+			args = append(args, String("version", bi.Main.Version))
+		}
+		for _, s := range bi.Settings {
+			if s.Key == "vcs.revision" {
+				args = append(args, String("revision", s.Value[:min(len(s.Value), 12)]))
+			}
+		}
+		args = append(args, String("go", bi.GoVersion))
+	}
+	args = append(args, Int("pid", os.Getpid()))
+	for _, a := range attrs {
+		args = append(args, a)
+	}
+	l.Info("starting", args...)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.Info("stopped", Highlight(String(AppKey, app)), Duration("uptime", time.Since(start).Round(time.Millisecond)))
+		})
+	}
+}
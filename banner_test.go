@@ -0,0 +1,28 @@
+package l4g
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBanner(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true})
+
+	stop := Banner(logger, "billing", String("listen", ":8080"))
+	got := buf.String()
+	for _, want := range []string{"INFO starting app=billing", " go=go", " pid=", " listen=:8080"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("startup record = %q, want %s", got, want)
+		}
+	}
+
+	buf.Reset()
+	stop()
+	stop()
+	got = buf.String()
+	if !strings.Contains(got, "INFO stopped app=billing uptime=") || strings.Count(got, "stopped") != 1 {
+		t.Errorf("shutdown record = %q, want a single stopped record with the uptime", got)
+	}
+}
@@ -0,0 +1,123 @@
+package l4g
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+)
+
+// BootstrapOptions are options for [Bootstrap].
+type BootstrapOptions struct {
+	// Options configure the logger, except for the sink chosen by
+	// Bootstrap, which is not chosen if Handler or NewHandlerFunc is set.
+	// Output, if set, replaces the standard output or error.
+	Options Options
+
+	// Journal configures the JournalHandler used under systemd. Its Level
+	// is that of the logger.
+	Journal JournalOptions
+
+	// EventSource is the source of the events reported to the Event Log
+	// when running as a Windows service (Default: the base name of the
+	// executable, without extension).
+	EventSource string
+}
+
+// stderrIsTerminal reports whether the standard error is a terminal.
+// It is a variable for testing.
+var stderrIsTerminal = func() bool {
+	fi, err := os.Stderr.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// runningAsService reports whether the process runs as a Windows service.
+// It is a variable for testing.
+var runningAsService = isWindowsService
+
+// Bootstrap returns a logger writing where the program runs, so that one
+// call sets up production-correct logging:
+//
+//   - to the Windows Event Log with an [EventLogHandler], if the process
+//     runs as a Windows service;
+//   - to the systemd journal with a [JournalHandler], if the standard
+//     output or error is connected to it (JOURNAL_STREAM is set);
+//   - as colored text to the standard error if it is a terminal, without
+//     colors if NO_COLOR is set;
+//   - otherwise as JSON to the standard output.
+//
+// It also returns a function to call before the program exits, which
+// flushes the logger, waiting at most its FlushTimeout, and closes the
+// journal connection or deregisters the event source, once however many
+// times it is called:
+//
+//	logger, cleanup := l4g.Bootstrap(l4g.BootstrapOptions{})
+//	defer cleanup()
+//	l4g.SetDefault(logger)
+func Bootstrap(opts BootstrapOptions) (logger *Logger, cleanup func()) {
+	o := opts.Options
+	var journal *JournalHandler
+	var eventLog *EventLogHandler
+	if o.Handler == nil && o.NewHandlerFunc == nil {
+		if runningAsService() {
+			h, err := NewEventLogHandler(EventLogOptions{Source: opts.EventSource})
+			if err != nil {
+				FallbackErrorf("unable to register the event source: %v", err)
+			} else {
+				// The handler is created by the logger, so that it gets
+				// the level and the format options of the logger.
+				eventLog = h
+				o.NewHandlerFunc = func(hopts HandlerOptions) Handler {
+					return newEventLogHandler(hopts, h.source)
+				}
+			}
+		} else if os.Getenv("JOURNAL_STREAM") != "" {
+			h, err := NewJournalHandler(opts.Journal)
+			if err != nil {
+				FallbackErrorf("unable to connect to the journal: %v", err)
+			} else {
+				journal = h
+				o.Handler = h
+			}
+		}
+		switch {
+		case journal != nil, eventLog != nil:
+		case stderrIsTerminal():
+			o.NoColor = o.NoColor || os.Getenv("NO_COLOR") != ""
+			if o.Output == nil {
+				o.Output = os.Stderr
+			}
+		default:
+			o.NewHandlerFunc = NewJSONHandler
+		}
+	}
+	if o.Output == nil {
+		o.Output = os.Stdout
+	}
+
+	l := New(o)
+	if journal != nil {
+		journal.j.opts.Level = l.level
+	}
+	var once sync.Once
+	return l, func() {
+		once.Do(func() {
+			ctx := context.Background()
+			if l.flushTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, l.flushTimeout)
+				defer cancel()
+			}
+			err := l.DrainContext(ctx)
+			if journal != nil {
+				err = errors.Join(err, journal.Close())
+			}
+			if eventLog != nil {
+				err = errors.Join(err, eventLog.Close())
+			}
+			if err != nil {
+				FallbackErrorf("unable to flush log messages: %v", err)
+			}
+		})
+	}
+}
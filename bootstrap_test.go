@@ -0,0 +1,92 @@
+package l4g
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBootstrap(t *testing.T) {
+	old := stderrIsTerminal
+	defer func() { stderrIsTerminal = old }()
+	t.Setenv("JOURNAL_STREAM", "")
+	oldService := runningAsService
+	defer func() { runningAsService = oldService }()
+	runningAsService = func() bool { return false }
+
+	t.Run("json", func(t *testing.T) {
+		stderrIsTerminal = func() bool { return false }
+		buf := &bytes.Buffer{}
+		logger, cleanup := Bootstrap(BootstrapOptions{Options: Options{Output: buf}})
+		defer cleanup()
+		logger.Info("started")
+		if out := buf.String(); !strings.Contains(out, `"level":"INFO","msg":"started"`) {
+			t.Errorf("output = %q, want JSON", out)
+		}
+	})
+
+	t.Run("terminal", func(t *testing.T) {
+		stderrIsTerminal = func() bool { return true }
+		t.Setenv("NO_COLOR", "1")
+		buf := &bytes.Buffer{}
+		logger, cleanup := Bootstrap(BootstrapOptions{Options: Options{Output: buf}})
+		defer cleanup()
+		logger.Info("started")
+		if out := buf.String(); !strings.HasSuffix(out, " INFO started\n") {
+			t.Errorf("output = %q, want text without colors", out)
+		}
+	})
+
+	t.Run("journal", func(t *testing.T) {
+		conn, path := listenJournal(t)
+		t.Setenv("JOURNAL_STREAM", "8:1234")
+		logger, cleanup := Bootstrap(BootstrapOptions{
+			Options: Options{Level: LevelWarn},
+			Journal: JournalOptions{Socket: path, Identifier: "billing"},
+		})
+		logger.Info("hidden")
+		logger.Warn("started")
+		if got := readDatagram(t, conn); !strings.Contains(got, "MESSAGE=started\n") || !strings.Contains(got, "SYSLOG_IDENTIFIER=billing\n") {
+			t.Errorf("datagram = %q, want the warning", got)
+		}
+		logger.SetLevel(LevelInfo)
+		logger.Info("verbose")
+		if got := readDatagram(t, conn); !strings.Contains(got, "MESSAGE=verbose\n") {
+			t.Errorf("datagram = %q, want the level of the logger to apply", got)
+		}
+		cleanup()
+		cleanup()
+	})
+
+	t.Run("service", func(t *testing.T) {
+		s := fakeEventLog(t)
+		runningAsService = func() bool { return true }
+		defer func() { runningAsService = func() bool { return false } }()
+		t.Setenv("JOURNAL_STREAM", "8:1234")
+		buf := &bytes.Buffer{}
+		logger, cleanup := Bootstrap(BootstrapOptions{
+			Options:     Options{Output: buf, Level: LevelWarn},
+			EventSource: "billing",
+		})
+		logger.Info("hidden")
+		logger.Warn("started", "port", 80)
+		logger.SetLevel(LevelInfo)
+		logger.Info("verbose")
+		cleanup()
+		cleanup()
+
+		if s.name != "billing" {
+			t.Errorf("source = %q, want billing", s.name)
+		}
+		if want := []string{"started port=80", "verbose"}; !reflect.DeepEqual(s.events, want) {
+			t.Errorf("events = %q, want %q", s.events, want)
+		}
+		if !s.closed {
+			t.Errorf("cleanup() did not deregister the event source")
+		}
+		if buf.Len() != 0 {
+			t.Errorf("output = %q, want nothing", buf.String())
+		}
+	})
+}
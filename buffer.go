@@ -0,0 +1,118 @@
+package l4g
+
+import (
+	"log/slog"
+	"time"
+)
+
+// A Buffer is a byte slice in which to format a record, with the encoding
+// primitives of the built-in handlers, so that handlers outside the
+// package quote and escape as they do without allocating. The Append
+// methods without JSON in their name follow the rules of the
+// [SimpleHandler] without colors; the others those of the [JSONHandler].
+//
+// A handler typically gets a Buffer with NewBuffer, appends the record to
+// it, writes it to its output and calls Free:
+//
+//	buf := l4g.NewBuffer()
+//	defer buf.Free()
+//	buf.AppendTime(r.Time)
+//	buf.WriteByte(' ')
+//	buf.AppendString(r.Message)
+//	for a := range r.All() {
+//		buf.WriteByte(' ')
+//		buf.AppendKey(a.Key)
+//		buf.AppendValue(a.Value)
+//	}
+//	buf.WriteByte('\n')
+//	_, err := w.Write(*buf)
+type Buffer []byte
+
+// plainHandler and jsonHandler format the values appended to a Buffer.
+var (
+	plainHandler = NewSimpleHandler(HandlerOptions{NoColor: true}).(*SimpleHandler)
+	jsonHandler  = NewJSONHandler(HandlerOptions{}).(*JSONHandler)
+)
+
+// NewBuffer returns an empty Buffer from the pool set by [SetBufferPool].
+func NewBuffer() *Buffer {
+	return (*Buffer)(currentBufferPool().Get())
+}
+
+// Free empties b and returns it to the pool. b must not be used afterwards.
+func (b *Buffer) Free() {
+	(*buffer)(b).Free()
+}
+
+// Write appends p to b. It implements [io.Writer] and never returns an error.
+func (b *Buffer) Write(p []byte) (int, error) {
+	*b = append(*b, p...)
+	return len(p), nil
+}
+
+// WriteString appends s to b. It implements [io.StringWriter] and never
+// returns an error.
+func (b *Buffer) WriteString(s string) (int, error) {
+	*b = append(*b, s...)
+	return len(s), nil
+}
+
+// WriteByte appends c to b. It implements [io.ByteWriter] and never
+// returns an error.
+func (b *Buffer) WriteByte(c byte) error {
+	*b = append(*b, c)
+	return nil
+}
+
+// AppendKey appends key followed by '=', quoting the key if it is empty or
+// holds spaces, '=', quotes or unprintable characters.
+func (b *Buffer) AppendKey(key string) {
+	appendString((*buffer)(b), key, true, false)
+	*b = append(*b, '=')
+}
+
+// AppendString appends s, quoted under the same conditions as a key.
+// ANSI escape sequences are removed from quoted strings.
+func (b *Buffer) AppendString(s string) {
+	appendString((*buffer)(b), s, true, false)
+}
+
+// AppendTime appends t in RFC 3339 with milliseconds, as the
+// [SimpleHandler] writes the values of time attributes.
+func (b *Buffer) AppendTime(t time.Time) {
+	*b = appendRFC3339Millis(*b, t)
+}
+
+// AppendValue appends v as the [SimpleHandler] writes attribute values,
+// with the attributes of groups as key=value pairs separated by spaces.
+func (b *Buffer) AppendValue(v slog.Value) {
+	v = v.Resolve()
+	if v.Kind() == slog.KindGroup {
+		for i, a := range v.Group() {
+			if i > 0 {
+				*b = append(*b, ' ')
+			}
+			b.AppendKey(a.Key)
+			b.AppendValue(a.Value)
+		}
+		return
+	}
+	plainHandler.appendValue((*buffer)(b), v, true)
+}
+
+// AppendJSONKey appends key as a JSON string followed by ':'.
+func (b *Buffer) AppendJSONKey(key string) {
+	jsonHandler.appendKey((*buffer)(b), key)
+}
+
+// AppendJSONString appends s as a JSON string, escaped as by the
+// [JSONHandler].
+func (b *Buffer) AppendJSONString(s string) {
+	appendJSONString((*buffer)(b), s)
+}
+
+// AppendJSONValue appends v as the [JSONHandler] writes attribute values,
+// with groups as objects.
+func (b *Buffer) AppendJSONValue(v slog.Value) {
+	jsonHandler.appendValue((*buffer)(b), v.Resolve())
+}
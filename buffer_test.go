@@ -0,0 +1,64 @@
+package l4g
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestBuffer(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 123456789, time.UTC)
+	tests := []struct {
+		name   string
+		append func(b *Buffer)
+		want   string
+	}{
+		{"key", func(b *Buffer) { b.AppendKey("a") }, `a=`},
+		{"quoted key", func(b *Buffer) { b.AppendKey("a b") }, `"a b"=`},
+		{"string", func(b *Buffer) { b.AppendString("v") }, `v`},
+		{"quoted string", func(b *Buffer) { b.AppendString("x=1") }, `"x=1"`},
+		{"empty string", func(b *Buffer) { b.AppendString("") }, `""`},
+		{"ansi", func(b *Buffer) { b.AppendString("\x1b[31mred\x1b[0m here") }, `"red here"`},
+		{"time", func(b *Buffer) { b.AppendTime(at) }, `2024-05-01T12:00:00.123Z`},
+		{"int", func(b *Buffer) { b.AppendValue(slog.IntValue(-3)) }, `-3`},
+		{"duration", func(b *Buffer) { b.AppendValue(slog.DurationValue(time.Second)) }, `1s`},
+		{"error", func(b *Buffer) { b.AppendValue(slog.AnyValue(errors.New("boom now"))) }, `"boom now"`},
+		{"group", func(b *Buffer) { b.AppendValue(slog.GroupValue(Int("a", 1), String("b", "x y"))) }, `a=1 b="x y"`},
+		{"json key", func(b *Buffer) { b.AppendJSONKey(`a"b`) }, `"a\"b":`},
+		{"json string", func(b *Buffer) { b.AppendJSONString("x\n") }, `"x\n"`},
+		{"json group", func(b *Buffer) { b.AppendJSONValue(slog.GroupValue(Int("a", 1), Bool("b", true))) }, `{"a":1,"b":true}`},
+		{"writer", func(b *Buffer) { fmt.Fprintf(b, "%d-%s", 1, "x") }, `1-x`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBuffer()
+			defer b.Free()
+			tt.append(b)
+			if got := string(*b); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuffer_Allocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+	at := time.Now()
+	allocs := testing.AllocsPerRun(100, func() {
+		b := NewBuffer()
+		b.AppendKey("key")
+		b.AppendString("value with spaces")
+		b.AppendTime(at)
+		b.AppendValue(slog.IntValue(42))
+		b.AppendJSONKey("key")
+		b.AppendJSONString("value")
+		b.Free()
+	})
+	if allocs != 0 {
+		t.Errorf("allocs = %v, want 0", allocs)
+	}
+}
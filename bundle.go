@@ -0,0 +1,101 @@
+package l4g
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// bundleFormat identifies the trailer of a bundle written by ExportBundle.
+const bundleFormat = "l4g-bundle/1"
+
+// bundleTrailer is the last line of a bundle, counting its records so
+// that the removal of records at the end is detected.
+type bundleTrailer struct {
+	Format  string `json:"bundle"`
+	Records int    `json:"records"`
+}
+
+// ExportBundle writes records to w as a bundle for archiving, such as an
+// export of audit records: a gzip stream of the records encoded by
+// [Record.MarshalJSON], one per line, followed by a trailer counting
+// them, each line authenticated as by an [HMACWriter] with key, after the
+// record starting its stream. Use
+// [VerifyBundle] to check the integrity of a bundle. To keep its content
+// confidential too, write the bundle to an [EncryptWriter], closing it
+// afterwards.
+//
+// It stops at the first error of records.
+func ExportBundle(w io.Writer, records iter.Seq2[Record, error], key []byte) error {
+	zw := gzip.NewWriter(w)
+	mw := NewHMACWriter(zw, key)
+	n := 0
+	for r, err := range records {
+		if err != nil {
+			return err
+		}
+		line, err := r.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		if _, err := mw.Write(line); err != nil {
+			return err
+		}
+		n++
+	}
+	trailer, err := json.Marshal(bundleTrailer{Format: bundleFormat, Records: n})
+	if err != nil {
+		return err
+	}
+	if _, err := mw.Write(trailer); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// VerifyBundle reads a bundle written by [ExportBundle] from r and checks
+// it against key. It returns an error identifying the first line that
+// fails verification, or reporting that records were removed at the end.
+func VerifyBundle(r io.Reader, key []byte) error {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("l4g: bundle: %w", err)
+	}
+	mac := hmac.New(sha256.New, key)
+	prev := make([]byte, sha256.Size)
+	sc := bufio.NewScanner(zr)
+	sc.Buffer(nil, 64<<20) // allow records of up to 64 MiB
+	var last []byte
+	n := -1 // the stream start is not counted
+	for ; sc.Scan(); n++ {
+		record, want, ok := splitMAC(sc.Bytes())
+		if !ok {
+			return fmt.Errorf("l4g: bundle line %d: missing %s", n+2, MACKey)
+		}
+		if _, ok := streamStartID(record); ok != (n == -1) {
+			return fmt.Errorf("l4g: bundle line %d: stream start misplaced", n+2)
+		}
+		sum := chainMAC(mac, prev, record)
+		if !hmac.Equal(sum, want) {
+			return fmt.Errorf("l4g: bundle line %d: %s mismatch", n+2, MACKey)
+		}
+		prev, last = sum, record
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("l4g: bundle: %w", err)
+	}
+	var trailer bundleTrailer
+	if n <= 0 || json.Unmarshal(last, &trailer) != nil || trailer.Format != bundleFormat {
+		return errors.New("l4g: bundle: missing trailer")
+	}
+	if trailer.Records != n-1 {
+		return fmt.Errorf("l4g: bundle: %d records, want %d", n-1, trailer.Records)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package l4g
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"iter"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordSeq returns an iterator over records, failing with err at the end
+// if it is not nil.
+func recordSeq(records []Record, err error) iter.Seq2[Record, error] {
+	return func(yield func(Record, error) bool) {
+		for _, r := range records {
+			if !yield(r, nil) {
+				return
+			}
+		}
+		if err != nil {
+			yield(Record{}, err)
+		}
+	}
+}
+
+// regzip decompresses a bundle, applies edit to its lines and compresses
+// the result.
+func regzip(t *testing.T, bundle []byte, edit func(lines []string) []string) []byte {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(bundle))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := edit(strings.SplitAfter(string(data), "\n"))
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	io.WriteString(zw, strings.Join(lines, ""))
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestBundle(t *testing.T) {
+	key := []byte("audit key")
+	var records []Record
+	for i, msg := range []string{"login", "grant", "logout"} {
+		r := NewRecord(time.Date(2024, 5, 1, 12, i, 0, 0, time.UTC), LevelInfo, msg)
+		r.AddAttrs(String("user", "alice"), Int("seq", i))
+		records = append(records, r)
+	}
+	var buf bytes.Buffer
+	if err := ExportBundle(&buf, recordSeq(records, nil), key); err != nil {
+		t.Fatalf("ExportBundle() error = %v", err)
+	}
+	bundle := buf.Bytes()
+
+	if err := VerifyBundle(bytes.NewReader(bundle), key); err != nil {
+		t.Errorf("VerifyBundle() error = %v", err)
+	}
+	if err := VerifyBundle(bytes.NewReader(bundle), []byte("other key")); err == nil || !strings.Contains(err.Error(), "line 1: mac mismatch") {
+		t.Errorf("VerifyBundle() with another key error = %v, want a mismatch", err)
+	}
+
+	tampered := regzip(t, bundle, func(lines []string) []string {
+		lines[2] = strings.Replace(lines[2], "grant", "grunt", 1)
+		return lines
+	})
+	if err := VerifyBundle(bytes.NewReader(tampered), key); err == nil || !strings.Contains(err.Error(), "line 3: mac mismatch") {
+		t.Errorf("VerifyBundle() of a modified record error = %v, want a mismatch", err)
+	}
+
+	unstarted := regzip(t, bundle, func(lines []string) []string { return lines[1:] })
+	if err := VerifyBundle(bytes.NewReader(unstarted), key); err == nil || !strings.Contains(err.Error(), "line 1: stream start misplaced") {
+		t.Errorf("VerifyBundle() of a bundle without its stream start error = %v, want a misplaced start", err)
+	}
+
+	truncated := regzip(t, bundle, func(lines []string) []string { return lines[:len(lines)-2] })
+	if err := VerifyBundle(bytes.NewReader(truncated), key); err == nil || !strings.Contains(err.Error(), "missing trailer") {
+		t.Errorf("VerifyBundle() of a truncated bundle error = %v, want a missing trailer", err)
+	}
+
+	if err := VerifyBundle(strings.NewReader("not gzip"), key); err == nil {
+		t.Errorf("VerifyBundle() accepted data that is not a bundle")
+	}
+}
+
+func TestExportBundle_Error(t *testing.T) {
+	want := errors.New("read failed")
+	if err := ExportBundle(io.Discard, recordSeq(nil, want), []byte("k")); err != want {
+		t.Errorf("ExportBundle() error = %v, want %v", err, want)
+	}
+}
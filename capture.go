@@ -0,0 +1,84 @@
+package l4g
+
+import (
+	"bytes"
+	"sync"
+)
+
+// CaptureBuffer is an io.Writer that keeps the most recent log output in
+// memory, up to a fixed number of bytes, so that it can be attached to bug
+// reports submitted from desktop and command-line applications.
+// It is safe for concurrent use by multiple goroutines.
+type CaptureBuffer struct {
+	mu   sync.Mutex
+	buf  []byte // ring of len maxBytes
+	pos  int    // index of the next byte to write
+	full bool   // whether the ring has wrapped
+}
+
+// CaptureWriter returns a CaptureBuffer retaining the last maxBytes bytes
+// written to it. It is typically added to the output of a logger with
+// io.MultiWriter.
+func CaptureWriter(maxBytes int) *CaptureBuffer {
+	if maxBytes < 0 {
+		maxBytes = 0
+	}
+	return &CaptureBuffer{buf: make([]byte, maxBytes)}
+}
+
+// Write appends p, overwriting the oldest bytes once the buffer is full.
+// It never fails.
+func (c *CaptureBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	size := len(c.buf)
+	if size == 0 {
+		return n, nil
+	}
+	if len(p) >= size {
+		copy(c.buf, p[len(p)-size:])
+		c.pos, c.full = 0, true
+		return n, nil
+	}
+	k := copy(c.buf[c.pos:], p)
+	if k < len(p) {
+		copy(c.buf, p[k:])
+		c.full = true
+	}
+	c.pos = (c.pos + len(p)) % size
+	if c.pos == 0 {
+		c.full = true
+	}
+	return n, nil
+}
+
+// Bytes returns a copy of the retained output, oldest first. Once older
+// output has been overwritten, the partial line at the start is dropped, so
+// that the result begins with a complete record.
+func (c *CaptureBuffer) Bytes() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.full {
+		return bytes.Clone(c.buf[:c.pos])
+	}
+	b := make([]byte, 0, len(c.buf))
+	b = append(b, c.buf[c.pos:]...)
+	b = append(b, c.buf[:c.pos]...)
+	if i := bytes.IndexByte(b, '\n'); i >= 0 {
+		b = b[i+1:]
+	}
+	return b
+}
+
+// String returns the retained output as a string, like Bytes.
+func (c *CaptureBuffer) String() string {
+	return string(c.Bytes())
+}
+
+// Reset discards the retained output.
+func (c *CaptureBuffer) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pos, c.full = 0, false
+}
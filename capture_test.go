@@ -0,0 +1,56 @@
+package l4g
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCaptureWriter(t *testing.T) {
+	c := CaptureWriter(64)
+	l := New(Options{Output: c, NoColor: true, TimeFormat: "-"})
+	l.Info("first")
+	if got := c.String(); !strings.Contains(got, "INFO first\n") {
+		t.Errorf("String() = %q, want the first record", got)
+	}
+
+	for i := range 20 {
+		l.Info(fmt.Sprintf("message %d", i))
+	}
+	got := c.String()
+	if len(got) > 64 {
+		t.Errorf("len(String()) = %d, want at most 64", len(got))
+	}
+	if !strings.HasSuffix(got, "INFO message 19\n") {
+		t.Errorf("String() = %q, want the last record at the end", got)
+	}
+	if strings.Contains(got, "first") {
+		t.Errorf("String() = %q, want the oldest records dropped", got)
+	}
+	if !strings.HasPrefix(got, "- INFO message") {
+		t.Errorf("String() = %q, want it to start with a complete record", got)
+	}
+
+	c.Reset()
+	if got := c.String(); got != "" {
+		t.Errorf("String() after Reset = %q, want empty", got)
+	}
+}
+
+func TestCaptureWriterLargeWrite(t *testing.T) {
+	c := CaptureWriter(4)
+	if n, err := c.Write([]byte("abc")); n != 3 || err != nil {
+		t.Errorf("Write() = %d, %v, want 3, nil", n, err)
+	}
+	if got := string(c.Bytes()); got != "abc" {
+		t.Errorf("Bytes() = %q, want %q", got, "abc")
+	}
+	c.Write([]byte("defghij"))
+	if got := string(c.Bytes()); got != "ghij" {
+		t.Errorf("Bytes() = %q, want %q", got, "ghij")
+	}
+	c.Write([]byte("kl"))
+	if got := string(c.Bytes()); got != "ijkl" {
+		t.Errorf("Bytes() = %q, want %q", got, "ijkl")
+	}
+}
@@ -0,0 +1,34 @@
+package l4g
+
+import "time"
+
+// A Clock is the source of time of the logger and of the handlers whose
+// behavior depends on it, such as the [SamplingHandler], so that tests
+// can drive them with a fake clock instead of sleeping. A nil Clock in
+// the options stands for the system clock.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// AfterFunc calls f in its own goroutine once d has elapsed, as
+	// [time.AfterFunc]. stop cancels the call, reporting false if f has
+	// already been called or the call canceled.
+	AfterFunc(d time.Duration, f func()) (stop func() bool)
+}
+
+// systemClock is the Clock of the time package.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) AfterFunc(d time.Duration, f func()) func() bool {
+	return time.AfterFunc(d, f).Stop
+}
+
+// clockOrSystem returns c, or the system clock if c is nil.
+func clockOrSystem(c Clock) Clock {
+	if c == nil {
+		return systemClock{}
+	}
+	return c
+}
@@ -0,0 +1,89 @@
+package l4g
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock whose time only moves with Advance.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// fakeTimer is a call scheduled with fakeClock.AfterFunc.
+type fakeTimer struct {
+	at   time.Time
+	f    func()
+	done bool // called or stopped
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) func() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+	return func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		stopped := !t.done
+		t.done = true
+		return stopped
+	}
+}
+
+// Advance moves the clock forward by d, calling the functions falling due
+// in order of time, in the calling goroutine.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	end := c.now.Add(d)
+	for {
+		var next *fakeTimer
+		for _, t := range c.timers {
+			if !t.done && !t.at.After(end) && (next == nil || t.at.Before(next.at)) {
+				next = t
+			}
+		}
+		if next == nil {
+			break
+		}
+		next.done = true
+		c.now = next.at
+		c.mu.Unlock()
+		next.f()
+		c.mu.Lock()
+	}
+	c.now = end
+	c.mu.Unlock()
+}
+
+func TestOptions_Clock(t *testing.T) {
+	clock := newFakeClock()
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, Clock: clock, NewHandlerFunc: NewJSONHandler})
+
+	logger.Info("first")
+	clock.Advance(1500 * time.Millisecond)
+	logger.WithOptions(func(*Options) {}).Info("second")
+
+	out := buf.String()
+	for _, want := range []string{`"time":"2024-05-01T12:00:00Z","level":"INFO","msg":"first"`, `"time":"2024-05-01T12:00:01.5Z","level":"INFO","msg":"second"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output = %q, want to contain %q", out, want)
+		}
+	}
+}
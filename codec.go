@@ -0,0 +1,460 @@
+package l4g
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// This file implements the encodings of a Record used to forward records
+// between processes. Attribute values keep their kind; values of kind
+// slog.KindAny are sent as JSON, or as their message for errors, and are
+// received as the result of json.Unmarshal into an any. The PC of a record
+// is not sent, since it is only meaningful within the process.
+
+// recordVersion is the version of the binary encoding of a Record.
+const recordVersion = 1
+
+// errRecordFormat is returned when decoding a malformed Record.
+var errRecordFormat = errors.New("l4g: malformed record encoding")
+
+// Values of kind slog.KindAny are sent in one of these forms.
+const (
+	anyJSON byte = iota
+	anyString
+)
+
+// MarshalBinary implements [encoding.BinaryMarshaler] with a compact
+// encoding suitable for forwarding the record to another process.
+func (r Record) MarshalBinary() ([]byte, error) {
+	b := []byte{recordVersion}
+	t, err := r.Time.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	b = appendBytes(b, t)
+	b = binary.AppendVarint(b, int64(r.Level))
+	b = appendBytes(b, []byte(r.Prefix))
+	b = appendBytes(b, []byte(r.Message))
+	b = binary.AppendUvarint(b, uint64(len(r.Tags)))
+	for _, tag := range r.Tags {
+		b = appendBytes(b, []byte(tag))
+	}
+	b = binary.AppendUvarint(b, uint64(r.NumAttrs()))
+	for a := range r.All() {
+		if b, err = appendBinaryAttr(b, a); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler], decoding a
+// record encoded by MarshalBinary.
+func (r *Record) UnmarshalBinary(data []byte) error {
+	d := decoder{data: data}
+	if v := d.byte(); v != recordVersion {
+		return fmt.Errorf("l4g: unsupported record encoding version %d", v)
+	}
+	var rec Record
+	if err := rec.Time.UnmarshalBinary(d.bytes()); err != nil {
+		d.fail()
+	}
+	rec.Level = Level(d.varint())
+	rec.Prefix = string(d.bytes())
+	rec.Message = string(d.bytes())
+	if n := d.count(); n > 0 {
+		rec.Tags = make([]string, n)
+		for i := range rec.Tags {
+			rec.Tags[i] = string(d.bytes())
+		}
+	}
+	attrs := make([]Attr, d.count())
+	for i := range attrs {
+		attrs[i] = d.attr()
+	}
+	if d.err != nil {
+		return d.err
+	}
+	if len(d.data) > 0 {
+		return errRecordFormat
+	}
+	rec.AddAttrs(attrs...)
+	*r = rec
+	return nil
+}
+
+func appendBytes(b, s []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+func appendBinaryAttr(b []byte, a Attr) ([]byte, error) {
+	b = appendBytes(b, []byte(a.Key))
+	v := a.Value.Resolve()
+	b = append(b, byte(v.Kind()))
+	switch v.Kind() {
+	case slog.KindString:
+		b = appendBytes(b, []byte(v.String()))
+	case slog.KindInt64:
+		b = binary.AppendVarint(b, v.Int64())
+	case slog.KindUint64:
+		b = binary.AppendUvarint(b, v.Uint64())
+	case slog.KindFloat64:
+		b = binary.BigEndian.AppendUint64(b, math.Float64bits(v.Float64()))
+	case slog.KindBool:
+		if v.Bool() {
+			b = append(b, 1)
+		} else {
+			b = append(b, 0)
+		}
+	case slog.KindDuration:
+		b = binary.AppendVarint(b, int64(v.Duration()))
+	case slog.KindTime:
+		t, err := v.Time().MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		b = appendBytes(b, t)
+	case slog.KindGroup:
+		group := v.Group()
+		b = binary.AppendUvarint(b, uint64(len(group)))
+		for _, ga := range group {
+			var err error
+			if b, err = appendBinaryAttr(b, ga); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		form, data := encodeAny(v.Any())
+		b = append(b, form)
+		b = appendBytes(b, data)
+	}
+	return b, nil
+}
+
+// encodeAny returns the form and the encoding of a value of kind
+// slog.KindAny.
+func encodeAny(v any) (byte, []byte) {
+	if err, ok := v.(error); ok {
+		if _, ok := v.(json.Marshaler); !ok {
+			return anyString, []byte(err.Error())
+		}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return anyString, []byte(fmt.Sprintf("%+v", v))
+	}
+	return anyJSON, data
+}
+
+// decodeAny decodes a value encoded by encodeAny.
+func decodeAny(form byte, data []byte) (slog.Value, error) {
+	switch form {
+	case anyString:
+		return slog.StringValue(string(data)), nil
+	case anyJSON:
+		var v any
+		if err := json.Unmarshal(data, &v); err != nil {
+			return slog.Value{}, err
+		}
+		return slog.AnyValue(v), nil
+	}
+	return slog.Value{}, errRecordFormat
+}
+
+// decoder reads the binary encoding of a Record, recording the first error.
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) fail() {
+	if d.err == nil {
+		d.err = errRecordFormat
+	}
+	d.data = nil
+}
+
+func (d *decoder) byte() byte {
+	if len(d.data) == 0 {
+		d.fail()
+		return 0
+	}
+	c := d.data[0]
+	d.data = d.data[1:]
+	return c
+}
+
+func (d *decoder) uvarint() uint64 {
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *decoder) varint() int64 {
+	v, n := binary.Varint(d.data)
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+// count reads a number of elements, each encoded in at least one byte.
+func (d *decoder) count() int {
+	n := d.uvarint()
+	if n > uint64(len(d.data)) {
+		d.fail()
+		return 0
+	}
+	return int(n)
+}
+
+func (d *decoder) bytes() []byte {
+	n := d.count()
+	b := d.data[:n:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *decoder) attr() Attr {
+	key := string(d.bytes())
+	switch kind := slog.Kind(d.byte()); kind {
+	case slog.KindString:
+		return slog.String(key, string(d.bytes()))
+	case slog.KindInt64:
+		return slog.Int64(key, d.varint())
+	case slog.KindUint64:
+		return slog.Uint64(key, d.uvarint())
+	case slog.KindFloat64:
+		if len(d.data) < 8 {
+			d.fail()
+			return Attr{}
+		}
+		f := math.Float64frombits(binary.BigEndian.Uint64(d.data))
+		d.data = d.data[8:]
+		return slog.Float64(key, f)
+	case slog.KindBool:
+		return slog.Bool(key, d.byte() != 0)
+	case slog.KindDuration:
+		return slog.Duration(key, time.Duration(d.varint()))
+	case slog.KindTime:
+		var t time.Time
+		if err := t.UnmarshalBinary(d.bytes()); err != nil {
+			d.fail()
+		}
+		return slog.Time(key, t)
+	case slog.KindGroup:
+		group := make([]Attr, d.count())
+		for i := range group {
+			group[i] = d.attr()
+		}
+		return Attr{Key: key, Value: slog.GroupValue(group...)}
+	case slog.KindAny:
+		form := d.byte()
+		v, err := decodeAny(form, d.bytes())
+		if err != nil {
+			d.fail()
+		}
+		return Attr{Key: key, Value: v}
+	default:
+		d.fail()
+		return Attr{}
+	}
+}
+
+// jsonRecord is the JSON encoding of a Record.
+type jsonRecord struct {
+	Time    time.Time  `json:"time"`
+	Level   Level      `json:"level"`
+	Prefix  string     `json:"prefix,omitempty"`
+	Message string     `json:"msg"`
+	Tags    []string   `json:"tags,omitempty"`
+	Attrs   []jsonAttr `json:"attrs,omitempty"`
+}
+
+// jsonAttr is the JSON encoding of an Attr, keeping the kind of its value.
+type jsonAttr struct {
+	Key   string          `json:"key"`
+	Kind  string          `json:"kind"`
+	Value json.RawMessage `json:"value"`
+}
+
+// MarshalJSON implements [encoding/json.Marshaler], encoding the record as
+// an object that keeps the kind of each attribute, unlike the output of a
+// [JSONHandler], so that UnmarshalJSON can restore it.
+func (r Record) MarshalJSON() ([]byte, error) {
+	jr := jsonRecord{
+		Time:    r.Time,
+		Level:   r.Level,
+		Prefix:  r.Prefix,
+		Message: r.Message,
+		Tags:    r.Tags,
+	}
+	var err error
+	if jr.Attrs, err = toJSONAttrs(r.All()); err != nil {
+		return nil, err
+	}
+	return json.Marshal(jr)
+}
+
+// UnmarshalJSON implements [encoding/json.Unmarshaler], decoding a record
+// encoded by MarshalJSON.
+func (r *Record) UnmarshalJSON(data []byte) error {
+	var jr jsonRecord
+	if err := json.Unmarshal(data, &jr); err != nil {
+		return err
+	}
+	attrs, err := fromJSONAttrs(jr.Attrs)
+	if err != nil {
+		return err
+	}
+	rec := NewRecord(jr.Time, jr.Level, jr.Message)
+	rec.Prefix = jr.Prefix
+	rec.Tags = jr.Tags
+	rec.AddAttrs(attrs...)
+	*r = rec
+	return nil
+}
+
+func toJSONAttrs(attrs iter.Seq[Attr]) ([]jsonAttr, error) {
+	var out []jsonAttr
+	var err error
+	attrs(func(a Attr) bool {
+		var ja jsonAttr
+		if ja, err = toJSONAttr(a); err != nil {
+			return false
+		}
+		out = append(out, ja)
+		return true
+	})
+	return out, err
+}
+
+func toJSONAttr(a Attr) (jsonAttr, error) {
+	v := a.Value.Resolve()
+	ja := jsonAttr{Key: a.Key, Kind: v.Kind().String()}
+	var x any
+	switch v.Kind() {
+	case slog.KindString:
+		x = v.String()
+	case slog.KindInt64:
+		x = v.Int64()
+	case slog.KindUint64:
+		x = v.Uint64()
+	case slog.KindFloat64:
+		f := v.Float64()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			x = fmt.Sprint(f) // parsed back by strconv
+		} else {
+			x = f
+		}
+	case slog.KindBool:
+		x = v.Bool()
+	case slog.KindDuration:
+		x = int64(v.Duration())
+	case slog.KindTime:
+		x = v.Time()
+	case slog.KindGroup:
+		group, err := toJSONAttrs(slices.Values(v.Group()))
+		if err != nil {
+			return ja, err
+		}
+		x = group
+	default:
+		form, data := encodeAny(v.Any())
+		if form == anyString {
+			ja.Kind = "String"
+			x = string(data)
+		} else {
+			ja.Value = data
+			return ja, nil
+		}
+	}
+	data, err := json.Marshal(x)
+	ja.Value = data
+	return ja, err
+}
+
+func fromJSONAttrs(jas []jsonAttr) ([]Attr, error) {
+	attrs := make([]Attr, len(jas))
+	for i, ja := range jas {
+		a, err := fromJSONAttr(ja)
+		if err != nil {
+			return nil, err
+		}
+		attrs[i] = a
+	}
+	return attrs, nil
+}
+
+func fromJSONAttr(ja jsonAttr) (Attr, error) {
+	var err error
+	decode := func(v any) { err = json.Unmarshal(ja.Value, v) }
+	var a Attr
+	switch ja.Kind {
+	case "String":
+		var s string
+		decode(&s)
+		a = slog.String(ja.Key, s)
+	case "Int64":
+		var n int64
+		decode(&n)
+		a = slog.Int64(ja.Key, n)
+	case "Uint64":
+		var n uint64
+		decode(&n)
+		a = slog.Uint64(ja.Key, n)
+	case "Float64":
+		var f any
+		decode(&f)
+		switch f := f.(type) {
+		case float64:
+			a = slog.Float64(ja.Key, f)
+		case string:
+			var g float64
+			g, err = strconv.ParseFloat(f, 64)
+			a = slog.Float64(ja.Key, g)
+		}
+	case "Bool":
+		var b bool
+		decode(&b)
+		a = slog.Bool(ja.Key, b)
+	case "Duration":
+		var n int64
+		decode(&n)
+		a = slog.Duration(ja.Key, time.Duration(n))
+	case "Time":
+		var t time.Time
+		decode(&t)
+		a = slog.Time(ja.Key, t)
+	case "Group":
+		var group []jsonAttr
+		decode(&group)
+		if err == nil {
+			var attrs []Attr
+			attrs, err = fromJSONAttrs(group)
+			a = Attr{Key: ja.Key, Value: slog.GroupValue(attrs...)}
+		}
+	case "Any":
+		var v slog.Value
+		v, err = decodeAny(anyJSON, ja.Value)
+		a = Attr{Key: ja.Key, Value: v}
+	default:
+		return Attr{}, fmt.Errorf("l4g: unknown attribute kind %q", ja.Kind)
+	}
+	return a, err
+}
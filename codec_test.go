@@ -0,0 +1,107 @@
+package l4g
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func codecRecord() Record {
+	r := NewRecord(time.Date(2024, 5, 1, 12, 0, 0, 123456789, time.FixedZone("X", 3600)), LevelWarn, "disk low")
+	r.Prefix = "agent"
+	r.Tags = []string{"ops", "disk"}
+	r.PC = 1234
+	r.AddAttrs(
+		String("s", "v"),
+		Int("i", -3),
+		Uint("u", uint64(math.MaxUint64)),
+		Float("f", 1.5),
+		Bool("b", true),
+		Duration("d", 1500*time.Millisecond),
+		Time("t", time.Unix(100, 5).UTC()),
+		Group("req", Int("id", 7), Group("user", String("name", "alice"))),
+		Any("err", errors.New("boom")),
+		Any("obj", map[string]any{"a": 1}),
+		Float("nan", math.Inf(1)),
+	)
+	return r
+}
+
+// checkDecoded checks that got is the record of codecRecord as received by
+// another process.
+func checkDecoded(t *testing.T, got Record) {
+	t.Helper()
+	want := codecRecord()
+	if !got.Time.Equal(want.Time) || got.Level != want.Level || got.Prefix != want.Prefix ||
+		got.Message != want.Message || !reflect.DeepEqual(got.Tags, want.Tags) {
+		t.Errorf("decoded record = %+v, want %+v", got, want)
+	}
+	if got.PC != 0 {
+		t.Errorf("PC = %d, want 0", got.PC)
+	}
+	var gotAttrs, wantAttrs []Attr
+	got.Attrs(func(a Attr) bool { gotAttrs = append(gotAttrs, a); return true })
+	want.Attrs(func(a Attr) bool { wantAttrs = append(wantAttrs, a); return true })
+	if len(gotAttrs) != len(wantAttrs) {
+		t.Fatalf("decoded %d attributes, want %d", len(gotAttrs), len(wantAttrs))
+	}
+	for i, a := range gotAttrs {
+		w := wantAttrs[i]
+		switch w.Key {
+		case "err":
+			w = String("err", "boom")
+		case "obj":
+			w = Any("obj", map[string]any{"a": 1.0})
+		}
+		if a.Key != w.Key || a.Value.Kind() != w.Value.Kind() {
+			t.Errorf("attribute %d = %v (%v), want %v (%v)", i, a, a.Value.Kind(), w, w.Value.Kind())
+		} else if w.Value.Kind() == slog.KindAny {
+			if !reflect.DeepEqual(a.Value.Any(), w.Value.Any()) {
+				t.Errorf("attribute %d = %v, want %v", i, a, w)
+			}
+		} else if !a.Equal(w) {
+			t.Errorf("attribute %d = %v, want %v", i, a, w)
+		}
+	}
+}
+
+func TestRecordMarshalBinary(t *testing.T) {
+	data, err := codecRecord().MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r Record
+	if err := r.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	checkDecoded(t, r)
+
+	for n := range len(data) {
+		if err := new(Record).UnmarshalBinary(data[:n]); err == nil {
+			t.Errorf("UnmarshalBinary(data[:%d]) = nil, want an error", n)
+		}
+	}
+	if err := new(Record).UnmarshalBinary(append(data, 0)); err == nil {
+		t.Errorf("UnmarshalBinary with trailing data = nil, want an error")
+	}
+}
+
+func TestRecordMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(codecRecord())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r Record
+	if err := json.Unmarshal(data, &r); err != nil {
+		t.Fatalf("Unmarshal(%s): %v", data, err)
+	}
+	checkDecoded(t, r)
+
+	if err := json.Unmarshal([]byte(`{"attrs":[{"key":"k","kind":"Complex","value":1}]}`), &r); err == nil {
+		t.Errorf("Unmarshal with an unknown kind = nil, want an error")
+	}
+}
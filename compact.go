@@ -0,0 +1,35 @@
+package l4g
+
+import (
+	"sync"
+	"time"
+)
+
+// CompactEvery calls [Compact] at each interval until stop is called, so
+// that the buffers grown during a burst of logging are released once the
+// program is idle again. stop waits for the compaction in progress, if
+// any, to finish.
+func CompactEvery(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	exited := make(chan struct{})
+
+	go runLabeled("compaction", func() {
+		defer close(exited)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				Compact()
+			}
+		}
+	})
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		<-exited
+	}
+}
@@ -0,0 +1,69 @@
+package l4g
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSamplingHandler_Compact(t *testing.T) {
+	h := NewSamplingHandler(
+		NewSimpleHandler(HandlerOptions{Output: io.Discard}),
+		SamplingOptions{Window: time.Hour, Head: 1, KeyFunc: KeyByAttr("id")},
+	)
+	logger := New(Options{Output: &bytes.Buffer{}, Handler: h})
+	for i := range 1000 {
+		logger.Info("m", "id", strconv.Itoa(i))
+	}
+	h.Flush()
+	logger.Info("m", "id", "last")
+
+	logger.Compact()
+	if ws := h.windows; ws.peak != 1 || len(ws.windows) != 1 {
+		t.Errorf("peak = %d, windows = %d, want 1 and 1", ws.peak, len(ws.windows))
+	}
+}
+
+func TestEncryptWriter_Compact(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	buf := &bytes.Buffer{}
+	w, err := NewEncryptWriter(buf, key)
+	if err != nil {
+		t.Fatalf("NewEncryptWriter() error = %v", err)
+	}
+	logger := New(Options{Output: w, NoColor: true})
+	logger.Info("small")
+	logger.Compact()
+	if w.buf == nil {
+		t.Errorf("small frame buffer released")
+	}
+	logger.Info(strings.Repeat("x", 2*maxRetainedFrame))
+	logger.Compact()
+	if w.buf != nil {
+		t.Errorf("cap(buf) = %d after Compact, want released", cap(w.buf))
+	}
+	logger.Info("after")
+	w.Close()
+
+	r, err := NewDecryptReader(bytes.NewReader(buf.Bytes()), key)
+	if err != nil {
+		t.Fatalf("NewDecryptReader() error = %v", err)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if !strings.Contains(string(out), "INFO after\n") {
+		t.Errorf("decrypted = %q", out)
+	}
+}
+
+func TestCompactEvery(t *testing.T) {
+	stop := CompactEvery(time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	stop()
+	stop()
+}
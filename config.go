@@ -0,0 +1,221 @@
+package l4g
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// A LoggerConfig configures a logger in a [Config]. Zero fields leave
+// the logger unchanged.
+type LoggerConfig struct {
+	// Level is the minimum level of the records, as "debug".
+	Level Level `json:"level,omitempty"`
+	// Format is the format of the records, "text" or "json".
+	Format string `json:"format,omitempty"`
+	// Output is "stderr", "stdout" or the path of a file to append to.
+	Output string `json:"output,omitempty"`
+	// NoColor disables or enables color output.
+	NoColor *bool `json:"no_color,omitempty"`
+}
+
+// A Config configures the default logger and the channel loggers, as read
+// from a JSON document by [LoadConfig]:
+//
+//	{
+//		"level": "info",
+//		"format": "json",
+//		"channels": {
+//			"db": {"level": "debug", "output": "/var/log/app/db.log"}
+//		}
+//	}
+type Config struct {
+	LoggerConfig
+	// Channels configures the loggers returned by [Channel], by name.
+	Channels map[string]LoggerConfig `json:"channels,omitempty"`
+}
+
+// LoadConfig reads the Config stored as JSON in the file at path.
+func LoadConfig(path string) (Config, error) {
+	var c Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("l4g: config %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// ApplyConfig applies c to the default logger and to the channel loggers,
+// creating the channels that do not exist yet. Levels and outputs are
+// changed in place, so they apply to the loggers already in use and to
+// those derived from them. A change of format or color replaces the
+// default logger and the channel loggers with new ones, used by the later
+// calls to [Default] and [Channel] only.
+//
+// Output files are opened once and stay open for the life of the program.
+// ApplyConfig applies as much of c as it can and returns the errors met.
+func ApplyConfig(c Config) error {
+	var errs []error
+	l, err := applyLoggerConfig(Default(), c.LoggerConfig)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if l != Default() {
+		SetDefault(l)
+	}
+	for name, lc := range c.Channels {
+		ch := Channel(name)
+		l, err := applyLoggerConfig(ch, lc)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("channel %s: %w", name, err))
+		}
+		if l != ch {
+			ls.Store(name, l)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// applyLoggerConfig applies c to l, returning l or the logger replacing it.
+func applyLoggerConfig(l *Logger, c LoggerConfig) (*Logger, error) {
+	if c.Level != 0 {
+		l.SetLevel(c.Level.Real())
+	}
+	var errs []error
+	if c.Output != "" {
+		if w, err := openOutput(c.Output); err != nil {
+			errs = append(errs, err)
+		} else {
+			l.SetOutput(w)
+		}
+	}
+	if c.Format != "" || c.NoColor != nil {
+		newHandler, err := formatHandler(c.Format)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			l = l.WithOptions(func(o *Options) {
+				if newHandler != nil {
+					o.NewHandlerFunc = newHandler
+				}
+				if c.NoColor != nil {
+					o.NoColor = *c.NoColor
+				}
+			})
+		}
+	}
+	return l, errors.Join(errs...)
+}
+
+// formatHandler returns the function creating the handler of format,
+// or nil for an empty format.
+func formatHandler(format string) (func(HandlerOptions) Handler, error) {
+	switch format {
+	case "":
+		return nil, nil
+	case "text":
+		return NewSimpleHandler, nil
+	case "json":
+		return NewJSONHandler, nil
+	}
+	return nil, fmt.Errorf("l4g: unknown log format %q", format)
+}
+
+var (
+	// outputFiles holds the files opened by openOutput, by path.
+	outputFiles   = make(map[string]*os.File)
+	outputFilesMu sync.Mutex
+)
+
+// openOutput returns the writer named by output: os.Stderr for "stderr",
+// os.Stdout for "stdout", or the file at that path opened for appending,
+// the same file for the same path.
+func openOutput(output string) (io.Writer, error) {
+	switch output {
+	case "", "stderr":
+		return os.Stderr, nil
+	case "stdout":
+		return os.Stdout, nil
+	}
+	outputFilesMu.Lock()
+	defer outputFilesMu.Unlock()
+	if f, ok := outputFiles[output]; ok {
+		return f, nil
+	}
+	f, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("l4g: open log output: %w", err)
+	}
+	outputFiles[output] = f
+	return f, nil
+}
+
+// configPollInterval is the interval at which WatchConfig checks the file.
+var configPollInterval = time.Second
+
+// WatchConfig loads the Config stored in the file at path and applies it
+// with [ApplyConfig], then checks the file every second and applies it
+// again whenever its modification time or size changes, so that logging
+// can be reconfigured without a restart. The errors met after the first
+// load are reported with [FallbackErrorf], and the file is read again on
+// its next change.
+//
+// stop may be called more than once; it returns once the watching
+// goroutine has exited.
+func WatchConfig(path string) (stop func(), err error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := ApplyConfig(c); err != nil {
+		return nil, err
+	}
+
+	ticker := time.NewTicker(configPollInterval)
+	done := make(chan struct{})
+	exited := make(chan struct{})
+
+	go runLabeled("config", func() {
+		defer close(exited)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			cur, err := os.Stat(path)
+			if err != nil {
+				continue // being replaced, or removed until it comes back
+			}
+			if cur.ModTime().Equal(fi.ModTime()) && cur.Size() == fi.Size() {
+				continue
+			}
+			fi = cur
+			c, err := LoadConfig(path)
+			if err == nil {
+				err = ApplyConfig(c)
+			}
+			if err != nil {
+				FallbackErrorf("l4g: reload config: %v", err)
+			}
+		}
+	})
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		<-exited
+	}, nil
+}
@@ -0,0 +1,93 @@
+package l4g
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyConfig(t *testing.T) {
+	saved := Default()
+	defer SetDefault(saved)
+	SetDefault(New(Options{Output: os.Stderr}))
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	out := filepath.Join(dir, "db.log")
+	data := `{"level": "warn", "channels": {"test-apply": {"level": "debug", "format": "json", "output": "` + out + `"}}}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	held := Channel("test-apply")
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if err := ApplyConfig(c); err != nil {
+		t.Fatalf("ApplyConfig() error = %v", err)
+	}
+
+	if GetLevel() != LevelWarn {
+		t.Errorf("GetLevel() = %v, want warn", GetLevel())
+	}
+	if held.Level() != LevelDebug {
+		t.Errorf("Level() of the channel in use = %v, want debug", held.Level())
+	}
+	Channel("test-apply").Debug("query", "rows", 3)
+	got, _ := os.ReadFile(out)
+	if !strings.Contains(string(got), `"msg":"query"`) {
+		t.Errorf("channel output = %q, want a JSON record", got)
+	}
+}
+
+func TestApplyConfig_Errors(t *testing.T) {
+	c := Config{Channels: map[string]LoggerConfig{
+		"test-errors": {Format: "xml", Output: filepath.Join(t.TempDir(), "missing", "x.log")},
+	}}
+	err := ApplyConfig(c)
+	if err == nil || !strings.Contains(err.Error(), "xml") || !strings.Contains(err.Error(), "test-errors") {
+		t.Errorf("ApplyConfig() error = %v, want the format and output errors", err)
+	}
+}
+
+func TestWatchConfig(t *testing.T) {
+	saved := configPollInterval
+	configPollInterval = time.Millisecond
+	defer func() { configPollInterval = saved }()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	write := func(data string) {
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`{"channels": {"test-watch": {"level": "error"}}}`)
+
+	stop, err := WatchConfig(path)
+	if err != nil {
+		t.Fatalf("WatchConfig() error = %v", err)
+	}
+	defer stop()
+	l := Channel("test-watch")
+	if l.Level() != LevelError {
+		t.Errorf("Level() = %v, want error", l.Level())
+	}
+
+	write(`{"channels": {"test-watch": {"level": "trace"}}}`)
+	deadline := time.Now().Add(5 * time.Second)
+	for l.Level() != LevelTrace && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if l.Level() != LevelTrace {
+		t.Errorf("Level() = %v after the change, want trace", l.Level())
+	}
+	stop()
+	stop()
+
+	if _, err := WatchConfig(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Errorf("WatchConfig() error = nil for a missing file")
+	}
+}
@@ -0,0 +1,131 @@
+package l4g
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strconv"
+)
+
+// Keys for the attributes that identify the origin of a record in
+// concurrent code.
+const (
+	// GoroutineKey is the key used for the goroutine id enabled by
+	// [Options.GoroutineID]. The associated value is an int64.
+	GoroutineKey = "goroutine"
+	// WorkerKey is the key used for the worker label set with [WithWorker].
+	// The associated value is a string.
+	WorkerKey = "worker"
+	// StackKey is the key used for the stack of the goroutine added to the
+	// records at or above [Options.StackLevel], Panic and Fatal by default.
+	// The associated value is a string holding a function and its file
+	// and line per frame, innermost first.
+	StackKey = "stack"
+)
+
+// attrsContextKey is the context key of the attributes added with
+// ContextWithAttrs.
+type attrsContextKey struct{}
+
+// ContextWithAttrs returns a copy of ctx carrying the given attributes in
+// addition to those already carried by ctx. Loggers derived with
+// [Logger.WithContext] add them to their records.
+// args can be key-value pairs (string, any, string, any, ...) or Attr values.
+func ContextWithAttrs(ctx context.Context, args ...any) context.Context {
+	if len(args) == 0 {
+		return ctx
+	}
+	attrs := AttrsFromContext(ctx)
+	return context.WithValue(ctx, attrsContextKey{}, append(attrs[:len(attrs):len(attrs)], argsToAttrSlice(args)...))
+}
+
+// AttrsFromContext returns the attributes stored in ctx by
+// [ContextWithAttrs]. The slice must not be modified.
+func AttrsFromContext(ctx context.Context) []Attr {
+	attrs, _ := ctx.Value(attrsContextKey{}).([]Attr)
+	return attrs
+}
+
+// workerContextKey is the context key of the worker label.
+type workerContextKey struct{}
+
+// WithWorker returns a copy of ctx carrying the worker label name.
+// Loggers derived with [Logger.WithContext] tag their records with it,
+// so that the interleaved output of concurrent workers can be told apart.
+func WithWorker(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, workerContextKey{}, name)
+}
+
+// WorkerFromContext returns the worker label stored in ctx by [WithWorker].
+func WorkerFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(workerContextKey{}).(string)
+	return name, ok
+}
+
+// forceLevelContextKey is the context key of the level set with ForceLevel.
+type forceLevelContextKey struct{}
+
+// ForceLevel returns a copy of ctx marking the records at level or above
+// as enabled whatever the minimum level of the logger, to debug a single
+// request without lowering the verbosity of the others. It takes effect
+// in the loggers derived with [Logger.WithContext].
+func ForceLevel(ctx context.Context, level Level) context.Context {
+	return context.WithValue(ctx, forceLevelContextKey{}, level.Real())
+}
+
+// ForcedLevelFromContext returns the level stored in ctx by [ForceLevel].
+func ForcedLevelFromContext(ctx context.Context) (Level, bool) {
+	level, ok := ctx.Value(forceLevelContextKey{}).(Level)
+	return level, ok
+}
+
+// WithContext returns a Logger that adds the attributes carried by ctx,
+// such as the correlation id set with [WithCorrelationID], the worker
+// label set with [WithWorker] and the attributes set with
+// [ContextWithAttrs], to all subsequent log output, and that logs the
+// records at the level set with [ForceLevel] or above. If the options of
+// l have an ErrorHook, the returned logger passes ctx to it with each
+// record at LevelError or above, so that the hook can, for instance, add
+// the record as an event to the span active in ctx. The record holds the
+// attributes of the log call, not those added to the logger.
+// It returns the receiver if ctx carries none of these and there is no
+// ErrorHook.
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	var args []any
+	if id, ok := CorrelationIDFromContext(ctx); ok {
+		args = append(args, String(CorrelationIDKey, id))
+	}
+	if name, ok := WorkerFromContext(ctx); ok {
+		args = append(args, String(WorkerKey, name))
+	}
+	for _, a := range AttrsFromContext(ctx) {
+		args = append(args, a)
+	}
+	l2 := l.WithAttrs(args...)
+	if level, ok := ForcedLevelFromContext(ctx); ok && level != l.forceLevel {
+		if l2 == l {
+			l2 = l.clone()
+		}
+		l2.forceLevel = level
+	}
+	if l.errorHook != nil {
+		if l2 == l {
+			l2 = l.clone()
+		}
+		l2.ctx = ctx
+	}
+	return l2
+}
+
+// goroutineID returns the id of the calling goroutine, parsed from the
+// header of its stack trace ("goroutine 18 [running]:").
+func goroutineID() int64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseInt(string(b), 10, 64)
+	return id
+}
@@ -0,0 +1,115 @@
+package l4g
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWithWorker(t *testing.T) {
+	ctx := WithWorker(context.Background(), "fetcher-1")
+
+	name, ok := WorkerFromContext(ctx)
+	if !ok || name != "fetcher-1" {
+		t.Errorf("WorkerFromContext() = %q, %v, want fetcher-1, true", name, ok)
+	}
+
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true})
+	logger.WithContext(ctx).Info("start")
+	if !strings.Contains(buf.String(), "worker=fetcher-1") {
+		t.Errorf("output = %q, want worker attribute", buf.String())
+	}
+}
+
+func TestLogger_WithContextWithoutWorker(t *testing.T) {
+	logger := New(Options{Output: &bytes.Buffer{}})
+	if logger.WithContext(context.Background()) != logger {
+		t.Errorf("WithContext() should return the receiver for an empty context")
+	}
+}
+
+func TestForceLevel(t *testing.T) {
+	ctx := ForceLevel(context.Background(), LevelTrace)
+	if level, ok := ForcedLevelFromContext(ctx); !ok || level != LevelTrace {
+		t.Errorf("ForcedLevelFromContext() = %v, %v, want trace, true", level, ok)
+	}
+
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true, Level: LevelWarn})
+	forced := logger.WithContext(ctx).WithAttrs("request", 1)
+	forced.Debug("request debug")
+	forced.Trace("request trace")
+	logger.Debug("global debug")
+
+	got := buf.String()
+	if !strings.Contains(got, "request debug") || !strings.Contains(got, "request trace") {
+		t.Errorf("output = %q, want the records of the forced context", got)
+	}
+	if strings.Contains(got, "global debug") {
+		t.Errorf("output = %q, want no debug records outside the forced context", got)
+	}
+	if logger.Level() != LevelWarn {
+		t.Errorf("Level() = %v, want warn", logger.Level())
+	}
+
+	buf.Reset()
+	logger.WithContext(ForceLevel(context.Background(), LevelDebug)).Trace("trace")
+	if buf.Len() != 0 {
+		t.Errorf("output = %q, want no records below the forced level", buf.String())
+	}
+}
+
+func TestGoroutineID(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true, GoroutineID: true})
+
+	id := goroutineID()
+	if id <= 0 {
+		t.Fatalf("goroutineID() = %d, want a positive id", id)
+	}
+
+	done := make(chan int64)
+	go func() { done <- goroutineID() }()
+	if other := <-done; other == id {
+		t.Errorf("goroutineID() = %d in another goroutine, want a different id", other)
+	}
+
+	logger.WithPrefix("p").Info("m")
+	if want := "goroutine=" + strconv.FormatInt(id, 10); !strings.Contains(buf.String(), want) {
+		t.Errorf("output = %q, want goroutine attribute", buf.String())
+	}
+}
+
+func TestLogger_ErrorHook(t *testing.T) {
+	type spanKey struct{}
+	var events []string
+	hook := func(ctx context.Context, r Record) {
+		span, _ := ctx.Value(spanKey{}).(string)
+		events = append(events, fmt.Sprintf("%s: %v %s %s", span, r.Level, r.Message, recordAttrsString(r)))
+	}
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true, ErrorHook: hook})
+	ctx := context.WithValue(context.Background(), spanKey{}, "span1")
+
+	logger.Error("without context")
+	l := logger.WithContext(ctx)
+	l.Warn("slow")
+	l.Error("failed", "code", 500)
+	l.WithAttrs("k", "v").Errorf("retry %d", 2, Int("n", 1))
+
+	want := []string{"span1: error failed [code=500]", "span1: error retry 2 [n=1]"}
+	if fmt.Sprint(events) != fmt.Sprint(want) {
+		t.Errorf("events = %q, want %q", events, want)
+	}
+	if !strings.Contains(buf.String(), "failed code=500") {
+		t.Errorf("output = %q, want the records written", buf.String())
+	}
+
+	if l := New(Options{Output: buf}); l.WithContext(ctx) != l {
+		t.Errorf("WithContext() without ErrorHook should return the receiver for an empty context")
+	}
+}
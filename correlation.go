@@ -0,0 +1,83 @@
+package l4g
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+// CorrelationIDKey is the key of the correlation id attribute added by
+// [Logger.WithContext]. The associated value is a string.
+const CorrelationIDKey = "correlation_id"
+
+// CorrelationIDHeader is the HTTP header read and written by
+// [CorrelationMiddleware].
+const CorrelationIDHeader = "X-Correlation-ID"
+
+// CorrelationIDFunc generates the ids returned by [NewCorrelationID].
+// It defaults to [NewUUIDv7] and can be replaced, e.g. with a KSUID
+// generator, at program start.
+var CorrelationIDFunc func() string = NewUUIDv7
+
+// NewCorrelationID returns a new correlation id generated by
+// [CorrelationIDFunc].
+func NewCorrelationID() string {
+	return CorrelationIDFunc()
+}
+
+// NewUUIDv7 returns a random, time-ordered UUID (RFC 9562, version 7)
+// in its canonical textual form.
+func NewUUIDv7() string {
+	var u [16]byte
+	_, _ = rand.Read(u[6:])
+	binary.BigEndian.PutUint64(u[:8], uint64(time.Now().UnixMilli())<<16|uint64(binary.BigEndian.Uint16(u[6:8])))
+	u[6] = 0x70 | u[6]&0x0f // version 7
+	u[8] = 0x80 | u[8]&0x3f // variant 10
+
+	var b [36]byte
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return string(b[:])
+}
+
+// correlationIDContextKey is the context key of the correlation id.
+type correlationIDContextKey struct{}
+
+// WithCorrelationID returns a copy of ctx carrying the correlation id.
+// Loggers derived with [Logger.WithContext] add it to their records.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDContextKey{}, id)
+}
+
+// CorrelationIDFromContext returns the correlation id stored in ctx by
+// [WithCorrelationID].
+func CorrelationIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(correlationIDContextKey{}).(string)
+	return id, ok
+}
+
+// CorrelationMiddleware returns an http.Handler that propagates the
+// correlation id of each request: the id is taken from the
+// [CorrelationIDHeader] request header, or generated with
+// [NewCorrelationID] if missing, then stored in the request context and
+// echoed in the response header before calling next.
+func CorrelationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(CorrelationIDHeader)
+		if id == "" {
+			id = NewCorrelationID()
+		}
+		w.Header().Set(CorrelationIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(WithCorrelationID(r.Context(), id)))
+	})
+}
@@ -0,0 +1,62 @@
+package l4g
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestNewUUIDv7(t *testing.T) {
+	re := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	prev := NewUUIDv7()
+	for range 100 {
+		id := NewUUIDv7()
+		if !re.MatchString(id) {
+			t.Fatalf("NewUUIDv7() = %q, want a version 7 UUID", id)
+		}
+		if id == prev {
+			t.Fatalf("NewUUIDv7() returned %q twice", id)
+		}
+		if id[:8] < prev[:8] {
+			t.Errorf("NewUUIDv7() = %q after %q, want time-ordered ids", id, prev)
+		}
+		prev = id
+	}
+}
+
+func TestNewCorrelationID_CustomFunc(t *testing.T) {
+	defer func(f func() string) { CorrelationIDFunc = f }(CorrelationIDFunc)
+	CorrelationIDFunc = func() string { return "fixed" }
+
+	if got := NewCorrelationID(); got != "fixed" {
+		t.Errorf("NewCorrelationID() = %q, want fixed", got)
+	}
+}
+
+func TestCorrelationMiddleware(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true})
+	h := CorrelationMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.WithContext(r.Context()).Info("handled")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(CorrelationIDHeader, "abc-123")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if got := rec.Header().Get(CorrelationIDHeader); got != "abc-123" {
+		t.Errorf("response header = %q, want the propagated id", got)
+	}
+	if !strings.Contains(buf.String(), "handled correlation_id=abc-123\n") {
+		t.Errorf("output = %q, want the correlation id", buf.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if id := rec.Header().Get(CorrelationIDHeader); id == "" || !strings.Contains(buf.String(), "correlation_id="+id) {
+		t.Errorf("generated id %q was not logged: %q", id, buf.String())
+	}
+}
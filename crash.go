@@ -0,0 +1,111 @@
+package l4g
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// ReportKey is the key of the attribute holding the path of the crash
+// report written by [CapturePanics].
+const ReportKey = "report"
+
+// crashReport is the content of a crash report file.
+type crashReport struct {
+	Record json.RawMessage  `json:"record"`
+	Stack  string           `json:"stack"`
+	Build  *debug.BuildInfo `json:"build,omitempty"`
+}
+
+// CapturePanics, deferred at the top of main, turns a panic escaping main
+// into a crash report. It writes to dir, created if needed, a file named
+// crash-<time>-<pid>.json holding the record of the panic as written by
+// the [JSONHandler], the stack of the goroutine and the build
+// information. It then logs the record with l at LevelFatal, with the
+// value of the panic under [PanicKey] and the path of the report under
+// [ReportKey], flushes l and panics again with the same value, so that
+// the program still crashes with the usual trace and exit status. If l
+// is nil, the default logger is used.
+//
+//	func main() {
+//		defer l4g.CapturePanics(logger, "/var/log/crash")
+//		...
+//	}
+//
+// It must be deferred directly, as recover only stops a panic there, and
+// only captures the panics of its own goroutine; see [Go] for the others.
+func CapturePanics(l *Logger, dir string) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	if l == nil {
+		l = Default()
+	}
+	stack := string(debug.Stack())
+	r := NewRecord(l.clock.Now(), LevelFatal, "unhandled panic")
+	r.PC = panicPC()
+	r.AddAttrs(Any(PanicKey, p))
+
+	path, err := writeCrashReport(dir, r, stack)
+	if err != nil {
+		FallbackErrorf("unable to write crash report: %v", err)
+	} else {
+		r.AddAttrs(String(ReportKey, path))
+	}
+	if l.stackLevel > 0 && LevelFatal >= l.stackLevel {
+		r.AddAttrs(String(StackKey, stack))
+	}
+	if l.Enabled(LevelFatal) {
+		l.handle(r)
+	}
+	l.flushBeforeExit()
+	panic(p)
+}
+
+// writeCrashReport writes the crash report of r and stack to a new file
+// in dir, returning its path.
+func writeCrashReport(dir string, r Record, stack string) (string, error) {
+	buf := NewBuffer()
+	defer buf.Free()
+	h := NewJSONHandler(HandlerOptions{Output: buf, AddSource: true})
+	if err := h.Handle(r); err != nil {
+		return "", err
+	}
+	report := crashReport{Record: json.RawMessage(*buf), Stack: stack}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		report.Build = bi
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("crash-%s-%d.json", r.Time.UTC().Format("20060102T150405.000Z"), os.Getpid())
+	path := filepath.Join(dir, name)
+	return path, os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// panicPC returns the program counter of the call to panic, or of the
+// faulting instruction, in the stack of the deferred function calling it,
+// that is the first frame outside the runtime after runtime.gopanic.
+func panicPC() uintptr {
+	var pcs [64]uintptr
+	n := runtime.Callers(3, pcs[:])
+	panicking := false
+	for i, pc := range pcs[:n] {
+		f, _ := runtime.CallersFrames(pcs[i : i+1]).Next()
+		if f.Function == "runtime.gopanic" {
+			panicking = true
+		} else if panicking && !strings.HasPrefix(f.Function, "runtime.") {
+			return pc
+		}
+	}
+	return 0
+}
@@ -0,0 +1,74 @@
+package l4g
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCapturePanics(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "crash")
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true, Clock: newFakeClock(), FlushTimeout: -1})
+
+	var p any
+	func() {
+		defer func() { p = recover() }()
+		func() {
+			defer CapturePanics(logger, dir)
+			panic("boom")
+		}()
+	}()
+	if p != "boom" {
+		t.Fatalf("recovered %v, want the panic rethrown", p)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("crash-20240501T120000.000Z-%d.json", os.Getpid()))
+	out := buf.String()
+	if !strings.Contains(out, "FATAL unhandled panic panic=boom report="+path) {
+		t.Errorf("output = %q, want the fatal record with the report", out)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	var report struct {
+		Record map[string]any
+		Stack  string
+		Build  map[string]any
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("report = %s: %v", data, err)
+	}
+	if report.Record["level"] != "FATAL" || report.Record[PanicKey] != "boom" {
+		t.Errorf("record = %v, want the fatal record", report.Record)
+	}
+	if source := fmt.Sprint(report.Record[SourceKey]); !strings.Contains(source, "crash_test.go") {
+		t.Errorf("source = %q, want the line of the panic", source)
+	}
+	if !strings.Contains(report.Stack, "TestCapturePanics") {
+		t.Errorf("stack = %q, want the goroutine of the panic", report.Stack)
+	}
+	if report.Build["GoVersion"] == nil {
+		t.Errorf("build = %v, want the build information", report.Build)
+	}
+}
+
+func TestCapturePanics_NoPanic(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "crash")
+	buf := &bytes.Buffer{}
+	func() {
+		defer CapturePanics(New(Options{Output: buf}), dir)
+	}()
+	if buf.Len() != 0 {
+		t.Errorf("output = %q, want none", buf.String())
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Stat(dir) error = %v, want no report", err)
+	}
+}
@@ -0,0 +1,368 @@
+package l4g
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// maxEncryptedFrame bounds the frame size accepted by a DecryptReader, so
+// that a corrupted length prefix cannot trigger a huge allocation.
+const maxEncryptedFrame = 64 << 20
+
+const (
+	// encryptMagic starts the header of an encrypted stream.
+	encryptMagic = "l4gE"
+	// streamIDSize is the size of the random identifier of a stream.
+	streamIDSize = 16
+	// finalFrame is the bit of the length prefix marking the end-of-stream
+	// frame.
+	finalFrame = 1 << 31
+	// frameADSize is the size of the additional data authenticated with a
+	// frame: the stream identifier, the frame number and the length prefix.
+	frameADSize = streamIDSize + 8 + 4
+)
+
+// EncryptWriter is an io.WriteCloser that encrypts log output at rest with
+// AES-GCM. Every Write is sealed as one frame, so that each record written
+// by a handler can be decrypted on its own. The stream starts with a
+// header holding a random stream identifier:
+//
+//	"l4gE" | stream identifier (16 bytes)
+//
+// followed by the frames:
+//
+//	length (4 bytes, big endian) | nonce (12 bytes) | ciphertext and tag
+//
+// where length counts the nonce, the ciphertext and the tag, and its high
+// bit marks the empty frame written by Flush or Close to end the stream.
+// Each frame is authenticated together with the stream identifier, its
+// number in the stream and its length, so that frames cannot be dropped,
+// reordered or moved from another stream without [DecryptReader]
+// reporting an error. The nonce is random, so a key may be reused across
+// files and process restarts. It is safe for concurrent use by multiple
+// goroutines.
+type EncryptWriter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	aead    cipher.AEAD
+	buf     []byte
+	stream  [streamIDSize]byte
+	seq     uint64 // number of frames written to the stream
+	started bool   // whether the stream header was written
+	closed  bool
+	ad      [frameADSize]byte
+}
+
+// NewEncryptWriter returns an EncryptWriter writing frames to w under key,
+// which must be 16, 24 or 32 bytes long to select AES-128, AES-192 or
+// AES-256.
+func NewEncryptWriter(w io.Writer, key []byte) (*EncryptWriter, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptWriter{w: w, aead: aead}, nil
+}
+
+// maxRetainedFrame is the largest frame buffer an EncryptWriter keeps
+// after Compact.
+const maxRetainedFrame = 64 << 10
+
+// Compact releases the frame buffer if a large record made it grow past
+// 64 KiB.
+func (w *EncryptWriter) Compact() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if cap(w.buf) > maxRetainedFrame {
+		w.buf = nil
+	}
+}
+
+// Write encrypts p as a single frame and writes it to the underlying writer.
+func (w *EncryptWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+	if err := w.writeFrame(p, false); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush ends the stream with the end-of-stream frame, without which a
+// DecryptReader reports the stream as truncated, and flushes the
+// underlying writer if it is a [Flusher]. The next Write starts a new
+// stream. The logger calls it before Fatal and Panic exit, so that the
+// records written so far read back as complete.
+func (w *EncryptWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	return w.endStream()
+}
+
+// Close ends the stream as Flush does. It does not close the underlying
+// writer.
+func (w *EncryptWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.endStream()
+}
+
+// endStream writes the end-of-stream frame if a stream was started and
+// flushes the underlying writer. w.mu must be held.
+func (w *EncryptWriter) endStream() error {
+	if !w.started {
+		return nil
+	}
+	if err := w.writeFrame(nil, true); err != nil {
+		return err
+	}
+	w.started = false
+	return flush(w.w)
+}
+
+// writeFrame encrypts p as the next frame and writes it, preceded by the
+// header of a new stream if none was started. w.mu must be held.
+func (w *EncryptWriter) writeFrame(p []byte, final bool) error {
+	size := w.aead.NonceSize() + len(p) + w.aead.Overhead()
+	if size > maxEncryptedFrame {
+		return fmt.Errorf("l4g: encrypted frame of %d bytes exceeds the limit", size)
+	}
+	buf := w.buf[:0]
+	if !w.started {
+		if _, err := rand.Read(w.stream[:]); err != nil {
+			return err
+		}
+		w.seq = 0
+		buf = append(buf, encryptMagic...)
+		buf = append(buf, w.stream[:]...)
+	}
+	start := len(buf)
+	buf = append(buf, make([]byte, 4+w.aead.NonceSize())...)
+	hdr := uint32(size)
+	if final {
+		hdr |= finalFrame
+	}
+	binary.BigEndian.PutUint32(buf[start:], hdr)
+	nonce := buf[start+4:]
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	buf = w.aead.Seal(buf, nonce, p, frameAD(&w.ad, &w.stream, w.seq, hdr))
+	w.buf = buf
+
+	if _, err := w.w.Write(buf); err != nil {
+		return err
+	}
+	w.started = true
+	w.seq++
+	return nil
+}
+
+// DecryptReader is an io.Reader returning the plaintext of the streams
+// written by [EncryptWriter]s, one after another, as when a file is
+// appended to after a restart.
+type DecryptReader struct {
+	r        *bufio.Reader
+	pending  []byte // bytes to read again before r, after a bad frame
+	aead     cipher.AEAD
+	buf      []byte // undelivered plaintext
+	header   [len(encryptMagic) + streamIDSize]byte
+	seq      uint64 // number of frames read from the stream
+	inStream bool   // whether the end of the stream is still to be read
+	ad       [frameADSize]byte
+	err      error // first error found in the input
+}
+
+// NewDecryptReader returns a DecryptReader reading frames from r, which
+// were encrypted under key.
+//
+// A frame that fails authentication, is missing or out of order, or a
+// stream that is truncated, including one that was neither flushed nor
+// closed before the process crashed, does not stop the reader: it skips
+// to the header of the next stream, so that the streams appended after
+// an unclean restart can still be read. The first such error is returned
+// by Read at the end of the input in place of io.EOF.
+func NewDecryptReader(r io.Reader, key []byte) (*DecryptReader, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return &DecryptReader{r: bufio.NewReader(r), aead: aead}, nil
+}
+
+// Read reads decrypted log output into p.
+func (r *DecryptReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if err := r.next(); err == io.EOF && r.err != nil {
+			return 0, r.err
+		} else if err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// next decrypts the next frame into r.buf, reading the header of the
+// stream first if the previous one has ended. It returns io.EOF at the
+// end of the input, and an error only if the input cannot be read.
+func (r *DecryptReader) next() error {
+	if !r.inStream {
+		header := r.header[:]
+		n, err := r.readFull(header)
+		if err == io.EOF {
+			return err // at a stream boundary
+		}
+		if err == io.ErrUnexpectedEOF {
+			return r.fail(errors.New("l4g: truncated encrypted stream header"), header[:n])
+		}
+		if err != nil {
+			return err
+		}
+		if string(header[:len(encryptMagic)]) != encryptMagic {
+			return r.fail(errors.New("l4g: invalid encrypted stream header"), header)
+		}
+		r.seq = 0
+		r.inStream = true
+	}
+
+	frame := make([]byte, 4, 64)
+	n, err := r.readFull(frame)
+	if err == io.EOF {
+		return r.fail(errMissingEndFrame, nil)
+	}
+	if err == io.ErrUnexpectedEOF {
+		return r.fail(errors.New("l4g: truncated encrypted frame"), frame[:n])
+	}
+	if err != nil {
+		return err
+	}
+	if string(frame) == encryptMagic {
+		// A new stream starts: the previous one was not ended.
+		r.pending = append(frame, r.pending...)
+		return r.fail(errMissingEndFrame, nil)
+	}
+	h := binary.BigEndian.Uint32(frame)
+	size := int(h &^ finalFrame)
+	if size < r.aead.NonceSize()+r.aead.Overhead() || size > maxEncryptedFrame {
+		return r.fail(fmt.Errorf("l4g: invalid encrypted frame size %d", size), frame)
+	}
+	frame = append(frame, make([]byte, size)...)
+	n, err = r.readFull(frame[4:])
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return r.fail(errors.New("l4g: truncated encrypted frame"), frame[:4+n])
+	}
+	if err != nil {
+		return err
+	}
+	nonce, ciphertext := frame[4:4+r.aead.NonceSize()], frame[4+r.aead.NonceSize():]
+	plain, err := r.aead.Open(nil, nonce, ciphertext, frameAD(&r.ad, (*[streamIDSize]byte)(r.header[len(encryptMagic):]), r.seq, h))
+	if err != nil {
+		return r.fail(fmt.Errorf("l4g: decrypt frame %d: %w", r.seq, err), frame)
+	}
+	r.seq++
+	r.inStream = h&finalFrame == 0
+	r.buf = plain
+	return nil
+}
+
+// errMissingEndFrame reports a stream that was not ended by its writer.
+var errMissingEndFrame = errors.New("l4g: truncated encrypted stream: missing end-of-stream frame")
+
+// fail records err, if it is the first, and skips to the next stream
+// header, looking for it from the second of the bytes of the bad frame or
+// header, skipped, on. If the first frame of a stream is bad, the search
+// starts within the stream header, which may have been torn by a crash
+// and completed by the header of the next stream.
+func (r *DecryptReader) fail(err error, skipped []byte) error {
+	if r.err == nil {
+		r.err = err
+	}
+	if r.inStream && r.seq == 0 {
+		skipped = append(r.header[:], skipped...)
+	}
+	r.inStream = false
+	if len(skipped) > 0 {
+		r.pending = append(skipped[1:len(skipped):len(skipped)], r.pending...)
+	}
+	matched := 0
+	for matched < len(encryptMagic) {
+		c, err := r.readByte()
+		if err == io.EOF {
+			return nil // next returns io.EOF
+		}
+		if err != nil {
+			return err
+		}
+		switch {
+		case c == encryptMagic[matched]:
+			matched++
+		case c == encryptMagic[0]:
+			matched = 1
+		default:
+			matched = 0
+		}
+	}
+	r.pending = append([]byte(encryptMagic), r.pending...)
+	return nil
+}
+
+// readFull reads len(p) bytes, from r.pending first, as io.ReadFull.
+func (r *DecryptReader) readFull(p []byte) (int, error) {
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	if n == len(p) {
+		return n, nil
+	}
+	m, err := io.ReadFull(r.r, p[n:])
+	if err == io.EOF && n > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return n + m, err
+}
+
+// readByte reads a byte, from r.pending first.
+func (r *DecryptReader) readByte() (byte, error) {
+	if len(r.pending) > 0 {
+		c := r.pending[0]
+		r.pending = r.pending[1:]
+		return c, nil
+	}
+	return r.r.ReadByte()
+}
+
+// frameAD fills ad with the additional data of the frame numbered seq in
+// the stream, with length prefix hdr, and returns it.
+func frameAD(ad *[frameADSize]byte, stream *[streamIDSize]byte, seq uint64, hdr uint32) []byte {
+	copy(ad[:], stream[:])
+	binary.BigEndian.PutUint64(ad[streamIDSize:], seq)
+	binary.BigEndian.PutUint32(ad[streamIDSize+8:], hdr)
+	return ad[:]
+}
+
+// newGCM returns an AES-GCM AEAD for key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
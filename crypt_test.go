@@ -0,0 +1,179 @@
+package l4g
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEncryptWriter(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	buf := &bytes.Buffer{}
+	w, err := NewEncryptWriter(buf, key)
+	if err != nil {
+		t.Fatalf("NewEncryptWriter() error = %v", err)
+	}
+	logger := New(Options{Output: w, NoColor: true})
+	logger.Info("secret", "ssn", "123-45-6789")
+	logger.Warn("second")
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err := w.Write([]byte("late\n")); err != ErrWriterClosed {
+		t.Errorf("Write() after Close error = %v, want ErrWriterClosed", err)
+	}
+
+	if strings.Contains(buf.String(), "123-45-6789") {
+		t.Fatalf("output contains plaintext")
+	}
+
+	r, err := NewDecryptReader(bytes.NewReader(buf.Bytes()), key)
+	if err != nil {
+		t.Fatalf("NewDecryptReader() error = %v", err)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if !strings.Contains(string(out), "INFO secret ssn=123-45-6789\n") || !strings.Contains(string(out), "WARN second\n") {
+		t.Errorf("decrypted = %q", out)
+	}
+}
+
+// encryptStream returns the stream written by an EncryptWriter for the
+// given writes, closed if close is set, with the offsets of its frames.
+func encryptStream(t *testing.T, key []byte, close bool, writes ...string) ([]byte, []int) {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	w, err := NewEncryptWriter(buf, key)
+	if err != nil {
+		t.Fatalf("NewEncryptWriter() error = %v", err)
+	}
+	offsets := []int{len(encryptMagic) + streamIDSize}
+	for _, s := range writes {
+		w.Write([]byte(s))
+		offsets = append(offsets, buf.Len())
+	}
+	if close {
+		w.Close()
+	}
+	return buf.Bytes(), offsets
+}
+
+func TestDecryptReader_Streams(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 16)
+	buf := &bytes.Buffer{}
+	w, _ := NewEncryptWriter(buf, key)
+	w.Write([]byte("a\n"))
+	w.Flush()
+	w.Flush()
+	w.Write([]byte("b\n"))
+	w.Close()
+	if n := bytes.Count(buf.Bytes(), []byte(encryptMagic)); n != 2 {
+		t.Errorf("%d stream headers, want 2 streams ended by Flush and Close", n)
+	}
+	second, _ := encryptStream(t, key, true, "c\n")
+
+	data := append(bytes.Clone(buf.Bytes()), second...)
+	r, err := NewDecryptReader(bytes.NewReader(data), key)
+	if err != nil {
+		t.Fatalf("NewDecryptReader() error = %v", err)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil || string(out) != "a\nb\nc\n" {
+		t.Errorf("ReadAll() = %q, %v, want the records of the appended streams", out, err)
+	}
+}
+
+func TestDecryptReader_Resync(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 16)
+	crashed, offsets := encryptStream(t, key, false, "before crash\n", "lost\n")
+	restarted, _ := encryptStream(t, key, true, "after restart\n")
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"not closed", slices.Concat(crashed, restarted), "before crash\nlost\nafter restart\n"},
+		{"torn frame", slices.Concat(crashed[:offsets[2]-5], restarted), "before crash\nafter restart\n"},
+		{"torn header", slices.Concat(crashed[:10], restarted), "after restart\n"},
+		{"garbage", slices.Concat(crashed[:offsets[1]], []byte("l4g\x00garbage"), restarted), "before crash\nafter restart\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewDecryptReader(bytes.NewReader(tt.data), key)
+			if err != nil {
+				t.Fatalf("NewDecryptReader() error = %v", err)
+			}
+			out, err := io.ReadAll(r)
+			if string(out) != tt.want {
+				t.Errorf("ReadAll() = %q, want %q", out, tt.want)
+			}
+			if err == nil {
+				t.Errorf("ReadAll() error = nil, want the truncation reported")
+			}
+		})
+	}
+}
+
+func TestEncryptWriter_FlushBeforeExit(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 16)
+	buf := &bytes.Buffer{}
+	w, _ := NewEncryptWriter(buf, key)
+	logger := New(Options{Output: w, NoColor: true})
+	func() {
+		defer func() { recover() }()
+		logger.Panic("crash")
+	}()
+
+	r, _ := NewDecryptReader(bytes.NewReader(buf.Bytes()), key)
+	out, err := io.ReadAll(r)
+	if err != nil || !strings.Contains(string(out), "crash") {
+		t.Errorf("ReadAll() = %q, %v, want the stream ended before the panic", out, err)
+	}
+}
+
+func TestDecryptReader_Errors(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 16)
+	data, _ := encryptStream(t, key, true, "record\n")
+	open, offsets := encryptStream(t, key, false, "a\n", "b\n", "c\n")
+	other, otherOffsets := encryptStream(t, key, true, "x\n", "y\n")
+	frame := func(data []byte, offsets []int, i int) []byte {
+		return data[offsets[i]:offsets[i+1]]
+	}
+	header := open[:offsets[0]]
+
+	tests := []struct {
+		name string
+		data []byte
+		key  []byte
+	}{
+		{"wrong key", data, bytes.Repeat([]byte{8}, 16)},
+		{"truncated", data[:len(data)-3], key},
+		{"tampered", append(bytes.Clone(data[:len(data)-1]), data[len(data)-1]^1), key},
+		{"not closed", open, key},
+		{"dropped", slices.Concat(header, frame(open, offsets, 0), frame(open, offsets, 2)), key},
+		{"reordered", slices.Concat(header, frame(open, offsets, 1), frame(open, offsets, 0)), key},
+		{"spliced", slices.Concat(header, frame(open, offsets, 0), frame(other, otherOffsets, 1)), key},
+		{"end of other stream", slices.Concat(open, other[otherOffsets[2]:]), key},
+		{"no header", data[offsets[0]:], key},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewDecryptReader(bytes.NewReader(tt.data), tt.key)
+			if err != nil {
+				t.Fatalf("NewDecryptReader() error = %v", err)
+			}
+			if _, err := io.ReadAll(r); err == nil {
+				t.Errorf("ReadAll() error = nil, want an error")
+			}
+		})
+	}
+
+	if _, err := NewEncryptWriter(&bytes.Buffer{}, []byte("short")); err == nil {
+		t.Errorf("NewEncryptWriter() with an invalid key should fail")
+	}
+}
@@ -0,0 +1,119 @@
+package l4g
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// QueryKey is the key of the group returned by [Query].
+const QueryKey = "query"
+
+// QueryOptions configure the attributes returned by [QueryOptions.Attr].
+// The zero value is the configuration of [Query].
+type QueryOptions struct {
+	// MaxLen is the largest number of bytes of SQL kept; longer statements
+	// are cut and end with "...". Zero means 1000, negative no limit.
+	MaxLen int
+
+	// StripLiterals replaces the string and number literals of the SQL
+	// with '?', for statements built with their values inline.
+	StripLiterals bool
+
+	// Args logs the values of the arguments instead of their types. The
+	// values may hold personal data or secrets.
+	Args bool
+}
+
+// Query returns a [QueryKey] group describing a database query for safe
+// logging: its SQL, cut after 1000 bytes, the number of its arguments and
+// their types, but not their values. Use [QueryOptions] for the other
+// settings.
+func Query(sql string, args []any) Attr {
+	return QueryOptions{}.Attr(sql, args)
+}
+
+// Attr returns a [QueryKey] group describing a database query as
+// configured by o: the sql, the number of args and their arg_types, or
+// their values as args if o.Args is set.
+func (o QueryOptions) Attr(sql string, args []any) Attr {
+	if o.StripLiterals {
+		sql = stripSQLLiterals(sql)
+	}
+	maxLen := o.MaxLen
+	if maxLen == 0 {
+		maxLen = 1000
+	}
+	if maxLen > 0 && len(sql) > maxLen {
+		for maxLen > 0 && !utf8.RuneStart(sql[maxLen]) {
+			maxLen--
+		}
+		sql = sql[:maxLen] + "..."
+	}
+	if o.Args {
+		return Group(QueryKey, String("sql", sql), Any("args", args))
+	}
+	types := make([]string, len(args))
+	for i, arg := range args {
+		types[i] = fmt.Sprintf("%T", arg)
+	}
+	return Group(QueryKey, String("sql", sql), Int("args", len(args)), Any("arg_types", types))
+}
+
+// stripSQLLiterals replaces the quoted strings and the numbers of sql
+// with '?', keeping quoted identifiers and placeholders such as $1.
+func stripSQLLiterals(sql string) string {
+	var b strings.Builder
+	b.Grow(len(sql))
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '\'':
+			// a string, where '' is a quote
+			j := i + 1
+			for j < len(sql) {
+				if sql[j] == '\'' {
+					if j+1 < len(sql) && sql[j+1] == '\'' {
+						j += 2
+						continue
+					}
+					j++
+					break
+				}
+				j++
+			}
+			b.WriteByte('?')
+			i = j
+		case isDigit(c) && (i == 0 || !isIdentByte(sql[i-1])):
+			j := i
+			for j < len(sql) && (isDigit(sql[j]) || sql[j] == '.' || isIdentByte(sql[j])) {
+				j++
+			}
+			b.WriteByte('?')
+			i = j
+		case c == '"' || c == '`':
+			// a quoted identifier, kept
+			j := strings.IndexByte(sql[i+1:], c)
+			if j < 0 {
+				j = len(sql) - i - 2
+			}
+			b.WriteString(sql[i : i+j+2])
+			i += j + 2
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// isIdentByte reports whether c may be part of an identifier or of a
+// placeholder such as $1, :1 or @p1.
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || c == ':' || c == '@' || isDigit(c) ||
+		'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c >= utf8.RuneSelf
+}
@@ -0,0 +1,42 @@
+package l4g
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuery(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(Options{Output: &buf, Handler: NewJSONHandler(HandlerOptions{Output: &buf})})
+
+	logger.Info("db", Query("SELECT * FROM users WHERE id = $1 AND name = $2", []any{7, "alice", nil, time.Time{}}))
+	want := `"query":{"sql":"SELECT * FROM users WHERE id = $1 AND name = $2","args":4,"arg_types":["int","string","<nil>","time.Time"]}`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("output = %s, want to contain %s", buf.String(), want)
+	}
+	if strings.Contains(buf.String(), "alice") {
+		t.Errorf("output = %s, want no argument values", buf.String())
+	}
+
+	buf.Reset()
+	logger.Info("db", QueryOptions{Args: true, MaxLen: 10}.Attr("SELECT name FROM users", []any{"x"}))
+	if want := `"query":{"sql":"SELECT nam...","args":["x"]}`; !strings.Contains(buf.String(), want) {
+		t.Errorf("output = %s, want to contain %s", buf.String(), want)
+	}
+}
+
+func TestStripSQLLiterals(t *testing.T) {
+	tests := []struct{ sql, want string }{
+		{`SELECT * FROM t WHERE a = 'x' AND b = 42`, `SELECT * FROM t WHERE a = ? AND b = ?`},
+		{`INSERT INTO t VALUES ('it''s', 3.14, -1e5)`, `INSERT INTO t VALUES (?, ?, -?)`},
+		{`SELECT "col1", t2.c3 FROM t2 WHERE x = $1 OR y = :2 OR z = @p3`, `SELECT "col1", t2.c3 FROM t2 WHERE x = $1 OR y = :2 OR z = @p3`},
+		{"SELECT `a'b` FROM t WHERE c = 'unterminated", "SELECT `a'b` FROM t WHERE c = ?"},
+	}
+	for _, tt := range tests {
+		if got := stripSQLLiterals(tt.sql); got != tt.want {
+			t.Errorf("stripSQLLiterals(%q) = %q, want %q", tt.sql, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package l4g
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DebugHandler returns an http.Handler serving the records kept by
+// [DefaultRing], to be mounted under a path such as /debug/logs:
+//
+//	l4g.SetDefault(l4g.New(l4g.Options{
+//		Output:  os.Stderr,
+//		Handler: l4g.NewRingHandler(l4g.NewSimpleHandler(opts), l4g.DefaultRing),
+//	}))
+//	http.Handle("/debug/logs", l4g.DebugHandler())
+//
+// See [Ring.ServeHTTP] for the requests it serves. Like net/http/pprof, it
+// exposes the internals of the program and should not be reachable by
+// untrusted clients.
+func DebugHandler() http.Handler {
+	return DefaultRing
+}
+
+// ServeHTTP serves the records kept by r: as an HTML page by default, as a
+// JSON array if the format parameter is "json" or the request accepts
+// application/json, and as a stream of server-sent events, one JSON entry
+// per event, if the format parameter is "sse" or the request accepts
+// text/event-stream. The stream starts with the kept records and follows
+// the records added later, and is used by the page for live tailing.
+//
+// The level parameter filters out the records below the given level, and
+// the n parameter limits the number of kept records returned to the most
+// recent ones.
+func (r *Ring) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	q := req.URL.Query()
+	minLevel := LevelTrace
+	if s := q.Get("level"); s != "" {
+		if err := minLevel.UnmarshalText([]byte(s)); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	entries := []RingEntry{}
+	for _, e := range r.Entries() {
+		if e.Level >= minLevel {
+			entries = append(entries, e)
+		}
+	}
+	if s := q.Get("n"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			http.Error(w, "l4g: invalid n "+strconv.Quote(s), http.StatusBadRequest)
+			return
+		}
+		entries = entries[max(len(entries)-n, 0):]
+	}
+
+	accept := req.Header.Get("Accept")
+	switch format := q.Get("format"); {
+	case format == "sse" || format == "" && strings.Contains(accept, "text/event-stream"):
+		r.stream(w, req, entries, minLevel)
+	case format == "json" || format == "" && strings.Contains(accept, "application/json"):
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(entries)
+	default:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = debugPage.Execute(w, struct {
+			Entries []RingEntry
+			Level   Level
+			Levels  []Level
+		}{entries, minLevel, []Level{LevelTrace, LevelDebug, LevelInfo, LevelWarn, LevelError, LevelPanic, LevelFatal}})
+	}
+}
+
+// stream writes entries, then the entries added to r at minLevel or above
+// until the client goes away, as server-sent events.
+func (r *Ring) stream(w http.ResponseWriter, req *http.Request, entries []RingEntry, minLevel Level) {
+	ch, stop := r.subscribe()
+	defer stop()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	rc := http.NewResponseController(w)
+	send := func(e RingEntry) error {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+		return err
+	}
+	for _, e := range entries {
+		if send(e) != nil {
+			return
+		}
+	}
+	if rc.Flush() != nil {
+		return
+	}
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case e := <-ch:
+			if e.Level < minLevel {
+				continue
+			}
+			if send(e) != nil || rc.Flush() != nil {
+				return
+			}
+		}
+	}
+}
+
+var debugPage = template.Must(template.New("logs").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Recent logs</title>
+<style>
+body { font-family: monospace; font-size: 13px; }
+table { border-collapse: collapse; }
+td { padding: 1px 8px; vertical-align: top; white-space: pre-wrap; }
+.warn { color: #a60; } .error, .panic, .fatal { color: #c00; } .trace, .debug { color: #888; }
+</style>
+</head>
+<body>
+<form>
+Level <select name="level" onchange="this.form.submit()">
+{{- range .Levels}}<option{{if eq . $.Level}} selected{{end}}>{{.}}</option>{{end -}}
+</select>
+<label><input type="checkbox" id="tail"> Live tail</label>
+</form>
+<table id="logs">
+{{- range .Entries}}
+<tr class="{{.Level}}"><td>{{.Time.UTC.Format "2006-01-02 15:04:05.000"}}</td><td>{{.Level}}</td><td>{{.Prefix}}</td><td>{{.Message}}</td><td>{{printf "%s" .Attrs}}</td></tr>
+{{- end}}
+</table>
+<script>
+var source;
+document.getElementById("tail").onchange = function() {
+	if (!this.checked) { source.close(); return; }
+	source = new EventSource("?format=sse&n=0&level={{.Level}}");
+	source.onmessage = function(event) {
+		var e = JSON.parse(event.data), row = document.getElementById("logs").insertRow();
+		row.className = e.level;
+		[new Date(e.time).toISOString().replace("T", " ").slice(0, 23), e.level, e.prefix || "", e.msg, JSON.stringify(e.attrs)]
+			.forEach(function(s) { row.insertCell().textContent = s; });
+		window.scrollTo(0, document.body.scrollHeight);
+	};
+};
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,113 @@
+package l4g
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newDebugRing(t *testing.T) (*Ring, *Logger) {
+	t.Helper()
+	ring := NewRing(10)
+	l := New(Options{Output: &bytes.Buffer{}, Level: LevelDebug, Handler: NewRingHandler(nil, ring)})
+	l.Debug("starting")
+	l.Info("ready", "port", 8080)
+	l.Error("failed <b>", "err", "boom")
+	return ring, l
+}
+
+func TestDebugHandlerJSON(t *testing.T) {
+	ring, _ := newDebugRing(t)
+	rec := httptest.NewRecorder()
+	ring.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/logs?format=json&level=info", nil))
+	var entries []RingEntry
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("body %q: %v", rec.Body, err)
+	}
+	if len(entries) != 2 || entries[0].Message != "ready" || entries[1].Level != LevelError {
+		t.Errorf("entries = %+v, want the info and error records", entries)
+	}
+	if string(entries[0].Attrs) != `{"port":8080}` {
+		t.Errorf("Attrs = %s", entries[0].Attrs)
+	}
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/debug/logs?n=1", nil)
+	req.Header.Set("Accept", "application/json")
+	ring.ServeHTTP(rec, req)
+	if body := rec.Body.String(); !strings.Contains(body, "failed") || strings.Contains(body, "ready") {
+		t.Errorf("body = %q, want the last record only", body)
+	}
+
+	rec = httptest.NewRecorder()
+	ring.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/logs?level=loud", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d for an unknown level, want 400", rec.Code)
+	}
+}
+
+func TestDebugHandlerHTML(t *testing.T) {
+	ring, _ := newDebugRing(t)
+	rec := httptest.NewRecorder()
+	ring.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/logs", nil))
+	body := rec.Body.String()
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("Content-Type = %q, want text/html", ct)
+	}
+	for _, want := range []string{"starting", "ready", "failed &lt;b&gt;", `class="error"`, "EventSource"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body does not contain %q", want)
+		}
+	}
+	if DebugHandler() != DefaultRing {
+		t.Errorf("DebugHandler() does not serve DefaultRing")
+	}
+}
+
+func TestDebugHandlerSSE(t *testing.T) {
+	ring, l := newDebugRing(t)
+	srv := httptest.NewServer(ring)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"?format=sse&level=warn", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	events := bufio.NewScanner(resp.Body)
+	next := func() RingEntry {
+		t.Helper()
+		for events.Scan() {
+			if data, ok := strings.CutPrefix(events.Text(), "data: "); ok {
+				var e RingEntry
+				if err := json.Unmarshal([]byte(data), &e); err != nil {
+					t.Fatalf("event %q: %v", data, err)
+				}
+				return e
+			}
+		}
+		t.Fatalf("stream ended: %v", events.Err())
+		return RingEntry{}
+	}
+	if e := next(); e.Message != "failed <b>" {
+		t.Errorf("first event = %+v, want the kept error record", e)
+	}
+	// the subscription is registered before the kept records are sent
+	l.Info("filtered")
+	l.Warn("live")
+	if e := next(); e.Message != "live" {
+		t.Errorf("next event = %+v, want the live warning", e)
+	}
+}
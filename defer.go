@@ -0,0 +1,51 @@
+package l4g
+
+import "sync/atomic"
+
+// nopCommit and nopCancel are returned by Defer when the record is
+// disabled.
+var (
+	nopCommit = func(...Attr) {}
+	nopCancel = func() {}
+)
+
+// Defer prepares a record at the given level, with the time and source of
+// the call, and returns functions deciding its fate: commit logs it with
+// extra attributes appended, cancel discards it. Only the first call of
+// either has an effect. It suits messages that should be suppressed when
+// a retry succeeds:
+//
+//	commit, cancel := logger.Defer(l4g.LevelError, "upload failed", l4g.String("file", name))
+//	if err := retry(upload); err != nil {
+//		commit(l4g.Err(err))
+//	} else {
+//		cancel()
+//	}
+//
+// The record is logged at LevelPanic and LevelFatal without panicking
+// or exiting.
+func (l *Logger) Defer(level Level, msg string, attrs ...Attr) (commit func(extra ...Attr), cancel func()) {
+	return l.deferRecord(level, msg, attrs)
+}
+
+// deferRecord implements Defer, as the internal log function of the
+// frames skipped by callerPC.
+func (l *Logger) deferRecord(level Level, msg string, attrs []Attr) (commit func(extra ...Attr), cancel func()) {
+	if l.output.Discard() || !l.Enabled(level) {
+		return nopCommit, nopCancel
+	}
+	r := l.newRecord(level, msg)
+	r.AddAttrs(attrs...)
+	var done atomic.Bool
+	commit = func(extra ...Attr) {
+		if done.Swap(true) {
+			return
+		}
+		r.AddAttrs(extra...)
+		l.handle(r)
+	}
+	cancel = func() {
+		done.Store(true)
+	}
+	return commit, cancel
+}
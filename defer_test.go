@@ -0,0 +1,41 @@
+package l4g
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLogger_Defer(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true, AddSource: true})
+
+	commit, cancel := logger.Defer(LevelError, "upload failed", String("file", "a.txt"))
+	if buf.Len() != 0 {
+		t.Errorf("output = %q before commit, want none", buf.String())
+	}
+	commit(Int("attempts", 3))
+	commit()
+	cancel()
+	got := buf.String()
+	if !strings.Contains(got, "upload failed file=a.txt attempts=3") || strings.Count(got, "upload failed") != 1 {
+		t.Errorf("output = %q, want a single committed record", got)
+	}
+	if !strings.Contains(got, "defer_test.go:") {
+		t.Errorf("output = %q, want the source of the Defer call", got)
+	}
+
+	buf.Reset()
+	commit, cancel = logger.Defer(LevelError, "retried")
+	cancel()
+	commit()
+	if buf.Len() != 0 {
+		t.Errorf("output = %q after cancel, want none", buf.String())
+	}
+
+	commit, _ = logger.Defer(LevelDebug, "disabled")
+	commit()
+	if buf.Len() != 0 {
+		t.Errorf("output = %q for a disabled level, want none", buf.String())
+	}
+}
@@ -0,0 +1,126 @@
+package l4g
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+)
+
+// A Description is the configuration of a Logger, as returned by
+// [Logger.Describe], to answer the question of why a record is not
+// logged, or logged elsewhere than expected.
+type Description struct {
+	Level     Level    // lowest level enabled, above LevelFatal if none is
+	Output    string   // file name or type of the output
+	Handler   string   // type of the handler
+	Namespace string   // innermost namespace set with WithNamespace
+	Prefix    string   // prefix set by Options.Prefix and WithPrefix
+	Tags      []string // tags added with WithTags
+	Attrs     []Attr   // attributes added with WithAttrs, keys qualified by their groups
+}
+
+// Describe returns the configuration of l: the lowest level it logs,
+// taking into account its namespace and handler, its output, the type of
+// its handler, and the prefix, tags and attributes it adds to records.
+func (l *Logger) Describe() Description {
+	d := Description{
+		Level:     LevelFatal + 1,
+		Output:    describeOutput(l.output.Output()),
+		Handler:   fmt.Sprintf("%T", l.handler),
+		Namespace: l.namespace,
+		Tags:      slices.Clone(l.tags),
+	}
+	for level := LevelTrace; level <= LevelFatal; level++ {
+		if l.Enabled(level) {
+			d.Level = level
+			break
+		}
+	}
+
+	// Replay the With calls on a handler recording them.
+	var last *describeHandler
+	rec := &describeHandler{last: &last}
+	if l.opts != nil && l.opts.Handler == nil {
+		rec.prefix = l.opts.Prefix
+	}
+	last = rec
+	var h Handler = rec
+	for _, f := range l.derive {
+		h = f(h)
+	}
+	d.Prefix, d.Attrs = last.prefix, last.attrs
+	return d
+}
+
+// LogValue returns d as a group, so that it can be logged.
+func (d Description) LogValue() slog.Value {
+	attrs := []Attr{Any("level", d.Level), String("output", d.Output), String("handler", d.Handler)}
+	if d.Namespace != "" {
+		attrs = append(attrs, String("namespace", d.Namespace))
+	}
+	if d.Prefix != "" {
+		attrs = append(attrs, String(PrefixKey, d.Prefix))
+	}
+	if len(d.Tags) > 0 {
+		attrs = append(attrs, Any(TagsKey, d.Tags))
+	}
+	if len(d.Attrs) > 0 {
+		attrs = append(attrs, slog.Attr{Key: "attrs", Value: slog.GroupValue(d.Attrs...)})
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// describeOutput returns the name of the file w, or its type.
+func describeOutput(w io.Writer) string {
+	if f, ok := w.(*os.File); ok {
+		return f.Name()
+	}
+	if w == io.Discard {
+		return "discard"
+	}
+	return fmt.Sprintf("%T", w)
+}
+
+// describeHandler records the calls of WithAttrs, WithGroup and
+// WithPrefix made on it and on the handlers derived from it, the last of
+// which it stores in last.
+type describeHandler struct {
+	last   **describeHandler
+	groups string // dot-separated group names, each followed by a dot
+	prefix string
+	attrs  []Attr
+}
+
+func (h *describeHandler) Enabled(Level) bool  { return false }
+func (h *describeHandler) Handle(Record) error { return nil }
+
+func (h *describeHandler) WithAttrs(attrs []Attr) Handler {
+	h2 := h.derive()
+	for _, a := range attrs {
+		a.Key = h.groups + a.Key
+		h2.attrs = append(h2.attrs, a)
+	}
+	return h2
+}
+
+func (h *describeHandler) WithGroup(name string) Handler {
+	h2 := h.derive()
+	h2.groups += name + "."
+	return h2
+}
+
+func (h *describeHandler) WithPrefix(prefix string) Handler {
+	h2 := h.derive()
+	h2.prefix = prefix + h2.prefix
+	return h2
+}
+
+// derive returns a copy of h recorded as the last handler.
+func (h *describeHandler) derive() *describeHandler {
+	h2 := *h
+	h2.attrs = slices.Clip(h.attrs)
+	*h.last = &h2
+	return &h2
+}
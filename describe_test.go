@@ -0,0 +1,49 @@
+package l4g
+
+import (
+	"bytes"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogger_Describe(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true, Prefix: "svc", NamespaceLevels: map[string]Leveler{"db": LevelWarn}})
+	l := logger.WithAttrs("app", "billing").WithPrefix("api.").WithTags("http").WithNamespace("db").WithAttrs("pool", 4)
+
+	d := l.Describe()
+	if d.Level != LevelWarn || d.Handler != "*l4g.SimpleHandler" || d.Output != "*bytes.Buffer" {
+		t.Errorf("Describe() = %+v, want level WARN, a SimpleHandler and a buffer", d)
+	}
+	if d.Prefix != "api.svc" || d.Namespace != "db" || !slices.Equal(d.Tags, []string{"http", NamespaceTagPrefix + "db"}) {
+		t.Errorf("Describe() = %+v, want the prefix, namespace and tags", d)
+	}
+	if len(d.Attrs) != 2 || d.Attrs[0].String() != "app=billing" || d.Attrs[1].String() != "db.pool=4" {
+		t.Errorf("Attrs = %v, want app=billing db.pool=4", d.Attrs)
+	}
+
+	logger.Info("config", "logger", d)
+	if out := buf.String(); !strings.Contains(out, "logger.level=warn logger.output=*bytes.Buffer logger.handler=*l4g.SimpleHandler logger.namespace=db logger.prefix=api.svc") {
+		t.Errorf("output = %q, want the description", out)
+	}
+
+	if d := New(Options{Output: os.Stderr, Level: LevelError}).Describe(); d.Output != "/dev/stderr" || d.Level != LevelError || d.Attrs != nil {
+		t.Errorf("Describe() = %+v, want /dev/stderr at ERROR", d)
+	}
+	if d := Nop().Describe(); d.Level != LevelFatal+1 || d.Output != "discard" {
+		t.Errorf("Nop().Describe() = %+v, want nothing enabled", d)
+	}
+}
+
+func TestHandler_Options(t *testing.T) {
+	opts := HandlerOptions{Prefix: "svc", Level: LevelWarn}
+	if got := NewSimpleHandler(opts).(*SimpleHandler).Options(); got.Prefix != "svc" || got.Level != LevelWarn || got.TimeFormat != time.StampMilli {
+		t.Errorf("SimpleHandler.Options() = %+v, want the options with their defaults", got)
+	}
+	if got := NewJSONHandler(opts).(*JSONHandler).Options(); got.Prefix != "svc" || got.TimeFormat != time.RFC3339Nano {
+		t.Errorf("JSONHandler.Options() = %+v, want the options with their defaults", got)
+	}
+}
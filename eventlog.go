@@ -0,0 +1,119 @@
+package l4g
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Types of the events reported to the Windows Event Log.
+const (
+	eventLogError       = 1 // EVENTLOG_ERROR_TYPE
+	eventLogWarning     = 2 // EVENTLOG_WARNING_TYPE
+	eventLogInformation = 4 // EVENTLOG_INFORMATION_TYPE
+)
+
+// eventLogID is the identifier of the reported events. No message file is
+// registered for the source, so Event Viewer shows the message as is.
+const eventLogID = 1
+
+// EventLogOptions are options for an [EventLogHandler].
+type EventLogOptions struct {
+	// Source is the event source name, under which the events are listed
+	// in Event Viewer (Default: the base name of the executable, without
+	// extension).
+	Source string
+
+	// HandlerOptions configure the message, which is formatted as by a
+	// [SimpleHandler] without time and level, and without colors. Output
+	// is ignored.
+	HandlerOptions
+}
+
+// EventLogHandler is a Handler that reports records to the Windows Event
+// Log, as the Application events of a source. The level is mapped to the
+// event type (error, warning or information) and the message holds the
+// prefix, the message and the attributes.
+type EventLogHandler struct {
+	inner  *SimpleHandler
+	source eventSource
+}
+
+// eventSource is an event source registered with the Event Log.
+type eventSource interface {
+	report(eventType uint16, message string) error
+	Close() error
+}
+
+// openEventSource registers an event source. It is a variable for testing.
+var openEventSource = registerEventSource
+
+var _ Handler = (*EventLogHandler)(nil)
+
+// NewEventLogHandler returns an [EventLogHandler] reporting to the Event
+// Log of the local computer. It fails on systems other than Windows.
+func NewEventLogHandler(opts EventLogOptions) (*EventLogHandler, error) {
+	if opts.Source == "" {
+		opts.Source = strings.TrimSuffix(filepath.Base(os.Args[0]), filepath.Ext(os.Args[0]))
+	}
+	source, err := openEventSource(opts.Source)
+	if err != nil {
+		return nil, err
+	}
+	return newEventLogHandler(opts.HandlerOptions, source), nil
+}
+
+// newEventLogHandler returns an EventLogHandler reporting to source.
+func newEventLogHandler(opts HandlerOptions, source eventSource) *EventLogHandler {
+	opts.NoColor = true
+	return &EventLogHandler{
+		inner:  NewSimpleHandler(opts).(*SimpleHandler),
+		source: source,
+	}
+}
+
+// Close deregisters the event source.
+func (h *EventLogHandler) Close() error {
+	return h.source.Close()
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *EventLogHandler) Enabled(level Level) bool {
+	return h.inner.Enabled(level)
+}
+
+// Handle reports r as one event.
+func (h *EventLogHandler) Handle(rr Record) error {
+	r := h.inner.prepare(rr)
+
+	buf := newBuffer()
+	defer buf.Free()
+	h.inner.appendBody(buf, r)
+
+	eventType := uint16(eventLogInformation)
+	switch {
+	case r.Level >= LevelError:
+		eventType = eventLogError
+	case r.Level >= LevelWarn:
+		eventType = eventLogWarning
+	}
+	return h.source.report(eventType, strings.TrimSuffix(string(*buf), " "))
+}
+
+// WithAttrs returns a new Handler whose attributes consist of
+// both the receiver's attributes and the arguments.
+func (h *EventLogHandler) WithAttrs(attrs []Attr) Handler {
+	return &EventLogHandler{h.inner.WithAttrs(attrs).(*SimpleHandler), h.source}
+}
+
+// WithGroup returns a new Handler with the given group appended to
+// the receiver's existing groups.
+func (h *EventLogHandler) WithGroup(name string) Handler {
+	return &EventLogHandler{h.inner.WithGroup(name).(*SimpleHandler), h.source}
+}
+
+// WithPrefix returns a new Handler with the given prefix prepended to
+// the receiver's existing prefix.
+func (h *EventLogHandler) WithPrefix(prefix string) Handler {
+	return &EventLogHandler{h.inner.WithPrefix(prefix).(*SimpleHandler), h.source}
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package l4g
+
+import "errors"
+
+// registerEventSource fails, as there is no Event Log outside Windows.
+func registerEventSource(name string) (eventSource, error) {
+	return nil, errors.New("l4g: the Windows Event Log is not available on this system")
+}
+
+// isWindowsService reports false, as the process is not on Windows.
+func isWindowsService() bool {
+	return false
+}
@@ -0,0 +1,102 @@
+package l4g
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// fakeEventSource records the reported events in place of the Event Log.
+type fakeEventSource struct {
+	mu     sync.Mutex
+	name   string
+	events []string
+	types  []uint16
+	closed bool
+}
+
+func (s *fakeEventSource) report(eventType uint16, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.types = append(s.types, eventType)
+	s.events = append(s.events, message)
+	return nil
+}
+
+func (s *fakeEventSource) Close() error {
+	s.closed = true
+	return nil
+}
+
+// fakeEventLog replaces the Event Log with a fakeEventSource for the
+// duration of the test.
+func fakeEventLog(t *testing.T) *fakeEventSource {
+	t.Helper()
+	s := &fakeEventSource{}
+	old := openEventSource
+	openEventSource = func(name string) (eventSource, error) {
+		s.name = name
+		return s, nil
+	}
+	t.Cleanup(func() { openEventSource = old })
+	return s
+}
+
+func TestEventLogHandler(t *testing.T) {
+	s := fakeEventLog(t)
+	h, err := NewEventLogHandler(EventLogOptions{
+		Source:         "billing",
+		HandlerOptions: HandlerOptions{Level: LevelDebug},
+	})
+	if err != nil {
+		t.Fatalf("NewEventLogHandler() error = %v", err)
+	}
+	if s.name != "billing" {
+		t.Errorf("source = %q, want billing", s.name)
+	}
+
+	l := New(Options{Output: &bytes.Buffer{}, Handler: h})
+	l.WithPrefix("db").WithAttrs("conn", 2).Debug("connected")
+	l.Warn("slow query", "ms", 1200)
+	l.Error("query failed")
+	l.Trace("hidden")
+
+	wantEvents := []string{"[db] connected conn=2", "slow query ms=1200", "query failed"}
+	if !reflect.DeepEqual(s.events, wantEvents) {
+		t.Errorf("events = %q, want %q", s.events, wantEvents)
+	}
+	wantTypes := []uint16{eventLogInformation, eventLogWarning, eventLogError}
+	if !reflect.DeepEqual(s.types, wantTypes) {
+		t.Errorf("event types = %v, want %v", s.types, wantTypes)
+	}
+
+	if err := h.Close(); err != nil || !s.closed {
+		t.Errorf("Close() = %v, closed = %v, want nil, true", err, s.closed)
+	}
+}
+
+func TestNewEventLogHandler_Unsupported(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the Event Log is available on Windows")
+	}
+	if _, err := NewEventLogHandler(EventLogOptions{}); err == nil {
+		t.Errorf("NewEventLogHandler() error = nil, want an error outside Windows")
+	}
+	if isWindowsService() {
+		t.Errorf("isWindowsService() = true, want false outside Windows")
+	}
+}
+
+func TestNewEventLogHandler_Error(t *testing.T) {
+	old := openEventSource
+	defer func() { openEventSource = old }()
+	openEventSource = func(name string) (eventSource, error) {
+		return nil, errors.New("access denied")
+	}
+	if h, err := NewEventLogHandler(EventLogOptions{}); err == nil || h != nil {
+		t.Errorf("NewEventLogHandler() = %v, %v, want the registration error", h, err)
+	}
+}
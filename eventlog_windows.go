@@ -0,0 +1,96 @@
+package l4g
+
+import (
+	"os"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32                  = syscall.NewLazyDLL("advapi32.dll")
+	procRegisterEventSourceW  = advapi32.NewProc("RegisterEventSourceW")
+	procDeregisterEventSource = advapi32.NewProc("DeregisterEventSource")
+	procReportEventW          = advapi32.NewProc("ReportEventW")
+
+	kernel32                 = syscall.NewLazyDLL("kernel32.dll")
+	procProcessIdToSessionId = kernel32.NewProc("ProcessIdToSessionId")
+)
+
+// windowsEventSource is an event source registered with
+// RegisterEventSourceW.
+type windowsEventSource struct {
+	handle uintptr
+}
+
+// registerEventSource registers the event source name on the local
+// computer.
+func registerEventSource(name string) (eventSource, error) {
+	p, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	h, _, err := procRegisterEventSourceW.Call(0, uintptr(unsafe.Pointer(p)))
+	if h == 0 {
+		return nil, err
+	}
+	return &windowsEventSource{handle: h}, nil
+}
+
+// report reports message as an event of the given type, with message as
+// its single insertion string.
+func (s *windowsEventSource) report(eventType uint16, message string) error {
+	p, err := syscall.UTF16PtrFromString(strings.ReplaceAll(message, "\x00", ""))
+	if err != nil {
+		return err
+	}
+	strs := []*uint16{p}
+	r, _, err := procReportEventW.Call(
+		s.handle,
+		uintptr(eventType),
+		0, // category
+		eventLogID,
+		0, // user SID
+		uintptr(len(strs)),
+		0, // raw data size
+		uintptr(unsafe.Pointer(&strs[0])),
+		0, // raw data
+	)
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+// Close deregisters the event source.
+func (s *windowsEventSource) Close() error {
+	r, _, err := procDeregisterEventSource.Call(s.handle)
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+// isWindowsService reports whether the process runs as a Windows service,
+// that is, in session 0 and started by the service control manager
+// (services.exe).
+func isWindowsService() bool {
+	var session uint32
+	r, _, _ := procProcessIdToSessionId.Call(uintptr(os.Getpid()), uintptr(unsafe.Pointer(&session)))
+	if r == 0 || session != 0 {
+		return false
+	}
+	snapshot, err := syscall.CreateToolhelp32Snapshot(syscall.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return false
+	}
+	defer syscall.CloseHandle(snapshot)
+	ppid := uint32(os.Getppid())
+	entry := syscall.ProcessEntry32{Size: uint32(unsafe.Sizeof(syscall.ProcessEntry32{}))}
+	for err = syscall.Process32First(snapshot, &entry); err == nil; err = syscall.Process32Next(snapshot, &entry) {
+		if entry.ProcessID == ppid {
+			return strings.EqualFold(syscall.UTF16ToString(entry.ExeFile[:]), "services.exe")
+		}
+	}
+	return false
+}
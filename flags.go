@@ -0,0 +1,58 @@
+package l4g
+
+import (
+	"flag"
+	"fmt"
+)
+
+// logFlags holds the values of the flags defined by RegisterFlags.
+var logFlags = struct {
+	level   Level
+	format  string
+	output  string
+	noColor bool
+}{level: LevelInfo, format: "text", output: "stderr"}
+
+// RegisterFlags defines the flags configuring the logger built by
+// [FromFlags] in fs, or in flag.CommandLine if fs is nil:
+//
+//	-log.level     minimum level of the records: trace, debug, info, ... (default info)
+//	-log.format    format of the records: text or json (default text)
+//	-log.output    stderr, stdout or the path of a file to append to (default stderr)
+//	-log.no-color  disable color output
+//
+// The values are shared by all the flag sets, so RegisterFlags is meant to
+// be called once, before fs.Parse.
+func RegisterFlags(fs *flag.FlagSet) {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	fs.TextVar(&logFlags.level, "log.level", logFlags.level, "minimum `level` of the log records: trace, debug, info, warn, error, panic or fatal")
+	fs.Func("log.format", "`format` of the log records: text or json (default text)", func(s string) error {
+		if _, err := formatHandler(s); err != nil || s == "" {
+			return fmt.Errorf("unknown log format %q", s)
+		}
+		logFlags.format = s
+		return nil
+	})
+	fs.StringVar(&logFlags.output, "log.output", logFlags.output, "log `destination`: stderr, stdout or the path of a file to append to")
+	fs.BoolVar(&logFlags.noColor, "log.no-color", logFlags.noColor, "disable color output of the log records")
+}
+
+// FromFlags creates a new Logger configured by the flags defined by
+// [RegisterFlags], to be called after the flags are parsed. A file given
+// with -log.output is created if needed and stays open for the life of the
+// program, as with [ApplyConfig]. It returns an error if the file cannot be opened.
+func FromFlags() (*Logger, error) {
+	w, err := openOutput(logFlags.output)
+	if err != nil {
+		return nil, err
+	}
+	newHandler, _ := formatHandler(logFlags.format)
+	return New(Options{
+		Level:          logFlags.level,
+		Output:         w,
+		NewHandlerFunc: newHandler,
+		NoColor:        logFlags.noColor,
+	}), nil
+}
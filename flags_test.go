@@ -0,0 +1,62 @@
+package l4g
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRegisterFlags(t *testing.T) {
+	saved := logFlags
+	defer func() { logFlags = saved }()
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	RegisterFlags(fs)
+	err := fs.Parse([]string{"-log.level", "debug", "-log.format", "json", "-log.output", path, "-log.no-color"})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	l, err := FromFlags()
+	if err != nil {
+		t.Fatalf("FromFlags() error = %v", err)
+	}
+	if l.Level() != LevelDebug {
+		t.Errorf("Level() = %v, want debug", l.Level())
+	}
+	l.Debug("hello", "k", 1)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); !strings.Contains(got, `"msg":"hello"`) || !strings.Contains(got, `"k":1`) {
+		t.Errorf("output = %q, want a JSON record", got)
+	}
+}
+
+func TestRegisterFlags_Invalid(t *testing.T) {
+	saved := logFlags
+	defer func() { logFlags = saved }()
+
+	for _, args := range [][]string{
+		{"-log.level", "loud"},
+		{"-log.format", "xml"},
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		RegisterFlags(fs)
+		if err := fs.Parse(args); err == nil {
+			t.Errorf("Parse(%q) error = nil, want an error", args)
+		}
+	}
+
+	logFlags.output = filepath.Join(t.TempDir(), "missing", "app.log")
+	if _, err := FromFlags(); err == nil {
+		t.Errorf("FromFlags() error = nil for a missing directory")
+	}
+}
@@ -0,0 +1,32 @@
+package l4g
+
+// PanicKey is the key of the attribute holding the value of a panic
+// recovered by [Go]. The associated value is the value passed to panic.
+const PanicKey = "panic"
+
+// Go runs fn in a new goroutine, recovering a panic of fn so that it does
+// not crash the program: the panic is logged by l at LevelPanic, with its
+// value under [PanicKey] and, unless l has a StackLevel above LevelPanic,
+// the stack of the goroutine from the panic under [StackKey]. If l is
+// nil, the default logger is used.
+//
+// It is meant for worker pools and other background goroutines, which
+// would otherwise each implement their own crash logging.
+func Go(l *Logger, fn func()) {
+	if l == nil {
+		l = Default()
+	}
+	go func() {
+		defer l.recoverPanic()
+		fn()
+	}()
+}
+
+// recoverPanic logs the panic of the calling goroutine, if any. It must
+// be deferred directly, so that recover stops the panic and so that the
+// stack of the record starts at the panic.
+func (l *Logger) recoverPanic() {
+	if p := recover(); p != nil {
+		l.log(LevelPanic, "goroutine panicked", []any{Any(PanicKey, p)})
+	}
+}
@@ -0,0 +1,29 @@
+package l4g
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGo(t *testing.T) {
+	buf := &syncBuffer{}
+	logger := New(Options{Output: buf, NoColor: true})
+
+	Go(logger, explode)
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(buf.String(), "\n") && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"PANIC", "goroutine panicked", "panic=boom", "stack=", "l4g.explode"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output = %q, want to contain %q", out, want)
+		}
+	}
+}
+
+func explode() {
+	panic("boom")
+}
@@ -0,0 +1,93 @@
+package l4g
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrWriterClosed is returned by writers of this package that are written
+// to after being closed.
+var ErrWriterClosed = errors.New("l4g: writer closed")
+
+// GzipWriter is an io.WriteCloser that streams gzip-compressed log output
+// to an underlying writer, such as an archive file or a network connection.
+//
+// To keep the tail of the stream readable while the writer is open, the
+// compressor is flushed at most FlushInterval after data was written to it.
+// Each flush point ends a deflate block, so that a reader of the partial
+// stream can decompress everything written up to it.
+// It is safe for concurrent use by multiple goroutines.
+type GzipWriter struct {
+	mu       sync.Mutex
+	zw       *gzip.Writer
+	interval time.Duration
+	timer    *time.Timer // pending flush, nil if none
+	closed   bool
+}
+
+// NewGzipWriter returns a GzipWriter compressing to w. Compressed data is
+// flushed to w at most flushInterval after it was written, or after every
+// Write if flushInterval is zero.
+func NewGzipWriter(w io.Writer, flushInterval time.Duration) *GzipWriter {
+	return &GzipWriter{
+		zw:       gzip.NewWriter(w),
+		interval: flushInterval,
+	}
+}
+
+// Write compresses p and schedules a flush point.
+func (w *GzipWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+	n, err := w.zw.Write(p)
+	if err != nil {
+		return n, err
+	}
+	if w.interval <= 0 {
+		return n, w.zw.Flush()
+	}
+	if w.timer == nil {
+		w.timer = time.AfterFunc(w.interval, func() {
+			runLabeled("gzip", func() { _ = w.Flush() })
+		})
+	}
+	return n, nil
+}
+
+// Flush writes any pending compressed data to the underlying writer.
+func (w *GzipWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.stopTimer()
+	return w.zw.Flush()
+}
+
+// Close flushes pending data and writes the gzip footer. It does not close
+// the underlying writer.
+func (w *GzipWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	w.stopTimer()
+	return w.zw.Close()
+}
+
+// stopTimer cancels the pending flush, if any. w.mu must be held.
+func (w *GzipWriter) stopTimer() {
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+}
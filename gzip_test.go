@@ -0,0 +1,69 @@
+package l4g
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+	"time"
+)
+
+// readPartialGzip decompresses a possibly unterminated gzip stream,
+// ignoring the error reported for the missing footer.
+func readPartialGzip(t *testing.T, data []byte) string {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	out, _ := io.ReadAll(zr)
+	return string(out)
+}
+
+func TestGzipWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewGzipWriter(buf, 0)
+	logger := New(Options{Output: w, NoColor: true})
+
+	logger.Info("first")
+	if got := readPartialGzip(t, buf.Bytes()); got == "" {
+		t.Errorf("stream is not readable before Close")
+	}
+
+	logger.Info("second")
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if !bytes.Contains(out, []byte("INFO first\n")) || !bytes.Contains(out, []byte("INFO second\n")) {
+		t.Errorf("decompressed = %q", out)
+	}
+
+	if _, err := w.Write([]byte("x")); err != ErrWriterClosed {
+		t.Errorf("Write() after Close error = %v, want ErrWriterClosed", err)
+	}
+}
+
+func TestGzipWriter_FlushInterval(t *testing.T) {
+	buf := &syncBuffer{}
+	w := NewGzipWriter(buf, 5*time.Millisecond)
+	defer w.Close()
+
+	if _, err := w.Write([]byte("line\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for readPartialGzip(t, []byte(buf.String())) != "line\n" {
+		if time.Now().After(deadline) {
+			t.Fatalf("data was not flushed within the interval")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -1,12 +1,14 @@
 package l4g
 
 import (
+	"context"
 	"encoding"
 	"fmt"
 	"io"
 	"log/slog"
-	"path/filepath"
+	"net/netip"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -64,10 +66,87 @@ type Handler interface {
 	WithPrefix(prefix string) Handler
 }
 
-// HandlerOptions are options for a [SimpleHandler].
+// Flusher is implemented by handlers and writers that buffer records, such
+// as [SQLiteHandler] and [GzipWriter]. Handlers wrapping other handlers
+// implement it by flushing the wrapped handlers.
+type Flusher interface {
+	// Flush writes the buffered records.
+	Flush() error
+}
+
+// flush flushes v if it implements Flusher.
+func flush(v any) error {
+	if f, ok := v.(Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Compactor is implemented by handlers and writers whose internal buffers
+// grow with bursts of records, such as [SamplingHandler] and
+// [EncryptWriter], so that they can release the memory retained after a
+// spike. Handlers wrapping other handlers implement it by compacting the
+// wrapped handlers. See [CompactEvery].
+type Compactor interface {
+	// Compact shrinks the internal buffers that are much larger than
+	// their current contents.
+	Compact()
+}
+
+// compact compacts v if it implements Compactor.
+func compact(v any) {
+	if c, ok := v.(Compactor); ok {
+		c.Compact()
+	}
+}
+
+// ContextFlusher is implemented by the Flushers whose Flush can be
+// cancelled, such as [SQLiteHandler], so that a deadline also stops the
+// writes in flight.
+type ContextFlusher interface {
+	// FlushContext writes the buffered records, giving up when ctx is done.
+	FlushContext(ctx context.Context) error
+}
+
+// flushContext flushes v if it implements Flusher, returning ctx.Err() if
+// ctx is done first. A Flush that cannot be cancelled goes on in the
+// background.
+func flushContext(ctx context.Context, v any) error {
+	if f, ok := v.(ContextFlusher); ok {
+		return f.FlushContext(ctx)
+	}
+	f, ok := v.(Flusher)
+	if !ok {
+		return nil
+	}
+	done := make(chan error, 1)
+	go func() { done <- f.Flush() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// DiscardHandler discards all log output.
+// DiscardHandler.Enabled returns false for all Levels.
+var DiscardHandler Handler = discardHandler{}
+
+type discardHandler struct{}
+
+func (discardHandler) Enabled(Level) bool          { return false }
+func (discardHandler) Handle(Record) error         { return nil }
+func (d discardHandler) WithAttrs([]Attr) Handler  { return d }
+func (d discardHandler) WithGroup(string) Handler  { return d }
+func (d discardHandler) WithPrefix(string) Handler { return d }
+
+// HandlerOptions are options for a [SimpleHandler] or a [JSONHandler].
 // A zero HandlerOptions consists entirely of default values.
 type HandlerOptions struct {
-	// Prefix is the prefix to use for all log messages.
+	// Prefix is the prefix to use for all log messages. It is written
+	// for the records whose own Prefix is empty, and WithPrefix prepends
+	// to it.
 	Prefix string
 
 	// Level reports the minimum record level that will be logged.
@@ -81,19 +160,187 @@ type HandlerOptions struct {
 	// See https://pkg.go.dev/log/slog#HandlerOptions for details.
 	ReplaceAttr func(groups []string, attr Attr) Attr
 
-	// TimeFormat time format (Default: time.StampMilli)
+	// ReplaceGroup is called to rewrite each named group attribute before
+	// its members are expanded. It may rename the group, drop it wholesale by
+	// returning an empty Attr, or replace it with a non-group attribute.
+	// groups lists the enclosing groups, as for ReplaceAttr.
+	ReplaceGroup func(groups []string, attr Attr) Attr
+
+	// TimeFormat time format (Default: time.StampMilli, or time.RFC3339Nano
+	// for the JSONHandler). Besides layouts accepted by [time.Time.Format],
+	// it may be one of the epoch formats such as [TimeFormatUnixMilli],
+	// which the JSONHandler writes as numbers.
 	TimeFormat string
 
-	// LevelFormat level format (Default: nil)
+	// LevelFormat level format (Default: nil). It also applies to the
+	// level returned by ReplaceAttr, unless it is not a Level, and is
+	// colored like the level name.
 	LevelFormat func(Level) string
 
-	// PrefixFormat prefix format (Default: nil)
+	// PrefixFormat prefix format of the SimpleHandler (Default: nil, for
+	// "[prefix]"). It also applies to the prefix returned by ReplaceAttr,
+	// unless it is changed, and is colored like the default format.
 	PrefixFormat func(string) string
 
+	// ColorMode selects the parts of a line that are colored
+	// when NoColor is false (Default: ColorAll).
+	ColorMode ColorMode
+
+	// LevelColors overrides the color of individual levels, using the
+	// color numbers described in [ColorAttr] (Default: nil).
+	LevelColors map[Level]uint8
+
+	// PartColors overrides the color of the parts of a line written by the
+	// SimpleHandler, using the color numbers described in [ColorAttr], as
+	// {PartSource: 244} (Default: nil). LevelColors takes precedence for
+	// the level, and the colors set by ReplaceAttr for every part.
+	PartColors map[PartKind]uint8
+
+	// ToneColors overrides the color of the tones of the attributes set
+	// with [Highlight], [Warning] and [OK], using the color numbers
+	// described in [ColorAttr] (Default: nil).
+	ToneColors map[Tone]uint8
+
+	// LevelIcons maps levels to glyphs that the SimpleHandler writes before
+	// the level name, see [DefaultLevelIcons] (Default: nil).
+	LevelIcons map[Level]string
+
+	// IconsOnly writes the icon of a level instead of its name, for levels
+	// that have an icon in LevelIcons (Default: false).
+	IconsOnly bool
+
+	// KeyFormat rewrites every attribute key and group name before it is
+	// written, e.g. [SnakeCase] or [CamelCase] (Default: nil).
+	// ReplaceAttr still receives the original keys and groups.
+	KeyFormat func(string) string
+
+	// KeyConflict is the policy for attributes that use a built-in key
+	// such as "time" or "msg" (Default: KeyConflictRename).
+	KeyConflict KeyConflictPolicy
+
 	// NoColor disable color (Default: false)
 	NoColor bool
 
+	// Elapsed adds an [ElapsedKey] attribute holding the time elapsed since
+	// ElapsedSince to every record (Default: false).
+	Elapsed bool
+
+	// ElapsedSince is the reference time of the Elapsed attribute
+	// (Default: the creation time of the handler). Use [ProcessStart]
+	// to report the process uptime instead.
+	ElapsedSince time.Time
+
+	// EmitTime adds an [EmitTimeKey] attribute holding the time at which
+	// the handler wrote the record, in addition to the event time of the
+	// record, so that replayed events keep their original time
+	// (Default: false).
+	EmitTime bool
+
+	// Severity makes the JSONHandler write the level under "severity" with
+	// the names expected by Google Cloud Logging and GKE, see
+	// [SeverityName]. An explicit FieldNames.Level takes precedence over
+	// the "severity" key (Default: false).
+	Severity bool
+
+	// FieldNames renames the built-in fields written by the JSONHandler,
+	// e.g. "time" to "@timestamp" (Default: the built-in keys).
+	FieldNames FieldNames
+
+	// SortAttrs writes attributes in key order, so that identical records
+	// always produce identical lines (Default: false). The attributes of a
+	// record and the members of each group are sorted; attributes added by
+	// WithAttrs are sorted per call and precede those of the record.
+	SortAttrs bool
+
+	// Canonical makes the JSONHandler write records in a form that
+	// compresses well in archives: SortAttrs is implied, and times are
+	// written in UTC with a fixed width, nanoseconds included, unless
+	// TimeFormat is set (Default: false).
+	Canonical bool
+
+	// MaxAttrs limits the number of attributes written for a record, not
+	// counting those added by WithAttrs, to protect memory and downstream
+	// parsers from pathological records. The attributes past the limit
+	// are replaced by an [AttrsTruncatedKey] attribute holding their number
+	// (Default: 0, no limit).
+	MaxAttrs int
+
+	// IncludeKeys, if not empty, restricts the attributes written to those
+	// whose key is listed, e.g. to keep a notifier to the essentials
+	// (Default: nil). ExcludeKeys lists the keys of attributes that are
+	// not written (Default: nil). Both apply to the attributes of records
+	// and of WithAttrs, before ReplaceAttr, a group being kept or dropped
+	// as a whole by its key; attributes past the filter do not count
+	// towards MaxAttrs.
+	IncludeKeys []string
+	ExcludeKeys []string
+
+	// ErrorTree writes the errors that wrap other errors, such as those of
+	// errors.Join or fmt.Errorf with %w, as a group holding their message
+	// under [ErrorMsgKey] and the tree of their causes under
+	// [ErrorCausesKey], instead of a single string (Default: false).
+	ErrorTree bool
+
+	// MaxRecordSize limits the size in bytes of the line written for a
+	// record, so that shippers with line-length limits are not broken by
+	// huge records. Once an attribute of the record takes the line past
+	// the limit, it and the remaining attributes are replaced by an
+	// [AttrsTruncatedKey] attribute holding their number; the line may
+	// still exceed the limit by that attribute and its closing
+	// (Default: 0, no limit).
+	MaxRecordSize int
+
+	// ValidUTF8 replaces the invalid UTF-8 sequences of the lines written
+	// with U+FFFD, the replacement character, for downstream systems
+	// rejecting them (Default: false). The JSONHandler already does so in
+	// strings.
+	ValidUTF8 bool
+
+	// StripControl removes the control characters from the lines written,
+	// except the ANSI escape sequences of colors and the final newline,
+	// tabs and line breaks being replaced by spaces, for parsers such as
+	// XML-based SIEMs and old syslog daemons rejecting them
+	// (Default: false).
+	StripControl bool
+
+	// EscapeNonASCII writes the non-ASCII characters of the lines as
+	// \uXXXX escapes, with surrogate pairs past U+FFFF as in JSON, so that
+	// the output is plain ASCII; invalid UTF-8 is written as \ufffd
+	// (Default: false). The escapes, like StripControl and ValidUTF8, are
+	// applied after MaxRecordSize, so the line may grow past it.
+	EscapeNonASCII bool
+
+	// FlattenGroups makes the JSONHandler write group members as dotted
+	// keys ("a.b.c") instead of nested objects (Default: false).
+	// The SimpleHandler always flattens groups.
+	FlattenGroups bool
+
+	// AddSource adds a [SourceKey] attribute holding the file and line of
+	// the log call to every record that carries a program counter
+	// (Default: false).
+	AddSource bool
+
+	// SourcePath selects how the file path of the source is shortened
+	// (Default: SourcePathShort).
+	SourcePath SourcePathMode
+
+	// SourceRoot is trimmed from the start of source file paths, taking
+	// precedence over SourcePath for the files under it (Default: "").
+	SourceRoot string
+
+	// SourceFunc includes the name of the calling function, qualified by
+	// the last element of its package path, in the source (Default: false).
+	SourceFunc bool
+
+	// SourceFormat renders the source of a record, replacing the built-in
+	// rendering and the options above, e.g. to produce editor links or VCS
+	// permalinks. The JSONHandler writes the result as a string
+	// (Default: nil).
+	SourceFormat func(*slog.Source) string
+
 	// Output is a destination to which log data will be written.
+	// After a short write, the rest of the record is written again a few
+	// times before the handler reports the number of bytes lost.
 	Output io.Writer
 }
 
@@ -113,6 +360,49 @@ const (
 	errorKey = "error"
 )
 
+// processStart approximates the start time of the process.
+var processStart = time.Now()
+
+// ProcessStart returns the time at which the process started, as observed
+// when the l4g package was initialized.
+func ProcessStart() time.Time {
+	return processStart
+}
+
+// elapsedAttr returns the [ElapsedKey] attribute for a record logged at t.
+func elapsedAttr(t, since time.Time) Attr {
+	if t.IsZero() {
+		return slog.Duration(ElapsedKey, time.Since(since))
+	}
+	return slog.Duration(ElapsedKey, t.Sub(since))
+}
+
+// Special TimeFormat values rendering the time as an integer offset from the
+// Unix epoch instead of formatting it with a layout.
+const (
+	TimeFormatUnix      = "unix"    // seconds
+	TimeFormatUnixMilli = "unix_ms" // milliseconds
+	TimeFormatUnixMicro = "unix_us" // microseconds
+	TimeFormatUnixNano  = "unix_ns" // nanoseconds
+)
+
+// appendTime appends t to b according to format, which is either a time
+// layout or one of the epoch formats. It reports whether the result is
+// numeric, in which case it must not be quoted.
+func appendTime(b []byte, t time.Time, format string) ([]byte, bool) {
+	switch format {
+	case TimeFormatUnix:
+		return strconv.AppendInt(b, t.Unix(), 10), true
+	case TimeFormatUnixMilli:
+		return strconv.AppendInt(b, t.UnixMilli(), 10), true
+	case TimeFormatUnixMicro:
+		return strconv.AppendInt(b, t.UnixMicro(), 10), true
+	case TimeFormatUnixNano:
+		return strconv.AppendInt(b, t.UnixNano(), 10), true
+	}
+	return t.AppendFormat(b, format), false
+}
+
 // Keys for "built-in" attributes.
 const (
 	// TimeKey is the key used by the built-in handlers for the time
@@ -127,8 +417,143 @@ const (
 	// PrefixKey is the key used by the built-in handlers for the
 	// prefix of the log call. The associated value is a string.
 	PrefixKey = "prefix"
+	// ElapsedKey is the key used by the built-in handlers for the
+	// elapsed time enabled by [HandlerOptions.Elapsed].
+	// The associated value is a [time.Duration].
+	ElapsedKey = "elapsed"
+	// SeverityKey is the key used by the JSONHandler for the level when
+	// [HandlerOptions.Severity] is set.
+	SeverityKey = "severity"
+	// TagsKey is the key used by the built-in handlers for the tags
+	// of a record. The associated value is a []string.
+	TagsKey = "tags"
+	// EmitTimeKey is the key used by the built-in handlers for the
+	// write time enabled by [HandlerOptions.EmitTime].
+	// The associated value is a [time.Time].
+	EmitTimeKey = "emit_time"
+	// AttrsTruncatedKey is the key used by the built-in handlers for the
+	// number of attributes dropped from a record by
+	// [HandlerOptions.MaxAttrs] or [HandlerOptions.MaxRecordSize].
+	// The associated value is an int.
+	AttrsTruncatedKey = "attrs_truncated"
+
+	// conflictGroup is the group under which KeyConflictRename moves
+	// attributes that collide with a built-in key.
+	conflictGroup = "fields"
+)
+
+// FieldNames holds the keys under which a [JSONHandler] writes the built-in
+// fields. An empty name keeps the corresponding built-in key.
+//
+// ReplaceAttr still receives the built-in fields under [TimeKey], [LevelKey],
+// [MessageKey] and [PrefixKey]; the renaming is applied to its result.
+type FieldNames struct {
+	Time    string
+	Level   string
+	Message string
+	Prefix  string
+}
+
+// withDefaults returns a copy of n with empty names set to the built-in keys.
+func (n FieldNames) withDefaults() FieldNames {
+	if n.Time == "" {
+		n.Time = TimeKey
+	}
+	if n.Level == "" {
+		n.Level = LevelKey
+	}
+	if n.Message == "" {
+		n.Message = MessageKey
+	}
+	if n.Prefix == "" {
+		n.Prefix = PrefixKey
+	}
+	return n
+}
+
+// rename maps a built-in key to its configured name.
+// Other keys are returned unchanged.
+func (n FieldNames) rename(key string) string {
+	switch key {
+	case TimeKey:
+		return n.Time
+	case LevelKey:
+		return n.Level
+	case MessageKey:
+		return n.Message
+	case PrefixKey:
+		return n.Prefix
+	}
+	return key
+}
+
+// has reports whether key is one of the names in n.
+func (n FieldNames) has(key string) bool {
+	return key == n.Time || key == n.Level || key == n.Message || key == n.Prefix
+}
+
+// A ColorMode selects the parts of a line that the [SimpleHandler] colors.
+// The level is colored in every mode.
+type ColorMode int
+
+const (
+	// ColorAll colors the time, level, prefix, attribute keys and values.
+	ColorAll ColorMode = iota
+	// ColorKeysOnly colors attribute keys but not their values.
+	ColorKeysOnly
+	// ColorValuesOnly colors attribute values set with [ColorAttr]
+	// but not the keys.
+	ColorValuesOnly
+	// ColorLevelOnly colors the level and nothing else.
+	ColorLevelOnly
+)
+
+// A PartKind identifies one of the parts of a line written by the
+// [SimpleHandler] before the attributes, for [HandlerOptions.PartColors].
+// ReplaceAttr receives each of them under its key, such as [SourceKey].
+type PartKind int
+
+const (
+	// PartTime is the time of the record, faint by default.
+	PartTime PartKind = iota
+	// PartLevel is the level, colored by level by default.
+	PartLevel
+	// PartPrefix is the prefix, uncolored by default.
+	PartPrefix
+	// PartTags are the tags, cyan by default.
+	PartTags
+	// PartSource is the source of the log call, faint by default.
+	PartSource
+	// PartMessage is the message, uncolored by default.
+	PartMessage
+)
+
+// A KeyConflictPolicy controls how handlers treat top-level attributes whose
+// key collides with one of the built-in keys ([TimeKey], [LevelKey],
+// [MessageKey] or [PrefixKey]) or, with a [JSONHandler], with another field
+// it writes: [TagsKey], [SourceKey] when AddSource is set, [ElapsedKey]
+// when Elapsed is set and [EmitTimeKey] when EmitTime is set.
+type KeyConflictPolicy int
+
+const (
+	// KeyConflictRename moves a colliding attribute under the "fields" group,
+	// so that "time" is written as "fields.time". This is the default.
+	KeyConflictRename KeyConflictPolicy = iota
+	// KeyConflictKeep writes colliding attributes unchanged.
+	KeyConflictKeep
+	// KeyConflictDrop discards colliding attributes.
+	KeyConflictDrop
 )
 
+// isBuiltinKey reports whether key is one of the built-in keys.
+func isBuiltinKey(key string) bool {
+	switch key {
+	case TimeKey, LevelKey, MessageKey, PrefixKey:
+		return true
+	}
+	return false
+}
+
 // NewSimpleHandler creates a [SimpleHandler] that writes to w,
 // using the given options.
 // If opts is nil, the default options are used.
@@ -136,6 +561,9 @@ func NewSimpleHandler(opts HandlerOptions) Handler {
 	if opts.TimeFormat == "" {
 		opts.TimeFormat = time.StampMilli
 	}
+	if opts.Elapsed && opts.ElapsedSince.IsZero() {
+		opts.ElapsedSince = time.Now()
+	}
 
 	return &SimpleHandler{
 		prefix: opts.Prefix,
@@ -157,6 +585,13 @@ type SimpleHandler struct {
 	opts        *HandlerOptions // Configuration options
 }
 
+// Options returns a copy of the options of h, with their defaults set.
+// The maps and slices they hold are shared with h and must not be
+// modified.
+func (h *SimpleHandler) Options() HandlerOptions {
+	return *h.opts
+}
+
 // clone creates a shallow copy of the handler with a new groups slice.
 // This is used by WithAttrs, WithGroup, and WithPrefix to create derived handlers.
 func (h *SimpleHandler) clone() *SimpleHandler {
@@ -182,11 +617,7 @@ func (h *SimpleHandler) Enabled(level Level) bool {
 // Handle formats its argument [Record] as a single line of space-separated
 // fields.
 func (h *SimpleHandler) Handle(rr Record) error {
-	r := rr.Clone()
-	// Only use handler prefix if record doesn't have its own prefix
-	if r.Prefix == "" {
-		r.Prefix = h.prefix
-	}
+	r := h.prepare(rr)
 
 	// get a buffer from the sync pool
 	buf := newBuffer()
@@ -198,10 +629,11 @@ func (h *SimpleHandler) Handle(rr Record) error {
 	if !r.Time.IsZero() {
 		val := r.Time.Round(0) // strip monotonic to match Attr behavior
 		if rep == nil {
-			h.appendTintTime(buf, r.Time, -1)
+			h.appendTintTime(buf, r.Time, h.partColor(PartTime, -1))
 			buf.WriteByte(' ')
 		} else if a := rep(nil /* groups */, slog.Time(TimeKey, val)); a.Key != "" {
 			val, color := h.resolve(a.Value)
+			color = h.partColor(PartTime, color)
 			if val.Kind() == slog.KindTime {
 				h.appendTintTime(buf, val.Time(), color)
 			} else {
@@ -229,57 +661,127 @@ func (h *SimpleHandler) Handle(rr Record) error {
 		buf.WriteByte(' ')
 	}
 
-	//write prefix
+	h.appendBody(buf, r)
+
+	if len(*buf) == 0 {
+		buf.WriteByte('\n')
+	} else {
+		(*buf)[len(*buf)-1] = '\n' // replace last space with newline
+	}
+	if h.opts.sanitizing() {
+		sanitize(buf, h.opts)
+	}
+
+	return writeFull(h.opts.Output, *buf)
+}
+
+// prepare returns a copy of rr carrying the handler prefix, unless it has
+// its own, and the level set by an OverrideLevel attribute.
+func (h *SimpleHandler) prepare(rr Record) Record {
+	r := rr.Clone()
+	if r.Prefix == "" {
+		r.Prefix = h.prefix
+	}
+	if level, ok := r.levelOverride(); ok {
+		r.Level = level
+	}
+	return r
+}
+
+// appendBody appends the fields of r following the time and the level,
+// each followed by a space. r must have been returned by prepare.
+func (h *SimpleHandler) appendBody(buf *buffer, r Record) {
+	rep := h.opts.ReplaceAttr
+
+	// write prefix
 	if r.Prefix != "" {
 		if rep == nil {
-			// Use custom PrefixFormat if provided, otherwise use default [prefix] format
-			if h.opts.PrefixFormat != nil {
-				buf.WriteString(h.opts.PrefixFormat(r.Prefix))
+			h.appendPrefix(buf, r.Prefix, -1)
+			buf.WriteByte(' ')
+		} else if a := rep(nil /* groups */, slog.String(PrefixKey, r.Prefix)); a.Key != "" {
+			val, color := h.resolve(a.Value)
+			// A prefix left unchanged keeps its format, as a level does.
+			if val.Kind() == slog.KindString && val.String() == r.Prefix {
+				h.appendPrefix(buf, r.Prefix, color)
 			} else {
-				buf.WriteString("[" + r.Prefix + "]")
+				h.appendTintValue(buf, val, false, h.partColor(PartPrefix, color), true)
 			}
 			buf.WriteByte(' ')
-		} else if a := rep(nil /* groups */, slog.String(PrefixKey, r.Prefix)); a.Key != "" {
+		}
+	}
+
+	// write tags
+	if len(r.Tags) > 0 {
+		if rep == nil {
+			h.appendTags(buf, r.Tags)
+			buf.WriteByte(' ')
+		} else if a := rep(nil /* groups */, slog.Any(TagsKey, r.Tags)); a.Key != "" {
 			val, color := h.resolve(a.Value)
-			h.appendTintValue(buf, val, false, color, true)
+			if tags, ok := val.Any().([]string); ok && val.Kind() == slog.KindAny {
+				h.appendTags(buf, tags)
+			} else {
+				h.appendTintValue(buf, val, false, h.partColor(PartTags, color), false)
+			}
+			buf.WriteByte(' ')
+		}
+	}
+
+	// write source
+	if h.opts.AddSource && r.PC != 0 {
+		src := r.Source()
+		if rep == nil {
+			h.appendTintValue(buf, slog.AnyValue(src), false, h.partColor(PartSource, -1), true)
+			buf.WriteByte(' ')
+		} else if a := rep(nil /* groups */, slog.Any(SourceKey, src)); a.Key != "" {
+			val, color := h.resolve(a.Value)
+			h.appendTintValue(buf, val, false, h.partColor(PartSource, color), true)
 			buf.WriteByte(' ')
 		}
 	}
 
 	// write message
 	if rep == nil {
-		buf.WriteString(r.Message)
+		msg := r.Message
+		if !h.colorsParts() {
+			msg = stripAnsi(msg)
+		}
+		if color := h.partColor(PartMessage, -1); color >= 0 {
+			h.appendTintValue(buf, slog.StringValue(msg), false, color, false)
+		} else {
+			buf.WriteString(msg)
+		}
 		buf.WriteByte(' ')
 	} else if a := rep(nil /* groups */, slog.String(MessageKey, r.Message)); a.Key != "" {
 		val, color := h.resolve(a.Value)
-		h.appendTintValue(buf, val, false, color, false)
+		h.appendTintValue(buf, val, false, h.partColor(PartMessage, color), false)
 		buf.WriteByte(' ')
 	}
 
+	// write elapsed time
+	if h.opts.Elapsed {
+		h.appendAttr(buf, elapsedAttr(r.Time, h.opts.ElapsedSince), "", nil)
+	}
+
+	// write emit time
+	if h.opts.EmitTime {
+		h.appendAttr(buf, slog.Time(EmitTimeKey, time.Now()), "", nil)
+	}
+
 	// write handler attributes
 	if len(h.attrsPrefix) > 0 {
 		buf.WriteString(h.attrsPrefix)
 	}
 
 	// write attributes
-	r.Attrs(func(attr slog.Attr) bool {
+	recordAttrs(buf, r, h.opts, func(attr Attr) {
 		h.appendAttr(buf, attr, h.groupPrefix, h.groups)
-		return true
 	})
-
-	if len(*buf) == 0 {
-		buf.WriteByte('\n')
-	} else {
-		(*buf)[len(*buf)-1] = '\n' // replace last space with newline
-	}
-
-	_, err := h.opts.Output.Write(*buf)
-	return err
 }
 
 // WithAttrs returns a new Handler whose attributes consist of
 // both the receiver's attributes and the arguments.
 func (h *SimpleHandler) WithAttrs(attrs []Attr) Handler {
+	attrs = h.opts.filterAttrs(attrs)
 	if len(attrs) == 0 {
 		return h
 	}
@@ -288,6 +790,9 @@ func (h *SimpleHandler) WithAttrs(attrs []Attr) Handler {
 	defer buf.Free()
 
 	// write attributes to buffer
+	if h.opts.SortAttrs {
+		attrs = sortAttrs(attrs)
+	}
 	for _, attr := range attrs {
 		h.appendAttr(buf, attr, h.groupPrefix, h.groups)
 	}
@@ -305,8 +810,8 @@ func (h *SimpleHandler) WithGroup(name string) Handler {
 	}
 
 	h2 := h.clone()
-	h2.groupPrefix += name + "."
-	h2.groups = append(h2.groups, name)
+	h2.groupPrefix += h.formatKey(name) + "."
+	h2.groups = append(h2.groups[:len(h2.groups):len(h2.groups)], name)
 	return h2
 }
 
@@ -327,21 +832,43 @@ func (h *SimpleHandler) WithPrefix(prefix string) Handler {
 }
 
 func (h *SimpleHandler) appendTintTime(buf *buffer, t time.Time, color int16) {
-	if h.opts.NoColor {
-		*buf = t.AppendFormat(*buf, h.opts.TimeFormat)
+	if h.opts.NoColor || h.opts.ColorMode == ColorLevelOnly {
+		*buf, _ = appendTime(*buf, t, h.opts.TimeFormat)
 	} else {
 		if color >= 0 {
 			appendAnsi(buf, uint8(color), true)
 		} else {
 			buf.WriteString(ansiFaint)
 		}
-		*buf = t.AppendFormat(*buf, h.opts.TimeFormat)
+		*buf, _ = appendTime(*buf, t, h.opts.TimeFormat)
+		buf.WriteString(ansiReset)
+	}
+}
+
+// appendPrefix appends prefix formatted by PrefixFormat, or between
+// brackets, in color if it is not negative, as set by PartColors or
+// returned by ReplaceAttr.
+func (h *SimpleHandler) appendPrefix(buf *buffer, prefix string, color int16) {
+	color = h.partColor(PartPrefix, color)
+	if color >= 0 && h.colorsParts() {
+		appendAnsi(buf, uint8(color), false)
+	}
+	if h.opts.PrefixFormat != nil {
+		buf.WriteString(h.opts.PrefixFormat(prefix))
+	} else {
+		buf.WriteString("[" + prefix + "]")
+	}
+	if color >= 0 && h.colorsParts() {
 		buf.WriteString(ansiReset)
 	}
 }
 
 func (h *SimpleHandler) appendTintLevel(buf *buffer, level Level, color int16) {
 	if !h.opts.NoColor {
+		if c, ok := h.opts.LevelColors[level]; ok && color < 0 {
+			color = int16(c)
+		}
+		color = h.partColor(PartLevel, color)
 		if color >= 0 {
 			appendAnsi(buf, uint8(color), false)
 		} else {
@@ -356,37 +883,28 @@ func (h *SimpleHandler) appendTintLevel(buf *buffer, level Level, color int16) {
 				buf.WriteString(ansiBrightYellow)
 			case LevelError:
 				buf.WriteString(ansiBrightRed)
-			case LevelPanic:
+			case LevelPanic, LevelFatal:
 				buf.WriteString(ansiBrightRed)
 			default:
-				if level <= LevelTrace {
-					buf.WriteString(ansiGray)
-				} else {
-					buf.WriteString(ansiBrightRed)
-				}
+				appendAnsi(buf, offLevelColor(level), false)
 			}
 		}
 	}
 
-	// Use custom LevelFormat if provided, otherwise use default
-	if h.opts.LevelFormat != nil {
-		buf.WriteString(h.opts.LevelFormat(level))
-	} else {
-		switch level.Real() {
-		case LevelTrace:
-			buf.WriteString("TRACE")
-		case LevelDebug:
-			buf.WriteString("DEBUG")
-		case LevelInfo:
-			buf.WriteString("INFO")
-		case LevelWarn:
-			buf.WriteString("WARN")
-		case LevelError:
-			buf.WriteString("ERROR")
-		case LevelPanic:
-			buf.WriteString("PANIC")
-		case LevelFatal:
-			buf.WriteString("FATAL")
+	icon, hasIcon := h.opts.LevelIcons[level]
+	if hasIcon {
+		buf.WriteString(icon)
+	}
+	if !hasIcon || !h.opts.IconsOnly {
+		if hasIcon {
+			buf.WriteByte(' ')
+		}
+		// Use custom LevelFormat if provided, otherwise use default
+		if h.opts.LevelFormat != nil {
+			buf.WriteString(h.opts.LevelFormat(level))
+		} else {
+			buf.WriteString(levelName(level))
+			appendLevelOffset(buf, level)
 		}
 	}
 
@@ -395,17 +913,239 @@ func (h *SimpleHandler) appendTintLevel(buf *buffer, level Level, color int16) {
 	}
 }
 
-func appendSource(buf *buffer, src *slog.Source) {
-	dir, file := filepath.Split(src.File)
+// sortAttrs returns a copy of attrs sorted by key.
+// Attributes with equal keys keep their relative order.
+func sortAttrs(attrs []Attr) []Attr {
+	sorted := slices.Clone(attrs)
+	slices.SortStableFunc(sorted, compareKeys)
+	return sorted
+}
+
+// compareKeys orders attributes by key.
+func compareKeys(a, b Attr) int {
+	return strings.Compare(a.Key, b.Key)
+}
+
+// recordAttrs calls appendAttr on each attribute of r, appending it to
+// buf, in key order if SortAttrs is set. Past MaxAttrs attributes, or once
+// buf exceeds MaxRecordSize bytes, the remaining attributes are replaced by
+// an [AttrsTruncatedKey] attribute holding their number.
+func recordAttrs(buf *buffer, r Record, opts *HandlerOptions, appendAttr func(Attr)) {
+	filter := opts.filtersKeys()
+	total := r.NumAttrs()
+	limit := total
+	if opts.MaxAttrs > 0 && opts.MaxAttrs < limit {
+		limit = opts.MaxAttrs
+	}
+	written, full := 0, false
+	emit := func(a Attr) bool {
+		if filter && !opts.keepKey(a.Key) {
+			total--
+			return true
+		}
+		// past the limits, go on only to discount the filtered attributes
+		if full || written == limit {
+			full = true
+			return filter
+		}
+		mark := len(*buf)
+		appendAttr(a)
+		if opts.MaxRecordSize > 0 && len(*buf) > opts.MaxRecordSize {
+			*buf = (*buf)[:mark]
+			full = true
+			return filter
+		}
+		written++
+		return true
+	}
+	if !opts.SortAttrs {
+		r.Attrs(emit)
+	} else {
+		attrs := make([]Attr, 0, limit)
+		r.Attrs(func(a Attr) bool {
+			if filter && !opts.keepKey(a.Key) {
+				total--
+				return true
+			}
+			if len(attrs) < limit {
+				attrs = append(attrs, a)
+			}
+			return filter || len(attrs) < limit
+		})
+		slices.SortStableFunc(attrs, compareKeys)
+		for _, a := range attrs {
+			if !emit(a) {
+				break
+			}
+		}
+	}
+	if dropped := total - written; dropped > 0 {
+		appendAttr(Int(AttrsTruncatedKey, dropped))
+	}
+}
+
+// filtersKeys reports whether IncludeKeys or ExcludeKeys is set.
+func (opts *HandlerOptions) filtersKeys() bool {
+	return len(opts.IncludeKeys) > 0 || len(opts.ExcludeKeys) > 0
+}
+
+// keepKey reports whether the attributes with the given key pass
+// IncludeKeys and ExcludeKeys. Groups without a key are always kept.
+func (opts *HandlerOptions) keepKey(key string) bool {
+	if key == "" {
+		return true
+	}
+	if len(opts.IncludeKeys) > 0 && !slices.Contains(opts.IncludeKeys, key) {
+		return false
+	}
+	return !slices.Contains(opts.ExcludeKeys, key)
+}
+
+// filterAttrs returns the attributes of attrs that pass IncludeKeys and
+// ExcludeKeys, attrs itself if neither is set.
+func (opts *HandlerOptions) filterAttrs(attrs []Attr) []Attr {
+	if !opts.filtersKeys() {
+		return attrs
+	}
+	return slices.DeleteFunc(slices.Clone(attrs), func(a Attr) bool {
+		return !opts.keepKey(a.Key)
+	})
+}
+
+// DefaultLevelIcons returns a new map holding a glyph for each of the
+// standard levels, for use as [HandlerOptions.LevelIcons].
+func DefaultLevelIcons() map[Level]string {
+	return map[Level]string{
+		LevelTrace: "🔍",
+		LevelDebug: "🐛",
+		LevelInfo:  "ℹ",
+		LevelWarn:  "⚠",
+		LevelError: "✖",
+		LevelPanic: "💥",
+		LevelFatal: "☠",
+	}
+}
+
+// SeverityName returns the Google Cloud Logging severity of level:
+// DEBUG for Trace and Debug, INFO, WARNING, ERROR, and CRITICAL for
+// Panic and Fatal.
+func SeverityName(level Level) string {
+	return cloudLoggingLevels.Name(level)
+}
+
+// appendLevelOffset appends the distance of a level outside Trace..Fatal
+// from the nearest of them, as in "FATAL+2", so that custom levels remain
+// distinguishable.
+func appendLevelOffset(buf *buffer, level Level) {
+	switch {
+	case level > LevelFatal:
+		buf.WriteByte('+')
+		*buf = strconv.AppendInt(*buf, int64(level-LevelFatal), 10)
+	case level < LevelTrace:
+		*buf = strconv.AppendInt(*buf, int64(level-LevelTrace), 10)
+	}
+}
+
+// offLevelColor returns the color of a level outside Trace..Fatal: reds
+// turning to magenta above Fatal and grays turning darker below Trace,
+// one step per level.
+func offLevelColor(level Level) uint8 {
+	if level > LevelFatal {
+		// 196 is red in the 6×6×6 cube, and 201 magenta
+		return 196 + uint8(min(level-LevelFatal, 5))
+	}
+	// 244 is about the gray of Trace, and 232 the darkest
+	return 244 - 2*uint8(min(LevelTrace-level, 6))
+}
+
+// levelName returns the default upper-case name used by the built-in
+// handlers to render level.
+func levelName(level Level) string {
+	switch level.Real() {
+	case LevelTrace:
+		return "TRACE"
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	case LevelPanic:
+		return "PANIC"
+	default:
+		return "FATAL"
+	}
+}
+
+// appendTags appends tags as space-separated words starting with '#'.
+func (h *SimpleHandler) appendTags(buf *buffer, tags []string) {
+	colored := h.colorsParts()
+	if colored {
+		if c, ok := h.opts.PartColors[PartTags]; ok {
+			appendAnsi(buf, c, false)
+		} else {
+			buf.WriteString(ansiBrightCyan)
+		}
+	}
+	for i, tag := range tags {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteByte('#')
+		buf.WriteString(tag)
+	}
+	if colored {
+		buf.WriteString(ansiReset)
+	}
+}
+
+// partColor returns color if it is set, by ReplaceAttr, or else the color
+// of part in PartColors, -1 if none.
+func (h *SimpleHandler) partColor(part PartKind, color int16) int16 {
+	if color < 0 {
+		if c, ok := h.opts.PartColors[part]; ok {
+			return int16(c)
+		}
+	}
+	return color
+}
 
-	buf.WriteString(filepath.Join(filepath.Base(dir), file))
+// colorsParts reports whether the parts other than the level are colored.
+func (h *SimpleHandler) colorsParts() bool {
+	return !h.opts.NoColor && h.opts.ColorMode != ColorLevelOnly
+}
+
+// appendSource appends src as "file:line", followed by the function name
+// if SourceFunc is set, unless SourceFormat is set.
+func (h *SimpleHandler) appendSource(buf *buffer, src *slog.Source) {
+	if h.opts.SourceFormat != nil {
+		buf.WriteString(h.opts.SourceFormat(src))
+		return
+	}
+	buf.WriteString(sourceFile(src, h.opts))
 	buf.WriteByte(':')
 	*buf = strconv.AppendInt(*buf, int64(src.Line), 10)
+	if h.opts.SourceFunc && src.Function != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(shortFunc(src.Function))
+	}
 }
 
 func (h *SimpleHandler) resolve(val slog.Value) (resolvedVal slog.Value, color int16) {
 	if !h.opts.NoColor && val.Kind() == slog.KindLogValuer {
+		if st, ok := val.Any().(ansiStringer); ok && h.colorsParts() {
+			return slog.StringValue(st.ANSIString()), -1
+		}
 		if tintVal, ok := val.Any().(colorValue); ok {
+			if tintVal.Tone != 0 {
+				c, ok := h.opts.ToneColors[tintVal.Tone]
+				if !ok {
+					c = toneColor(tintVal.Tone)
+				}
+				return tintVal.Value.Resolve(), int16(c)
+			}
 			return tintVal.Value.Resolve(), int16(tintVal.Color)
 		}
 	}
@@ -413,9 +1153,19 @@ func (h *SimpleHandler) resolve(val slog.Value) (resolvedVal slog.Value, color i
 }
 
 func (h *SimpleHandler) appendAttr(buf *buffer, attr slog.Attr, groupsPrefix string, groups []string) {
+	if isLevelOverride(attr.Value) {
+		return
+	}
 	var color int16 // -1 if no color
 	attr.Value, color = h.resolve(attr.Value)
-	if rep := h.opts.ReplaceAttr; rep != nil && attr.Value.Kind() != slog.KindGroup {
+	var rep func([]string, Attr) Attr
+	switch {
+	case attr.Value.Kind() != slog.KindGroup:
+		rep = h.opts.ReplaceAttr
+	case attr.Key != "":
+		rep = h.opts.ReplaceGroup
+	}
+	if rep != nil {
 		attr = rep(groups, attr)
 		var colorRep int16
 		attr.Value, colorRep = h.resolve(attr.Value)
@@ -427,43 +1177,110 @@ func (h *SimpleHandler) appendAttr(buf *buffer, attr slog.Attr, groupsPrefix str
 	if attr.Equal(slog.Attr{}) {
 		return
 	}
+	if h.opts.ErrorTree {
+		attr.Value = errorTreeValue(attr.Value)
+	}
 
 	if attr.Value.Kind() == slog.KindGroup {
 		if attr.Key != "" {
-			groupsPrefix += attr.Key + "."
-			groups = append(groups, attr.Key)
+			// Build the prefix of the members in a pooled buffer, viewed
+			// as a string only while they are appended.
+			prefix := newBuffer()
+			defer prefix.Free()
+			prefix.WriteString(groupsPrefix)
+			prefix.WriteString(h.formatKey(attr.Key))
+			prefix.WriteByte('.')
+			groupsPrefix = prefix.view()
+			if h.opts.ReplaceAttr != nil || h.opts.ReplaceGroup != nil {
+				groups = append(groups[:len(groups):len(groups)], attr.Key)
+			}
 		}
-		for _, groupAttr := range attr.Value.Group() {
+		members := attr.Value.Group()
+		if h.opts.SortAttrs {
+			members = sortAttrs(members)
+		}
+		for _, groupAttr := range members {
 			h.appendAttr(buf, groupAttr, groupsPrefix, groups)
 		}
 		return
 	}
 
-	if h.opts.NoColor {
+	if groupsPrefix == "" && isBuiltinKey(attr.Key) {
+		switch h.opts.KeyConflict {
+		case KeyConflictDrop:
+			return
+		case KeyConflictRename:
+			groupsPrefix = conflictGroup + "."
+		}
+	}
+
+	colorKeys, colorValues := h.colorParts()
+	switch {
+	case color >= 0 && colorKeys:
+		appendAnsi(buf, uint8(color), true)
 		h.appendKey(buf, attr.Key, groupsPrefix)
-		h.appendValue(buf, attr.Value, true)
-	} else {
-		if color >= 0 {
-			appendAnsi(buf, uint8(color), true)
-			h.appendKey(buf, attr.Key, groupsPrefix)
+		if colorValues {
 			buf.WriteString(ansiResetFaint)
 			h.appendValue(buf, attr.Value, true)
 			buf.WriteString(ansiReset)
 		} else {
-			buf.WriteString(ansiFaint)
-			h.appendKey(buf, attr.Key, groupsPrefix)
 			buf.WriteString(ansiReset)
 			h.appendValue(buf, attr.Value, true)
 		}
+	case color >= 0 && colorValues:
+		h.appendKey(buf, attr.Key, groupsPrefix)
+		appendAnsi(buf, uint8(color), false)
+		h.appendValue(buf, attr.Value, true)
+		buf.WriteString(ansiReset)
+	case colorKeys:
+		buf.WriteString(ansiFaint)
+		h.appendKey(buf, attr.Key, groupsPrefix)
+		buf.WriteString(ansiReset)
+		h.appendValue(buf, attr.Value, true)
+	default:
+		h.appendKey(buf, attr.Key, groupsPrefix)
+		h.appendValue(buf, attr.Value, true)
 	}
 	buf.WriteByte(' ')
 }
 
+// colorParts reports whether attribute keys and values are colored.
+func (h *SimpleHandler) colorParts() (keys, values bool) {
+	if h.opts.NoColor {
+		return false, false
+	}
+	switch h.opts.ColorMode {
+	case ColorKeysOnly:
+		return true, false
+	case ColorValuesOnly:
+		return false, true
+	case ColorLevelOnly:
+		return false, false
+	}
+	return true, true
+}
+
 func (h *SimpleHandler) appendKey(buf *buffer, key, groups string) {
-	appendString(buf, groups+key, true, !h.opts.NoColor)
+	key = h.formatKey(key)
+	if groups != "" && !needsQuoting(groups) && !needsQuoting(key) {
+		// the joined key needs no quoting either: write it without
+		// concatenating
+		buf.WriteString(groups)
+		buf.WriteString(key)
+	} else {
+		appendString(buf, groups+key, true, !h.opts.NoColor)
+	}
 	buf.WriteByte('=')
 }
 
+// formatKey applies the KeyFormat option to key, if any.
+func (h *SimpleHandler) formatKey(key string) string {
+	if h.opts.KeyFormat == nil {
+		return key
+	}
+	return h.opts.KeyFormat(key)
+}
+
 func (h *SimpleHandler) appendValue(buf *buffer, v slog.Value, quote bool) {
 	switch v.Kind() {
 	case slog.KindString:
@@ -500,6 +1317,12 @@ func (h *SimpleHandler) appendValue(buf *buffer, v slog.Value, quote bool) {
 		}()
 
 		switch cv := v.Any().(type) {
+		case idValue, netip.Addr, netip.Prefix:
+			if b, ok := appendBareText(*buf, cv); ok {
+				*buf = b
+				break
+			}
+			appendString(buf, cv.(fmt.Stringer).String(), quote, !h.opts.NoColor)
 		case encoding.TextMarshaler:
 			data, err := cv.MarshalText()
 			if err != nil {
@@ -507,7 +1330,16 @@ func (h *SimpleHandler) appendValue(buf *buffer, v slog.Value, quote bool) {
 			}
 			appendString(buf, string(data), quote, !h.opts.NoColor)
 		case *slog.Source:
-			appendSource(buf, cv)
+			h.appendSource(buf, cv)
+		case fmt.Formatter:
+			// may print more with %+v than its message, e.g. a stack trace
+			appendString(buf, fmt.Sprintf("%+v", cv), quote, !h.opts.NoColor)
+		case error:
+			// Fast paths for the most common values, avoiding fmt; the
+			// panic of a nil receiver is recovered above as "<nil>".
+			appendString(buf, cv.Error(), quote, !h.opts.NoColor)
+		case fmt.Stringer:
+			appendString(buf, cv.String(), quote, !h.opts.NoColor)
 		default:
 			appendString(buf, fmt.Sprintf("%+v", cv), quote, !h.opts.NoColor)
 		}
@@ -536,7 +1368,7 @@ func (h *SimpleHandler) appendValue(buf *buffer, v slog.Value, quote bool) {
 }
 
 func (h *SimpleHandler) appendTintValue(buf *buffer, val slog.Value, quote bool, color int16, faint bool) {
-	if h.opts.NoColor {
+	if h.opts.NoColor || h.opts.ColorMode == ColorLevelOnly {
 		h.appendValue(buf, val, quote)
 	} else {
 		if color >= 0 {
@@ -610,7 +1442,43 @@ func appendString(buf *buffer, s string, quote, color bool) {
 	}
 }
 
+// ansiStringer is implemented by the values holding text styled with ANSI
+// escape sequences, such as those of the ansi package, whose LogValue is
+// the text without them. The SimpleHandler writes the styled text when it
+// colors values.
+type ansiStringer interface {
+	ANSIString() string
+}
+
+// stripAnsi returns s without its ANSI escape sequences, as written by
+// the ansi package: ESC, '[', parameters and a final letter.
+func stripAnsi(s string) string {
+	i := strings.IndexByte(s, ansiEsc)
+	if i < 0 {
+		return s
+	}
+	b := make([]byte, 0, len(s))
+	for i >= 0 {
+		b = append(b, s[:i]...)
+		s = s[i+1:]
+		if len(s) > 0 && s[0] == '[' {
+			j := 1
+			for j < len(s) && (s[j] < 0x40 || s[j] > 0x7e) {
+				j++
+			}
+			s = s[min(j+1, len(s)):]
+		}
+		i = strings.IndexByte(s, ansiEsc)
+	}
+	return string(append(b, s...))
+}
+
 func cut(s string, f func(r rune) bool) string {
+	// Fast path: without escapes nor invalid UTF-8, at which the loop
+	// below stops, the string is returned unchanged and not copied.
+	if strings.IndexByte(s, ansiEsc) < 0 && !strings.ContainsRune(s, utf8.RuneError) {
+		return s
+	}
 	var res []rune
 	for i := 0; i < len(s); {
 		r, size := utf8.DecodeRuneInString(s[i:])
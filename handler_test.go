@@ -2,6 +2,13 @@ package l4g
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -181,6 +188,23 @@ func TestSimpleHandler_HandleWithPrefix(t *testing.T) {
 	}
 }
 
+func TestSimpleHandler_OptionsPrefix(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := NewSimpleHandler(HandlerOptions{Output: buf, NoColor: true, Prefix: "svc"})
+
+	h.Handle(NewRecord(time.Time{}, LevelInfo, "handler"))
+	h.WithPrefix("db.").Handle(NewRecord(time.Time{}, LevelInfo, "derived"))
+	r := NewRecord(time.Time{}, LevelInfo, "record")
+	r.Prefix = "own"
+	h.WithPrefix("db.").Handle(r)
+
+	for _, want := range []string{"INFO [svc] handler\n", "INFO [db.svc] derived\n", "INFO [own] record\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output = %q, want to contain %q", buf.String(), want)
+		}
+	}
+}
+
 func TestSimpleHandler_WithAttrs(t *testing.T) {
 	buf := &bytes.Buffer{}
 	h := NewSimpleHandler(HandlerOptions{
@@ -382,6 +406,69 @@ func TestSimpleHandler_ColorAttr(t *testing.T) {
 	}
 }
 
+func TestSimpleHandler_Tones(t *testing.T) {
+	render := func(opts HandlerOptions, a Attr) string {
+		buf := &bytes.Buffer{}
+		opts.Output = buf
+		r := NewRecord(time.Time{}, LevelInfo, "m")
+		r.AddAttrs(a)
+		if err := NewSimpleHandler(opts).Handle(r); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	tests := []struct {
+		attr  Attr
+		color uint8
+	}{
+		{Highlight(String("k", "v")), 13},
+		{Warning(String("k", "v")), 11},
+		{OK(String("k", "v")), 10},
+	}
+	for _, tt := range tests {
+		want := render(HandlerOptions{}, ColorAttr(tt.color, String("k", "v")))
+		if got := render(HandlerOptions{}, tt.attr); got != want {
+			t.Errorf("tone output = %q, want %q", got, want)
+		}
+	}
+
+	got := render(HandlerOptions{ToneColors: map[Tone]uint8{ToneOK: 42}}, OK(String("k", "v")))
+	if want := render(HandlerOptions{}, ColorAttr(42, String("k", "v"))); got != want {
+		t.Errorf("ToneColors output = %q, want %q", got, want)
+	}
+	if got := render(HandlerOptions{NoColor: true}, OK(String("k", "v"))); got != "INFO m k=v\n" {
+		t.Errorf("NoColor output = %q, want a plain attribute", got)
+	}
+}
+
+func TestSimpleHandler_OffLevels(t *testing.T) {
+	tests := []struct {
+		level Level
+		name  string
+		color string
+	}{
+		{LevelFatal + 2, "FATAL+2", "\x1b[38;5;198m"},
+		{LevelFatal + 9, "FATAL+9", "\x1b[38;5;201m"},
+		{LevelTrace - 1, "TRACE-1", "\x1b[38;5;242m"},
+		{LevelFatal, "FATAL", "\x1b[91m"},
+	}
+	for _, tt := range tests {
+		buf := &bytes.Buffer{}
+		NewSimpleHandler(HandlerOptions{Output: buf, Level: Level(-10)}).Handle(NewRecord(time.Time{}, tt.level, "m"))
+		if want := tt.color + tt.name + "\x1b[0m m\n"; buf.String() != want {
+			t.Errorf("level %d = %q, want %q", tt.level, buf.String(), want)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	l := New(Options{Output: buf, NoColor: true})
+	l.Log(LevelError+3, "custom")
+	if got := buf.String(); !strings.Contains(got, "FATAL+1 custom") {
+		t.Errorf("Log() = %q, want the offset of the level", got)
+	}
+}
+
 func TestHandlerOptions_Defaults(t *testing.T) {
 	opts := HandlerOptions{}
 
@@ -466,6 +553,41 @@ func BenchmarkSimpleHandler_HandleWithColor(b *testing.B) {
 	}
 }
 
+func BenchmarkSimpleHandler_HandleGroups(b *testing.B) {
+	buf := &bytes.Buffer{}
+	h := NewSimpleHandler(HandlerOptions{
+		Level:   LevelInfo,
+		Output:  buf,
+		NoColor: true,
+	}).WithGroup("req")
+
+	r := NewRecord(time.Now(), LevelInfo, "benchmark message")
+	r.AddAttrs(Group("user", String("id", "u1"), Group("geo", String("country", "FR"))))
+
+	for b.Loop() {
+		buf.Reset()
+		_ = h.Handle(r)
+	}
+}
+
+func BenchmarkSimpleHandler_HandleReplaceAttr(b *testing.B) {
+	buf := &bytes.Buffer{}
+	h := NewSimpleHandler(HandlerOptions{
+		Level:       LevelInfo,
+		Output:      buf,
+		NoColor:     true,
+		ReplaceAttr: func(_ []string, a Attr) Attr { return a },
+	})
+
+	r := NewRecord(time.Now(), LevelWarn, "benchmark message")
+	r.AddAttrs(String("key1", "value1"), Int("key2", 42))
+
+	for b.Loop() {
+		buf.Reset()
+		_ = h.Handle(r)
+	}
+}
+
 func BenchmarkSimpleHandler_WithAttrs(b *testing.B) {
 	buf := &bytes.Buffer{}
 	h := NewSimpleHandler(HandlerOptions{
@@ -777,3 +899,513 @@ func TestSimpleHandler_PrefixFormat_WithReplaceAttr(t *testing.T) {
 		t.Errorf("Output should not contain PrefixFormat when ReplaceAttr is used, got: %q", output)
 	}
 }
+
+func TestSimpleHandler_Formats_UnchangedByReplaceAttr(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := NewSimpleHandler(HandlerOptions{
+		Output:       buf,
+		NoColor:      true,
+		LevelFormat:  func(l Level) string { return strings.ToLower(l.String())[:1] },
+		PrefixFormat: func(p string) string { return p + ":" },
+		ReplaceAttr: func(groups []string, attr Attr) Attr {
+			if attr.Key == "password" {
+				return String("password", "***")
+			}
+			return attr
+		},
+	})
+
+	r := NewRecord(time.Time{}, LevelWarn, "login")
+	r.Prefix = "auth"
+	r.AddAttrs(String("password", "secret"))
+	h.Handle(r)
+
+	if got, want := buf.String(), "w auth: login password=***\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestSimpleHandler_KeyConflict(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  KeyConflictPolicy
+		want    []string
+		notWant []string
+	}{
+		{"rename", KeyConflictRename, []string{"fields.msg=dup", "fields.level=1", "user.msg=ok"}, nil},
+		{"keep", KeyConflictKeep, []string{" msg=dup", " level=1"}, []string{"fields."}},
+		{"drop", KeyConflictDrop, []string{"user.msg=ok"}, []string{"dup", "level=1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			h := NewSimpleHandler(HandlerOptions{
+				Output:      buf,
+				NoColor:     true,
+				KeyConflict: tt.policy,
+			})
+
+			r := NewRecord(time.Now(), LevelInfo, "test")
+			r.AddAttrs(String("msg", "dup"), Int("level", 1), Group("user", String("msg", "ok")))
+			if err := h.Handle(r); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+
+			output := buf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("output = %q, want to contain %q", output, want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(output, notWant) {
+					t.Errorf("output = %q, should not contain %q", output, notWant)
+				}
+			}
+		})
+	}
+}
+
+func TestSimpleHandler_EpochTimeFormat(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := NewSimpleHandler(HandlerOptions{
+		Output:     buf,
+		NoColor:    true,
+		TimeFormat: TimeFormatUnixMilli,
+	})
+
+	now := time.UnixMilli(1704164645006)
+	if err := h.Handle(NewRecord(now, LevelInfo, "m")); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if want := "1704164645006 INFO m\n"; buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestSimpleHandler_Elapsed(t *testing.T) {
+	buf := &bytes.Buffer{}
+	start := time.Now()
+	h := NewSimpleHandler(HandlerOptions{
+		Output:       buf,
+		NoColor:      true,
+		Elapsed:      true,
+		ElapsedSince: start,
+	})
+
+	r := NewRecord(start.Add(1500*time.Millisecond), LevelInfo, "step")
+	r.AddAttrs(String("k", "v"))
+	if err := h.Handle(r); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "step elapsed=1.5s k=v") {
+		t.Errorf("output = %q, want elapsed=1.5s before the attributes", buf.String())
+	}
+}
+
+func TestLogger_ElapsedWithOptions(t *testing.T) {
+	buf := &bytes.Buffer{}
+	clock := newFakeClock()
+	logger := New(Options{Output: buf, NoColor: true, Elapsed: true, Clock: clock})
+
+	clock.Advance(2 * time.Second)
+	logger = logger.WithOptions(func(o *Options) { o.Prefix = "app" })
+	clock.Advance(time.Second)
+	logger.Info("step")
+	if !strings.Contains(buf.String(), "step elapsed=3s") {
+		t.Errorf("output = %q, want elapsed=3s since New", buf.String())
+	}
+}
+
+func TestProcessStart(t *testing.T) {
+	if ProcessStart().IsZero() || ProcessStart().After(time.Now()) {
+		t.Errorf("ProcessStart() = %v, want a time in the past", ProcessStart())
+	}
+}
+
+func TestSimpleHandler_ReplaceGroup(t *testing.T) {
+	buf := &bytes.Buffer{}
+	var seen []string
+	h := NewSimpleHandler(HandlerOptions{
+		Output:  buf,
+		NoColor: true,
+		ReplaceGroup: func(groups []string, attr Attr) Attr {
+			seen = append(seen, strings.Join(append(groups, attr.Key), "."))
+			switch attr.Key {
+			case "secret":
+				return Attr{}
+			case "req":
+				attr.Key = "request"
+			case "count":
+				return Int("count", len(attr.Value.Group()))
+			}
+			return attr
+		},
+	})
+
+	r := NewRecord(time.Now(), LevelInfo, "m")
+	r.AddAttrs(
+		Group("secret", String("token", "abc")),
+		Group("req", String("path", "/"), Group("inner", Int("x", 1))),
+		Group("count", Int("a", 1), Int("b", 2)),
+	)
+	if err := h.Handle(r); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	output := buf.String()
+	if strings.Contains(output, "token") {
+		t.Errorf("output = %q, dropped group should not be written", output)
+	}
+	for _, want := range []string{"request.path=/", "request.inner.x=1", "count=2"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output = %q, want to contain %q", output, want)
+		}
+	}
+	if got := strings.Join(seen, " "); got != "secret req request.inner count" {
+		t.Errorf("ReplaceGroup saw %q", got)
+	}
+}
+
+func TestDiscardHandler(t *testing.T) {
+	h := DiscardHandler
+	if h.Enabled(LevelFatal) {
+		t.Errorf("DiscardHandler.Enabled() = true, want false")
+	}
+	if err := h.Handle(NewRecord(time.Now(), LevelInfo, "m")); err != nil {
+		t.Errorf("DiscardHandler.Handle() error = %v", err)
+	}
+	if h.WithAttrs([]Attr{String("k", "v")}) != h || h.WithGroup("g") != h || h.WithPrefix("p") != h {
+		t.Errorf("DiscardHandler derived handlers should be DiscardHandler")
+	}
+}
+
+func TestSimpleHandler_LevelColors(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := NewSimpleHandler(HandlerOptions{
+		Output:      buf,
+		LevelColors: map[Level]uint8{LevelWarn: 3},
+	})
+
+	if err := h.Handle(NewRecord(time.Time{}, LevelWarn, "w")); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if err := h.Handle(NewRecord(time.Time{}, LevelInfo, "i")); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	lines := strings.Split(buf.String(), "\n")
+	if !strings.HasPrefix(lines[0], "\x1b[33mWARN\x1b[0m") {
+		t.Errorf("warn line = %q, want yellow (33) level", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], ansiBrightGreen+"INFO") {
+		t.Errorf("info line = %q, want the default color", lines[1])
+	}
+}
+
+func TestSimpleHandler_ColorMode(t *testing.T) {
+	tests := []struct {
+		name string
+		mode ColorMode
+		want string
+	}{
+		{"all", ColorAll, ansiFaint + "k=" + ansiReset + "v \x1b[2;31mc=" + ansiResetFaint + "red" + ansiReset + "\n"},
+		{"keys only", ColorKeysOnly, ansiFaint + "k=" + ansiReset + "v \x1b[2;31mc=" + ansiReset + "red\n"},
+		{"values only", ColorValuesOnly, "k=v c=\x1b[31mred" + ansiReset + "\n"},
+		{"level only", ColorLevelOnly, "k=v c=red\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			h := NewSimpleHandler(HandlerOptions{Output: buf, ColorMode: tt.mode})
+
+			r := NewRecord(time.Now(), LevelInfo, "m")
+			r.AddAttrs(String("k", "v"), ColorAttr(1, String("c", "red")))
+			if err := h.Handle(r); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+
+			output := buf.String()
+			if !strings.HasSuffix(output, tt.want) {
+				t.Errorf("output = %q, want suffix %q", output, tt.want)
+			}
+			if !strings.Contains(output, ansiBrightGreen+"INFO") {
+				t.Errorf("output = %q, the level should always be colored", output)
+			}
+			if hasFaintTime := strings.HasPrefix(output, ansiFaint); hasFaintTime == (tt.mode == ColorLevelOnly) {
+				t.Errorf("output = %q, unexpected time coloring", output)
+			}
+		})
+	}
+}
+
+func TestSimpleHandler_SortAttrs(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := NewSimpleHandler(HandlerOptions{Output: buf, NoColor: true, SortAttrs: true}).
+		WithAttrs([]Attr{String("z", "1"), String("a", "2")})
+
+	r := NewRecord(time.Now(), LevelInfo, "m")
+	r.AddAttrs(Int("c", 1), Group("b", Int("y", 1), Int("x", 2)), Int("a", 3), Int("d", 4), Int("f", 5), Int("e", 6))
+	if err := h.Handle(r); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	if want := "m a=2 z=1 a=3 b.x=2 b.y=1 c=1 d=4 e=6 f=5\n"; !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("output = %q, want suffix %q", buf.String(), want)
+	}
+}
+
+func TestSimpleHandler_LevelIcons(t *testing.T) {
+	tests := []struct {
+		name      string
+		iconsOnly bool
+		level     Level
+		want      string
+	}{
+		{"with name", false, LevelWarn, "⚠ WARN m\n"},
+		{"icon only", true, LevelError, "✖ m\n"},
+		{"no icon", true, LevelDebug, "DEBUG m\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			icons := DefaultLevelIcons()
+			delete(icons, LevelDebug)
+			h := NewSimpleHandler(HandlerOptions{
+				Level:      LevelTrace,
+				Output:     buf,
+				NoColor:    true,
+				LevelIcons: icons,
+				IconsOnly:  tt.iconsOnly,
+			})
+
+			if err := h.Handle(NewRecord(time.Time{}, tt.level, "m")); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("output = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestSimpleHandler_SourceFormat(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := NewSimpleHandler(HandlerOptions{
+		Output:    buf,
+		NoColor:   true,
+		AddSource: true,
+		SourceFormat: func(src *slog.Source) string {
+			return "vscode://file" + src.File + ":" + strconv.Itoa(src.Line)
+		},
+	})
+
+	r := NewRecord(time.Time{}, LevelInfo, "m")
+	r.PC, _, _, _ = runtime.Caller(0)
+	if err := h.Handle(r); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "INFO vscode://file/") {
+		t.Errorf("output = %q, want a vscode link", buf.String())
+	}
+}
+
+func TestSimpleHandler_HandleAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+	h := NewSimpleHandler(HandlerOptions{
+		Level:   LevelInfo,
+		Output:  io.Discard,
+		NoColor: true,
+	}).WithGroup("req")
+
+	r := NewRecord(time.Now(), LevelInfo, "message")
+	r.AddAttrs(String("key", "value"), Group("user", String("id", "u1")))
+
+	if n := testing.AllocsPerRun(100, func() { _ = h.Handle(r) }); n != 0 {
+		t.Errorf("Handle allocated %v times, want 0", n)
+	}
+}
+
+func TestSimpleHandler_WithGroupSiblings(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewSimpleHandler(HandlerOptions{Output: &buf, NoColor: true}).WithGroup("a").WithGroup("b").WithGroup("c")
+	h1 := h.WithGroup("d").(*SimpleHandler)
+	h.WithGroup("e") // must not overwrite the groups of its sibling
+	if want := []string{"a", "b", "c", "d"}; !slices.Equal(h1.groups, want) {
+		t.Errorf("groups = %v, want %v", h1.groups, want)
+	}
+}
+
+func TestSimpleHandler_MaxAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewSimpleHandler(HandlerOptions{Output: &buf, NoColor: true, MaxAttrs: 2}).
+		WithAttrs([]Attr{String("host", "a1")})
+
+	r := NewRecord(time.Time{}, LevelInfo, "loop")
+	for i := range 1000 {
+		r.AddAttrs(Int("i"+strconv.Itoa(i), i))
+	}
+	if err := h.Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "INFO loop host=a1 i0=0 i1=1 attrs_truncated=998\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	r = NewRecord(time.Time{}, LevelInfo, "small")
+	r.AddAttrs(Int("a", 1), Int("b", 2))
+	if err := h.Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), AttrsTruncatedKey) {
+		t.Errorf("record within the limit truncated: %q", buf.String())
+	}
+}
+
+func TestSimpleHandler_IncludeExcludeKeys(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewSimpleHandler(HandlerOptions{
+		Output:      &buf,
+		NoColor:     true,
+		IncludeKeys: []string{"user", "http", "n"},
+		ExcludeKeys: []string{"n"},
+		MaxAttrs:    2,
+	}).WithAttrs([]Attr{String("host", "a1"), String("user", "bob")})
+
+	r := NewRecord(time.Time{}, LevelInfo, "request")
+	r.AddAttrs(Int("n", 1), Group("http", String("method", "GET"), Int("status", 200)), String("trace", "x"))
+	if err := h.Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "INFO request user=bob http.method=GET http.status=200\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSimpleHandler_PartColors(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewSimpleHandler(HandlerOptions{
+		Output:     &buf,
+		AddSource:  true,
+		PartColors: map[PartKind]uint8{PartSource: 4, PartMessage: 1, PartPrefix: 2, PartTags: 3},
+	}).WithPrefix("db")
+
+	r := NewRecord(time.Time{}, LevelInfo, "query")
+	r.Tags = []string{"slow"}
+	var pcs [1]uintptr
+	runtime.Callers(1, pcs[:])
+	r.PC = pcs[0]
+	if err := h.Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{"\x1b[32m[db]\x1b[0m", "\x1b[33m#slow\x1b[0m", "\x1b[2;34mmodule/handler_test.go:", "\x1b[31mquery\x1b[0m"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output = %q, want %q", got, want)
+		}
+	}
+
+	buf.Reset()
+	h = NewSimpleHandler(HandlerOptions{Output: &buf, NoColor: true, PartColors: map[PartKind]uint8{PartMessage: 1}})
+	h.Handle(r)
+	if got := buf.String(); got != "INFO #slow query\n" {
+		t.Errorf("output = %q without colors, want no escape codes", got)
+	}
+}
+
+func TestSimpleHandler_MaxRecordSize(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewSimpleHandler(HandlerOptions{Output: &buf, NoColor: true, MaxRecordSize: 40})
+
+	r := NewRecord(time.Time{}, LevelInfo, "big")
+	r.AddAttrs(String("a", "short"), String("blob", strings.Repeat("x", 1<<20)), Int("n", 1))
+	if err := h.Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "INFO big a=short attrs_truncated=2\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSimpleHandler_ErrorTree(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewSimpleHandler(HandlerOptions{Output: &buf, NoColor: true, ErrorTree: true})
+
+	err := fmt.Errorf("load config: %w", errors.New("file not found"))
+	r := NewRecord(time.Time{}, LevelError, "failed")
+	r.AddAttrs(Err(err))
+	if err := h.Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	want := `ERROR failed error.msg="load config: file not found" error.causes="[file not found]"` + "\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+// ptrStringer is a fmt.Stringer with a pointer receiver.
+type ptrStringer struct{ name string }
+
+func (s *ptrStringer) String() string { return "stringer:" + s.name }
+
+// ptrError is an error with a pointer receiver.
+type ptrError struct{ msg string }
+
+func (e *ptrError) Error() string { return e.msg }
+
+// verboseError is an error printing more with %+v than its message.
+type verboseError struct{}
+
+func (verboseError) Error() string { return "short" }
+
+func (e verboseError) Format(f fmt.State, verb rune) {
+	if f.Flag('+') {
+		io.WriteString(f, "short\n\tat main.go:12")
+		return
+	}
+	io.WriteString(f, e.Error())
+}
+
+func TestSimpleHandler_StringerAndError(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewSimpleHandler(HandlerOptions{Output: &buf, NoColor: true})
+
+	r := NewRecord(time.Time{}, LevelInfo, "m")
+	r.AddAttrs(
+		Any("s", &ptrStringer{"x"}),
+		Any("e", &ptrError{"boom"}),
+		Any("nils", (*ptrStringer)(nil)),
+		Any("nile", (*ptrError)(nil)),
+		Any("v", verboseError{}),
+	)
+	if err := h.Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	want := `INFO m s=stringer:x e=boom nils=<nil> nile=<nil> v="short\n\tat main.go:12"` + "\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func BenchmarkSimpleHandler_HandleError(b *testing.B) {
+	buf := &bytes.Buffer{}
+	h := NewSimpleHandler(HandlerOptions{
+		Level:   LevelInfo,
+		Output:  buf,
+		NoColor: true,
+	})
+
+	r := NewRecord(time.Now(), LevelInfo, "benchmark message")
+	r.AddAttrs(Any("error", errors.New("connection refused")), Any("addr", &ptrStringer{"db:5432"}))
+
+	for b.Loop() {
+		buf.Reset()
+		_ = h.Handle(r)
+	}
+}
@@ -0,0 +1,55 @@
+package l4g
+
+import (
+	"runtime/debug"
+	"time"
+)
+
+// HeaderKey is the key of the attribute identifying the header record
+// written by a logger created with [Options.Header]. The associated value
+// is the version of the layout of the records, [HeaderVersion].
+const HeaderKey = "l4g_header"
+
+// HeaderVersion is the version of the layout of the records described by
+// the header record, incremented whenever parsers must tell them apart.
+const HeaderVersion = 1
+
+// headerRecord returns the record describing the stream written by h:
+// the record layout, the names of the built-in JSON fields and the build
+// information of the binary.
+func headerRecord(h Handler, opts *Options) Record {
+	r := NewRecord(time.Now(), LevelInfo, "l4g stream header")
+	r.AddAttrs(Int(HeaderKey, HeaderVersion))
+	switch h.(type) {
+	case *JSONHandler:
+		names := opts.FieldNames
+		if opts.Severity && names.Level == "" {
+			names.Level = SeverityKey
+		}
+		names = names.withDefaults()
+		r.AddAttrs(String("format", "json"), Group("fields",
+			String("time", names.Time),
+			String("level", names.Level),
+			String("msg", names.Message),
+			String("prefix", names.Prefix),
+		))
+	case *SimpleHandler:
+		r.AddAttrs(String("format", "text"))
+	}
+	if opts.TimeFormat != "" {
+		r.AddAttrs(String("time_format", opts.TimeFormat))
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		build := []any{String("path", bi.Path), String("go", bi.GoVersion)}
+		if bi.Main.Version != "" {
+			build = append(build, String("version", bi.Main.Version))
+		}
+		for _, s := range bi.Settings {
+			if s.Key == "vcs.revision" || s.Key == "vcs.time" || s.Key == "vcs.modified" {
+				build = append(build, String(s.Key[len("vcs."):], s.Value))
+			}
+		}
+		r.AddAttrs(Group("build", build...))
+	}
+	return r
+}
@@ -0,0 +1,43 @@
+package l4g
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestOptions_Header(t *testing.T) {
+	buf := &bytes.Buffer{}
+	New(Options{Output: buf, Level: LevelError, NewHandlerFunc: NewJSONHandler})
+	if buf.Len() != 0 {
+		t.Errorf("output = %q without Header, want none", buf.String())
+	}
+
+	New(Options{
+		Output:         buf,
+		Level:          LevelError,
+		NewHandlerFunc: NewJSONHandler,
+		FieldNames:     FieldNames{Message: "message"},
+		Header:         true,
+	})
+	got := buf.String()
+	for _, want := range []string{
+		`"l4g_header":1`,
+		`"format":"json"`,
+		`"fields":{"time":"time","level":"level","msg":"message","prefix":"prefix"}`,
+		`"build":{"path":`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("header = %q, want %s", got, want)
+		}
+	}
+	if strings.Count(got, "\n") != 1 {
+		t.Errorf("header = %q, want a single record", got)
+	}
+
+	buf.Reset()
+	New(Options{Output: buf, NoColor: true, Header: true})
+	if got := buf.String(); !strings.Contains(got, "l4g_header=1") || !strings.Contains(got, "format=text") {
+		t.Errorf("text header = %q, want the version and format", got)
+	}
+}
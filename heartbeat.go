@@ -0,0 +1,60 @@
+package l4g
+
+import (
+	"sync"
+	"time"
+)
+
+// Heartbeat logs msg at info level every interval until stop is called,
+// as a liveness signal for long-running workers. Each record carries the
+// given args followed by an "uptime" attribute holding the time since
+// Heartbeat was called and a "beats" attribute counting the records
+// logged so far, starting at 1. The beats are timed by the clock of l.
+// A non-positive interval means one minute.
+//
+// stop may be called more than once; it returns once the beat in
+// progress, if any, is logged, so no record is logged after it returns.
+func Heartbeat(l *Logger, interval time.Duration, msg string, args ...any) (stop func()) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	clock := l.clock
+	start := clock.Now()
+	var (
+		mu      sync.Mutex
+		stopped bool
+		cancel  func() bool
+		beats   int64
+		beat    func()
+	)
+	beat = func() {
+		runLabeled("heartbeat", func() {
+			mu.Lock()
+			defer mu.Unlock()
+			if stopped {
+				return
+			}
+			beats++
+			now := clock.Now()
+			l.log(LevelInfo, msg, append(args[:len(args):len(args)],
+				Duration("uptime", now.Sub(start).Round(time.Millisecond)),
+				Int64("beats", beats),
+			))
+			// Schedule the next multiple of interval since start, so that the
+			// beats do not drift and those missed by a slow log are skipped.
+			cancel = clock.AfterFunc(interval-now.Sub(start)%interval, beat)
+		})
+	}
+	mu.Lock()
+	cancel = clock.AfterFunc(interval, beat)
+	mu.Unlock()
+
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if !stopped {
+			stopped = true
+			cancel()
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package l4g
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a string builder safe for concurrent use.
+type syncBuffer struct {
+	mu sync.Mutex
+	sb strings.Builder
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sb.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sb.String()
+}
+
+func TestHeartbeat(t *testing.T) {
+	clock := newFakeClock()
+	buf := &syncBuffer{}
+	logger := New(Options{Output: buf, NoColor: true, Clock: clock})
+
+	stop := Heartbeat(logger, 5*time.Second, "alive", "worker", "w1")
+	clock.Advance(4 * time.Second)
+	if out := buf.String(); out != "" {
+		t.Errorf("output = %q, want no heartbeat before the interval", out)
+	}
+	clock.Advance(6 * time.Second)
+	out := buf.String()
+	for _, want := range []string{"alive worker=w1 uptime=5s beats=1\n", "alive worker=w1 uptime=10s beats=2\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output = %q, want to contain %q", out, want)
+		}
+	}
+
+	stop()
+	stop()
+	clock.Advance(time.Minute)
+	if got := buf.String(); got != out {
+		t.Errorf("output = %q after stop, want %q", got, out)
+	}
+}
+
+func TestHeartbeat_DefaultInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		clock := newFakeClock()
+		buf := &syncBuffer{}
+		logger := New(Options{Output: buf, NoColor: true, Clock: clock})
+
+		stop := Heartbeat(logger, interval, "alive")
+		clock.Advance(59 * time.Second)
+		if out := buf.String(); out != "" {
+			t.Errorf("interval %v: output = %q, want no heartbeat before a minute", interval, out)
+		}
+		clock.Advance(time.Second)
+		if out := buf.String(); strings.Count(out, "alive") != 1 || !strings.Contains(out, "uptime=1m0s beats=1\n") {
+			t.Errorf("interval %v: output = %q, want one heartbeat after a minute", interval, out)
+		}
+		stop()
+	}
+}
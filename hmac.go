@@ -0,0 +1,218 @@
+package l4g
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+	"sync"
+)
+
+// MACKey is the key under which an [HMACWriter] appends the MAC of each
+// record.
+const MACKey = "mac"
+
+// HMACStreamKey is the key of the record with which an [HMACWriter]
+// starts its stream, holding the random identifier of the stream.
+const HMACStreamKey = "hmac_stream"
+
+// macHexLen is the length of a hex-encoded HMAC-SHA256.
+const macHexLen = 2 * sha256.Size
+
+// hmacStreamIDSize is the size of the identifier of an HMACWriter stream.
+const hmacStreamIDSize = 16
+
+// HMACWriter is an io.Writer that makes log output tamper-evident. Each
+// Write is treated as one record, and is written followed by an HMAC-SHA256
+// over the previous record's MAC and the record itself, so that modifying,
+// reordering or removing records breaks the chain.
+//
+// Before its first record, the writer starts a stream with a record
+// holding a random identifier under [HMACStreamKey], chained to a MAC of
+// all zeros, so that a file appended to by the writers of successive runs
+// of a program holds one chain per run. JSON records ("{...}") get a "mac"
+// member; other records get a " mac=<hex>" suffix. Use [VerifyHMACChain]
+// to check a stream. Removing records at the end of a stream can only be
+// detected by comparing the last MAC with one stored elsewhere, see
+// [HMACWriter.Last].
+// It is safe for concurrent use by multiple goroutines.
+type HMACWriter struct {
+	mu   sync.Mutex
+	w    io.Writer
+	mac  hash.Hash
+	last [sha256.Size]byte
+	buf  []byte
+
+	started bool // whether the stream start was written
+}
+
+// NewHMACWriter returns an HMACWriter writing to w, authenticated with key.
+func NewHMACWriter(w io.Writer, key []byte) *HMACWriter {
+	return &HMACWriter{w: w, mac: hmac.New(sha256.New, key)}
+}
+
+// Write writes p, minus a trailing newline, followed by its MAC and a newline.
+func (w *HMACWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	record := bytes.TrimSuffix(p, []byte{'\n'})
+	buf := w.buf[:0]
+	last := w.last[:]
+	if !w.started {
+		var id [hmacStreamIDSize]byte
+		if _, err := rand.Read(id[:]); err != nil {
+			return 0, err
+		}
+		start := appendStreamStart(nil, id[:], isJSONObject(record))
+		last = chainMAC(w.mac, last, start)
+		buf = appendMAC(buf, start, last)
+	}
+	sum := chainMAC(w.mac, last, record)
+	w.buf = appendMAC(buf, record, sum)
+	if _, err := w.w.Write(w.buf); err != nil {
+		return 0, err
+	}
+	w.started = true
+	copy(w.last[:], sum)
+	return len(p), nil
+}
+
+// Last returns the MAC of the last record written, which can be stored
+// out of band to detect the truncation of the stream.
+func (w *HMACWriter) Last() []byte {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return bytes.Clone(w.last[:])
+}
+
+// VerifyHMACChain reads the records written by [HMACWriter]s from r and
+// checks their MACs against key. Each stream must start with its stream
+// record, be chained without gap up to the next stream or the end of r,
+// and appear once. It returns the MAC of the last record, or an error
+// identifying the first line that fails verification.
+func VerifyHMACChain(r io.Reader, key []byte) (last []byte, err error) {
+	mac := hmac.New(sha256.New, key)
+	zero := make([]byte, sha256.Size)
+	prev := zero
+	streams := make(map[string]bool)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 64<<20) // allow records of up to 64 MiB
+	for n := 1; sc.Scan(); n++ {
+		record, want, ok := splitMAC(sc.Bytes())
+		if !ok {
+			return nil, fmt.Errorf("l4g: line %d: missing %s", n, MACKey)
+		}
+		if id, ok := streamStartID(record); ok {
+			if streams[id] {
+				return nil, fmt.Errorf("l4g: line %d: repeated stream %s", n, id)
+			}
+			streams[id] = true
+			prev = zero
+		} else if len(streams) == 0 {
+			return nil, fmt.Errorf("l4g: line %d: record before the start of a stream", n)
+		}
+		sum := chainMAC(mac, prev, record)
+		if !hmac.Equal(sum, want) {
+			return nil, fmt.Errorf("l4g: line %d: %s mismatch", n, MACKey)
+		}
+		prev = sum
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return prev, nil
+}
+
+// appendStreamStart appends the record starting the stream id to b, as a
+// JSON object if json is set.
+func appendStreamStart(b, id []byte, json bool) []byte {
+	if json {
+		b = append(b, `{"`+HMACStreamKey+`":"`...)
+		b = hex.AppendEncode(b, id)
+		return append(b, `"}`...)
+	}
+	b = append(b, HMACStreamKey+"="...)
+	return hex.AppendEncode(b, id)
+}
+
+// streamStartID returns the hex identifier of the stream started by
+// record, if it is the record starting a stream.
+func streamStartID(record []byte) (string, bool) {
+	const jsonPrefix, textPrefix = `{"` + HMACStreamKey + `":"`, HMACStreamKey + "="
+	s := string(record)
+	var id string
+	switch {
+	case len(s) == len(jsonPrefix)+2*hmacStreamIDSize+2 && strings.HasPrefix(s, jsonPrefix) && strings.HasSuffix(s, `"}`):
+		id = s[len(jsonPrefix) : len(s)-2]
+	case len(s) == len(textPrefix)+2*hmacStreamIDSize && strings.HasPrefix(s, textPrefix):
+		id = s[len(textPrefix):]
+	default:
+		return "", false
+	}
+	if _, err := hex.DecodeString(id); err != nil {
+		return "", false
+	}
+	return id, true
+}
+
+// chainMAC returns the MAC of record chained to the previous MAC prev.
+func chainMAC(mac hash.Hash, prev, record []byte) []byte {
+	mac.Reset()
+	mac.Write(prev)
+	mac.Write(record)
+	return mac.Sum(nil)
+}
+
+// appendMAC appends record to b with sum attached, followed by a newline.
+func appendMAC(b, record, sum []byte) []byte {
+	if isJSONObject(record) {
+		b = append(b, record[:len(record)-1]...)
+		if len(record) > 2 {
+			b = append(b, ',')
+		}
+		b = append(b, `"`+MACKey+`":"`...)
+		b = hex.AppendEncode(b, sum)
+		return append(b, "\"}\n"...)
+	}
+	b = append(b, record...)
+	b = append(b, " "+MACKey+"="...)
+	b = hex.AppendEncode(b, sum)
+	return append(b, '\n')
+}
+
+// splitMAC reverses appendMAC, returning the original record and its MAC.
+func splitMAC(line []byte) (record, sum []byte, ok bool) {
+	var prefix, suffix string
+	if isJSONObject(line) {
+		prefix, suffix = `"`+MACKey+`":"`, `"}`
+	} else {
+		prefix, suffix = " "+MACKey+"=", ""
+	}
+	end := len(line) - len(suffix)
+	start := end - macHexLen
+	if start-len(prefix) < 0 || string(line[start-len(prefix):start]) != prefix {
+		return nil, nil, false
+	}
+	sum, err := hex.DecodeString(string(line[start:end]))
+	if err != nil {
+		return nil, nil, false
+	}
+	record = line[:start-len(prefix)]
+	if suffix != "" {
+		// restore the closing brace and drop the separating comma
+		record = append(bytes.TrimSuffix(bytes.Clone(record), []byte{','}), '}')
+	}
+	return record, sum, true
+}
+
+// isJSONObject reports whether b looks like a JSON object.
+func isJSONObject(b []byte) bool {
+	return len(b) >= 2 && b[0] == '{' && b[len(b)-1] == '}'
+}
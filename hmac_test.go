@@ -0,0 +1,96 @@
+package l4g
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHMACWriter(t *testing.T) {
+	key := []byte("audit-key")
+	for _, newHandler := range []func(HandlerOptions) Handler{NewSimpleHandler, NewJSONHandler} {
+		buf := &bytes.Buffer{}
+		w := NewHMACWriter(buf, key)
+		logger := New(Options{Output: w, NoColor: true, NewHandlerFunc: newHandler})
+		logger.Info("login", "user", "alice")
+		logger.Warn("denied", "user", "bob")
+		logger.Error("")
+
+		last, err := VerifyHMACChain(bytes.NewReader(buf.Bytes()), key)
+		if err != nil {
+			t.Fatalf("VerifyHMACChain() error = %v\n%s", err, buf.String())
+		}
+		if !bytes.Equal(last, w.Last()) {
+			t.Errorf("VerifyHMACChain() last = %x, want %x", last, w.Last())
+		}
+
+		lines := strings.SplitAfter(buf.String(), "\n")
+		if !strings.Contains(lines[0], HMACStreamKey) {
+			t.Errorf("first line = %q, want the start of the stream", lines[0])
+		}
+		if strings.HasPrefix(lines[1], "{") {
+			var m map[string]any
+			if err := json.Unmarshal([]byte(lines[1]), &m); err != nil || m[MACKey] == nil {
+				t.Errorf("JSON record with mac = %q, error = %v", lines[1], err)
+			}
+		}
+
+		tampered := []string{
+			strings.Replace(buf.String(), "alice", "mallory", 1),
+			lines[0] + lines[2] + lines[3],            // head removed
+			lines[0] + lines[1] + lines[3] + lines[2], // reordered
+			lines[1] + lines[2] + lines[3],            // start removed
+		}
+		for _, s := range tampered {
+			if _, err := VerifyHMACChain(strings.NewReader(s), key); err == nil {
+				t.Errorf("VerifyHMACChain() accepted tampered stream %q", s)
+			}
+		}
+		if _, err := VerifyHMACChain(bytes.NewReader(buf.Bytes()), []byte("other")); err == nil {
+			t.Errorf("VerifyHMACChain() accepted a wrong key")
+		}
+	}
+}
+
+func TestHMACWriter_EmptyJSONObject(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewHMACWriter(buf, []byte("k"))
+	w.Write([]byte("{}\n"))
+	if lines := strings.Split(buf.String(), "\n"); !strings.HasPrefix(lines[1], `{"mac":"`) {
+		t.Errorf("output = %q", buf.String())
+	}
+	if _, err := VerifyHMACChain(bytes.NewReader(buf.Bytes()), []byte("k")); err != nil {
+		t.Errorf("VerifyHMACChain() error = %v", err)
+	}
+}
+
+func TestHMACWriter_Restart(t *testing.T) {
+	key := []byte("audit-key")
+	buf := &bytes.Buffer{}
+	for _, run := range []string{"first", "second", "third"} {
+		w := NewHMACWriter(buf, key)
+		logger := New(Options{Output: w, NoColor: true})
+		logger.Info("started", "run", run)
+		logger.Info("stopped", "run", run)
+	}
+
+	if _, err := VerifyHMACChain(bytes.NewReader(buf.Bytes()), key); err != nil {
+		t.Fatalf("VerifyHMACChain() error = %v\n%s", err, buf.String())
+	}
+
+	lines := strings.SplitAfter(buf.String(), "\n")
+	tampered := map[string]string{
+		"record removed":  strings.Join(slices.Delete(slices.Clone(lines), 4, 5), ""),
+		"start removed":   strings.Join(slices.Delete(slices.Clone(lines), 3, 4), ""),
+		"stream repeated": buf.String() + strings.Join(lines[3:6], ""),
+		"streams spliced": strings.Join(lines[:2], "") + strings.Join(lines[5:], ""),
+		"record edited":   strings.Replace(buf.String(), "run=second", "run=fourth", 1),
+	}
+	for name, s := range tampered {
+		if _, err := VerifyHMACChain(strings.NewReader(s), key); err == nil {
+			t.Errorf("VerifyHMACChain() accepted %s:\n%s", name, s)
+		}
+	}
+}
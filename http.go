@@ -0,0 +1,38 @@
+package l4g
+
+import (
+	"net/http"
+	"time"
+)
+
+// Keys of the groups returned by [Request] and [Response].
+const (
+	RequestKey  = "request"
+	ResponseKey = "response"
+)
+
+// Request returns a [RequestKey] group summarizing r, so that the access
+// logs of all services carry the same fields: the method, the url, the
+// proto, the remote address and the user_agent, omitted if empty. The url
+// is the one received, without the host for requests to a server.
+func Request(r *http.Request) Attr {
+	attrs := make([]any, 0, 5)
+	attrs = append(attrs,
+		String("method", r.Method),
+		String("url", r.URL.String()),
+		String("proto", r.Proto),
+	)
+	if r.RemoteAddr != "" {
+		attrs = append(attrs, String("remote", r.RemoteAddr))
+	}
+	if ua := r.UserAgent(); ua != "" {
+		attrs = append(attrs, String("user_agent", ua))
+	}
+	return Group(RequestKey, attrs...)
+}
+
+// Response returns a [ResponseKey] group summarizing a response: its
+// status, its size in bytes and the duration of the request.
+func Response(status int, size int64, d time.Duration) Attr {
+	return Group(ResponseKey, Int("status", status), Int64("size", size), Duration("duration", d))
+}
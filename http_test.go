@@ -0,0 +1,35 @@
+package l4g
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestResponse(t *testing.T) {
+	req := httptest.NewRequest("GET", "/users?id=7", nil)
+	req.Header.Set("User-Agent", "curl/8.0")
+
+	var buf bytes.Buffer
+	logger := New(Options{Output: &buf, Handler: NewJSONHandler(HandlerOptions{Output: &buf})})
+	logger.Info("done", Request(req), Response(200, 512, 42*time.Millisecond))
+
+	for _, want := range []string{
+		`"request":{"method":"GET","url":"/users?id=7","proto":"HTTP/1.1","remote":"192.0.2.1:1234","user_agent":"curl/8.0"}`,
+		`"response":{"status":200,"size":512,"duration":42000000}`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output = %s, want to contain %s", buf.String(), want)
+		}
+	}
+
+	buf.Reset()
+	req.RemoteAddr = ""
+	req.Header.Del("User-Agent")
+	logger.Info("done", Request(req))
+	if strings.Contains(buf.String(), "remote") || strings.Contains(buf.String(), "user_agent") {
+		t.Errorf("output = %s, want empty fields omitted", buf.String())
+	}
+}
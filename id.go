@@ -0,0 +1,92 @@
+package l4g
+
+import (
+	"encoding/hex"
+	"log/slog"
+)
+
+// idForm is the textual form of an identifier attribute.
+type idForm uint8
+
+const (
+	idHex  idForm = iota // 32 lowercase hex digits
+	idUUID               // 8-4-4-4-12 hex digits
+	idULID               // 26 Crockford base32 digits
+)
+
+// idValue is the value of the attributes returned by ID, UUID and ULID.
+// The built-in handlers append its text directly to their buffer; other
+// handlers get it through AppendText, MarshalText or String.
+type idValue struct {
+	id   [16]byte
+	form idForm
+}
+
+// ID returns an [Attr] for a 16-byte identifier, such as a trace ID,
+// written as 32 lowercase hex digits. The built-in handlers format it
+// without allocating, unlike fmt with %x.
+func ID(key string, id [16]byte) Attr {
+	return slog.Any(key, idValue{id: id, form: idHex})
+}
+
+// UUID returns an [Attr] for a UUID, written in its canonical form
+// "xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx" as by [ID].
+func UUID(key string, id [16]byte) Attr {
+	return slog.Any(key, idValue{id: id, form: idUUID})
+}
+
+// ULID returns an [Attr] for a ULID, written as its 26 Crockford base32
+// digits as by [ID].
+func ULID(key string, id [16]byte) Attr {
+	return slog.Any(key, idValue{id: id, form: idULID})
+}
+
+// crockford is the alphabet of the Crockford base32 encoding of ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// AppendText implements [encoding.TextAppender].
+func (v idValue) AppendText(b []byte) ([]byte, error) {
+	switch v.form {
+	case idUUID:
+		b = hex.AppendEncode(b, v.id[0:4])
+		b = append(b, '-')
+		b = hex.AppendEncode(b, v.id[4:6])
+		b = append(b, '-')
+		b = hex.AppendEncode(b, v.id[6:8])
+		b = append(b, '-')
+		b = hex.AppendEncode(b, v.id[8:10])
+		b = append(b, '-')
+		return hex.AppendEncode(b, v.id[10:16]), nil
+	case idULID:
+		// 128 bits in 26 digits of 5 bits, the first holding the 3 top bits
+		hi := uint64(v.id[0])<<56 | uint64(v.id[1])<<48 | uint64(v.id[2])<<40 | uint64(v.id[3])<<32 |
+			uint64(v.id[4])<<24 | uint64(v.id[5])<<16 | uint64(v.id[6])<<8 | uint64(v.id[7])
+		lo := uint64(v.id[8])<<56 | uint64(v.id[9])<<48 | uint64(v.id[10])<<40 | uint64(v.id[11])<<32 |
+			uint64(v.id[12])<<24 | uint64(v.id[13])<<16 | uint64(v.id[14])<<8 | uint64(v.id[15])
+		for shift := 125; shift >= 0; shift -= 5 {
+			var d uint64
+			switch {
+			case shift >= 64:
+				d = hi >> (shift - 64)
+			case shift > 59:
+				d = hi<<(64-shift) | lo>>shift
+			default:
+				d = lo >> shift
+			}
+			b = append(b, crockford[d&31])
+		}
+		return b, nil
+	}
+	return hex.AppendEncode(b, v.id[:]), nil
+}
+
+// MarshalText implements [encoding.TextMarshaler].
+func (v idValue) MarshalText() ([]byte, error) {
+	return v.AppendText(make([]byte, 0, 36))
+}
+
+// String returns the text of the identifier.
+func (v idValue) String() string {
+	b, _ := v.MarshalText()
+	return string(b)
+}
@@ -0,0 +1,63 @@
+package l4g
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestID(t *testing.T) {
+	var seq, ones [16]byte
+	for i := range seq {
+		seq[i] = byte(i)
+		ones[i] = 0xff
+	}
+	tests := []struct {
+		name string
+		attr Attr
+		want string
+	}{
+		{"hex", ID("id", seq), "000102030405060708090a0b0c0d0e0f"},
+		{"uuid", UUID("id", seq), "00010203-0405-0607-0809-0a0b0c0d0e0f"},
+		{"ulid", ULID("id", seq), "00041061050R3GG28A1C60T3GF"},
+		{"ulid zero", ULID("id", [16]byte{}), "00000000000000000000000000"},
+		{"ulid max", ULID("id", ones), "7ZZZZZZZZZZZZZZZZZZZZZZZZZ"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.attr.Value.String(); got != tt.want {
+				t.Errorf("String() = %s, want %s", got, tt.want)
+			}
+
+			var buf bytes.Buffer
+			h := NewSimpleHandler(HandlerOptions{Output: &buf, NoColor: true})
+			r := NewRecord(time.Time{}, LevelInfo, "m")
+			r.AddAttrs(tt.attr)
+			h.Handle(r)
+			if !strings.Contains(buf.String(), " id="+tt.want) {
+				t.Errorf("SimpleHandler output = %q, want id=%s", buf.String(), tt.want)
+			}
+
+			buf.Reset()
+			NewJSONHandler(HandlerOptions{Output: &buf}).Handle(r)
+			if !strings.Contains(buf.String(), `"id":"`+tt.want+`"`) {
+				t.Errorf("JSONHandler output = %q, want id %s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestID_Allocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+	h := NewJSONHandler(HandlerOptions{Output: io.Discard})
+	r := NewRecord(time.Now(), LevelInfo, "m")
+	r.AddAttrs(UUID("request_id", [16]byte{1, 2, 3}))
+	h.Handle(r) // warm up the buffer pool
+	if allocs := testing.AllocsPerRun(100, func() { h.Handle(r) }); allocs != 0 {
+		t.Errorf("allocs = %v, want 0", allocs)
+	}
+}
@@ -0,0 +1,161 @@
+// Package bench defines the standard workloads and the set of bundled
+// handlers used to compare their performance, so that a regression in any
+// handler shows up in one run:
+//
+//	go test -bench . -benchmem ./internal/bench
+//
+// Each benchmark is named after the handler and the workload, as in
+// BenchmarkHandlers/json/attrs10, and can be compared across commits with
+// benchstat.
+package bench
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go-slim.dev/l4g"
+	"go-slim.dev/l4g/l4gserver"
+)
+
+// A Workload is a kind of record handled in the benchmarks.
+type Workload struct {
+	// Name names the workload in the benchmark names.
+	Name string
+
+	// Derive derives the handler the records are passed to, as a logger
+	// returned by WithAttrs or WithGroup would, if set.
+	Derive func(l4g.Handler) l4g.Handler
+
+	// Attrs are the attributes of each record.
+	Attrs []l4g.Attr
+}
+
+// Workloads returns the standard workloads.
+func Workloads() []Workload {
+	return []Workload{
+		{Name: "small"},
+		{
+			Name: "attrs10",
+			Attrs: []l4g.Attr{
+				l4g.String("method", "GET"),
+				l4g.String("path", "/api/v1/users"),
+				l4g.Int("status", 200),
+				l4g.Int64("bytes", 5183),
+				l4g.Duration("latency", 42*time.Millisecond),
+				l4g.Bool("cached", false),
+				l4g.Float("ratio", 0.25),
+				l4g.String("remote", "192.0.2.1:51234"),
+				l4g.Time("started", time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)),
+				l4g.Any("err", errors.New("connection reset by peer")),
+			},
+		},
+		{
+			Name: "groups",
+			Derive: func(h l4g.Handler) l4g.Handler {
+				return h.WithAttrs([]l4g.Attr{l4g.String("service", "api")}).WithGroup("req")
+			},
+			Attrs: []l4g.Attr{
+				l4g.String("id", "0af7651916cd43dd"),
+				l4g.Group("user", l4g.Int("id", 7), l4g.String("name", "alice")),
+			},
+		},
+	}
+}
+
+// A Handler is a bundled handler benchmarked with each workload.
+type Handler struct {
+	// Name names the handler in the benchmark names.
+	Name string
+
+	// New returns the handler writing to w, if it writes, and a function
+	// releasing the resources it holds.
+	New func(w io.Writer) (h l4g.Handler, close func(), err error)
+}
+
+// Handlers returns the bundled handlers, the wrapping ones passing the
+// records on to a JSONHandler. The SQLiteHandler is left out, since it
+// needs a database driver outside the standard library.
+func Handlers() []Handler {
+	return []Handler{
+		{Name: "text", New: simple(l4g.HandlerOptions{NoColor: true})},
+		{Name: "text-color", New: simple(l4g.HandlerOptions{})},
+		{Name: "json", New: wrap(func(w io.Writer) l4g.Handler { return newJSON(w) })},
+		{Name: "syslog", New: wrap(func(w io.Writer) l4g.Handler {
+			return l4g.NewSyslogHandler(l4g.SyslogOptions{Tag: "bench", Hostname: "host", HandlerOptions: l4g.HandlerOptions{Output: w}})
+		})},
+		{Name: "journal", New: journal},
+		{Name: "kv", New: wrap(func(io.Writer) l4g.Handler { return l4g.NewKVHandler(discardKV{}, l4g.KVOptions{}) })},
+		{Name: "ring", New: wrap(func(w io.Writer) l4g.Handler { return l4g.NewRingHandler(newJSON(w), l4g.NewRing(1000)) })},
+		{Name: "sampling", New: wrap(func(w io.Writer) l4g.Handler {
+			return l4g.NewSamplingHandler(newJSON(w), l4g.SamplingOptions{Rates: map[l4g.Level]float64{l4g.LevelInfo: 0.5}})
+		})},
+		{Name: "spill", New: wrap(func(w io.Writer) l4g.Handler {
+			return l4g.NewSpillHandler(newJSON(w), l4g.SpillOptions{Output: io.Discard})
+		})},
+		{Name: "runtime-stats", New: wrap(func(w io.Writer) l4g.Handler {
+			return l4g.NewRuntimeStatsHandler(newJSON(w), time.Second)
+		})},
+		{Name: "forward", New: wrap(func(w io.Writer) l4g.Handler { return l4gserver.NewForwardHandler(w, nil) })},
+	}
+}
+
+func newJSON(w io.Writer) l4g.Handler {
+	return l4g.NewJSONHandler(l4g.HandlerOptions{Output: w})
+}
+
+func simple(opts l4g.HandlerOptions) func(io.Writer) (l4g.Handler, func(), error) {
+	return wrap(func(w io.Writer) l4g.Handler {
+		opts.Output = w
+		return l4g.NewSimpleHandler(opts)
+	})
+}
+
+// wrap adapts the constructor of a handler holding no resources.
+func wrap(f func(io.Writer) l4g.Handler) func(io.Writer) (l4g.Handler, func(), error) {
+	return func(w io.Writer) (l4g.Handler, func(), error) {
+		return f(w), func() {}, nil
+	}
+}
+
+// journal returns a JournalHandler sending to a socket of its own, whose
+// datagrams are read and discarded so that the sends do not block.
+func journal(io.Writer) (l4g.Handler, func(), error) {
+	dir, err := os.MkdirTemp("", "l4g-bench")
+	if err != nil {
+		return nil, nil, err
+	}
+	socket := filepath.Join(dir, "journal.socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, err
+	}
+	go func() {
+		buf := make([]byte, 1<<16)
+		for {
+			if _, _, err := conn.ReadFrom(buf); err != nil {
+				return
+			}
+		}
+	}()
+	h, err := l4g.NewJournalHandler(l4g.JournalOptions{Socket: socket})
+	if err != nil {
+		conn.Close()
+		os.RemoveAll(dir)
+		return nil, nil, err
+	}
+	return h, func() {
+		conn.Close()
+		os.RemoveAll(dir)
+	}, nil
+}
+
+// discardKV is a KVStore keeping nothing.
+type discardKV struct{}
+
+func (discardKV) Put(key, value []byte) error   { return nil }
+func (discardKV) DeleteBefore(key []byte) error { return nil }
@@ -0,0 +1,58 @@
+package bench
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"go-slim.dev/l4g"
+)
+
+func BenchmarkHandlers(b *testing.B) {
+	for _, bh := range Handlers() {
+		for _, w := range Workloads() {
+			b.Run(bh.Name+"/"+w.Name, func(b *testing.B) {
+				h, closeHandler, err := bh.New(io.Discard)
+				if err != nil {
+					b.Skip(err)
+				}
+				defer closeHandler()
+				if w.Derive != nil {
+					h = w.Derive(h)
+				}
+				r := l4g.NewRecord(time.Now(), l4g.LevelInfo, "request completed")
+				r.AddAttrs(w.Attrs...)
+				b.ReportAllocs()
+				b.ResetTimer()
+				for range b.N {
+					if err := h.Handle(r); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestHandlers checks that every handler of the benchmarks handles every
+// workload, so that the benchmarks do not fail when run.
+func TestHandlers(t *testing.T) {
+	for _, bh := range Handlers() {
+		for _, w := range Workloads() {
+			h, closeHandler, err := bh.New(io.Discard)
+			if err != nil {
+				t.Logf("%s: %v", bh.Name, err)
+				continue
+			}
+			if w.Derive != nil {
+				h = w.Derive(h)
+			}
+			r := l4g.NewRecord(time.Now(), l4g.LevelInfo, "request completed")
+			r.AddAttrs(w.Attrs...)
+			if err := h.Handle(r); err != nil {
+				t.Errorf("%s/%s: Handle() error = %v", bh.Name, w.Name, err)
+			}
+			closeHandler()
+		}
+	}
+}
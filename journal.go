@@ -0,0 +1,238 @@
+package l4g
+
+import (
+	"encoding/binary"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultJournalSocket is the socket of the native journald protocol.
+const defaultJournalSocket = "/run/systemd/journal/socket"
+
+// JournalOptions are options for a [JournalHandler].
+type JournalOptions struct {
+	// Level reports the minimum record level that will be logged.
+	// If Level is nil, the handler assumes LevelInfo.
+	Level Leveler
+
+	// Identifier is written as SYSLOG_IDENTIFIER (Default: none, in which
+	// case journald uses the process name).
+	Identifier string
+
+	// FieldMap maps attribute keys to journal field names. Keys of
+	// attributes within groups are dotted, as in "http.status". Attributes
+	// missing from FieldMap are named by upper-casing their key and
+	// replacing the characters not allowed by the journal with '_', so that
+	// "http.status" becomes HTTP_STATUS. The prefix is written under the
+	// key [PrefixKey].
+	FieldMap map[string]string
+
+	// AllowList, if not nil, restricts the attributes written to those
+	// whose key it contains, so that only selected attributes become
+	// indexed journal fields.
+	AllowList []string
+
+	// Levels maps the levels to the syslog severities written as PRIORITY
+	// (Default: SyslogLevels()).
+	Levels LevelMap
+
+	// Socket is the journald socket (Default: /run/systemd/journal/socket).
+	Socket string
+}
+
+// JournalHandler is a Handler that sends records to the systemd journal
+// using its native protocol, so that attributes become journal fields
+// that can be matched with journalctl. The message is written as MESSAGE
+// and the level as the syslog PRIORITY.
+type JournalHandler struct {
+	j      *journal
+	fields []byte // pre-encoded fields from WithAttrs
+	group  string // dotted group names followed by '.'
+	prefix string // log prefix from WithPrefix
+}
+
+// journal holds the state shared by the handlers derived from one another.
+type journal struct {
+	opts  JournalOptions
+	allow map[string]bool
+	mu    sync.Mutex
+	conn  net.Conn
+}
+
+var _ Handler = (*JournalHandler)(nil)
+
+// NewJournalHandler returns a [JournalHandler] connected to the journald
+// socket.
+func NewJournalHandler(opts JournalOptions) (*JournalHandler, error) {
+	if opts.Socket == "" {
+		opts.Socket = defaultJournalSocket
+	}
+	if opts.Levels == nil {
+		opts.Levels = syslogLevels
+	}
+	conn, err := net.Dial("unixgram", opts.Socket)
+	if err != nil {
+		return nil, err
+	}
+	j := &journal{opts: opts, conn: conn}
+	if opts.AllowList != nil {
+		j.allow = make(map[string]bool, len(opts.AllowList))
+		for _, key := range opts.AllowList {
+			j.allow[key] = true
+		}
+	}
+	return &JournalHandler{j: j}, nil
+}
+
+// Close closes the connection to journald.
+func (h *JournalHandler) Close() error {
+	return h.j.conn.Close()
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *JournalHandler) Enabled(level Level) bool {
+	minLevel := LevelInfo
+	if h.j.opts.Level != nil {
+		minLevel = h.j.opts.Level.Level()
+	}
+	return level >= minLevel
+}
+
+// Handle sends r to the journal as one datagram.
+func (h *JournalHandler) Handle(r Record) error {
+	if level, ok := r.levelOverride(); ok {
+		r.Level = level
+	}
+	buf := newBuffer()
+	defer buf.Free()
+
+	appendJournalField(buf, "MESSAGE", r.Message)
+	appendJournalField(buf, "PRIORITY", strconv.Itoa(h.j.opts.Levels.Code(r.Level)))
+	if h.j.opts.Identifier != "" {
+		appendJournalField(buf, "SYSLOG_IDENTIFIER", h.j.opts.Identifier)
+	}
+	prefix := r.Prefix
+	if prefix == "" {
+		prefix = h.prefix
+	}
+	if prefix != "" {
+		h.j.appendAttr(buf, slog.String(PrefixKey, prefix), "")
+	}
+	buf.Write(h.fields)
+	r.Attrs(func(a Attr) bool {
+		h.j.appendAttr(buf, a, h.group)
+		return true
+	})
+
+	h.j.mu.Lock()
+	defer h.j.mu.Unlock()
+	_, err := h.j.conn.Write(*buf)
+	return err
+}
+
+// WithAttrs returns a new Handler whose attributes consist of
+// both the receiver's attributes and the arguments.
+func (h *JournalHandler) WithAttrs(attrs []Attr) Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	buf := newBuffer()
+	defer buf.Free()
+	for _, a := range attrs {
+		h.j.appendAttr(buf, a, h.group)
+	}
+	h2 := *h
+	h2.fields = append(h.fields[:len(h.fields):len(h.fields)], *buf...)
+	return &h2
+}
+
+// WithGroup returns a new Handler qualifying the keys of subsequent
+// attributes with name.
+func (h *JournalHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.group += name + "."
+	return &h2
+}
+
+// WithPrefix returns a new Handler with the given prefix prepended to
+// the receiver's existing prefix.
+func (h *JournalHandler) WithPrefix(prefix string) Handler {
+	if prefix == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix = prefix + h.prefix
+	return &h2
+}
+
+// appendAttr appends a as a journal field, expanding groups.
+func (j *journal) appendAttr(buf *buffer, a Attr, group string) {
+	if isLevelOverride(a.Value) {
+		return
+	}
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			group += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			j.appendAttr(buf, ga, group)
+		}
+		return
+	}
+	if a.Equal(Attr{}) {
+		return
+	}
+	key := group + a.Key
+	if j.allow != nil && !j.allow[key] {
+		return
+	}
+	name, ok := j.opts.FieldMap[key]
+	if !ok {
+		name = journalFieldName(key)
+	}
+	if name == "" {
+		return
+	}
+	appendJournalField(buf, name, a.Value.String())
+}
+
+// journalFieldName converts key to a valid journal field name: uppercase
+// ASCII letters, digits and '_', not starting with '_' or a digit, and at
+// most 64 characters long.
+func journalFieldName(key string) string {
+	var sb strings.Builder
+	for i := 0; i < len(key) && sb.Len() < 64; i++ {
+		c := key[i]
+		switch {
+		case 'a' <= c && c <= 'z':
+			sb.WriteByte(c - 'a' + 'A')
+		case 'A' <= c && c <= 'Z', '0' <= c && c <= '9' && sb.Len() > 0:
+			sb.WriteByte(c)
+		case sb.Len() > 0:
+			sb.WriteByte('_')
+		}
+	}
+	return sb.String()
+}
+
+// appendJournalField appends a field in the native journal format. Values
+// containing newlines are written with an explicit length.
+func appendJournalField(buf *buffer, name, value string) {
+	buf.WriteString(name)
+	if strings.IndexByte(value, '\n') < 0 {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+	} else {
+		buf.WriteByte('\n')
+		*buf = binary.LittleEndian.AppendUint64(*buf, uint64(len(value)))
+		buf.WriteString(value)
+	}
+	buf.WriteByte('\n')
+}
@@ -0,0 +1,78 @@
+package l4g
+
+import (
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// listenJournal returns a datagram socket standing in for journald or a
+// local syslog daemon.
+func listenJournal(t *testing.T) (*net.UnixConn, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unixgram sockets unavailable: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, path
+}
+
+func readDatagram(t *testing.T, conn *net.UnixConn) string {
+	t.Helper()
+	buf := make([]byte, 64<<10)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	return string(buf[:n])
+}
+
+func TestJournalHandler(t *testing.T) {
+	conn, path := listenJournal(t)
+	h, err := NewJournalHandler(JournalOptions{
+		Socket:     path,
+		Identifier: "billing",
+		FieldMap:   map[string]string{"req.id": "REQUEST_ID"},
+		AllowList:  []string{"req.id", "req.status", "user", PrefixKey},
+	})
+	if err != nil {
+		t.Fatalf("NewJournalHandler() error = %v", err)
+	}
+	defer h.Close()
+
+	logger := New(Options{Output: &strings.Builder{}, Handler: h.WithPrefix("api").WithAttrs([]Attr{String("user", "alice")})})
+	logger.Warn("multi\nline", Group("req", "id", "r-1", "status", 502, "secret", "x"), "dropped", 1)
+
+	got := readDatagram(t, conn)
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len("multi\nline")))
+	want := "MESSAGE\n" + string(size[:]) + "multi\nline\n" +
+		"PRIORITY=4\n" +
+		"SYSLOG_IDENTIFIER=billing\n" +
+		"PREFIX=api\n" +
+		"USER=alice\n" +
+		"REQUEST_ID=r-1\n" +
+		"REQ_STATUS=502\n"
+	if got != want {
+		t.Errorf("datagram = %q, want %q", got, want)
+	}
+}
+
+func TestJournalFieldName(t *testing.T) {
+	tests := map[string]string{
+		"http.status": "HTTP_STATUS",
+		"userID":      "USERID",
+		"_private":    "PRIVATE",
+		"2fa-method":  "FA_METHOD",
+		"":            "",
+	}
+	for key, want := range tests {
+		if got := journalFieldName(key); got != want {
+			t.Errorf("journalFieldName(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,656 @@
+package l4g
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/netip"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// NewJSONHandler creates a [JSONHandler] that writes to opts.Output,
+// using the given options.
+func NewJSONHandler(opts HandlerOptions) Handler {
+	if opts.Canonical {
+		opts.SortAttrs = true
+		if opts.TimeFormat == "" {
+			opts.TimeFormat = canonicalTimeFormat
+		}
+	}
+	if opts.TimeFormat == "" {
+		opts.TimeFormat = time.RFC3339Nano
+	}
+	if opts.Severity && opts.FieldNames.Level == "" {
+		opts.FieldNames.Level = SeverityKey
+	}
+	opts.FieldNames = opts.FieldNames.withDefaults()
+	if opts.Elapsed && opts.ElapsedSince.IsZero() {
+		opts.ElapsedSince = time.Now()
+	}
+
+	h := &JSONHandler{opts: &opts}
+	format := AttrFormat{AppendAttr: h.appendStateAttr}
+	if !opts.FlattenGroups {
+		format.OpenGroup = h.openGroup
+	}
+	h.state = NewHandlerState(format).WithPrefix(opts.Prefix)
+	return h
+}
+
+// canonicalTimeFormat is the time format of [HandlerOptions.Canonical],
+// RFC 3339 with a fixed number of fractional digits.
+const canonicalTimeFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+var _ Handler = (*JSONHandler)(nil)
+
+// JSONHandler is a Handler that writes log records to an io.Writer as
+// line-delimited JSON objects.
+//
+// Groups are written as nested objects unless [HandlerOptions.FlattenGroups]
+// is set, in which case their members are written as dotted keys.
+type JSONHandler struct {
+	state       HandlerState    // Prefix, groups and pre-encoded attributes, each followed by a comma
+	groupPrefix string          // Dot-separated group names, used when flattening
+	opts        *HandlerOptions // Configuration options
+}
+
+// Options returns a copy of the options of h, with their defaults set.
+// The maps and slices they hold are shared with h and must not be
+// modified.
+func (h *JSONHandler) Options() HandlerOptions {
+	return *h.opts
+}
+
+// clone creates a shallow copy of the handler.
+// This is used by WithAttrs, WithGroup, and WithPrefix to create derived handlers.
+func (h *JSONHandler) clone() *JSONHandler {
+	return &JSONHandler{
+		state:       h.state,
+		groupPrefix: h.groupPrefix,
+		opts:        h.opts,
+	}
+}
+
+// Enabled reports whether the handler handles records at the given level.
+// The handler ignores records whose level is lower.
+func (h *JSONHandler) Enabled(level Level) bool {
+	minLevel := LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return level >= minLevel
+}
+
+// Handle formats its argument [Record] as a single JSON object followed
+// by a newline.
+func (h *JSONHandler) Handle(r Record) error {
+	buf := newBuffer()
+	defer buf.Free()
+	h.appendRecord(buf, r)
+	if h.opts.sanitizing() {
+		sanitize(buf, h.opts)
+	}
+	err := writeFull(h.opts.Output, *buf)
+	return err
+}
+
+// appendRecord appends r to buf as a JSON object followed by a newline.
+func (h *JSONHandler) appendRecord(buf *buffer, r Record) {
+	prefix := r.Prefix
+	if prefix == "" {
+		prefix = h.state.Prefix()
+	}
+	if level, ok := r.levelOverride(); ok {
+		r.Level = level
+	}
+
+	rep := h.opts.ReplaceAttr
+	buf.WriteByte('{')
+
+	// write time
+	if !r.Time.IsZero() {
+		if rep == nil {
+			h.appendKey(buf, h.opts.FieldNames.Time)
+			h.appendTime(buf, r.Time)
+			buf.WriteByte(',')
+		} else if a := rep(nil /* groups */, slog.Time(TimeKey, r.Time.Round(0))); a.Key != "" {
+			h.appendKey(buf, h.opts.FieldNames.rename(a.Key))
+			if v := a.Value.Resolve(); v.Kind() == slog.KindTime {
+				h.appendTime(buf, v.Time())
+			} else {
+				h.appendValue(buf, v)
+			}
+			buf.WriteByte(',')
+		}
+	}
+
+	// write level
+	if rep == nil {
+		h.appendKey(buf, h.opts.FieldNames.Level)
+		h.appendLevel(buf, r.Level)
+		buf.WriteByte(',')
+	} else if a := rep(nil /* groups */, slog.Any(LevelKey, r.Level)); a.Key != "" {
+		h.appendKey(buf, h.opts.FieldNames.rename(a.Key))
+		v := a.Value.Resolve()
+		if lvl, ok := v.Any().(Level); ok && v.Kind() == slog.KindAny {
+			h.appendLevel(buf, lvl)
+		} else {
+			h.appendValue(buf, v)
+		}
+		buf.WriteByte(',')
+	}
+
+	// write prefix
+	if prefix != "" {
+		a := slog.String(PrefixKey, prefix)
+		if rep != nil {
+			a = rep(nil /* groups */, a)
+		}
+		if a.Key != "" {
+			h.appendKey(buf, h.opts.FieldNames.rename(a.Key))
+			h.appendValue(buf, a.Value.Resolve())
+			buf.WriteByte(',')
+		}
+	}
+
+	// write tags
+	if len(r.Tags) > 0 {
+		a := slog.Any(TagsKey, r.Tags)
+		if rep != nil {
+			a = rep(nil /* groups */, a)
+		}
+		if a.Key != "" {
+			h.appendKey(buf, a.Key)
+			h.appendValue(buf, a.Value.Resolve())
+			buf.WriteByte(',')
+		}
+	}
+
+	// write source
+	if h.opts.AddSource && r.PC != 0 {
+		a := slog.Any(SourceKey, r.Source())
+		if rep != nil {
+			a = rep(nil /* groups */, a)
+		}
+		if a.Key != "" {
+			h.appendKey(buf, a.Key)
+			h.appendValue(buf, a.Value.Resolve())
+			buf.WriteByte(',')
+		}
+	}
+
+	// write message
+	a := slog.String(MessageKey, stripAnsi(r.Message))
+	if rep != nil {
+		a = rep(nil /* groups */, a)
+	}
+	if a.Key != "" {
+		h.appendKey(buf, h.opts.FieldNames.rename(a.Key))
+		h.appendValue(buf, a.Value.Resolve())
+		buf.WriteByte(',')
+	}
+
+	// write elapsed time
+	if h.opts.Elapsed {
+		h.appendBuiltinAttr(buf, elapsedAttr(r.Time, h.opts.ElapsedSince))
+	}
+
+	// write emit time
+	if h.opts.EmitTime {
+		h.appendBuiltinAttr(buf, slog.Time(EmitTimeKey, time.Now()))
+	}
+
+	h.appendAttrs(buf, r)
+	closeObject(buf)
+	buf.WriteByte('\n')
+}
+
+// appendAttrs appends the attributes of the handler and of r as members of
+// the object being written to buf, nesting them in the groups of the
+// handler. The object is left open for [closeObject].
+func (h *JSONHandler) appendAttrs(buf *buffer, r Record) {
+	// write handler attributes
+	buf.WriteString(h.state.Attrs())
+
+	// open the groups that have not been opened by WithAttrs yet,
+	// and write the record attributes inside them
+	groups := h.state.Groups()
+	closing := h.state.OpenGroups()
+	if r.NumAttrs() > 0 {
+		mark := len(*buf)
+		if !h.opts.FlattenGroups {
+			for _, g := range h.state.PendingGroups() {
+				*buf = h.openGroup(*buf, g)
+			}
+		}
+		body := len(*buf)
+		recordAttrs(buf, r, h.opts, func(attr Attr) {
+			h.appendAttr(buf, attr, h.groupPrefix, groups)
+		})
+		if len(*buf) == body {
+			*buf = (*buf)[:mark] // every attribute was dropped
+		} else if !h.opts.FlattenGroups {
+			closing = len(groups)
+		}
+	}
+	for range closing {
+		closeObject(buf)
+	}
+}
+
+// WithAttrs returns a new Handler whose attributes consist of
+// both the receiver's attributes and the arguments.
+// The attributes are encoded once, here, rather than for every record.
+func (h *JSONHandler) WithAttrs(attrs []Attr) Handler {
+	attrs = h.opts.filterAttrs(attrs)
+	if len(attrs) == 0 {
+		return h
+	}
+	if h.opts.SortAttrs {
+		attrs = sortAttrs(attrs)
+	}
+
+	h2 := h.clone()
+	h2.state = h.state.WithAttrs(attrs)
+	return h2
+}
+
+// WithGroup returns a new Handler with the given group appended to
+// the receiver's existing groups.
+func (h *JSONHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return h
+	}
+
+	h2 := h.clone()
+	h2.groupPrefix += h.formatKey(name) + "."
+	h2.state = h.state.WithGroup(name)
+	return h2
+}
+
+// WithPrefix returns a new Handler with the given prefix prepended to
+// the receiver's existing prefix.
+func (h *JSONHandler) WithPrefix(prefix string) Handler {
+	if prefix == "" {
+		return h
+	}
+
+	h2 := h.clone()
+	h2.state = h.state.WithPrefix(prefix)
+	return h2
+}
+
+// appendStateAttr implements [AttrFormat.AppendAttr] for the state of the
+// handler.
+func (h *JSONHandler) appendStateAttr(b []byte, groups []string, a Attr) []byte {
+	buf := buffer(b)
+	var groupsPrefix strings.Builder
+	for _, g := range groups {
+		groupsPrefix.WriteString(h.formatKey(g))
+		groupsPrefix.WriteByte('.')
+	}
+	h.appendAttr(&buf, a, groupsPrefix.String(), groups)
+	return buf
+}
+
+// openGroup implements [AttrFormat.OpenGroup], opening the object of the
+// group name.
+func (h *JSONHandler) openGroup(b []byte, name string) []byte {
+	buf := buffer(b)
+	h.appendKey(&buf, h.formatKey(name))
+	buf.WriteByte('{')
+	return buf
+}
+
+// formatKey applies the KeyFormat option to key, if any.
+func (h *JSONHandler) formatKey(key string) string {
+	if h.opts.KeyFormat == nil {
+		return key
+	}
+	return h.opts.KeyFormat(key)
+}
+
+func (h *JSONHandler) appendAttr(buf *buffer, attr Attr, groupsPrefix string, groups []string) {
+	if isLevelOverride(attr.Value) {
+		return
+	}
+	attr.Value = attr.Value.Resolve()
+	var rep func([]string, Attr) Attr
+	switch {
+	case attr.Value.Kind() != slog.KindGroup:
+		rep = h.opts.ReplaceAttr
+	case attr.Key != "":
+		rep = h.opts.ReplaceGroup
+	}
+	if rep != nil {
+		attr = rep(groups, attr)
+		attr.Value = attr.Value.Resolve()
+	}
+
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+	if h.opts.ErrorTree {
+		attr.Value = errorTreeValue(attr.Value)
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		members := attr.Value.Group()
+		if h.opts.SortAttrs {
+			members = sortAttrs(members)
+		}
+		if attr.Key == "" || h.opts.FlattenGroups {
+			// inline the members of groups with an empty key
+			if attr.Key != "" {
+				groupsPrefix += h.formatKey(attr.Key) + "."
+				groups = append(groups[:len(groups):len(groups)], attr.Key)
+			}
+			for _, a := range members {
+				h.appendAttr(buf, a, groupsPrefix, groups)
+			}
+			return
+		}
+
+		mark := len(*buf)
+		h.appendKey(buf, h.formatKey(attr.Key))
+		buf.WriteByte('{')
+		body := len(*buf)
+		groups = append(groups[:len(groups):len(groups)], attr.Key)
+		for _, a := range members {
+			h.appendAttr(buf, a, "", groups)
+		}
+		if len(*buf) == body {
+			*buf = (*buf)[:mark] // omit empty groups
+			return
+		}
+		closeObject(buf)
+		buf.WriteByte(',')
+		return
+	}
+
+	key := h.formatKey(attr.Key)
+	if h.opts.FlattenGroups {
+		key = groupsPrefix + key
+	}
+	if len(groups) == 0 && h.isBuiltinKey(key) {
+		switch h.opts.KeyConflict {
+		case KeyConflictDrop:
+			return
+		case KeyConflictRename:
+			key = conflictGroup + "." + key
+		}
+	}
+
+	h.appendKey(buf, key)
+	h.appendValue(buf, attr.Value)
+	buf.WriteByte(',')
+}
+
+// appendBuiltinAttr appends a, a field written by the handler after the
+// message, passing it to ReplaceAttr as the attributes are but without
+// applying KeyConflict.
+func (h *JSONHandler) appendBuiltinAttr(buf *buffer, a Attr) {
+	if rep := h.opts.ReplaceAttr; rep != nil {
+		a = rep(nil /* groups */, a)
+	}
+	if a.Key == "" {
+		return
+	}
+	h.appendKey(buf, h.formatKey(a.Key))
+	h.appendValue(buf, a.Value.Resolve())
+	buf.WriteByte(',')
+}
+
+// isBuiltinKey reports whether key is the name of a field the handler
+// writes besides the attributes, so that an attribute cannot duplicate it.
+func (h *JSONHandler) isBuiltinKey(key string) bool {
+	switch {
+	case h.opts.FieldNames.has(key), key == TagsKey:
+		return true
+	case h.opts.AddSource && key == SourceKey:
+		return true
+	case h.opts.Elapsed && key == h.formatKey(ElapsedKey):
+		return true
+	case h.opts.EmitTime && key == h.formatKey(EmitTimeKey):
+		return true
+	}
+	return false
+}
+
+func (h *JSONHandler) appendKey(buf *buffer, key string) {
+	appendJSONString(buf, key)
+	buf.WriteByte(':')
+}
+
+func (h *JSONHandler) appendTime(buf *buffer, t time.Time) {
+	if h.opts.Canonical {
+		t = t.UTC()
+	}
+	n := len(*buf)
+	buf.WriteByte('"')
+	b, numeric := appendTime(*buf, t, h.opts.TimeFormat)
+	if numeric {
+		// drop the opening quote
+		b = append(b[:n], b[n+1:]...)
+	} else {
+		b = append(b, '"')
+	}
+	*buf = b
+}
+
+func (h *JSONHandler) appendLevel(buf *buffer, level Level) {
+	if h.opts.Severity {
+		appendJSONString(buf, SeverityName(level))
+	} else if h.opts.LevelFormat != nil {
+		appendJSONString(buf, h.opts.LevelFormat(level))
+	} else {
+		appendJSONString(buf, levelName(level))
+	}
+}
+
+func (h *JSONHandler) appendValue(buf *buffer, v slog.Value) {
+	switch v.Kind() {
+	case slog.KindString:
+		appendJSONString(buf, v.String())
+	case slog.KindInt64:
+		*buf = strconv.AppendInt(*buf, v.Int64(), 10)
+	case slog.KindUint64:
+		*buf = strconv.AppendUint(*buf, v.Uint64(), 10)
+	case slog.KindFloat64:
+		f := v.Float64()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			// JSON has no representation for these values
+			appendJSONString(buf, strconv.FormatFloat(f, 'g', -1, 64))
+		} else {
+			*buf = strconv.AppendFloat(*buf, f, 'g', -1, 64)
+		}
+	case slog.KindBool:
+		*buf = strconv.AppendBool(*buf, v.Bool())
+	case slog.KindDuration:
+		// Do what json.Marshal does.
+		*buf = strconv.AppendInt(*buf, int64(v.Duration()), 10)
+	case slog.KindTime:
+		buf.WriteByte('"')
+		if h.opts.Canonical {
+			*buf = v.Time().UTC().AppendFormat(*buf, canonicalTimeFormat)
+		} else {
+			*buf = v.Time().AppendFormat(*buf, time.RFC3339Nano)
+		}
+		buf.WriteByte('"')
+	case slog.KindGroup:
+		buf.WriteByte('{')
+		for _, a := range v.Group() {
+			h.appendKey(buf, a.Key)
+			h.appendValue(buf, a.Value.Resolve())
+			buf.WriteByte(',')
+		}
+		closeObject(buf)
+	default:
+		h.appendAny(buf, v.Any())
+	}
+}
+
+func (h *JSONHandler) appendAny(buf *buffer, v any) {
+	defer func() {
+		// Copied from log/slog/handler.go.
+		if r := recover(); r != nil {
+			if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+				buf.WriteString("null")
+				return
+			}
+			appendJSONString(buf, fmt.Sprintf("!PANIC: %v", r))
+		}
+	}()
+
+	switch cv := v.(type) {
+	case nil:
+		buf.WriteString("null")
+		return
+	case []string:
+		buf.WriteByte('[')
+		for i, s := range cv {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			appendJSONString(buf, s)
+		}
+		buf.WriteByte(']')
+		return
+	case *slog.Source:
+		if cv != nil {
+			h.appendSource(buf, cv)
+			return
+		}
+	case idValue, netip.Addr, netip.Prefix:
+		if b, ok := appendBareText(append(*buf, '"'), cv); ok {
+			*buf = append(b, '"')
+		} else {
+			appendJSONString(buf, cv.(fmt.Stringer).String())
+		}
+		return
+	case json.Marshaler:
+		// handled by json.Marshal below, even if it is also an error
+	case error:
+		appendJSONString(buf, cv.Error())
+		return
+	case encoding.TextMarshaler:
+		data, err := cv.MarshalText()
+		if err == nil {
+			appendJSONString(buf, string(data))
+			return
+		}
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		appendJSONString(buf, fmt.Sprintf("%+v", v))
+		return
+	}
+	buf.Write(data)
+}
+
+// appendSource appends src as an object holding the file, shortened as
+// configured, the line and, if SourceFunc is set, the function.
+// If SourceFormat is set, its result is appended as a string instead.
+func (h *JSONHandler) appendSource(buf *buffer, src *slog.Source) {
+	if h.opts.SourceFormat != nil {
+		appendJSONString(buf, h.opts.SourceFormat(src))
+		return
+	}
+	buf.WriteByte('{')
+	if h.opts.SourceFunc && src.Function != "" {
+		h.appendKey(buf, "function")
+		appendJSONString(buf, shortFunc(src.Function))
+		buf.WriteByte(',')
+	}
+	h.appendKey(buf, "file")
+	appendJSONString(buf, sourceFile(src, h.opts))
+	buf.WriteByte(',')
+	h.appendKey(buf, "line")
+	*buf = strconv.AppendInt(*buf, int64(src.Line), 10)
+	buf.WriteByte('}')
+}
+
+// closeObject terminates the JSON object being written to buf, replacing
+// the trailing comma of its last member, if any.
+func closeObject(buf *buffer) {
+	if n := len(*buf); n > 0 && (*buf)[n-1] == ',' {
+		(*buf)[n-1] = '}'
+	} else {
+		buf.WriteByte('}')
+	}
+}
+
+// appendJSONString appends s to buf as a quoted JSON string.
+//
+// Adapted from log/slog/json_handler.go.
+func appendJSONString(buf *buffer, s string) {
+	const hex = "0123456789abcdef"
+
+	buf.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if safeSet[b] && b != ansiEsc {
+				i++
+				continue
+			}
+			buf.WriteString(s[start:i])
+			switch b {
+			case '\\', '"':
+				buf.WriteByte('\\')
+				buf.WriteByte(b)
+			case '\b':
+				buf.WriteString(`\b`)
+			case '\f':
+				buf.WriteString(`\f`)
+			case '\n':
+				buf.WriteString(`\n`)
+			case '\r':
+				buf.WriteString(`\r`)
+			case '\t':
+				buf.WriteString(`\t`)
+			default:
+				// This encodes bytes < 0x20 except for \t, \n and \r.
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hex[b>>4])
+				buf.WriteByte(hex[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if c == utf8.RuneError && size == 1 {
+			buf.WriteString(s[start:i])
+			buf.WriteString(`\ufffd`)
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 is LINE SEPARATOR.
+		// U+2029 is PARAGRAPH SEPARATOR.
+		// They are both technically valid characters in JSON strings,
+		// but don't work in JSONP, which has to be evaluated as JavaScript,
+		// and can lead to security holes there. It is valid JSON to
+		// escape them, so we do so unconditionally.
+		// See http://timelessrepo.com/json-isnt-a-javascript-subset for discussion.
+		if c == '\u2028' || c == '\u2029' {
+			buf.WriteString(s[start:i])
+			buf.WriteString(`\u202`)
+			buf.WriteByte(hex[c&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf.WriteString(s[start:])
+	buf.WriteByte('"')
+}
@@ -0,0 +1,593 @@
+package l4g
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func decodeJSONLine(t *testing.T, line []byte) map[string]any {
+	t.Helper()
+	var m map[string]any
+	if err := json.Unmarshal(line, &m); err != nil {
+		t.Fatalf("invalid JSON %q: %v", line, err)
+	}
+	return m
+}
+
+func TestNewJSONHandler(t *testing.T) {
+	h := NewJSONHandler(HandlerOptions{Output: &bytes.Buffer{}})
+
+	jh, ok := h.(*JSONHandler)
+	if !ok {
+		t.Fatalf("NewJSONHandler() did not return *JSONHandler")
+	}
+	if jh.opts.TimeFormat != time.RFC3339Nano {
+		t.Errorf("NewJSONHandler() default TimeFormat = %v, want %v", jh.opts.TimeFormat, time.RFC3339Nano)
+	}
+}
+
+func TestJSONHandler_Handle(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := NewJSONHandler(HandlerOptions{Output: buf, Prefix: "app"})
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	r := NewRecord(now, LevelWarn, "hello \"world\"")
+	r.AddAttrs(
+		String("s", "x\ny"),
+		Int("i", -1),
+		Uint("u", uint(2)),
+		Float("f", 1.5),
+		Float("nan", math.NaN()),
+		Bool("b", true),
+		Duration("d", time.Second),
+		Any("err", errors.New("boom")),
+		Any("nil", nil),
+		Any("slice", []int{1, 2}),
+		ColorAttr(9, String("colored", "red")),
+	)
+	if err := h.Handle(r); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	m := decodeJSONLine(t, buf.Bytes())
+	want := map[string]any{
+		TimeKey:    "2024-01-02T03:04:05Z",
+		LevelKey:   "WARN",
+		PrefixKey:  "app",
+		MessageKey: "hello \"world\"",
+		"s":        "x\ny",
+		"i":        float64(-1),
+		"u":        float64(2),
+		"f":        1.5,
+		"nan":      "NaN",
+		"b":        true,
+		"d":        float64(time.Second),
+		"err":      "boom",
+		"nil":      nil,
+		"colored":  "red",
+	}
+	for k, v := range want {
+		if m[k] != v {
+			t.Errorf("%s = %#v, want %#v", k, m[k], v)
+		}
+	}
+	if s, ok := m["slice"].([]any); !ok || len(s) != 2 {
+		t.Errorf("slice = %#v, want [1 2]", m["slice"])
+	}
+}
+
+func TestJSONHandler_Groups(t *testing.T) {
+	tests := []struct {
+		name    string
+		flatten bool
+		want    string
+	}{
+		{
+			name: "nested",
+			want: `"a":1,"req":{"b":2,"user":{"id":3},"c":4}}`,
+		},
+		{
+			name:    "flattened",
+			flatten: true,
+			want:    `"a":1,"req.b":2,"req.user.id":3,"req.c":4}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			var h Handler = NewJSONHandler(HandlerOptions{Output: buf, FlattenGroups: tt.flatten})
+			h = h.WithAttrs([]Attr{Int("a", 1)}).WithGroup("req").WithAttrs([]Attr{Int("b", 2)})
+
+			r := NewRecord(time.Time{}, LevelInfo, "m")
+			r.AddAttrs(Group("user", Int("id", 3)), Int("c", 4))
+			if err := h.Handle(r); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+
+			output := strings.TrimSpace(buf.String())
+			decodeJSONLine(t, []byte(output))
+			if !strings.HasSuffix(output, tt.want) {
+				t.Errorf("output = %s, want suffix %s", output, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONHandler_EmptyGroups(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := NewJSONHandler(HandlerOptions{
+		Output: buf,
+		ReplaceAttr: func(groups []string, attr Attr) Attr {
+			if attr.Key == "drop" {
+				return Attr{}
+			}
+			return attr
+		},
+	}).WithGroup("g1").WithGroup("g2")
+
+	r := NewRecord(time.Time{}, LevelInfo, "m")
+	if err := h.Handle(r); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	r.AddAttrs(Int("drop", 1))
+	if err := h.Handle(r); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line != `{"level":"INFO","msg":"m"}` {
+			t.Errorf("line = %s, want empty groups to be omitted", line)
+		}
+	}
+}
+
+func TestJSONHandler_WithPrefix(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := NewJSONHandler(HandlerOptions{Output: buf}).WithPrefix("b").WithPrefix("a")
+
+	if err := h.Handle(NewRecord(time.Time{}, LevelInfo, "m")); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if m := decodeJSONLine(t, buf.Bytes()); m[PrefixKey] != "ab" {
+		t.Errorf("prefix = %v, want ab", m[PrefixKey])
+	}
+}
+
+func TestJSONHandler_ReplaceAttr(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := NewJSONHandler(HandlerOptions{
+		Output: buf,
+		ReplaceAttr: func(groups []string, attr Attr) Attr {
+			switch attr.Key {
+			case TimeKey:
+				return Attr{}
+			case "password":
+				return String("password", "***")
+			}
+			return attr
+		},
+	})
+
+	r := NewRecord(time.Now(), LevelInfo, "login")
+	r.AddAttrs(String("password", "secret"))
+	if err := h.Handle(r); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	m := decodeJSONLine(t, buf.Bytes())
+	if _, ok := m[TimeKey]; ok {
+		t.Errorf("time should have been removed: %v", m)
+	}
+	if m["password"] != "***" {
+		t.Errorf("password = %v, want ***", m["password"])
+	}
+}
+
+func TestAppendJSONString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain", `"plain"`},
+		{`a"b\c`, `"a\"b\\c"`},
+		{"tab\there", `"tab\there"`},
+		{"\x1b[31m", `"\u001b[31m"`},
+		{"\u2028", `"\u2028"`},
+		{"\xff", `"\ufffd"`},
+	}
+
+	for _, tt := range tests {
+		buf := newBuffer()
+		appendJSONString(buf, tt.in)
+		if got := string(*buf); got != tt.want {
+			t.Errorf("appendJSONString(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+		buf.Free()
+	}
+}
+
+func BenchmarkJSONHandler_Handle(b *testing.B) {
+	h := NewJSONHandler(HandlerOptions{Output: &bytes.Buffer{}})
+	r := NewRecord(time.Now(), LevelInfo, "benchmark message")
+	r.AddAttrs(String("key", "value"), Int("count", 42))
+
+	for b.Loop() {
+		_ = h.Handle(r)
+	}
+}
+
+func TestJSONHandler_FieldNames(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := NewJSONHandler(HandlerOptions{
+		Output: buf,
+		Prefix: "svc",
+		FieldNames: FieldNames{
+			Time:    "@timestamp",
+			Level:   "severity",
+			Message: "message",
+		},
+	})
+
+	r := NewRecord(time.Now(), LevelError, "failed")
+	r.AddAttrs(String("time", "user"), String("message", "dup"))
+	if err := h.Handle(r); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	m := decodeJSONLine(t, buf.Bytes())
+	for _, key := range []string{"@timestamp", "severity", "message", "prefix"} {
+		if _, ok := m[key]; !ok {
+			t.Errorf("missing key %q in %v", key, m)
+		}
+	}
+	if m["message"] != "failed" || m["fields.message"] != "dup" {
+		t.Errorf("message = %v, fields.message = %v", m["message"], m["fields.message"])
+	}
+	if m["time"] != "user" {
+		t.Errorf("time = %v, want the user attribute once the key is free", m["time"])
+	}
+}
+
+func TestJSONHandler_EpochTimeFormat(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{TimeFormatUnix, `{"time":1704164645,`},
+		{TimeFormatUnixMilli, `{"time":1704164645006,`},
+		{TimeFormatUnixMicro, `{"time":1704164645006000,`},
+		{TimeFormatUnixNano, `{"time":1704164645006000000,`},
+		{time.RFC3339Nano, `{"time":"2024-01-02T03:04:05.006Z",`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			h := NewJSONHandler(HandlerOptions{Output: buf, TimeFormat: tt.format})
+			if err := h.Handle(NewRecord(now, LevelInfo, "m")); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+			decodeJSONLine(t, buf.Bytes())
+			if !strings.HasPrefix(buf.String(), tt.want) {
+				t.Errorf("output = %s, want prefix %s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONHandler_Elapsed(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := NewJSONHandler(HandlerOptions{Output: buf, Elapsed: true})
+
+	if err := h.Handle(NewRecord(time.Now().Add(time.Hour), LevelInfo, "m")); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	m := decodeJSONLine(t, buf.Bytes())
+	if d, ok := m[ElapsedKey].(float64); !ok || time.Duration(d) < time.Hour {
+		t.Errorf("elapsed = %v, want at least one hour", m[ElapsedKey])
+	}
+}
+
+func TestJSONHandler_ReplaceGroup(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := NewJSONHandler(HandlerOptions{
+		Output: buf,
+		ReplaceGroup: func(groups []string, attr Attr) Attr {
+			if attr.Key == "secret" {
+				return Attr{}
+			}
+			return attr
+		},
+	})
+
+	r := NewRecord(time.Time{}, LevelInfo, "m")
+	r.AddAttrs(Group("secret", String("token", "abc")), Group("req", String("path", "/")))
+	if err := h.Handle(r); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if want := `{"level":"INFO","msg":"m","req":{"path":"/"}}` + "\n"; buf.String() != want {
+		t.Errorf("output = %s, want %s", buf.String(), want)
+	}
+}
+
+func TestJSONHandler_SortAttrs(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := NewJSONHandler(HandlerOptions{Output: buf, SortAttrs: true})
+
+	r := NewRecord(time.Time{}, LevelInfo, "m")
+	r.AddAttrs(Int("c", 1), Group("b", Int("y", 1), Int("x", 2)), Int("a", 3))
+	if err := h.Handle(r); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	if want := `{"level":"INFO","msg":"m","a":3,"b":{"x":2,"y":1},"c":1}` + "\n"; buf.String() != want {
+		t.Errorf("output = %s, want %s", buf.String(), want)
+	}
+}
+
+func TestJSONHandler_Canonical(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := NewJSONHandler(HandlerOptions{Output: buf, Canonical: true})
+
+	at := time.Date(2024, 5, 1, 14, 0, 0, 120000000, time.FixedZone("CEST", 2*3600))
+	r := NewRecord(at, LevelInfo, "m")
+	r.AddAttrs(Int("b", 1), Time("a", at))
+	if err := h.Handle(r); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	want := `{"time":"2024-05-01T12:00:00.120000000Z","level":"INFO","msg":"m","a":"2024-05-01T12:00:00.120000000Z","b":1}` + "\n"
+	if buf.String() != want {
+		t.Errorf("output = %s, want %s", buf.String(), want)
+	}
+}
+
+// BenchmarkJSONHandler_GzipSize reports the compressed size of records
+// whose attributes come in varying orders, as from different call sites.
+func BenchmarkJSONHandler_GzipSize(b *testing.B) {
+	attrs := []Attr{String("user", "alice"), Int("status", 200), Duration("took", 1234*time.Microsecond), String("route", "/api/orders"), Bool("cached", false)}
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, canonical := range []bool{false, true} {
+		b.Run(fmt.Sprintf("canonical=%v", canonical), func(b *testing.B) {
+			const records = 1000
+			var size int
+			for range b.N {
+				var buf bytes.Buffer
+				zw := gzip.NewWriter(&buf)
+				h := NewJSONHandler(HandlerOptions{Output: zw, Canonical: canonical})
+				for i := range records {
+					r := NewRecord(start.Add(time.Duration(i)*time.Millisecond+time.Duration(i%7)*time.Microsecond), LevelInfo, "request")
+					for j := range attrs {
+						r.AddAttrs(attrs[(i+j)%len(attrs)])
+					}
+					h.Handle(r)
+				}
+				zw.Close()
+				size = buf.Len()
+			}
+			b.ReportMetric(float64(size)/records, "gzip-B/record")
+		})
+	}
+}
+
+func TestJSONHandler_AddSource(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{
+		Output:         buf,
+		NewHandlerFunc: NewJSONHandler,
+		AddSource:      true,
+		SourcePath:     SourcePathModule,
+	})
+
+	logger.Warn("hello")
+
+	m := decodeJSONLine(t, buf.Bytes())
+	src, ok := m[SourceKey].(map[string]any)
+	if !ok {
+		t.Fatalf("source = %v, want an object", m[SourceKey])
+	}
+	if src["file"] != "json_test.go" {
+		t.Errorf("file = %v, want json_test.go", src["file"])
+	}
+	if _, ok := src["function"]; ok {
+		t.Errorf("function = %v, want omitted", src["function"])
+	}
+	if line, _ := src["line"].(float64); line == 0 {
+		t.Errorf("line = %v, want non-zero", src["line"])
+	}
+}
+
+func TestJSONHandler_AddSourceConflict(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NewHandlerFunc: NewJSONHandler, AddSource: true})
+	logger.Info("hello", "source", "s")
+
+	out := buf.String()
+	if n := strings.Count(out, `"source":`); n != 1 {
+		t.Errorf("output = %s, want one source field", out)
+	}
+	if !strings.Contains(out, `"fields.source":"s"`) {
+		t.Errorf("output = %s, want the attribute renamed", out)
+	}
+}
+
+func TestJSONHandler_BuiltinFieldConflict(t *testing.T) {
+	tests := []struct {
+		key  string
+		opts Options
+	}{
+		{TagsKey, Options{}},
+		{ElapsedKey, Options{Elapsed: true}},
+		{EmitTimeKey, Options{EmitTime: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			opts := tt.opts
+			opts.Output = buf
+			opts.NewHandlerFunc = NewJSONHandler
+			New(opts).WithTags("t").Info("hello", tt.key, "x")
+
+			out := buf.String()
+			if n := strings.Count(out, `"`+tt.key+`":`); n != 1 {
+				t.Errorf("output = %s, want one %s field", out, tt.key)
+			}
+			if !strings.Contains(out, `"fields.`+tt.key+`":"x"`) {
+				t.Errorf("output = %s, want the attribute renamed", out)
+			}
+		})
+	}
+}
+
+func TestJSONHandler_SourceFormat(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := NewJSONHandler(HandlerOptions{
+		Output:       buf,
+		AddSource:    true,
+		SourceFormat: func(src *slog.Source) string { return "src" },
+	})
+
+	r := NewRecord(time.Time{}, LevelInfo, "m")
+	r.PC, _, _, _ = runtime.Caller(0)
+	if err := h.Handle(r); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if got := decodeJSONLine(t, buf.Bytes())[SourceKey]; got != "src" {
+		t.Errorf("source = %v, want src", got)
+	}
+}
+
+func TestJSONHandler_EmitTime(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := NewJSONHandler(HandlerOptions{Output: buf, EmitTime: true})
+
+	event := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := h.Handle(NewRecord(event, LevelInfo, "m")); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	m := decodeJSONLine(t, buf.Bytes())
+	if m[TimeKey] != "2020-01-02T03:04:05Z" {
+		t.Errorf("time = %v, want the event time", m[TimeKey])
+	}
+	emitted, err := time.Parse(time.RFC3339Nano, m[EmitTimeKey].(string))
+	if err != nil || emitted.Before(event) {
+		t.Errorf("emit_time = %v, error = %v", m[EmitTimeKey], err)
+	}
+}
+
+func TestJSONHandler_Tags(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NewHandlerFunc: NewJSONHandler})
+
+	logger.WithTags("billing", "security").Info("m")
+
+	tags, ok := decodeJSONLine(t, buf.Bytes())[TagsKey].([]any)
+	if !ok || len(tags) != 2 || tags[0] != "billing" || tags[1] != "security" {
+		t.Errorf("tags = %v, want [billing security]", tags)
+	}
+}
+
+func TestJSONHandler_Severity(t *testing.T) {
+	tests := []struct {
+		level Level
+		want  string
+	}{
+		{LevelTrace, "DEBUG"},
+		{LevelDebug, "DEBUG"},
+		{LevelInfo, "INFO"},
+		{LevelWarn, "WARNING"},
+		{LevelError, "ERROR"},
+		{LevelPanic, "CRITICAL"},
+		{LevelFatal, "CRITICAL"},
+	}
+
+	for _, tt := range tests {
+		buf := &bytes.Buffer{}
+		h := NewJSONHandler(HandlerOptions{Output: buf, Level: LevelTrace, Severity: true})
+		if err := h.Handle(NewRecord(time.Time{}, tt.level, "m")); err != nil {
+			t.Fatalf("Handle() error = %v", err)
+		}
+		m := decodeJSONLine(t, buf.Bytes())
+		if m[SeverityKey] != tt.want {
+			t.Errorf("severity of %v = %v, want %s", tt.level, m[SeverityKey], tt.want)
+		}
+		if _, ok := m[LevelKey]; ok {
+			t.Errorf("level written besides severity: %v", m)
+		}
+	}
+}
+
+func TestJSONHandler_MaxAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewJSONHandler(HandlerOptions{Output: &buf, MaxAttrs: 1, SortAttrs: true}).WithGroup("g")
+
+	r := NewRecord(time.Time{}, LevelInfo, "loop")
+	r.AddAttrs(Int("b", 2), Int("a", 1), Int("c", 3))
+	if err := h.Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	if want := `"g":{"b":2,"attrs_truncated":2}`; !strings.Contains(buf.String(), want) {
+		t.Errorf("output missing %s:\n%s", want, buf.String())
+	}
+}
+
+func TestJSONHandler_ExcludeKeys(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewJSONHandler(HandlerOptions{Output: &buf, ExcludeKeys: []string{"internal", "debug"}, SortAttrs: true}).
+		WithAttrs([]Attr{String("internal", "x")}).WithGroup("g")
+
+	r := NewRecord(time.Time{}, LevelInfo, "m")
+	r.AddAttrs(Int("b", 2), Group("debug", Int("depth", 3)), Int("a", 1))
+	if err := h.Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	if want := `"g":{"a":1,"b":2}`; !strings.Contains(got, want) || strings.Contains(got, "internal") {
+		t.Errorf("output = %s, want %s only", got, want)
+	}
+}
+
+func TestJSONHandler_MaxRecordSize(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewJSONHandler(HandlerOptions{Output: &buf, MaxRecordSize: 100})
+
+	r := NewRecord(time.Time{}, LevelInfo, "big")
+	r.AddAttrs(String("a", "short"), String("blob", strings.Repeat("x", 1<<20)))
+	if err := h.Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"level":"INFO","msg":"big","a":"short","attrs_truncated":1}` + "\n"; buf.String() != want {
+		t.Errorf("got %s, want %s", buf.String(), want)
+	}
+}
+
+func TestJSONHandler_ErrorTree(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewJSONHandler(HandlerOptions{Output: &buf, ErrorTree: true})
+
+	base := errors.New("connection refused")
+	err := errors.Join(fmt.Errorf("primary: %w", base), errors.New("replica: timeout"))
+	r := NewRecord(time.Time{}, LevelError, "query failed")
+	r.AddAttrs(Err(err), Any("plain", errors.New("single")))
+	if err := h.Handle(r); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `"error":{"msg":"primary: connection refused\nreplica: timeout","causes":[` +
+		`{"msg":"primary: connection refused","causes":[{"msg":"connection refused"}]},` +
+		`{"msg":"replica: timeout"}]},"plain":"single"`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("output missing %s:\n%s", want, buf.String())
+	}
+}
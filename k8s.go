@@ -0,0 +1,55 @@
+package l4g
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// KubernetesKey is the key of the group of Kubernetes metadata added by
+// [Options.Kubernetes].
+const KubernetesKey = "k8s"
+
+// serviceAccountNamespace is the file holding the namespace of the pod,
+// mounted by Kubernetes with the service account token.
+var serviceAccountNamespace = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// KubernetesAttr returns a [KubernetesKey] group describing the pod the
+// program runs in, read from the environment variables commonly set with
+// the Downward API and from the service account files:
+//
+//	pod:       POD_NAME, or HOSTNAME when running in a cluster
+//	namespace: POD_NAMESPACE, or the service account namespace file
+//	node:      NODE_NAME
+//
+// It reports false if none of them is available, such as outside a cluster.
+func KubernetesAttr() (Attr, bool) {
+	inCluster := os.Getenv("KUBERNETES_SERVICE_HOST") != ""
+
+	pod := os.Getenv("POD_NAME")
+	if pod == "" && inCluster {
+		pod = os.Getenv("HOSTNAME")
+	}
+	namespace := os.Getenv("POD_NAMESPACE")
+	if namespace == "" {
+		if data, err := os.ReadFile(serviceAccountNamespace); err == nil {
+			namespace = strings.TrimSpace(string(data))
+		}
+	}
+	node := os.Getenv("NODE_NAME")
+
+	var attrs []any
+	if pod != "" {
+		attrs = append(attrs, slog.String("pod", pod))
+	}
+	if namespace != "" {
+		attrs = append(attrs, slog.String("namespace", namespace))
+	}
+	if node != "" {
+		attrs = append(attrs, slog.String("node", node))
+	}
+	if len(attrs) == 0 {
+		return Attr{}, false
+	}
+	return slog.Group(KubernetesKey, attrs...), true
+}
@@ -0,0 +1,44 @@
+package l4g
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKubernetesAttr(t *testing.T) {
+	dir := t.TempDir()
+	nsFile := filepath.Join(dir, "namespace")
+	if err := os.WriteFile(nsFile, []byte("payments\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defer func(f string) { serviceAccountNamespace = f }(serviceAccountNamespace)
+	serviceAccountNamespace = nsFile
+
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	t.Setenv("HOSTNAME", "api-7d9f")
+	t.Setenv("POD_NAME", "")
+	t.Setenv("POD_NAMESPACE", "")
+	t.Setenv("NODE_NAME", "node-a")
+
+	buf := &bytes.Buffer{}
+	New(Options{Output: buf, NoColor: true, Kubernetes: true}).Info("m")
+	want := "m k8s.pod=api-7d9f k8s.namespace=payments k8s.node=node-a\n"
+	if !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("output = %q, want suffix %q", buf.String(), want)
+	}
+}
+
+func TestKubernetesAttr_OutsideCluster(t *testing.T) {
+	defer func(f string) { serviceAccountNamespace = f }(serviceAccountNamespace)
+	serviceAccountNamespace = filepath.Join(t.TempDir(), "missing")
+	for _, env := range []string{"KUBERNETES_SERVICE_HOST", "POD_NAME", "POD_NAMESPACE", "NODE_NAME"} {
+		t.Setenv(env, "")
+	}
+
+	if a, ok := KubernetesAttr(); ok {
+		t.Errorf("KubernetesAttr() = %v, want none outside a cluster", a)
+	}
+}
@@ -0,0 +1,87 @@
+package l4g
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SnakeCase converts an attribute key to snake_case. It is intended for use
+// as [HandlerOptions.KeyFormat]:
+//
+//	"userID"     -> "user_id"
+//	"HTTPServer" -> "http_server"
+//	"user-name"  -> "user_name"
+func SnakeCase(key string) string {
+	if isFormatted(key, '_') {
+		return key
+	}
+	return strings.Join(splitWords(key), "_")
+}
+
+// CamelCase converts an attribute key to lowerCamelCase. It is intended for
+// use as [HandlerOptions.KeyFormat]:
+//
+//	"user_id"    -> "userId"
+//	"HTTPServer" -> "httpServer"
+//	"user-name"  -> "userName"
+func CamelCase(key string) string {
+	words := splitWords(key)
+	if len(words) == 0 {
+		return key
+	}
+	var sb strings.Builder
+	sb.Grow(len(key))
+	sb.WriteString(words[0])
+	for _, w := range words[1:] {
+		r, size := utf8.DecodeRuneInString(w)
+		sb.WriteRune(unicode.ToUpper(r))
+		sb.WriteString(w[size:])
+	}
+	return sb.String()
+}
+
+// isFormatted reports whether key consists only of lowercase letters,
+// digits and sep, so that converting it would be a no-op.
+func isFormatted(key string, sep byte) bool {
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if !('a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == sep) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitWords splits s into lowercase words. Words are separated by any
+// non-alphanumeric rune and by case transitions, treating a run of capitals
+// as a single acronym ("HTTPServer" is split into "http" and "server").
+func splitWords(s string) []string {
+	var (
+		words []string
+		word  []rune
+	)
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(r) && len(word) > 0 {
+			prev := word[len(word)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || nextLower {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
@@ -0,0 +1,88 @@
+package l4g
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnakeCase(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"user", "user"},
+		{"user_id", "user_id"},
+		{"userID", "user_id"},
+		{"UserName", "user_name"},
+		{"HTTPServer", "http_server"},
+		{"user-name", "user_name"},
+		{"User Name", "user_name"},
+		{"request.ID", "request_id"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := SnakeCase(tt.in); got != tt.want {
+			t.Errorf("SnakeCase(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCamelCase(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"user", "user"},
+		{"user_id", "userId"},
+		{"UserName", "userName"},
+		{"HTTPServer", "httpServer"},
+		{"user-name", "userName"},
+		{"userName", "userName"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := CamelCase(tt.in); got != tt.want {
+			t.Errorf("CamelCase(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSimpleHandler_KeyFormat(t *testing.T) {
+	buf := &bytes.Buffer{}
+	var seen []string
+	h := NewSimpleHandler(HandlerOptions{
+		Output:    buf,
+		NoColor:   true,
+		KeyFormat: SnakeCase,
+		ReplaceAttr: func(groups []string, attr Attr) Attr {
+			seen = append(seen, strings.Join(append(groups, attr.Key), "."))
+			return attr
+		},
+	})
+
+	h = h.WithGroup("httpRequest").WithAttrs([]Attr{String("remoteAddr", "127.0.0.1")})
+	r := NewRecord(time.Now(), LevelInfo, "served")
+	r.AddAttrs(Int("statusCode", 200), Group("userInfo", String("userID", "42")))
+	if err := h.Handle(r); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{
+		"http_request.remote_addr=127.0.0.1",
+		"http_request.status_code=200",
+		"http_request.user_info.user_id=42",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output = %q, want to contain %q", output, want)
+		}
+	}
+
+	if !strings.Contains(strings.Join(seen, " "), "httpRequest.userInfo.userID") {
+		t.Errorf("ReplaceAttr saw %v, want original keys", seen)
+	}
+}
@@ -0,0 +1,146 @@
+package l4g
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+// A KVStore is an ordered, embedded key-value store, such as a bbolt
+// bucket or a Badger database, adapted by the program so that this package
+// stays free of dependencies. Keys are compared bytewise.
+type KVStore interface {
+	// Put stores value under key.
+	Put(key, value []byte) error
+	// DeleteBefore deletes the keys sorting before key.
+	DeleteBefore(key []byte) error
+}
+
+// KVOptions are options for a [KVHandler].
+type KVOptions struct {
+	// HandlerOptions configure the JSON encoding of the values, as for a
+	// [JSONHandler]. Output is ignored.
+	HandlerOptions
+
+	// Retention is how long records are kept. Older records are deleted
+	// at most once per PruneInterval. If Retention is zero, records are
+	// never deleted.
+	Retention time.Duration
+
+	// PruneInterval is the shortest time between two deletions of old
+	// records (Default: 1m).
+	PruneInterval time.Duration
+}
+
+// KVHandler is a Handler that stores records in an embedded key-value
+// store, so that appliances can keep queryable logs without an external
+// service. Each record is stored as a JSON object, as written by a
+// [JSONHandler], under a key made by [KVKey] from its time and a sequence
+// number, so that iterating over the store yields records in
+// chronological order.
+type KVHandler struct {
+	kv   *kvSink
+	json *JSONHandler
+}
+
+// kvSink holds the state shared by the handlers derived from one another.
+type kvSink struct {
+	store     KVStore
+	retention time.Duration
+	interval  time.Duration
+	mu        sync.Mutex
+	seq       uint64
+	pruned    time.Time // time of the last deletion
+}
+
+var _ Handler = (*KVHandler)(nil)
+
+// NewKVHandler returns a [KVHandler] writing to store.
+func NewKVHandler(store KVStore, opts KVOptions) *KVHandler {
+	if opts.PruneInterval <= 0 {
+		opts.PruneInterval = time.Minute
+	}
+	jsonOpts := opts.HandlerOptions
+	jsonOpts.Output = nil
+	return &KVHandler{
+		kv: &kvSink{
+			store:     store,
+			retention: opts.Retention,
+			interval:  opts.PruneInterval,
+		},
+		json: NewJSONHandler(jsonOpts).(*JSONHandler),
+	}
+}
+
+// KVKey returns the key under which a [KVHandler] stores the record logged
+// at t with the given sequence number: the Unix time of t in nanoseconds
+// followed by seq, both big-endian. KVKey(t, 0) is a lower bound for the
+// keys of the records logged at t or later.
+func KVKey(t time.Time, seq uint64) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	binary.BigEndian.PutUint64(key[8:], seq)
+	return key
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *KVHandler) Enabled(level Level) bool {
+	return h.json.Enabled(level)
+}
+
+// Handle stores r, deleting the records older than the retention period
+// if they have not been deleted recently.
+func (h *KVHandler) Handle(r Record) error {
+	buf := newBuffer()
+	defer buf.Free()
+	h.json.appendRecord(buf, r)
+	value := (*buf)[:len(*buf)-1] // drop the newline
+
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	h.kv.mu.Lock()
+	defer h.kv.mu.Unlock()
+	h.kv.seq++
+	if err := h.kv.store.Put(KVKey(t, h.kv.seq), value); err != nil {
+		return err
+	}
+	return h.kv.prune(time.Now())
+}
+
+// Prune deletes the records older than the retention period.
+func (h *KVHandler) Prune() error {
+	h.kv.mu.Lock()
+	defer h.kv.mu.Unlock()
+	h.kv.pruned = time.Time{}
+	return h.kv.prune(time.Now())
+}
+
+// WithAttrs returns a new Handler whose attributes consist of
+// both the receiver's attributes and the arguments.
+func (h *KVHandler) WithAttrs(attrs []Attr) Handler {
+	return &KVHandler{h.kv, h.json.WithAttrs(attrs).(*JSONHandler)}
+}
+
+// WithGroup returns a new Handler with the given group appended to
+// the receiver's existing groups.
+func (h *KVHandler) WithGroup(name string) Handler {
+	return &KVHandler{h.kv, h.json.WithGroup(name).(*JSONHandler)}
+}
+
+// WithPrefix returns a new Handler with the given prefix prepended to
+// the receiver's existing prefix.
+func (h *KVHandler) WithPrefix(prefix string) Handler {
+	return &KVHandler{h.kv, h.json.WithPrefix(prefix).(*JSONHandler)}
+}
+
+// prune deletes the records older than the retention period unless it was
+// done less than an interval before now. kv.mu must be held.
+func (kv *kvSink) prune(now time.Time) error {
+	if kv.retention <= 0 || now.Sub(kv.pruned) < kv.interval {
+		return nil
+	}
+	kv.pruned = now
+	return kv.store.DeleteBefore(KVKey(now.Add(-kv.retention), 0))
+}
@@ -0,0 +1,88 @@
+package l4g
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memKV is a KVStore kept in a sorted slice.
+type memKV struct {
+	keys, values [][]byte
+	deletes      int
+}
+
+func (m *memKV) Put(key, value []byte) error {
+	i, _ := slices.BinarySearchFunc(m.keys, key, bytes.Compare)
+	m.keys = slices.Insert(m.keys, i, bytes.Clone(key))
+	m.values = slices.Insert(m.values, i, bytes.Clone(value))
+	return nil
+}
+
+func (m *memKV) DeleteBefore(key []byte) error {
+	i, _ := slices.BinarySearchFunc(m.keys, key, bytes.Compare)
+	m.keys, m.values = m.keys[i:], m.values[i:]
+	m.deletes++
+	return nil
+}
+
+func TestKVHandler(t *testing.T) {
+	store := &memKV{}
+	h := NewKVHandler(store, KVOptions{})
+	l := New(Options{Output: &strings.Builder{}, Handler: h}).WithPrefix("app").WithAttrs("k", "v")
+	now := time.Now()
+	l.Info("first")
+	l.Warn("second", "n", 2)
+
+	if len(store.keys) != 2 {
+		t.Fatalf("stored %d records, want 2", len(store.keys))
+	}
+	if bytes.Compare(store.keys[0], KVKey(now.Add(-time.Second), 0)) < 0 {
+		t.Errorf("key %x sorts before the time of the record", store.keys[0])
+	}
+	var rec map[string]any
+	if err := json.Unmarshal(store.values[1], &rec); err != nil {
+		t.Fatalf("value %q: %v", store.values[1], err)
+	}
+	if rec["msg"] != "second" || rec["level"] != "WARN" || rec["prefix"] != "app" || rec["k"] != "v" || rec["n"] != 2.0 {
+		t.Errorf("record = %v", rec)
+	}
+	if store.deletes != 0 {
+		t.Errorf("deleted %d times without Retention, want 0", store.deletes)
+	}
+}
+
+func TestKVHandlerRetention(t *testing.T) {
+	store := &memKV{}
+	h := NewKVHandler(store, KVOptions{Retention: time.Hour})
+	old := Record{Time: time.Now().Add(-2 * time.Hour), Level: LevelInfo, Message: "old"}
+	h.Handle(old)
+	if store.deletes != 1 || len(store.keys) != 0 {
+		t.Fatalf("deletes = %d, records = %d, want 1 and 0", store.deletes, len(store.keys))
+	}
+	// within PruneInterval of the first deletion
+	h.Handle(old)
+	h.Handle(Record{Time: time.Now(), Level: LevelInfo, Message: "new"})
+	if store.deletes != 1 || len(store.keys) != 2 {
+		t.Fatalf("deletes = %d, records = %d, want 1 and 2", store.deletes, len(store.keys))
+	}
+	if err := h.Prune(); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.keys) != 1 || !bytes.Contains(store.values[0], []byte(`"new"`)) {
+		t.Errorf("values = %q, want only the new record", store.values)
+	}
+}
+
+func TestKVKey(t *testing.T) {
+	t0 := time.Unix(100, 0)
+	keys := [][]byte{KVKey(t0, 2), KVKey(t0.Add(time.Nanosecond), 1), KVKey(t0, 1)}
+	slices.SortFunc(keys, bytes.Compare)
+	want := [][]byte{KVKey(t0, 1), KVKey(t0, 2), KVKey(t0.Add(time.Nanosecond), 1)}
+	if !slices.EqualFunc(keys, want, bytes.Equal) {
+		t.Errorf("sorted keys = %x, want %x", keys, want)
+	}
+}
@@ -1,9 +1,13 @@
 package l4g
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path"
+	"slices"
 	"sync"
 )
 
@@ -54,10 +58,103 @@ func Channel(name string) *Logger {
 	newLogger := NewFunc(name)
 
 	// Store the logger, or return existing one if another goroutine created it first
-	actual, _ := ls.LoadOrStore(name, newLogger)
+	actual, loaded := ls.LoadOrStore(name, newLogger)
+	if !loaded {
+		channelLevelsMu.Lock()
+		for _, cl := range channelLevels {
+			if ok, _ := path.Match(cl.pattern, name); ok {
+				newLogger.SetLevel(cl.level)
+			}
+		}
+		for _, co := range channelOutputs {
+			if ok, _ := path.Match(co.pattern, name); ok {
+				newLogger.SetOutput(co.w)
+			}
+		}
+		channelLevelsMu.Unlock()
+	}
 	return actual.(*Logger)
 }
 
+// channelLevel is a level set with SetChannelLevel.
+type channelLevel struct {
+	pattern string
+	level   Level
+}
+
+// channelOutput is an output set with SetChannelOutput.
+type channelOutput struct {
+	pattern string
+	w       io.Writer
+}
+
+var (
+	// channelLevels holds the levels set with SetChannelLevel, in order.
+	channelLevels []channelLevel
+	// channelOutputs holds the outputs set with SetChannelOutput, in order.
+	channelOutputs  []channelOutput
+	channelLevelsMu sync.Mutex
+)
+
+// SetChannelLevel sets the minimum level of the channel loggers whose name
+// matches pattern, with the syntax of [path.Match], as "db.*" or "*".
+// It also applies to the channels created later by [Channel]; when several
+// patterns match a channel, the last one set wins.
+// It returns an error only if pattern is malformed.
+func SetChannelLevel(pattern string, level Level) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return err
+	}
+	level = level.Real()
+	channelLevelsMu.Lock()
+	defer channelLevelsMu.Unlock()
+	channelLevels = append(slices.DeleteFunc(channelLevels, func(cl channelLevel) bool {
+		return cl.pattern == pattern
+	}), channelLevel{pattern, level})
+	ls.Range(func(name, l any) bool {
+		if ok, _ := path.Match(pattern, name.(string)); ok {
+			l.(*Logger).SetLevel(level)
+		}
+		return true
+	})
+	return nil
+}
+
+// unsetChannelLevel removes the level set for pattern with
+// SetChannelLevel, so that it no longer applies to the channels created
+// later; the existing channels keep their level.
+func unsetChannelLevel(pattern string) {
+	channelLevelsMu.Lock()
+	defer channelLevelsMu.Unlock()
+	channelLevels = slices.DeleteFunc(channelLevels, func(cl channelLevel) bool {
+		return cl.pattern == pattern
+	})
+}
+
+// SetChannelOutput redirects the channel loggers whose name matches
+// pattern, as for [SetChannelLevel], to w, such as a dedicated file for
+// the "audit" channel; the other channels keep their output. It also
+// applies to the channels created later by [Channel]; when several
+// patterns match a channel, the last one set wins.
+// It returns an error only if pattern is malformed.
+func SetChannelOutput(pattern string, w io.Writer) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return err
+	}
+	channelLevelsMu.Lock()
+	defer channelLevelsMu.Unlock()
+	channelOutputs = append(slices.DeleteFunc(channelOutputs, func(co channelOutput) bool {
+		return co.pattern == pattern
+	}), channelOutput{pattern, w})
+	ls.Range(func(name, l any) bool {
+		if ok, _ := path.Match(pattern, name.(string)); ok {
+			l.(*Logger).SetOutput(w)
+		}
+		return true
+	})
+	return nil
+}
+
 // Default returns the default logger used by the package-level output functions.
 func Default() *Logger {
 	return std
@@ -70,6 +167,28 @@ func SetDefault(l *Logger) {
 	std = l
 }
 
+// DrainContext flushes the default logger and the channel loggers, as
+// [Logger.DrainContext], giving up when ctx is done. It is meant for the
+// shutdown of a program, bounding how long the final flush may take.
+func DrainContext(ctx context.Context) error {
+	errs := []error{Default().DrainContext(ctx)}
+	ls.Range(func(_, l any) bool {
+		errs = append(errs, l.(*Logger).DrainContext(ctx))
+		return true
+	})
+	return errors.Join(errs...)
+}
+
+// Compact compacts the standard logger and the loggers returned by
+// Channel, as described by [Logger.Compact].
+func Compact() {
+	Default().Compact()
+	ls.Range(func(_, l any) bool {
+		l.(*Logger).Compact()
+		return true
+	})
+}
+
 // Output returns the output destination for the standard logger.
 func Output() io.Writer {
 	return std.Output()
@@ -111,121 +230,227 @@ func WithGroup(name string) *Logger {
 	return std.WithGroup(name)
 }
 
+// WithGroupAttrs returns a new Logger based on the standard logger that starts the group name
+// and adds the given attributes inside it. See [Logger.WithGroupAttrs].
+func WithGroupAttrs(name string, args ...any) *Logger {
+	return std.WithGroupAttrs(name, args...)
+}
+
 // Trace logs a message at trace level using the standard logger.
 // args can be key-value pairs (string, any, string, any, ...) or Attr values.
 func Trace(msg string, args ...any) {
-	std.Trace(msg, args...)
+	std.log(LevelTrace, msg, args)
 }
 
 // Tracef logs a formatted message at trace level using the standard logger.
 // It supports [fmt.Printf]-style formatting and optional structured attributes.
 func Tracef(format string, args ...any) {
-	std.Tracef(format, args...)
+	std.logf(LevelTrace, format, args)
+}
+
+// Tracet logs a message at trace level built from template using the standard logger.
+// Placeholders are resolved as described in [Logger.Logt].
+func Tracet(template string, args ...any) {
+	std.logt(LevelTrace, template, args)
 }
 
 // Tracej logs a message at trace level with structured key-value pairs from a map using the standard logger.
 func Tracej(j map[string]any) {
-	std.Tracej(j)
+	std.logj(LevelTrace, "", j)
+}
+
+// Tracejm logs a message at trace level with structured key-value pairs from a map using the standard logger.
+func Tracejm(msg string, j map[string]any) {
+	std.logj(LevelTrace, msg, j)
 }
 
 // Debug logs a message at debug level using the standard logger.
 // args can be key-value pairs (string, any, string, any, ...) or Attr values.
 func Debug(msg string, args ...any) {
-	std.Debug(msg, args...)
+	std.log(LevelDebug, msg, args)
 }
 
 // Debugf logs a formatted message at debug level using the standard logger.
 // It supports [fmt.Printf]-style formatting and optional structured attributes.
 func Debugf(format string, args ...any) {
-	std.Debugf(format, args...)
+	std.logf(LevelDebug, format, args)
+}
+
+// Debugt logs a message at debug level built from template using the standard logger.
+// Placeholders are resolved as described in [Logger.Logt].
+func Debugt(template string, args ...any) {
+	std.logt(LevelDebug, template, args)
 }
 
 // Debugj logs a message at debug level with structured key-value pairs from a map using the standard logger.
 func Debugj(j map[string]any) {
-	std.Debugj(j)
+	std.logj(LevelDebug, "", j)
+}
+
+// Debugjm logs a message at debug level with structured key-value pairs from a map using the standard logger.
+func Debugjm(msg string, j map[string]any) {
+	std.logj(LevelDebug, msg, j)
 }
 
 // Info logs a message at info level using the standard logger.
 // args can be key-value pairs (string, any, string, any, ...) or Attr values.
 func Info(msg string, args ...any) {
-	std.Info(msg, args...)
+	std.log(LevelInfo, msg, args)
 }
 
 // Infof logs a formatted message at info level using the standard logger.
 // It supports [fmt.Printf]-style formatting and optional structured attributes.
 func Infof(format string, args ...any) {
-	std.Infof(format, args...)
+	std.logf(LevelInfo, format, args)
+}
+
+// Infot logs a message at info level built from template using the standard logger.
+// Placeholders are resolved as described in [Logger.Logt].
+func Infot(template string, args ...any) {
+	std.logt(LevelInfo, template, args)
 }
 
 // Infoj logs a message at info level with structured key-value pairs from a map using the standard logger.
 func Infoj(j map[string]any) {
-	std.Infoj(j)
+	std.logj(LevelInfo, "", j)
+}
+
+// Infojm logs a message at info level with structured key-value pairs from a map using the standard logger.
+func Infojm(msg string, j map[string]any) {
+	std.logj(LevelInfo, msg, j)
 }
 
 // Warn logs a message at warn level using the standard logger.
 // args can be key-value pairs (string, any, string, any, ...) or Attr values.
 func Warn(msg string, args ...any) {
-	std.Warn(msg, args...)
+	std.log(LevelWarn, msg, args)
 }
 
 // Warnf logs a formatted message at warn level using the standard logger.
 // It supports [fmt.Printf]-style formatting and optional structured attributes.
 func Warnf(format string, args ...any) {
-	std.Warnf(format, args...)
+	std.logf(LevelWarn, format, args)
+}
+
+// Warnt logs a message at warn level built from template using the standard logger.
+// Placeholders are resolved as described in [Logger.Logt].
+func Warnt(template string, args ...any) {
+	std.logt(LevelWarn, template, args)
 }
 
 // Warnj logs a message at warn level with structured key-value pairs from a map using the standard logger.
 func Warnj(j map[string]any) {
-	std.Warnj(j)
+	std.logj(LevelWarn, "", j)
+}
+
+// Warnjm logs a message at warn level with structured key-value pairs from a map using the standard logger.
+func Warnjm(msg string, j map[string]any) {
+	std.logj(LevelWarn, msg, j)
 }
 
 // Error logs a message at error level using the standard logger.
 // args can be key-value pairs (string, any, string, any, ...) or Attr values.
 func Error(msg string, args ...any) {
-	std.Error(msg, args...)
+	std.log(LevelError, msg, args)
 }
 
 // Errorf logs a formatted message at error level using the standard logger.
 // It supports [fmt.Printf]-style formatting and optional structured attributes.
 func Errorf(format string, args ...any) {
-	std.Errorf(format, args...)
+	std.logf(LevelError, format, args)
+}
+
+// Errort logs a message at error level built from template using the standard logger.
+// Placeholders are resolved as described in [Logger.Logt].
+func Errort(template string, args ...any) {
+	std.logt(LevelError, template, args)
 }
 
 // Errorj logs a message at error level with structured key-value pairs from a map using the standard logger.
 func Errorj(j map[string]any) {
-	std.Errorj(j)
+	std.logj(LevelError, "", j)
+}
+
+// Errorjm logs a message at error level with structured key-value pairs from a map using the standard logger.
+func Errorjm(msg string, j map[string]any) {
+	std.logj(LevelError, msg, j)
 }
 
 // Panic logs a message at panic level using the standard logger, then panics.
 // args can be key-value pairs (string, any, string, any, ...) or Attr values.
 func Panic(msg string, args ...any) {
-	std.Panic(msg, args...)
+	std.log(LevelPanic, msg, args)
+	std.flushBeforeExit()
+	panic(msg)
 }
 
 // Panicf logs a formatted message at panic level using the standard logger, then panics.
 // It supports [fmt.Printf]-style formatting and optional structured attributes.
 func Panicf(format string, args ...any) {
-	std.Panicf(format, args...)
+	std.logf(LevelPanic, format, args)
+	std.flushBeforeExit()
+	panic(sprintf(format, args))
+}
+
+// Panict logs a message at panic level built from template using the standard logger, then panics.
+// Placeholders are resolved as described in [Logger.Logt].
+func Panict(template string, args ...any) {
+	msg, logged := std.logt(LevelPanic, template, args)
+	if !logged {
+		msg = interpolate(template, argsToAttrSlice(args))
+	}
+	std.flushBeforeExit()
+	panic(msg)
 }
 
 // Panicj logs a message at panic level with structured key-value pairs from a map using the standard logger, then panics.
 func Panicj(j map[string]any) {
-	std.Panicj(j)
+	std.logj(LevelPanic, "", j)
+	std.flushBeforeExit()
+	panic(j)
+}
+
+// Panicjm logs a message at panic level with structured key-value pairs from a map using the standard logger, then panics.
+func Panicjm(msg string, j map[string]any) {
+	std.logj(LevelPanic, msg, j)
+	std.flushBeforeExit()
+	panic(msg)
 }
 
 // Fatal logs a message at fatal level using the standard logger, then calls os.Exit(1).
 // args can be key-value pairs (string, any, string, any, ...) or Attr values.
 func Fatal(msg string, args ...any) {
-	std.Fatal(msg, args...)
+	std.log(LevelFatal, msg, args)
+	std.flushBeforeExit()
+	OsExiter(1)
 }
 
 // Fatalf logs a formatted message at fatal level using the standard logger, then calls os.Exit(1).
 // It supports [fmt.Printf]-style formatting and optional structured attributes.
 func Fatalf(format string, v ...any) {
-	std.Fatalf(format, v...)
+	std.logf(LevelFatal, format, v)
+	std.flushBeforeExit()
+	OsExiter(1)
+}
+
+// Fatalt logs a message at fatal level built from template using the standard logger, then calls os.Exit(1).
+// Placeholders are resolved as described in [Logger.Logt].
+func Fatalt(template string, args ...any) {
+	std.logt(LevelFatal, template, args)
+	std.flushBeforeExit()
+	OsExiter(1)
 }
 
 // Fatalj logs a message at fatal level with structured key-value pairs from a map using the standard logger, then calls os.Exit(1).
 func Fatalj(j map[string]any) {
-	std.Fatalj(j)
+	std.logj(LevelFatal, "", j)
+	std.flushBeforeExit()
+	OsExiter(1)
+}
+
+// Fataljm logs a message at fatal level with structured key-value pairs from a map using the standard logger, then calls os.Exit(1).
+func Fataljm(msg string, j map[string]any) {
+	std.logj(LevelFatal, msg, j)
+	std.flushBeforeExit()
+	OsExiter(1)
 }
@@ -2,7 +2,9 @@ package l4g
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"runtime"
 	"strings"
 	"sync"
 	"testing"
@@ -645,3 +647,79 @@ func TestPackage_Chaining(t *testing.T) {
 
 	SetDefault(New(Options{Output: io.Discard}))
 }
+
+func TestPackageInfot(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf})
+	SetDefault(logger)
+
+	Infot("order {id} shipped", "id", 42)
+	output := buf.String()
+
+	if !strings.Contains(output, "order 42 shipped") {
+		t.Errorf("Infot() output = %q, want to contain 'order 42 shipped'", output)
+	}
+
+	SetDefault(New(Options{Output: io.Discard}))
+}
+
+func TestPackageAddSource(t *testing.T) {
+	buf := &bytes.Buffer{}
+	SetDefault(New(Options{Output: buf, NoColor: true, AddSource: true}))
+	defer SetDefault(New(Options{Output: io.Discard}))
+
+	_, _, line, _ := runtime.Caller(0)
+	Infof("hello %s", "world")
+
+	want := fmt.Sprintf("/l4g_test.go:%d hello world", line+1)
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("output = %q, want to contain %q", buf.String(), want)
+	}
+}
+
+func TestSetChannelLevel(t *testing.T) {
+	db := Channel("test-levels.db")
+	if err := SetChannelLevel("test-levels.*", LevelError); err != nil {
+		t.Fatalf("SetChannelLevel() error = %v", err)
+	}
+	if err := SetChannelLevel("test-levels.db", LevelDebug); err != nil {
+		t.Fatalf("SetChannelLevel() error = %v", err)
+	}
+	if db.Level() != LevelDebug {
+		t.Errorf("Level() = %v, want debug", db.Level())
+	}
+	if l := Channel("test-levels.http"); l.Level() != LevelError {
+		t.Errorf("Level() of a channel created later = %v, want error", l.Level())
+	}
+	if l := Channel("test-other"); l.Level() != LevelInfo {
+		t.Errorf("Level() of a channel not matching = %v, want info", l.Level())
+	}
+	if err := SetChannelLevel("[", LevelDebug); err == nil {
+		t.Errorf("SetChannelLevel() error = nil for a malformed pattern")
+	}
+}
+
+func TestSetChannelOutput(t *testing.T) {
+	audit := Channel("test-outputs.audit")
+	var all, own bytes.Buffer
+	if err := SetChannelOutput("test-outputs.*", &all); err != nil {
+		t.Fatalf("SetChannelOutput() error = %v", err)
+	}
+	if err := SetChannelOutput("test-outputs.audit", &own); err != nil {
+		t.Fatalf("SetChannelOutput() error = %v", err)
+	}
+	audit.Info("login")
+	Channel("test-outputs.access").Info("get")
+	if !strings.Contains(own.String(), "login") || strings.Contains(own.String(), "get") {
+		t.Errorf("audit output = %q, want only login", own.String())
+	}
+	if !strings.Contains(all.String(), "get") || strings.Contains(all.String(), "login") {
+		t.Errorf("output of a channel created later = %q, want only get", all.String())
+	}
+	if l := Channel("test-outputs-other"); l.Output() == &all {
+		t.Errorf("Output() of a channel not matching = the redirected output")
+	}
+	if err := SetChannelOutput("[", io.Discard); err == nil {
+		t.Errorf("SetChannelOutput() error = nil for a malformed pattern")
+	}
+}
@@ -0,0 +1,66 @@
+// Package l4gkeys defines the keys of the attributes common to most
+// services, with constructors for them, so that services written by
+// different teams name their fields alike and the same dashboards and
+// queries work everywhere:
+//
+//	logger.Info("charged",
+//		l4gkeys.RequestIDAttr(id),
+//		l4gkeys.UserIDAttr(user),
+//		l4gkeys.DurationMSAttr(time.Since(start)),
+//	)
+package l4gkeys
+
+import (
+	"time"
+
+	"go-slim.dev/l4g"
+)
+
+// Keys of the well-known attributes.
+const (
+	// RequestID identifies the request being served.
+	RequestID = "request_id"
+	// UserID identifies the user on whose behalf the work is done.
+	UserID = "user_id"
+	// TraceID is the distributed trace ID, as written by
+	// [l4g.ParseTraceparent].
+	TraceID = l4g.TraceIDKey
+	// Component names the part of the service logging the record.
+	Component = "component"
+	// Err holds an error, as written by [l4g.Err].
+	Err = "error"
+	// DurationMS holds a duration in milliseconds.
+	DurationMS = "duration_ms"
+)
+
+// RequestIDAttr returns an [l4g.Attr] for the ID of a request.
+func RequestIDAttr(id string) l4g.Attr {
+	return l4g.String(RequestID, id)
+}
+
+// UserIDAttr returns an [l4g.Attr] for the ID of a user.
+func UserIDAttr(id string) l4g.Attr {
+	return l4g.String(UserID, id)
+}
+
+// TraceIDAttr returns an [l4g.Attr] for the ID of a trace.
+func TraceIDAttr(id string) l4g.Attr {
+	return l4g.String(TraceID, id)
+}
+
+// ComponentAttr returns an [l4g.Attr] naming a component.
+func ComponentAttr(name string) l4g.Attr {
+	return l4g.String(Component, name)
+}
+
+// ErrAttr returns an [l4g.Attr] for err, as [l4g.Err].
+func ErrAttr(err error) l4g.Attr {
+	return l4g.Err(err)
+}
+
+// DurationMSAttr returns an [l4g.Attr] for d in milliseconds, with the
+// fraction of a millisecond, rather than in the format of time.Duration,
+// so that it can be aggregated by log backends.
+func DurationMSAttr(d time.Duration) l4g.Attr {
+	return l4g.Float(DurationMS, float64(d)/float64(time.Millisecond))
+}
@@ -0,0 +1,37 @@
+package l4gkeys
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go-slim.dev/l4g"
+)
+
+func TestAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := l4g.New(l4g.Options{Output: &buf, NewHandlerFunc: l4g.NewJSONHandler})
+	logger.Info("charged",
+		RequestIDAttr("r1"),
+		UserIDAttr("u1"),
+		TraceIDAttr("t1"),
+		ComponentAttr("billing"),
+		ErrAttr(errors.New("declined")),
+		DurationMSAttr(1500*time.Microsecond),
+	)
+	got := buf.String()
+	for _, want := range []string{
+		`"request_id":"r1"`,
+		`"user_id":"u1"`,
+		`"trace_id":"t1"`,
+		`"component":"billing"`,
+		`"error":"declined"`,
+		`"duration_ms":1.5`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output = %s, want %s", got, want)
+		}
+	}
+}
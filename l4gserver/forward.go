@@ -0,0 +1,116 @@
+package l4gserver
+
+import (
+	"io"
+	"log/slog"
+	"sync"
+
+	"go-slim.dev/l4g"
+)
+
+// ForwardHandler is an [l4g.Handler] writing records to a [Server], framed
+// by WriteRecord. The attributes and groups added by WithAttrs and
+// WithGroup are folded into the records, and the prefix is set on them, so
+// that the server receives complete records.
+type ForwardHandler struct {
+	w      *forwardWriter
+	level  l4g.Leveler
+	ops    []forwardOp // WithAttrs and WithGroup calls, in order
+	prefix string
+}
+
+// forwardOp records a call of WithGroup, if group is set, or of WithAttrs.
+type forwardOp struct {
+	group string
+	attrs []l4g.Attr
+}
+
+// forwardWriter serializes the frames written by the handlers derived
+// from one another.
+type forwardWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+var _ l4g.Handler = (*ForwardHandler)(nil)
+
+// NewForwardHandler returns a ForwardHandler writing to w, typically a
+// connection to a Server. If level is nil, records below LevelInfo are
+// dropped.
+func NewForwardHandler(w io.Writer, level l4g.Leveler) *ForwardHandler {
+	if level == nil {
+		level = l4g.LevelInfo
+	}
+	return &ForwardHandler{w: &forwardWriter{w: w}, level: level}
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *ForwardHandler) Enabled(level l4g.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle writes r to the server.
+func (h *ForwardHandler) Handle(r l4g.Record) error {
+	if r.Prefix == "" {
+		r.Prefix = h.prefix
+	}
+	if len(h.ops) > 0 {
+		var attrs []l4g.Attr
+		for a := range r.All() {
+			attrs = append(attrs, a)
+		}
+		// Nest the attributes of the record in the groups, innermost first;
+		// empty groups are dropped as by the built-in handlers.
+		for i := len(h.ops) - 1; i >= 0; i-- {
+			op := h.ops[i]
+			switch {
+			case op.group == "":
+				attrs = append(op.attrs[:len(op.attrs):len(op.attrs)], attrs...)
+			case len(attrs) > 0:
+				attrs = []l4g.Attr{{Key: op.group, Value: slog.GroupValue(attrs...)}}
+			}
+		}
+		r2 := l4g.NewRecord(r.Time, r.Level, r.Message)
+		r2.Prefix, r2.Tags, r2.PC = r.Prefix, r.Tags, r.PC
+		r2.AddAttrs(attrs...)
+		r = r2
+	}
+	h.w.mu.Lock()
+	defer h.w.mu.Unlock()
+	return WriteRecord(h.w.w, r)
+}
+
+// WithAttrs returns a new Handler whose attributes consist of
+// both the receiver's attributes and the arguments.
+func (h *ForwardHandler) WithAttrs(attrs []l4g.Attr) l4g.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.withOp(forwardOp{attrs: attrs})
+}
+
+// WithGroup returns a new Handler with the given group appended to
+// the receiver's existing groups.
+func (h *ForwardHandler) WithGroup(name string) l4g.Handler {
+	if name == "" {
+		return h
+	}
+	return h.withOp(forwardOp{group: name})
+}
+
+// WithPrefix returns a new Handler with the given prefix prepended to
+// the receiver's existing prefix.
+func (h *ForwardHandler) WithPrefix(prefix string) l4g.Handler {
+	if prefix == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix = prefix + h.prefix
+	return &h2
+}
+
+func (h *ForwardHandler) withOp(op forwardOp) *ForwardHandler {
+	h2 := *h
+	h2.ops = append(h.ops[:len(h.ops):len(h.ops)], op)
+	return &h2
+}
@@ -0,0 +1,206 @@
+// Package l4gserver receives records forwarded by other processes and
+// passes them to a local [l4g.Handler], so that a per-host log aggregator
+// can be built from this module alone.
+//
+// Records are sent over a stream connection, such as TCP or a unix socket,
+// each framed as its length, a uvarint, followed by its binary encoding
+// ([l4g.Record.MarshalBinary]). A [ForwardHandler] sends the records of a
+// logger in this format:
+//
+//	// aggregator
+//	srv := &l4gserver.Server{Handler: l4g.NewJSONHandler(l4g.HandlerOptions{Output: f})}
+//	go srv.ListenAndServe("unix", "/run/logs.sock")
+//
+//	// clients
+//	conn, err := net.Dial("unix", "/run/logs.sock")
+//	l := l4g.New(l4g.Options{Output: conn, Handler: l4gserver.NewForwardHandler(conn, nil)})
+package l4gserver
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"go-slim.dev/l4g"
+)
+
+// DefaultMaxRecordSize is the size of the largest record accepted by a
+// Server whose MaxRecordSize is zero.
+const DefaultMaxRecordSize = 1 << 20
+
+// ErrServerClosed is returned by Serve and ListenAndServe after Close.
+var ErrServerClosed = errors.New("l4gserver: server closed")
+
+// WriteRecord writes r to w as one frame.
+func WriteRecord(w io.Writer, r l4g.Record) error {
+	data, err := r.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	frame := binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64+len(data)), uint64(len(data)))
+	_, err = w.Write(append(frame, data...))
+	return err
+}
+
+// ReadRecord reads one frame from r, rejecting records larger than
+// maxSize bytes. It returns io.EOF if r ends before the frame starts.
+func ReadRecord(r *bufio.Reader, maxSize int) (l4g.Record, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return l4g.Record{}, err
+	}
+	if n > uint64(maxSize) {
+		return l4g.Record{}, fmt.Errorf("l4gserver: record of %d bytes exceeds %d", n, maxSize)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return l4g.Record{}, io.ErrUnexpectedEOF
+	}
+	var rec l4g.Record
+	err = rec.UnmarshalBinary(data)
+	return rec, err
+}
+
+// A Server accepts connections and passes the records read from them to
+// Handler. The zero value is not usable: Handler must be set.
+type Server struct {
+	// Handler handles the records received, if it is enabled for their
+	// level.
+	Handler l4g.Handler
+
+	// MaxRecordSize is the size of the largest record accepted, in bytes;
+	// a connection sending a larger record is closed.
+	// If zero, DefaultMaxRecordSize is used.
+	MaxRecordSize int
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+	wg        sync.WaitGroup
+}
+
+// ListenAndServe listens on the given network address, as by net.Listen,
+// and calls Serve.
+func (s *Server) ListenAndServe(network, address string) error {
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ln)
+}
+
+// Serve accepts connections on ln, reading records from each in its own
+// goroutine, until ln fails or the server is closed. It closes ln.
+func (s *Server) Serve(ln net.Listener) error {
+	if !s.track(ln, nil) {
+		ln.Close()
+		return ErrServerClosed
+	}
+	defer s.untrack(ln, nil)
+	defer ln.Close()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			return err
+		}
+		if !s.track(nil, conn) {
+			conn.Close()
+			return ErrServerClosed
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer s.untrack(nil, conn)
+			defer conn.Close()
+			if err := s.ServeConn(conn); err != nil && !s.isClosed() {
+				l4g.FallbackErrorf("l4gserver: %v: %v", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+// ServeConn reads records from conn until it ends, returning nil at the
+// end of the stream and the error that stopped it otherwise. Errors of
+// the handler are reported with [l4g.FallbackErrorf] and do not stop it.
+func (s *Server) ServeConn(conn io.Reader) error {
+	maxSize := s.MaxRecordSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxRecordSize
+	}
+	r := bufio.NewReader(conn)
+	for {
+		rec, err := ReadRecord(r, maxSize)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !s.Handler.Enabled(rec.Level) {
+			continue
+		}
+		if err := s.Handler.Handle(rec); err != nil {
+			l4g.FallbackErrorf("l4gserver: unable to handle record: %v", err)
+		}
+	}
+}
+
+// Close closes the listeners and the connections of the server and waits
+// for the records being handled.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	var err error
+	for ln := range s.listeners {
+		err = errors.Join(err, ln.Close())
+	}
+	for conn := range s.conns {
+		err = errors.Join(err, conn.Close())
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// track registers ln or conn, reporting false if the server is closed.
+func (s *Server) track(ln net.Listener, conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	if ln != nil {
+		if s.listeners == nil {
+			s.listeners = make(map[net.Listener]struct{})
+		}
+		s.listeners[ln] = struct{}{}
+	}
+	if conn != nil {
+		if s.conns == nil {
+			s.conns = make(map[net.Conn]struct{})
+		}
+		s.conns[conn] = struct{}{}
+	}
+	return true
+}
+
+func (s *Server) untrack(ln net.Listener, conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.listeners, ln)
+	delete(s.conns, conn)
+}
@@ -0,0 +1,131 @@
+package l4gserver
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-slim.dev/l4g"
+)
+
+func TestWriteReadRecord(t *testing.T) {
+	var buf bytes.Buffer
+	r := l4g.NewRecord(time.Unix(100, 0), l4g.LevelWarn, "disk full")
+	r.AddAttrs(l4g.String("dev", "sda"))
+	if err := WriteRecord(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteRecord(&buf, l4g.NewRecord(time.Unix(101, 0), l4g.LevelInfo, "ok")); err != nil {
+		t.Fatal(err)
+	}
+
+	br := bufio.NewReader(&buf)
+	got, err := ReadRecord(br, DefaultMaxRecordSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Message != "disk full" || got.Level != l4g.LevelWarn || !got.Time.Equal(r.Time) {
+		t.Errorf("got %+v", got)
+	}
+	if got.NumAttrs() != 1 {
+		t.Errorf("NumAttrs = %d, want 1", got.NumAttrs())
+	}
+	if got, err := ReadRecord(br, DefaultMaxRecordSize); err != nil || got.Message != "ok" {
+		t.Errorf("second record = %q, %v", got.Message, err)
+	}
+	if _, err := ReadRecord(br, DefaultMaxRecordSize); err == nil {
+		t.Error("expected io.EOF at the end of the stream")
+	}
+}
+
+func TestReadRecordTooLarge(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteRecord(&buf, l4g.NewRecord(time.Now(), l4g.LevelInfo, strings.Repeat("x", 100))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadRecord(bufio.NewReader(&buf), 10); err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Errorf("err = %v, want size error", err)
+	}
+}
+
+// notifyBuffer signals each write on ch.
+type notifyBuffer struct {
+	ch  chan struct{}
+	buf bytes.Buffer
+}
+
+func (b *notifyBuffer) Write(p []byte) (int, error) {
+	n, err := b.buf.Write(p)
+	b.ch <- struct{}{}
+	return n, err
+}
+
+func TestServer(t *testing.T) {
+	ln, err := net.Listen("unix", filepath.Join(t.TempDir(), "l4g.sock"))
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	out := &notifyBuffer{ch: make(chan struct{}, 10)}
+	srv := &Server{Handler: l4g.NewJSONHandler(l4g.HandlerOptions{Output: out, Level: l4g.LevelInfo})}
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(ln) }()
+
+	conn, err := net.Dial("unix", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	l := l4g.New(l4g.Options{Output: conn, Level: l4g.LevelDebug, Handler: NewForwardHandler(conn, l4g.LevelDebug)})
+	l.WithPrefix("app").WithAttrs("host", "a1").WithGroup("req").Info("served", "status", 200)
+	l.Debug("dropped by the server")
+	l.Warn("slow")
+
+	for range 2 {
+		select {
+		case <-out.ch:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for records")
+		}
+	}
+	if err := srv.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	if err := <-done; !errors.Is(err, ErrServerClosed) {
+		t.Errorf("Serve = %v, want ErrServerClosed", err)
+	}
+
+	got := out.buf.String()
+	for _, want := range []string{
+		`"msg":"served"`,
+		`"prefix":"app"`,
+		`"host":"a1","req":{"status":200}`,
+		`"msg":"slow"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %s:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "dropped") {
+		t.Errorf("debug record handled:\n%s", got)
+	}
+}
+
+func TestForwardHandlerEmptyGroup(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewForwardHandler(&buf, nil).WithGroup("g")
+	if err := h.Handle(l4g.NewRecord(time.Now(), l4g.LevelInfo, "m")); err != nil {
+		t.Fatal(err)
+	}
+	r, err := ReadRecord(bufio.NewReader(&buf), DefaultMaxRecordSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.NumAttrs() != 0 {
+		t.Errorf("NumAttrs = %d, want 0", r.NumAttrs())
+	}
+}
@@ -0,0 +1,91 @@
+package l4gtest
+
+import (
+	"io"
+	"sync/atomic"
+	"testing"
+
+	"go-slim.dev/l4g"
+)
+
+// FailOnError returns a logger writing as logger does and marking t as
+// failed, with the offending record in the message, for every record at
+// [l4g.LevelError] or above logged through it or the loggers derived from
+// it until the end of the test:
+//
+//	logger := l4gtest.FailOnError(t, l4g.New(l4g.Options{Output: io.Discard}))
+//	srv := NewServer(logger)
+//
+// A logger discarding its output logs nothing, so when logger writes to
+// [io.Discard], the returned logger formats its records for a writer
+// ignoring them instead. Records logged after the test are not checked.
+func FailOnError(t testing.TB, logger *l4g.Logger) *l4g.Logger {
+	t.Helper()
+	var done atomic.Bool
+	t.Cleanup(func() { done.Store(true) })
+	if logger.Output() == io.Discard {
+		logger = logger.WithOptions(func(o *l4g.Options) { o.Output = discard{} })
+	}
+	return logger.Wrap(func(h l4g.Handler) l4g.Handler {
+		return &failHandler{Handler: h, t: t, done: &done}
+	})
+}
+
+// discard is a writer ignoring what is written to it, unlike io.Discard
+// without making loggers skip records.
+type discard struct{}
+
+func (discard) Write(p []byte) (int, error) { return len(p), nil }
+
+// failHandler fails t for the records at LevelError or above it passes
+// to Handler.
+type failHandler struct {
+	l4g.Handler
+	t    testing.TB
+	done *atomic.Bool
+}
+
+func (h *failHandler) Handle(r l4g.Record) error {
+	if r.Level >= l4g.LevelError && !h.done.Load() {
+		h.t.Errorf("l4gtest: record logged at %v: %s", r.Level, formatRecord(r))
+	}
+	return h.Handler.Handle(r)
+}
+
+func (h *failHandler) WithAttrs(attrs []l4g.Attr) l4g.Handler {
+	return &failHandler{Handler: h.Handler.WithAttrs(attrs), t: h.t, done: h.done}
+}
+
+func (h *failHandler) WithGroup(name string) l4g.Handler {
+	return &failHandler{Handler: h.Handler.WithGroup(name), t: h.t, done: h.done}
+}
+
+func (h *failHandler) WithPrefix(prefix string) l4g.Handler {
+	return &failHandler{Handler: h.Handler.WithPrefix(prefix), t: h.t, done: h.done}
+}
+
+func (h *failHandler) Flush() error {
+	if f, ok := h.Handler.(l4g.Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (h *failHandler) Compact() {
+	if c, ok := h.Handler.(l4g.Compactor); ok {
+		c.Compact()
+	}
+}
+
+// formatRecord returns the message and attributes of r as key=value pairs.
+func formatRecord(r l4g.Record) string {
+	buf := l4g.NewBuffer()
+	defer buf.Free()
+	buf.WriteString(r.Message)
+	for a := range r.All() {
+		buf.WriteByte(' ')
+		buf.AppendKey(a.Key)
+		buf.AppendValue(a.Value)
+	}
+	return string(*buf)
+}
@@ -0,0 +1,65 @@
+package l4gtest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"go-slim.dev/l4g"
+)
+
+// recordingT records the failures of a test instead of reporting them.
+type recordingT struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *recordingT) Cleanup(f func()) { t.cleanups = append(t.cleanups, f) }
+
+func (t *recordingT) finish() {
+	for _, f := range t.cleanups {
+		f()
+	}
+}
+
+func TestFailOnError(t *testing.T) {
+	rt := &recordingT{TB: t}
+	var buf bytes.Buffer
+	logger := FailOnError(rt, l4g.New(l4g.Options{Output: &buf}))
+
+	logger.Warn("slow")
+	if len(rt.errors) != 0 {
+		t.Errorf("errors = %q after a warning, want none", rt.errors)
+	}
+	logger.WithGroup("db").Error("query failed", "table", "users")
+	if len(rt.errors) != 1 || !strings.HasSuffix(rt.errors[0], ": query failed table=users") {
+		t.Errorf("errors = %q, want the record", rt.errors)
+	}
+	if !strings.Contains(buf.String(), "query failed") {
+		t.Errorf("output = %q, want the record written", buf.String())
+	}
+
+	rt.finish()
+	logger.Error("after the test")
+	if len(rt.errors) != 1 {
+		t.Errorf("errors = %q, want no failure after the test", rt.errors)
+	}
+}
+
+func TestFailOnError_Discard(t *testing.T) {
+	rt := &recordingT{TB: t}
+	logger := FailOnError(rt, l4g.New(l4g.Options{Output: io.Discard}))
+	logger.Error("boom")
+	if len(rt.errors) != 1 {
+		t.Errorf("errors = %q, want the record logged to io.Discard", rt.errors)
+	}
+}
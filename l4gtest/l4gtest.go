@@ -0,0 +1,282 @@
+// Package l4gtest checks that an [l4g.Handler] behaves like the built-in
+// handlers, in the manner of testing/slogtest, so that authors of
+// third-party handlers can validate them:
+//
+//	func TestHandler(t *testing.T) {
+//		var buf bytes.Buffer
+//		l4gtest.TestHandler(t,
+//			func(opts l4g.HandlerOptions) l4g.Handler {
+//				buf.Reset()
+//				opts.Output = &buf
+//				return myhandler.New(opts)
+//			},
+//			func() map[string]any {
+//				m := map[string]any{}
+//				if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+//					t.Fatal(err)
+//				}
+//				return m
+//			})
+//	}
+//
+// It also provides [FailOnError], failing the tests in which the code
+// under test logs errors.
+package l4gtest
+
+import (
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"go-slim.dev/l4g"
+)
+
+// A testCase checks one behavior of a handler.
+type testCase struct {
+	name  string
+	opts  l4g.HandlerOptions
+	h     func(l4g.Handler) l4g.Handler // derives the handler, if set
+	attrs []l4g.Attr                    // attributes of the record
+	zero  bool                          // whether the record has no time
+	check func(m map[string]any) error
+}
+
+// TestHandler runs a subtest per behavior expected of a handler. For each
+// one, it calls newHandler for a new handler configured by the given
+// options, handles one record at LevelInfo with the message "message", and
+// calls result for the record as a map, built-in fields under the keys of
+// [l4g.TimeKey], [l4g.LevelKey], [l4g.MessageKey] and [l4g.PrefixKey], and
+// groups as nested maps.
+//
+// The behaviors checked are those of WithAttrs, WithGroup and WithPrefix,
+// the calls to ReplaceAttr, and the omission of empty attributes and
+// groups.
+func TestHandler(t *testing.T, newHandler func(opts l4g.HandlerOptions) l4g.Handler, result func() map[string]any) {
+	t.Helper()
+	for _, c := range cases() {
+		t.Run(c.name, func(t *testing.T) {
+			h := newHandler(c.opts)
+			if c.h != nil {
+				h = c.h(h)
+			}
+			tm := time.Now()
+			if c.zero {
+				tm = time.Time{}
+			}
+			r := l4g.NewRecord(tm, l4g.LevelInfo, "message")
+			r.AddAttrs(c.attrs...)
+			if err := h.Handle(r); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+			m := result()
+			if err := c.check(m); err != nil {
+				t.Errorf("%v\nresult: %v", err, m)
+			}
+		})
+	}
+}
+
+func cases() []testCase {
+	return []testCase{
+		{
+			name:  "built-ins",
+			check: all(hasKey(l4g.TimeKey), hasValue(l4g.LevelKey, nil), hasValue(l4g.MessageKey, "message")),
+		},
+		{
+			name:  "attrs",
+			attrs: []l4g.Attr{l4g.String("a", "b"), l4g.Int("c", 3)},
+			check: all(hasValue("a", "b"), hasKey("c")),
+		},
+		{
+			name:  "zero time",
+			zero:  true,
+			check: missingKey(l4g.TimeKey),
+		},
+		{
+			name:  "empty attr",
+			attrs: []l4g.Attr{{}, l4g.String("a", "b")},
+			check: all(missingKey(""), hasValue("a", "b")),
+		},
+		{
+			name:  "WithAttrs",
+			h:     func(h l4g.Handler) l4g.Handler { return h.WithAttrs([]l4g.Attr{l4g.String("a", "b")}) },
+			attrs: []l4g.Attr{l4g.String("c", "d")},
+			check: all(hasValue("a", "b"), hasValue("c", "d")),
+		},
+		{
+			name: "WithAttrs receiver unchanged",
+			h: func(h l4g.Handler) l4g.Handler {
+				h.WithAttrs([]l4g.Attr{l4g.String("a", "b")})
+				return h
+			},
+			check: missingKey("a"),
+		},
+		{
+			name:  "inline group",
+			attrs: []l4g.Attr{{Key: "", Value: slog.GroupValue(l4g.String("a", "b"))}},
+			check: hasValue("a", "b"),
+		},
+		{
+			name:  "empty group",
+			attrs: []l4g.Attr{l4g.Group("G"), l4g.String("a", "b")},
+			check: all(missingKey("G"), hasValue("a", "b")),
+		},
+		{
+			name:  "group",
+			attrs: []l4g.Attr{l4g.Group("G", l4g.String("a", "b"))},
+			check: inGroup("G", hasValue("a", "b")),
+		},
+		{
+			name:  "WithGroup",
+			h:     func(h l4g.Handler) l4g.Handler { return h.WithGroup("G") },
+			attrs: []l4g.Attr{l4g.String("a", "b")},
+			check: all(hasValue(l4g.MessageKey, "message"), inGroup("G", hasValue("a", "b"))),
+		},
+		{
+			name: "WithAttrs then WithGroup",
+			h: func(h l4g.Handler) l4g.Handler {
+				return h.WithAttrs([]l4g.Attr{l4g.String("a", "b")}).WithGroup("G")
+			},
+			attrs: []l4g.Attr{l4g.String("c", "d")},
+			check: all(hasValue("a", "b"), inGroup("G", hasValue("c", "d"))),
+		},
+		{
+			name: "WithGroup then WithAttrs",
+			h: func(h l4g.Handler) l4g.Handler {
+				return h.WithGroup("G").WithAttrs([]l4g.Attr{l4g.String("a", "b")})
+			},
+			attrs: []l4g.Attr{l4g.String("c", "d")},
+			check: inGroup("G", all(hasValue("a", "b"), hasValue("c", "d"))),
+		},
+		{
+			name:  "WithGroup without attrs",
+			h:     func(h l4g.Handler) l4g.Handler { return h.WithGroup("G") },
+			check: missingKey("G"),
+		},
+		{
+			name: "nested WithGroup",
+			h: func(h l4g.Handler) l4g.Handler {
+				return h.WithGroup("G").WithAttrs([]l4g.Attr{l4g.String("a", "b")}).WithGroup("H")
+			},
+			attrs: []l4g.Attr{l4g.String("c", "d")},
+			check: inGroup("G", all(hasValue("a", "b"), inGroup("H", hasValue("c", "d")))),
+		},
+		{
+			name:  "WithGroup empty name",
+			h:     func(h l4g.Handler) l4g.Handler { return h.WithGroup("") },
+			attrs: []l4g.Attr{l4g.String("a", "b")},
+			check: hasValue("a", "b"),
+		},
+		{
+			name:  "WithPrefix",
+			h:     func(h l4g.Handler) l4g.Handler { return h.WithPrefix("p") },
+			check: hasValue(l4g.PrefixKey, "p"),
+		},
+		{
+			name:  "WithPrefix prepends",
+			h:     func(h l4g.Handler) l4g.Handler { return h.WithPrefix("inner").WithPrefix("outer.") },
+			check: hasValue(l4g.PrefixKey, "outer.inner"),
+		},
+		{
+			name:  "resolve LogValuer",
+			attrs: []l4g.Attr{l4g.Any("a", logValuer("b"))},
+			check: hasValue("a", "b"),
+		},
+		{
+			name: "ReplaceAttr",
+			opts: l4g.HandlerOptions{ReplaceAttr: func(groups []string, a l4g.Attr) l4g.Attr {
+				switch a.Key {
+				case "drop":
+					return l4g.Attr{}
+				case "a":
+					return l4g.String("a", fmt.Sprint(groups, a.Value))
+				}
+				return a
+			}},
+			h: func(h l4g.Handler) l4g.Handler { return h.WithGroup("G") },
+			attrs: []l4g.Attr{
+				l4g.String("drop", "x"),
+				l4g.Group("H", l4g.Int("a", 1)),
+			},
+			check: inGroup("G", all(missingKey("drop"), inGroup("H", hasValue("a", "[G H] 1")))),
+		},
+		{
+			name: "ReplaceAttr built-ins",
+			opts: l4g.HandlerOptions{ReplaceAttr: func(groups []string, a l4g.Attr) l4g.Attr {
+				if len(groups) == 0 && (a.Key == l4g.TimeKey || a.Key == l4g.LevelKey) {
+					return l4g.Attr{}
+				}
+				return a
+			}},
+			check: all(missingKey(l4g.TimeKey), missingKey(l4g.LevelKey), hasValue(l4g.MessageKey, "message")),
+		},
+	}
+}
+
+type logValuer string
+
+func (v logValuer) LogValue() slog.Value { return slog.StringValue(string(v)) }
+
+type check = func(m map[string]any) error
+
+// all combines checks.
+func all(checks ...check) check {
+	return func(m map[string]any) error {
+		for _, c := range checks {
+			if err := c(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// hasKey checks that m has key.
+func hasKey(key string) check {
+	return func(m map[string]any) error {
+		if _, ok := m[key]; !ok {
+			return fmt.Errorf("missing key %q", key)
+		}
+		return nil
+	}
+}
+
+// missingKey checks that m does not have key.
+func missingKey(key string) check {
+	return func(m map[string]any) error {
+		if _, ok := m[key]; ok {
+			return fmt.Errorf("unexpected key %q", key)
+		}
+		return nil
+	}
+}
+
+// hasValue checks that m has key with a value formatted as want, or any
+// value if want is nil.
+func hasValue(key string, want any) check {
+	return func(m map[string]any) error {
+		got, ok := m[key]
+		if !ok {
+			return fmt.Errorf("missing key %q", key)
+		}
+		if want != nil && fmt.Sprint(got) != fmt.Sprint(want) {
+			return fmt.Errorf("%q: got %v, want %v", key, got, want)
+		}
+		return nil
+	}
+}
+
+// inGroup applies c to the group at key.
+func inGroup(key string, c check) check {
+	return func(m map[string]any) error {
+		g, ok := m[key].(map[string]any)
+		if !ok {
+			return fmt.Errorf("missing group %q", key)
+		}
+		if err := c(g); err != nil {
+			return fmt.Errorf("group %q: %w", key, err)
+		}
+		return nil
+	}
+}
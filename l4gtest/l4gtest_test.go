@@ -0,0 +1,26 @@
+package l4gtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"go-slim.dev/l4g"
+)
+
+func TestJSONHandler(t *testing.T) {
+	var buf bytes.Buffer
+	TestHandler(t,
+		func(opts l4g.HandlerOptions) l4g.Handler {
+			buf.Reset()
+			opts.Output = &buf
+			return l4g.NewJSONHandler(opts)
+		},
+		func() map[string]any {
+			m := map[string]any{}
+			if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+				t.Fatalf("%v: %q", err, buf.String())
+			}
+			return m
+		})
+}
@@ -0,0 +1,167 @@
+package l4g
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// This file holds the printf-style API of earlier versions of this
+// package, kept so that their callers can upgrade without changes.
+// The flags are those of the standard log package, such as log.LstdFlags.
+
+// Flags returns the output flags of the standard logger, as set by
+// SetFlags. It returns log.LstdFlags if SetFlags was not called.
+func Flags() int {
+	mu.Lock()
+	defer mu.Unlock()
+	if std.flags == nil {
+		return log.LstdFlags
+	}
+	return std.flags.flag
+}
+
+// SetFlags sets the output flags of the standard logger. The time is
+// written as selected by log.Ldate, log.Ltime, log.Lmicroseconds and
+// log.LUTC, and omitted without them; log.Lshortfile and log.Llongfile
+// add the source of the log call. Other flags are ignored.
+func SetFlags(flag int) {
+	mu.Lock()
+	defer mu.Unlock()
+	if std.opts == nil {
+		return // Nop
+	}
+	f := &legacyFlags{flag: flag, build: std.build}
+	if std.flags != nil {
+		f.build = std.flags.build
+	}
+	l := std.clone()
+	l.flags = f
+	l.build = f.buildHandler
+	std = l.WithOptions(func(*Options) {})
+}
+
+// legacyFlags holds the flags set by SetFlags on the standard logger, and
+// the function building its handler without them.
+type legacyFlags struct {
+	flag  int
+	build func(opts *Options, level Leveler, output io.Writer) Handler
+}
+
+// buildHandler builds the handler of the standard logger with the options
+// changed by the flags, as its build function.
+func (f *legacyFlags) buildHandler(opts *Options, level Leveler, output io.Writer) Handler {
+	o := *opts
+	applyFlags(&o, f.flag)
+	return f.build(&o, level, output)
+}
+
+// Prefix returns the prefix of the standard logger.
+func Prefix() string {
+	mu.Lock()
+	defer mu.Unlock()
+	if std.opts == nil {
+		return ""
+	}
+	return std.opts.Prefix
+}
+
+// SetPrefix sets the prefix of the standard logger.
+func SetPrefix(prefix string) {
+	mu.Lock()
+	defer mu.Unlock()
+	std = std.WithOptions(func(o *Options) { o.Prefix = prefix })
+}
+
+// Print logs a message at info level using the standard logger.
+// Arguments are handled in the manner of [fmt.Print].
+func Print(v ...any) {
+	std.log(LevelInfo, fmt.Sprint(v...), nil)
+}
+
+// Printf logs a formatted message at info level using the standard logger.
+// Arguments are handled as by [Infof].
+func Printf(format string, v ...any) {
+	std.logf(LevelInfo, format, v)
+}
+
+// Println logs a message at info level using the standard logger.
+// Arguments are handled in the manner of [fmt.Println].
+func Println(v ...any) {
+	std.log(LevelInfo, strings.TrimSuffix(fmt.Sprintln(v...), "\n"), nil)
+}
+
+// Printj logs a message at info level with structured key-value pairs from
+// a map using the standard logger.
+func Printj(j map[string]any) {
+	std.logj(LevelInfo, "", j)
+}
+
+// Write logs s at the given level using the standard logger. Like
+// [log.Output], calldepth is the number of stack frames to skip to find
+// the source of the record, 1 being the caller of Write. It is meant for
+// wrappers of the package functions.
+func Write(calldepth int, level Level, s string) error {
+	return std.write(calldepth+1, level, s)
+}
+
+// write logs s at level with the source calldepth frames above its caller.
+func (l *Logger) write(calldepth int, level Level, s string) error {
+	if l.output.Discard() || !l.Enabled(level) {
+		return nil
+	}
+	return l.handleErr(l.newRecordAt(level, s, calldepth))
+}
+
+// applyFlags sets the options of opts selected by the legacy flags.
+func applyFlags(opts *Options, flag int) {
+	switch {
+	case flag&(log.Ldate|log.Ltime|log.Lmicroseconds) == 0:
+		opts.TimeFormat = ""
+	default:
+		var layout []string
+		if flag&log.Ldate != 0 {
+			layout = append(layout, "2006/01/02")
+		}
+		if flag&(log.Ltime|log.Lmicroseconds) != 0 {
+			if flag&log.Lmicroseconds != 0 {
+				layout = append(layout, "15:04:05.000000")
+			} else {
+				layout = append(layout, "15:04:05")
+			}
+		}
+		opts.TimeFormat = strings.Join(layout, " ")
+	}
+	switch {
+	case flag&log.Lshortfile != 0:
+		opts.AddSource = true
+		opts.SourceFormat = func(src *slog.Source) string {
+			return filepath.Base(src.File) + ":" + strconv.Itoa(src.Line)
+		}
+	case flag&log.Llongfile != 0:
+		opts.AddSource, opts.SourcePath = true, SourcePathFull
+	}
+
+	noTime := flag&(log.Ldate|log.Ltime|log.Lmicroseconds) == 0
+	utc := flag&log.LUTC != 0
+	if !noTime && !utc {
+		return
+	}
+	rep := opts.ReplaceAttr
+	opts.ReplaceAttr = func(groups []string, a Attr) Attr {
+		if len(groups) == 0 && a.Key == TimeKey && a.Value.Kind() == slog.KindTime {
+			if noTime {
+				return Attr{}
+			}
+			a.Value = slog.TimeValue(a.Value.Time().UTC())
+		}
+		if rep != nil {
+			return rep(groups, a)
+		}
+		return a
+	}
+}
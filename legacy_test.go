@@ -0,0 +1,137 @@
+package l4g
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// useStd makes the standard logger write to a new buffer until the end of
+// the test.
+func useStd(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	old := Default()
+	t.Cleanup(func() { SetDefault(old) })
+	buf := &bytes.Buffer{}
+	SetDefault(New(Options{Output: buf, NoColor: true}))
+	return buf
+}
+
+func TestPrint(t *testing.T) {
+	buf := useStd(t)
+	Print("a", 1, 2, "b")
+	Println("a", 1, 2, "b")
+	Printf("n=%d", 3, String("k", "v"))
+	Printj(map[string]any{"k": "v"})
+	out := buf.String()
+	for _, want := range []string{"INFO a1 2b\n", "INFO a 1 2 b\n", "INFO n=3 k=v\n", "INFO  k=v\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output = %q, want %q", out, want)
+		}
+	}
+}
+
+func TestSetFlags(t *testing.T) {
+	buf := useStd(t)
+	if Flags() != log.LstdFlags {
+		t.Errorf("Flags() = %d, want log.LstdFlags", Flags())
+	}
+
+	SetFlags(0)
+	Print("bare")
+	if got := buf.String(); got != "INFO bare\n" {
+		t.Errorf("output = %q, want no time", got)
+	}
+	if Flags() != 0 {
+		t.Errorf("Flags() = %d, want 0", Flags())
+	}
+
+	buf.Reset()
+	SetFlags(log.Ldate | log.Lmicroseconds | log.LUTC | log.Lshortfile)
+	_, _, line, _ := runtime.Caller(0)
+	Print("full")
+	want := regexp.MustCompile(fmt.Sprintf(`^\d{4}/\d\d/\d\d \d\d:\d\d:\d\d\.\d{6} INFO legacy_test\.go:%d full\n$`, line+1))
+	if got := buf.String(); !want.MatchString(got) {
+		t.Errorf("output = %q, want %v", got, want)
+	}
+}
+
+func TestSetFlags_Kept(t *testing.T) {
+	buf := useStd(t)
+	SetFlags(log.Lshortfile)
+	SetFlags(0)
+	SetPrefix("app")
+	Print("bare")
+	if got := buf.String(); got != "INFO [app] bare\n" {
+		t.Errorf("output = %q, want the last flags kept by SetPrefix", got)
+	}
+
+	SetDefault(New(Options{Output: buf}))
+	if Flags() != log.LstdFlags {
+		t.Errorf("Flags() = %d after SetDefault, want log.LstdFlags", Flags())
+	}
+}
+
+func TestSetPrefix(t *testing.T) {
+	buf := useStd(t)
+	SetPrefix("app")
+	if Prefix() != "app" {
+		t.Errorf("Prefix() = %q, want app", Prefix())
+	}
+	Print("hello")
+	if got := buf.String(); !strings.Contains(got, "INFO [app] hello") {
+		t.Errorf("output = %q, want the prefix", got)
+	}
+}
+
+// legacyWrapper logs through Write like the helpers of earlier versions.
+func legacyWrapper(s string) error {
+	return Write(2, LevelWarn, s)
+}
+
+func TestWrite(t *testing.T) {
+	useStd(t)
+	buf := &bytes.Buffer{}
+	SetDefault(New(Options{Output: buf, NoColor: true, AddSource: true}))
+	_, _, line, _ := runtime.Caller(0)
+	if err := legacyWrapper("wrapped"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), fmt.Sprintf("legacy_test.go:%d wrapped", line+1); !strings.Contains(got, want) {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestWrite_Handle(t *testing.T) {
+	useStd(t)
+	buf := &bytes.Buffer{}
+	w := bufio.NewWriter(buf)
+	var hooked []string
+	SetDefault(New(Options{
+		Output:     w,
+		NoColor:    true,
+		FlushLevel: LevelError,
+		StackLevel: LevelError,
+		ErrorHook:  func(ctx context.Context, r Record) { hooked = append(hooked, r.Message) },
+	}).WithContext(context.Background()))
+
+	if err := Write(1, LevelError, "legacy"); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "ERROR legacy") {
+		t.Errorf("output = %q, want the record flushed at the flush level", out)
+	}
+	if fmt.Sprint(hooked) != "[legacy]" {
+		t.Errorf("hooked = %q, want the record passed to the ErrorHook", hooked)
+	}
+	if !strings.Contains(out, `stack="go-slim.dev/l4g.TestWrite_Handle\n`) {
+		t.Errorf("output = %q, want the stack starting at the caller of Write", out)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // A Level is the importance or severity of a log event.
@@ -161,6 +163,29 @@ func (v *LevelVar) Set(l Level) {
 	v.val.Store(int64(l))
 }
 
+// BoostLevel sets the level to level and sets it back to the current level
+// after d, as when enabling debug logging for five minutes during an
+// incident. A d of zero or less keeps level until restore is called.
+// restore sets the level back at once and stops the timer; it may be
+// called more than once. Neither the timer nor restore changes a level
+// set by another call in the meantime.
+func (v *LevelVar) BoostLevel(level Level, d time.Duration) (restore func()) {
+	prev := v.Level()
+	v.Set(level)
+	var once sync.Once
+	revert := func() {
+		once.Do(func() { v.val.CompareAndSwap(int64(level), int64(prev)) })
+	}
+	if d <= 0 {
+		return revert
+	}
+	timer := time.AfterFunc(d, revert)
+	return func() {
+		timer.Stop()
+		revert()
+	}
+}
+
 // String returns a string representation of the LevelVar in the form "LevelVar(level)".
 func (v *LevelVar) String() string {
 	return fmt.Sprintf("LevelVar(%s)", v.Level())
@@ -3,6 +3,7 @@ package l4g
 import (
 	"encoding/json"
 	"testing"
+	"time"
 )
 
 func TestLevel_String(t *testing.T) {
@@ -294,3 +295,32 @@ func TestLevelVar_Concurrent(t *testing.T) {
 		<-done
 	}
 }
+
+func TestLevelVar_BoostLevel(t *testing.T) {
+	lv := NewLevelVar(LevelInfo)
+	lv.BoostLevel(LevelDebug, 10*time.Millisecond)
+	if lv.Level() != LevelDebug {
+		t.Errorf("Level() = %v after BoostLevel, want debug", lv.Level())
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for lv.Level() != LevelInfo && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if lv.Level() != LevelInfo {
+		t.Errorf("Level() = %v after the boost expired, want info", lv.Level())
+	}
+
+	restore := lv.BoostLevel(LevelTrace, time.Hour)
+	restore()
+	restore()
+	if lv.Level() != LevelInfo {
+		t.Errorf("Level() = %v after restore, want info", lv.Level())
+	}
+
+	restore = lv.BoostLevel(LevelDebug, 0)
+	lv.Set(LevelError)
+	restore()
+	if lv.Level() != LevelError {
+		t.Errorf("Level() = %v after restore, want the level set in the meantime", lv.Level())
+	}
+}
@@ -0,0 +1,97 @@
+package l4g
+
+import "slices"
+
+// A LevelMapping is an entry of a [LevelMap]: the severity, as a code and
+// a name, of the levels from Level up to the level of the next entry.
+type LevelMapping struct {
+	Level Level
+	Code  int
+	Name  string
+}
+
+// A LevelMap translates levels into the severities of a sink, such as the
+// syslog severity of the [SyslogHandler] and the [JournalHandler], so that
+// custom levels and nonstandard mappings are configured once and shared
+// by the handlers. Its entries are in increasing level order; the levels
+// below the first entry take its severity.
+//
+// For example, to report warnings as syslog notices:
+//
+//	levels := l4g.SyslogLevels()
+//	levels.Set(l4g.LevelWarn, 5, "notice")
+type LevelMap []LevelMapping
+
+// syslogLevels and cloudLoggingLevels are the maps returned by
+// SyslogLevels and CloudLoggingLevels.
+var (
+	syslogLevels = LevelMap{
+		{LevelTrace, 7, "debug"},
+		{LevelInfo, 6, "info"},
+		{LevelWarn, 4, "warning"},
+		{LevelError, 3, "err"},
+		{LevelPanic, 2, "crit"},
+		{LevelFatal, 1, "alert"},
+	}
+	cloudLoggingLevels = LevelMap{
+		{LevelTrace, 100, "DEBUG"},
+		{LevelInfo, 200, "INFO"},
+		{LevelWarn, 400, "WARNING"},
+		{LevelError, 500, "ERROR"},
+		{LevelPanic, 600, "CRITICAL"},
+	}
+)
+
+// SyslogLevels returns a new LevelMap holding the syslog severities
+// (RFC 5424) of the levels: debug for Trace and Debug, info, warning, err,
+// crit for Panic and alert for Fatal.
+func SyslogLevels() LevelMap {
+	return slices.Clone(syslogLevels)
+}
+
+// CloudLoggingLevels returns a new LevelMap holding the Google Cloud
+// Logging severities of the levels, see [SeverityName].
+func CloudLoggingLevels() LevelMap {
+	return slices.Clone(cloudLoggingLevels)
+}
+
+// Lookup returns the entry of m that applies to level, the zero
+// LevelMapping if m is empty.
+func (m LevelMap) Lookup(level Level) LevelMapping {
+	i, found := slices.BinarySearchFunc(m, level, func(e LevelMapping, l Level) int {
+		return int(e.Level) - int(l)
+	})
+	switch {
+	case found:
+		return m[i]
+	case i > 0:
+		return m[i-1]
+	case len(m) > 0:
+		return m[0]
+	}
+	return LevelMapping{}
+}
+
+// Code returns the severity code of level.
+func (m LevelMap) Code(level Level) int {
+	return m.Lookup(level).Code
+}
+
+// Name returns the severity name of level.
+func (m LevelMap) Name(level Level) string {
+	return m.Lookup(level).Name
+}
+
+// Set maps the levels from level up to the next entry to the given
+// severity, adding an entry for level if m has none.
+func (m *LevelMap) Set(level Level, code int, name string) {
+	e := LevelMapping{level, code, name}
+	i, found := slices.BinarySearchFunc(*m, level, func(e LevelMapping, l Level) int {
+		return int(e.Level) - int(l)
+	})
+	if found {
+		(*m)[i] = e
+		return
+	}
+	*m = slices.Insert(*m, i, e)
+}
@@ -0,0 +1,46 @@
+package l4g
+
+import "testing"
+
+func TestLevelMap(t *testing.T) {
+	m := SyslogLevels()
+	tests := []struct {
+		level Level
+		code  int
+		name  string
+	}{
+		{0, 7, "debug"},
+		{LevelDebug, 7, "debug"},
+		{LevelInfo, 6, "info"},
+		{LevelWarn, 4, "warning"},
+		{LevelFatal, 1, "alert"},
+		{LevelFatal + 3, 1, "alert"},
+	}
+	for _, tt := range tests {
+		if got := m.Lookup(tt.level); got.Code != tt.code || got.Name != tt.name {
+			t.Errorf("Lookup(%d) = %d %s, want %d %s", tt.level, got.Code, got.Name, tt.code, tt.name)
+		}
+	}
+
+	m.Set(LevelDebug, 5, "notice")
+	m.Set(LevelInfo, 6, "information")
+	if got := m.Name(LevelDebug); got != "notice" {
+		t.Errorf("Name(debug) after Set = %s, want notice", got)
+	}
+	if got := m.Name(LevelTrace); got != "debug" {
+		t.Errorf("Name(trace) after Set = %s, want debug", got)
+	}
+	if got := m.Name(LevelInfo); got != "information" {
+		t.Errorf("Name(info) after Set = %s, want information", got)
+	}
+	if got := SyslogLevels().Name(LevelDebug); got != "debug" {
+		t.Errorf("SyslogLevels() changed by Set: Name(debug) = %s", got)
+	}
+
+	if got := CloudLoggingLevels().Code(LevelFatal); got != 600 {
+		t.Errorf("CloudLoggingLevels().Code(fatal) = %d, want 600", got)
+	}
+	if got := (LevelMap{}).Lookup(LevelInfo); got != (LevelMapping{}) {
+		t.Errorf("Lookup on an empty map = %v, want the zero LevelMapping", got)
+	}
+}
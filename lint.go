@@ -0,0 +1,78 @@
+package l4g
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// A LintPolicy controls how a Logger treats the common mistakes in the
+// arguments of a log call, for catching them during development before
+// they silently produce "!BADKEY" attributes in production: a key without
+// a value, an argument that is neither a key nor an Attr, a key repeated
+// within the call, and the keys of the built-in fields, such as "msg",
+// which the handlers write next to the attribute.
+type LintPolicy int
+
+const (
+	// LintOff does not check the arguments. This is the default.
+	LintOff LintPolicy = iota
+	// LintWarn reports the mistakes with FallbackErrorf and logs the call.
+	LintWarn
+	// LintPanic panics on the first mistake.
+	LintPanic
+)
+
+// reservedKeys are the keys of the built-in fields.
+var reservedKeys = []string{TimeKey, LevelKey, MessageKey, PrefixKey, TagsKey, SourceKey}
+
+// lintArgs returns the mistakes in args, which are converted to attributes
+// as described in [Logger.Log].
+func lintArgs(args []any) []string {
+	var problems []string
+	var seen []string
+	for len(args) > 0 {
+		var key string
+		switch x := args[0].(type) {
+		case string:
+			if len(args) == 1 {
+				problems = append(problems, fmt.Sprintf("key %q has no value", x))
+				return problems
+			}
+			key, args = x, args[2:]
+		case Attr:
+			key, args = x.Key, args[1:]
+		default:
+			problems = append(problems, fmt.Sprintf("argument %v of type %T is not a key", x, x))
+			args = args[1:]
+			continue
+		}
+		if key == "" {
+			continue // empty attributes and inline groups
+		}
+		if slices.Contains(seen, key) {
+			problems = append(problems, fmt.Sprintf("key %q is repeated", key))
+		} else {
+			seen = append(seen, key)
+		}
+		if slices.Contains(reservedKeys, key) {
+			problems = append(problems, fmt.Sprintf("key %q is reserved for a built-in field", key))
+		}
+	}
+	return problems
+}
+
+// lint checks args under the LintPolicy of the logger.
+func (l *Logger) lint(args []any) {
+	if l.lintPolicy == LintOff {
+		return
+	}
+	problems := lintArgs(args)
+	if len(problems) == 0 {
+		return
+	}
+	if l.lintPolicy == LintPanic {
+		panic("l4g: lint: " + problems[0])
+	}
+	FallbackErrorf("l4g: lint: %s", strings.Join(problems, "; "))
+}
@@ -0,0 +1,56 @@
+package l4g
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLintArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []any
+		want []string
+	}{
+		{"ok", []any{"a", 1, String("b", "x"), Group("", Int("c", 2))}, nil},
+		{"no value", []any{"a", 1, "b"}, []string{`key "b" has no value`}},
+		{"not a key", []any{errors.New("boom"), "a", 1}, []string{"argument boom of type *errors.errorString is not a key"}},
+		{"repeated", []any{"a", 1, Int("a", 2)}, []string{`key "a" is repeated`}},
+		{"reserved", []any{MessageKey, "x"}, []string{`key "msg" is reserved for a built-in field`}},
+		{"empty attrs", []any{Attr{}, Attr{}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := lintArgs(tt.args)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("lintArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogger_Lint(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true, Lint: LintPanic})
+
+	logger.Info("ok", "a", 1)
+	if !strings.Contains(buf.String(), "a=1") {
+		t.Errorf("Info() output = %q, want a=1", buf.String())
+	}
+
+	func() {
+		defer func() {
+			if p := recover(); p == nil || !strings.Contains(p.(string), `key "a" is repeated`) {
+				t.Errorf("Info() panic = %v, want repeated key", p)
+			}
+		}()
+		logger.Info("dup", "a", 1, "a", 2)
+	}()
+
+	buf.Reset()
+	logger.WithOptions(func(o *Options) { o.Lint = LintWarn }).Info("dup", "a", 1, "a", 2)
+	if !strings.Contains(buf.String(), "dup") {
+		t.Errorf("Info() output = %q, want the record logged under LintWarn", buf.String())
+	}
+}
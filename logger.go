@@ -5,14 +5,26 @@
 package l4g
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"log/slog"
+	"maps"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
 // Options holds configuration options for creating a new Logger.
 type Options struct {
-	// Prefix is the prefix to use for all log messages.
+	// Prefix is the prefix to use for all log messages, to which WithPrefix prepends.
 	Prefix string
 	// Level minimum log level to output
 	Level Level
@@ -22,16 +34,100 @@ type Options struct {
 	Handler Handler
 	// ReplaceAttr function to rewrite attributes before logging
 	ReplaceAttr func(groups []string, attr Attr) Attr
+	// ReplaceGroup function to rewrite group attributes before expansion
+	ReplaceGroup func(groups []string, attr Attr) Attr
 	// TimeFormat time format string (default: time.StampMilli)
 	TimeFormat string
 	// LevelFormat level format (Default: nil)
 	LevelFormat func(Level) string
 	// PrefixFormat prefix format (Default: nil)
 	PrefixFormat func(string) string
+	// ColorMode parts of a line to color (Default: ColorAll)
+	ColorMode ColorMode
+	// LevelColors per-level color overrides (Default: nil)
+	LevelColors map[Level]uint8
+	// PartColors per-part color overrides (Default: nil)
+	PartColors map[PartKind]uint8
+	// ToneColors colors of the tones of Highlight, Warning and OK attributes (Default: nil)
+	ToneColors map[Tone]uint8
+	// LevelIcons glyphs written before level names (Default: nil)
+	LevelIcons map[Level]string
+	// IconsOnly write level icons instead of names (Default: false)
+	IconsOnly bool
+	// KeyFormat attribute key format, e.g. SnakeCase (Default: nil)
+	KeyFormat func(string) string
+	// KeyConflict policy for attributes using built-in keys (default: KeyConflictRename)
+	KeyConflict KeyConflictPolicy
 	// Output destination (default: os.Stderr)
 	Output io.Writer
 	// NoColor disable color output (default: false)
 	NoColor bool
+	// Elapsed add the time elapsed since ElapsedSince to every record (default: false)
+	Elapsed bool
+	// ElapsedSince reference time of Elapsed, kept by the loggers derived with WithOptions (default: the creation of the logger by New)
+	ElapsedSince time.Time
+	// EmitTime add the time each record is written besides its event time (default: false)
+	EmitTime bool
+	// SortAttrs write attributes in key order (default: false)
+	SortAttrs bool
+	// Canonical write JSON records in a form that compresses well, as by HandlerOptions.Canonical (default: false)
+	Canonical bool
+	// MaxAttrs limit of the attributes written per record (default: 0, no limit)
+	MaxAttrs int
+	// IncludeKeys keys of the only attributes written (default: nil, all)
+	IncludeKeys []string
+	// ExcludeKeys keys of the attributes not written (default: nil)
+	ExcludeKeys []string
+	// MaxRecordSize limit in bytes of the line written per record (default: 0, no limit)
+	MaxRecordSize int
+	// ValidUTF8 replace invalid UTF-8 with U+FFFD in the lines written (default: false)
+	ValidUTF8 bool
+	// StripControl remove control characters other than color escapes from the lines written (default: false)
+	StripControl bool
+	// EscapeNonASCII write non-ASCII characters as \uXXXX escapes (default: false)
+	EscapeNonASCII bool
+	// ErrorTree write wrapped and joined errors as a group of their causes (default: false)
+	ErrorTree bool
+	// Severity write JSON levels as Google Cloud Logging severities (default: false)
+	Severity bool
+	// FieldNames JSON keys for the built-in fields (default: time, level, msg, prefix)
+	FieldNames FieldNames
+	// FlattenGroups write JSON groups as dotted keys (default: false)
+	FlattenGroups bool
+	// AddSource add the file and line of the log call (default: false)
+	AddSource bool
+	// SourcePath how source file paths are shortened (default: SourcePathShort)
+	SourcePath SourcePathMode
+	// SourceRoot prefix trimmed from source file paths (default: "")
+	SourceRoot string
+	// SourceFunc include the calling function in the source (default: false)
+	SourceFunc bool
+	// SourceFormat custom source rendering (default: nil)
+	SourceFormat func(*slog.Source) string
+	// GoroutineID add the id of the logging goroutine to every record (default: false)
+	GoroutineID bool
+	// Kubernetes add the pod metadata returned by KubernetesAttr to every record (default: false)
+	Kubernetes bool
+	// BadKey policy for arguments not paired with a key (default: BadKeyKeep)
+	BadKey BadKeyPolicy
+	// Lint policy for mistakes in the arguments of log calls, such as repeated keys, for development (default: LintOff)
+	Lint LintPolicy
+	// Header write a record describing the stream and the binary when the logger is created, whatever the level (default: false)
+	Header bool
+	// FlushTimeout longest wait for Flush before Fatal exits or Panic panics, negative to skip it (default: 5s)
+	FlushTimeout time.Duration
+	// NamespaceLevels minimum levels of the loggers returned by WithNamespace, by namespace (Default: nil)
+	NamespaceLevels map[string]Leveler
+	// StackLevel lowest level of the records carrying the stack of their goroutine, above LevelFatal for none (default: LevelPanic)
+	StackLevel Level
+	// WriteTimeout longest a write to Output may block, as by TimeoutWriter; ignored with Handler (default: none)
+	WriteTimeout time.Duration
+	// FlushLevel lowest level of the records after which Output is flushed, if it buffers them as described by Flusher, so that they are written before a crash (default: none)
+	FlushLevel Level
+	// Clock source of the times of records and of Heartbeat and StartProgress (default: the system clock)
+	Clock Clock
+	// ErrorHook function called with the context and each record at LevelError or above logged by a logger derived with WithContext, e.g. SpanEventHook of go-slim.dev/l4g/otel to add the record as an event to the span of the context (default: nil)
+	ErrorHook func(ctx context.Context, r Record)
 }
 
 // New creates a new Logger that writes to the given io.Writer.
@@ -41,35 +137,286 @@ func New(opts Options) *Logger {
 	if opts.Level == 0 {
 		opts.Level = LevelInfo
 	}
-	if opts.NewHandlerFunc == nil {
-		opts.NewHandlerFunc = NewSimpleHandler
+	if opts.FlushTimeout == 0 {
+		opts.FlushTimeout = 5 * time.Second
+	}
+	if opts.StackLevel == 0 {
+		opts.StackLevel = LevelPanic
+	}
+	if opts.ElapsedSince.IsZero() {
+		opts.ElapsedSince = clockOrSystem(opts.Clock).Now()
+	}
+	// Resolve the default factory now, so that the logger keeps its
+	// format when SetDefaultHandlerFactory is called later.
+	defaultFactory := opts.NewHandlerFunc == nil
+	if defaultFactory {
+		opts.NewHandlerFunc = defaultHandlerFunc()
 	}
 	l := &Logger{
-		level:   NewLevelVar(opts.Level.Real()),
-		output:  NewOutputVar(opts.Output),
-		handler: opts.Handler,
-	}
-	if opts.Handler == nil {
-		l.handler = opts.NewHandlerFunc(HandlerOptions{
-			Prefix:       opts.Prefix,
-			Level:        l.level,
-			Output:       l.output,
-			ReplaceAttr:  opts.ReplaceAttr,
-			TimeFormat:   opts.TimeFormat,
-			LevelFormat:  opts.LevelFormat,
-			PrefixFormat: opts.PrefixFormat,
-			NoColor:      opts.NoColor,
-		})
+		level:          NewLevelVar(opts.Level.Real()),
+		output:         NewOutputVar(opts.Output),
+		goroutineID:    opts.GoroutineID,
+		flushTimeout:   opts.FlushTimeout,
+		badKey:         opts.BadKey,
+		lintPolicy:     opts.Lint,
+		stackLevel:     opts.StackLevel,
+		clock:          clockOrSystem(opts.Clock),
+		flushLevel:     opts.FlushLevel,
+		errorHook:      opts.ErrorHook,
+		pushed:         new(pushStack),
+		opts:           &opts,
+		defaultFactory: defaultFactory,
+		build:          buildHandler,
+	}
+	l.handler = l.build(&opts, l.level, l.output)
+	if opts.Header && !l.output.Discard() {
+		l.handle(headerRecord(l.handler, &opts))
 	}
 	return l
 }
 
+// NewStdSplit creates a new Logger that writes records below LevelWarn to
+// os.Stdout and the others to os.Stderr, formatted alike, since containers
+// and CI systems treat the two streams differently.
+// opts.Output and opts.Handler are ignored; SetOutput replaces os.Stdout only.
+func NewStdSplit(opts Options) *Logger {
+	opts.Output = os.Stdout
+	opts.Handler = nil
+	l := New(opts)
+	l.build = buildSplitHandler
+	l.handler = l.build(l.opts, l.level, l.output)
+	return l
+}
+
+// buildHandler creates the handler of a logger created by New.
+func buildHandler(opts *Options, level Leveler, output io.Writer) Handler {
+	h := opts.Handler
+	if h == nil {
+		h = opts.newHandler(level, output)
+	}
+	return opts.enrich(h)
+}
+
+// buildSplitHandler creates the handler of a logger created by NewStdSplit.
+func buildSplitHandler(opts *Options, level Leveler, output io.Writer) Handler {
+	return &splitHandler{
+		below: opts.enrich(opts.newHandler(level, output)),
+		above: opts.enrich(opts.newHandler(level, os.Stderr)),
+		level: LevelWarn,
+	}
+}
+
+// defaultHandlerFactory is the factory set by SetDefaultHandlerFactory.
+var defaultHandlerFactory atomic.Pointer[func(HandlerOptions) Handler]
+
+// SetDefaultHandlerFactory sets the function creating the handler of the
+// loggers whose Options have no NewHandlerFunc, in place of
+// [NewSimpleHandler], so that a single call at startup, as
+//
+//	l4g.SetDefaultHandlerFactory(l4g.NewJSONHandler)
+//
+// switches the default logger and the channels created afterwards to
+// JSON. The default logger is rebuilt with f unless it has its own
+// NewHandlerFunc or Handler; loggers already created keep the factory
+// they were created with, even when rebuilt by WithOptions, as by
+// SetPrefix or ApplyConfig. If f is nil, NewSimpleHandler is restored.
+func SetDefaultHandlerFactory(f func(HandlerOptions) Handler) {
+	if f == nil {
+		defaultHandlerFactory.Store(nil)
+	} else {
+		defaultHandlerFactory.Store(&f)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if std.defaultFactory && std.opts.Handler == nil {
+		l := std.WithOptions(func(o *Options) { o.NewHandlerFunc = defaultHandlerFunc() })
+		l.defaultFactory = true
+		std = l
+	}
+}
+
+// defaultHandlerFunc returns the factory set by SetDefaultHandlerFactory,
+// or NewSimpleHandler.
+func defaultHandlerFunc() func(HandlerOptions) Handler {
+	if f := defaultHandlerFactory.Load(); f != nil {
+		return *f
+	}
+	return NewSimpleHandler
+}
+
+// newHandler creates the handler described by opts, writing to output.
+func (opts *Options) newHandler(level Leveler, output io.Writer) Handler {
+	newHandler := opts.NewHandlerFunc
+	if newHandler == nil {
+		newHandler = NewSimpleHandler
+	}
+	ho := HandlerOptions{
+		Prefix:         opts.Prefix,
+		Level:          level,
+		Output:         TimeoutWriter(output, opts.WriteTimeout),
+		ReplaceAttr:    opts.ReplaceAttr,
+		ReplaceGroup:   opts.ReplaceGroup,
+		TimeFormat:     opts.TimeFormat,
+		LevelFormat:    opts.LevelFormat,
+		PrefixFormat:   opts.PrefixFormat,
+		ColorMode:      opts.ColorMode,
+		LevelColors:    opts.LevelColors,
+		PartColors:     opts.PartColors,
+		ToneColors:     opts.ToneColors,
+		LevelIcons:     opts.LevelIcons,
+		IconsOnly:      opts.IconsOnly,
+		KeyFormat:      opts.KeyFormat,
+		KeyConflict:    opts.KeyConflict,
+		NoColor:        opts.NoColor,
+		Elapsed:        opts.Elapsed,
+		ElapsedSince:   opts.ElapsedSince,
+		EmitTime:       opts.EmitTime,
+		SortAttrs:      opts.SortAttrs,
+		Canonical:      opts.Canonical,
+		MaxAttrs:       opts.MaxAttrs,
+		IncludeKeys:    opts.IncludeKeys,
+		ExcludeKeys:    opts.ExcludeKeys,
+		MaxRecordSize:  opts.MaxRecordSize,
+		ValidUTF8:      opts.ValidUTF8,
+		StripControl:   opts.StripControl,
+		EscapeNonASCII: opts.EscapeNonASCII,
+		ErrorTree:      opts.ErrorTree,
+		Severity:       opts.Severity,
+		FieldNames:     opts.FieldNames,
+		FlattenGroups:  opts.FlattenGroups,
+		AddSource:      opts.AddSource,
+		SourcePath:     opts.SourcePath,
+		SourceRoot:     opts.SourceRoot,
+		SourceFunc:     opts.SourceFunc,
+		SourceFormat:   opts.SourceFormat,
+	}
+	return newHandler(ho)
+}
+
+// enrich adds the attributes enabled by opts to h.
+func (opts *Options) enrich(h Handler) Handler {
+	if opts.Kubernetes {
+		if a, ok := KubernetesAttr(); ok {
+			h = h.WithAttrs([]Attr{a})
+		}
+	}
+	return h
+}
+
+// splitHandler passes records below level to one handler and the others
+// to another.
+type splitHandler struct {
+	below, above Handler
+	level        Level
+}
+
+func (h *splitHandler) Enabled(level Level) bool {
+	if level >= h.level {
+		return h.above.Enabled(level)
+	}
+	return h.below.Enabled(level)
+}
+
+func (h *splitHandler) Handle(r Record) error {
+	level := r.Level
+	if l, ok := r.levelOverride(); ok {
+		level = l
+	}
+	if level >= h.level {
+		return h.above.Handle(r)
+	}
+	return h.below.Handle(r)
+}
+
+func (h *splitHandler) Flush() error {
+	return errors.Join(flush(h.below), flush(h.above))
+}
+
+func (h *splitHandler) Compact() {
+	compact(h.below)
+	compact(h.above)
+}
+
+func (h *splitHandler) WithAttrs(attrs []Attr) Handler {
+	return &splitHandler{h.below.WithAttrs(attrs), h.above.WithAttrs(attrs), h.level}
+}
+
+func (h *splitHandler) WithGroup(name string) Handler {
+	return &splitHandler{h.below.WithGroup(name), h.above.WithGroup(name), h.level}
+}
+
+func (h *splitHandler) WithPrefix(prefix string) Handler {
+	return &splitHandler{h.below.WithPrefix(prefix), h.above.WithPrefix(prefix), h.level}
+}
+
+// NewLogLogger returns a new [log.Logger] such that each call to its Output method
+// dispatches a Record to the specified handler. The logger acts as a bridge from
+// the older log API to newer structured logging handlers.
+func NewLogLogger(h Handler, level Level) *log.Logger {
+	return log.New(&handlerWriter{h, level}, "", 0)
+}
+
+// handlerWriter is an io.Writer that calls a Handler.
+// It is used to link the default log.Logger to the default Logger.
+type handlerWriter struct {
+	h     Handler
+	level Leveler
+}
+
+// Write turns buf, minus a trailing newline, into the message of a record.
+func (w *handlerWriter) Write(buf []byte) (int, error) {
+	level := w.level.Level()
+	if !w.h.Enabled(level) {
+		return 0, nil
+	}
+
+	// Remove final newline.
+	origLen := len(buf) // Report that the entire buf was written.
+	if len(buf) > 0 && buf[len(buf)-1] == '\n' {
+		buf = buf[:len(buf)-1]
+	}
+	r := NewRecord(time.Now(), level, string(buf))
+	var pcs [1]uintptr
+	// skip [runtime.Callers, w.Write, Logger.Output, log.Print]
+	runtime.Callers(4, pcs[:])
+	r.PC = pcs[0]
+	return origLen, w.h.Handle(r)
+}
+
 // Logger represents a logger instance that outputs log messages through a handler.
 // It is safe for concurrent use by multiple goroutines.
 type Logger struct {
-	level   *LevelVar  // Minimum log level, can be changed dynamically
-	output  *OutputVar // Output destination, can be changed dynamically
-	handler Handler    // Handler for processing and formatting log records
+	level        *LevelVar     // Minimum log level, can be changed dynamically
+	output       *OutputVar    // Output destination, can be changed dynamically
+	handler      Handler       // Handler for processing and formatting log records
+	goroutineID  bool          // Add the goroutine id to every record
+	tags         []string      // Tags of every record, shared and never modified
+	flushTimeout time.Duration // Longest wait for Flush before exiting
+	badKey       BadKeyPolicy  // Treatment of arguments without a key
+	lintPolicy   LintPolicy    // Treatment of mistakes in the arguments
+	stackLevel   Level         // Lowest level of the records carrying a stack, 0 for none
+	forceLevel   Level         // Lowest level enabled whatever the handler says, 0 for none
+	namespace    string        // Innermost namespace set with WithNamespace
+	nsLevel      Leveler       // Minimum level of the namespace, nil for none
+	clock        Clock         // Source of the times of records
+	flushLevel   Level         // Lowest level of the records flushing the output, 0 for none
+	pushed       *pushStack    // Attributes added with Push, nil for Nop
+	flags        *legacyFlags  // Flags set by SetFlags, nil for none
+
+	// defaultFactory reports that opts.NewHandlerFunc is the default
+	// factory at the creation of the logger, the options having none.
+	defaultFactory bool
+
+	// errorHook is called with ctx, the context set with WithContext,
+	// for the records at LevelError or above.
+	errorHook func(context.Context, Record)
+	ctx       context.Context
+
+	// opts and build create the handler again for WithOptions,
+	// which then replays derive, the With calls made since.
+	opts   *Options
+	build  func(opts *Options, level Leveler, output io.Writer) Handler
+	derive []func(Handler) Handler
 }
 
 // Output returns the current output destination for the logger.
@@ -94,12 +441,139 @@ func (l *Logger) SetLevel(lvl Level) {
 	l.level.Set(lvl)
 }
 
+// WithLevelOverride sets the minimum log level of the logger until restore
+// is called, which sets back the previous one unless the level was changed
+// in the meantime. It is typically deferred:
+//
+//	defer logger.WithLevelOverride(l4g.LevelDebug)()
+//
+// Use [LevelVar.BoostLevel] on a shared LevelVar for an override that
+// reverts by itself after a while.
+func (l *Logger) WithLevelOverride(level Level) (restore func()) {
+	return l.level.BoostLevel(level, 0)
+}
+
 // Enabled reports whether the logger is enabled for the given log level.
 // It returns true if a log message at the given level would be output.
+// Levels at or above one set with [ForceLevel] are always enabled.
 func (l *Logger) Enabled(level Level) bool {
+	if l.forceLevel > 0 && level >= l.forceLevel {
+		return true
+	}
+	if l.nsLevel != nil && level < l.nsLevel.Level() {
+		return false
+	}
 	return l.handler.Enabled(level)
 }
 
+// nopLogger is the logger returned by Nop and by If(false).
+var nopLogger = &Logger{
+	level:        NewLevelVar(LevelInfo),
+	output:       NewOutputVar(io.Discard),
+	handler:      DiscardHandler,
+	flushTimeout: -1,
+	clock:        systemClock{},
+}
+
+// Nop returns a Logger that discards all output without formatting
+// anything. It is intended for tests and for APIs taking an optional
+// logger. All calls return the same Logger.
+func Nop() *Logger {
+	return nopLogger
+}
+
+// If returns the receiver when enabled is true, and a logger discarding all
+// output otherwise. It allows verbose code paths to be guarded by a flag
+// without wrapping every call in an if statement:
+//
+//	l.If(cfg.TraceSQL).Debug("query", "sql", q)
+//
+// The disabled logger is shared, so calling If does not allocate.
+func (l *Logger) If(enabled bool) *Logger {
+	if enabled {
+		return l
+	}
+	return nopLogger
+}
+
+// Wrap returns a new Logger whose handler is f applied to the handler of
+// l, for middleware observing or changing the records of an existing
+// logger. f is applied again when the handler is rebuilt by
+// [Logger.WithOptions], so it must not keep state meant to be shared
+// with the handler it wraps.
+//
+//	counted := l.Wrap(func(h l4g.Handler) l4g.Handler {
+//		return &countingHandler{Handler: h}
+//	})
+func (l *Logger) Wrap(f func(Handler) Handler) *Logger {
+	return l.with(f)
+}
+
+// with returns a copy of the logger whose handler is derived from the
+// handler of l by f.
+func (l *Logger) with(f func(Handler) Handler) *Logger {
+	l2 := l.clone()
+	l2.handler = f(l.handler)
+	l2.derive = append(l.derive[:len(l.derive):len(l.derive)], f)
+	return l2
+}
+
+// WithOptions returns a new Logger created with the options of l modified
+// by f, keeping the attributes, groups, prefix and tags added to l. When f
+// is called, Level and Output hold the current level and output of l; the
+// new logger shares them with l, so that SetLevel and SetOutput affect
+// both, unless f changes them.
+//
+//	verbose := l.WithOptions(func(o *l4g.Options) {
+//		o.Level = l4g.LevelDebug
+//		o.AddSource = true
+//	})
+func (l *Logger) WithOptions(f func(*Options)) *Logger {
+	if l.opts == nil {
+		return l // Nop
+	}
+	opts := *l.opts
+	opts.Level = l.level.Level()
+	opts.Output = l.output
+	if l.defaultFactory {
+		opts.NewHandlerFunc = nil
+	}
+	f(&opts)
+
+	l2 := l.clone()
+	// Keep the factory resolved by New unless f sets another one.
+	l2.defaultFactory = l.defaultFactory && opts.NewHandlerFunc == nil
+	if l2.defaultFactory {
+		opts.NewHandlerFunc = l.opts.NewHandlerFunc
+	}
+	if opts.Level != l.level.Level() {
+		l2.level = NewLevelVar(opts.Level.Real())
+	}
+	if opts.Output != io.Writer(l.output) {
+		l2.output = NewOutputVar(opts.Output)
+	}
+	if opts.FlushTimeout == 0 {
+		opts.FlushTimeout = 5 * time.Second
+	}
+	l2.goroutineID = opts.GoroutineID
+	l2.flushTimeout = opts.FlushTimeout
+	l2.badKey = opts.BadKey
+	l2.lintPolicy = opts.Lint
+	l2.stackLevel = opts.StackLevel
+	l2.clock = clockOrSystem(opts.Clock)
+	l2.flushLevel = opts.FlushLevel
+	l2.errorHook = opts.ErrorHook
+	if l.namespace != "" {
+		l2.nsLevel = opts.NamespaceLevels[l.namespace]
+	}
+	l2.opts = &opts
+	l2.handler = l.build(&opts, l2.level, l2.output)
+	for _, f := range l.derive {
+		l2.handler = f(l2.handler)
+	}
+	return l2
+}
+
 // WithAttrs returns a new Logger that includes the given attributes in all subsequent log output.
 // The attributes are added to every log record produced by the returned logger.
 // args can be key-value pairs (string, any, string, any, ...) or Attr values.
@@ -107,11 +581,42 @@ func (l *Logger) WithAttrs(args ...any) *Logger {
 	if len(args) == 0 {
 		return l
 	}
-	return &Logger{
-		level:   l.level,
-		output:  l.output,
-		handler: l.handler.WithAttrs(argsToAttrSlice(args)),
+	attrs := l.argsToAttrs(args)
+	return l.with(func(h Handler) Handler { return h.WithAttrs(attrs) })
+}
+
+// NamespaceTagPrefix starts the tag added by [Logger.WithNamespace]
+// to the records of a namespace, followed by its name.
+const NamespaceTagPrefix = "ns:"
+
+// WithNamespace returns a new Logger for handing to a dependency, such as
+// a third-party library, whose records carry their attributes in the
+// group name and the tag NamespaceTagPrefix+name, so that handlers can
+// filter them. Their minimum level is also the level of name in
+// [Options.NamespaceLevels], if any, to keep the noise of the dependency
+// under control.
+func (l *Logger) WithNamespace(name string) *Logger {
+	if name == "" {
+		return l
+	}
+	l2 := l.WithGroup(name).WithTags(NamespaceTagPrefix + name)
+	l2.namespace = name
+	l2.nsLevel = nil
+	if l.opts != nil {
+		l2.nsLevel = l.opts.NamespaceLevels[name]
 	}
+	return l2
+}
+
+// WithTags returns a new Logger that adds the given tags to the tags of
+// all subsequent records. See [Record.Tags].
+func (l *Logger) WithTags(tags ...string) *Logger {
+	if len(tags) == 0 {
+		return l
+	}
+	l2 := l.clone()
+	l2.tags = append(slices.Clip(l.tags), tags...)
+	return l2
 }
 
 // WithPrefix returns a new Logger that includes the given prefix in all subsequent log output.
@@ -120,11 +625,7 @@ func (l *Logger) WithPrefix(prefix string) *Logger {
 	if prefix == "" {
 		return l
 	}
-	return &Logger{
-		level:   l.level,
-		output:  l.output,
-		handler: l.handler.WithPrefix(prefix),
-	}
+	return l.with(func(h Handler) Handler { return h.WithPrefix(prefix) })
 }
 
 // WithGroup returns a new Logger that starts a group for all subsequent log output.
@@ -134,11 +635,23 @@ func (l *Logger) WithGroup(name string) *Logger {
 	if name == "" {
 		return l
 	}
-	return &Logger{
-		level:   l.level,
-		output:  l.output,
-		handler: l.handler.WithGroup(name),
-	}
+	return l.with(func(h Handler) Handler { return h.WithGroup(name) })
+}
+
+// WithGroupAttrs returns a new Logger that opens the group name, like
+// WithGroup, and adds the given attributes inside it, like WithAttrs.
+// It is a shorthand for l.WithGroup(name).WithAttrs(args...).
+func (l *Logger) WithGroupAttrs(name string, args ...any) *Logger {
+	attrs := l.argsToAttrs(args)
+	return l.with(func(h Handler) Handler {
+		if name != "" {
+			h = h.WithGroup(name)
+		}
+		if len(attrs) > 0 {
+			h = h.WithAttrs(attrs)
+		}
+		return h
+	})
 }
 
 // Log outputs a log record at the specified level with the given message and optional attributes.
@@ -148,23 +661,75 @@ func (l *Logger) Log(level Leveler, msg string, args ...any) {
 	l.log(level.Level(), msg, args)
 }
 
+// LogRecord outputs r, such as an event replayed from a buffer or imported
+// from another system, keeping its time and attributes.
+// It returns immediately if the level of r is disabled.
+func (l *Logger) LogRecord(r Record) {
+	if l.output.Discard() || !l.Enabled(r.Level) {
+		return
+	}
+	l.handle(r)
+}
+
+// Write outputs line, an already formatted message such as a line of the
+// output of a child process or relayed from another logger, as the
+// message of a record at the given level. A trailing newline is removed,
+// and line is neither parsed nor formatted. Unlike the other methods, it
+// returns the error of the handler.
+func (l *Logger) Write(level Level, line []byte) error {
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	return l.write(2, level, string(line))
+}
+
 // Logf outputs a formatted log record at the specified level.
 // It supports both [fmt.Printf]-style formatting and optional structured attributes.
 // args can mix format arguments with Attr values for structured logging.
-func (l *Logger) Logf(level Level, format string, args ...any) {
-	l.logf(level, format, args)
+func (l *Logger) Logf(level Leveler, format string, args ...any) {
+	l.logf(level.Level(), format, args)
 }
 
 // Logj outputs a log record at the specified level with structured key-value pairs from a map.
-// The map is converted to structured attributes in the log output.
-func (l *Logger) Logj(level Level, j map[string]any) {
-	l.logj(level, j)
+// The map is converted to structured attributes in the log output, sorted by key
+// so that equal maps always produce the same output. Nested maps become groups;
+// other values, such as slices, are logged as with [Any], slices being written
+// as arrays by the [JSONHandler].
+func (l *Logger) Logj(level Leveler, j map[string]any) {
+	l.logj(level.Level(), "", j)
+}
+
+// Logjm is like [Logger.Logj] but with a message.
+func (l *Logger) Logjm(level Leveler, msg string, j map[string]any) {
+	l.logj(level.Level(), msg, j)
+}
+
+// Logt outputs a log record at the specified level whose message is built from
+// template by replacing each {key} placeholder with the value of the attribute
+// of that key. The attributes are still logged as structured attributes.
+// Placeholders without a matching attribute are left unchanged, and "{{"
+// produces a literal "{".
+// args can be key-value pairs (string, any, string, any, ...) or Attr values.
+func (l *Logger) Logt(level Leveler, template string, args ...any) {
+	l.logt(level.Level(), template, args)
+}
+
+// LogString outputs a log record at the level named by level, such as
+// "warn", for levels taken from configuration or forwarded by another
+// process. Names are those accepted by [Level.UnmarshalText], ignoring case.
+// If level is not a level name, the record is logged at LevelInfo and the
+// parse error is returned.
+// args can be key-value pairs (string, any, string, any, ...) or Attr values.
+func (l *Logger) LogString(level, msg string, args ...any) error {
+	lvl := LevelInfo
+	err := lvl.parse(level) // leaves lvl unchanged on error
+	l.log(lvl, msg, args)
+	return err
 }
 
 // Trace logs a message at trace level with optional structured attributes.
 // args can be key-value pairs (string, any, string, any, ...) or Attr values.
 func (l *Logger) Trace(msg string, args ...any) {
-	l.Log(LevelTrace, msg, args...)
+	l.log(LevelTrace, msg, args)
 }
 
 // Tracef logs a formatted message at trace level.
@@ -173,9 +738,20 @@ func (l *Logger) Tracef(format string, args ...any) {
 	l.logf(LevelTrace, format, args)
 }
 
+// Tracet logs a message at trace level built from template.
+// Placeholders are resolved as described in [Logger.Logt].
+func (l *Logger) Tracet(template string, args ...any) {
+	l.logt(LevelTrace, template, args)
+}
+
 // Tracej logs a message at trace level with structured key-value pairs from a map.
 func (l *Logger) Tracej(j map[string]any) {
-	l.logj(LevelTrace, j)
+	l.logj(LevelTrace, "", j)
+}
+
+// Tracejm logs a message at trace level with structured key-value pairs from a map.
+func (l *Logger) Tracejm(msg string, j map[string]any) {
+	l.logj(LevelTrace, msg, j)
 }
 
 // Debug logs a message at debug level with optional structured attributes.
@@ -190,9 +766,20 @@ func (l *Logger) Debugf(format string, args ...any) {
 	l.logf(LevelDebug, format, args)
 }
 
+// Debugt logs a message at debug level built from template.
+// Placeholders are resolved as described in [Logger.Logt].
+func (l *Logger) Debugt(template string, args ...any) {
+	l.logt(LevelDebug, template, args)
+}
+
 // Debugj logs a message at debug level with structured key-value pairs from a map.
 func (l *Logger) Debugj(j map[string]any) {
-	l.logj(LevelDebug, j)
+	l.logj(LevelDebug, "", j)
+}
+
+// Debugjm logs a message at debug level with structured key-value pairs from a map.
+func (l *Logger) Debugjm(msg string, j map[string]any) {
+	l.logj(LevelDebug, msg, j)
 }
 
 // Info logs a message at info level with optional structured attributes.
@@ -207,9 +794,20 @@ func (l *Logger) Infof(format string, args ...any) {
 	l.logf(LevelInfo, format, args)
 }
 
+// Infot logs a message at info level built from template.
+// Placeholders are resolved as described in [Logger.Logt].
+func (l *Logger) Infot(template string, args ...any) {
+	l.logt(LevelInfo, template, args)
+}
+
 // Infoj logs a message at info level with structured key-value pairs from a map.
 func (l *Logger) Infoj(j map[string]any) {
-	l.logj(LevelInfo, j)
+	l.logj(LevelInfo, "", j)
+}
+
+// Infojm logs a message at info level with structured key-value pairs from a map.
+func (l *Logger) Infojm(msg string, j map[string]any) {
+	l.logj(LevelInfo, msg, j)
 }
 
 // Warn logs a message at warn level with optional structured attributes.
@@ -224,9 +822,20 @@ func (l *Logger) Warnf(format string, args ...any) {
 	l.logf(LevelWarn, format, args)
 }
 
+// Warnt logs a message at warn level built from template.
+// Placeholders are resolved as described in [Logger.Logt].
+func (l *Logger) Warnt(template string, args ...any) {
+	l.logt(LevelWarn, template, args)
+}
+
 // Warnj logs a message at warn level with structured key-value pairs from a map.
 func (l *Logger) Warnj(j map[string]any) {
-	l.logj(LevelWarn, j)
+	l.logj(LevelWarn, "", j)
+}
+
+// Warnjm logs a message at warn level with structured key-value pairs from a map.
+func (l *Logger) Warnjm(msg string, j map[string]any) {
+	l.logj(LevelWarn, msg, j)
 }
 
 // Error logs a message at error level with optional structured attributes.
@@ -241,15 +850,27 @@ func (l *Logger) Errorf(format string, args ...any) {
 	l.logf(LevelError, format, args)
 }
 
+// Errort logs a message at error level built from template.
+// Placeholders are resolved as described in [Logger.Logt].
+func (l *Logger) Errort(template string, args ...any) {
+	l.logt(LevelError, template, args)
+}
+
 // Errorj logs a message at error level with structured key-value pairs from a map.
 func (l *Logger) Errorj(j map[string]any) {
-	l.logj(LevelError, j)
+	l.logj(LevelError, "", j)
+}
+
+// Errorjm logs a message at error level with structured key-value pairs from a map.
+func (l *Logger) Errorjm(msg string, j map[string]any) {
+	l.logj(LevelError, msg, j)
 }
 
 // Panic logs a message at panic level with optional structured attributes, then panics.
 // args can be key-value pairs (string, any, string, any, ...) or Attr values.
 func (l *Logger) Panic(msg string, args ...any) {
 	l.log(LevelPanic, msg, args)
+	l.flushBeforeExit()
 	panic(msg)
 }
 
@@ -257,25 +878,40 @@ func (l *Logger) Panic(msg string, args ...any) {
 // It supports [fmt.Printf]-style formatting and optional structured attributes.
 func (l *Logger) Panicf(format string, args ...any) {
 	l.logf(LevelPanic, format, args)
+	l.flushBeforeExit()
+	panic(sprintf(format, args))
+}
 
-	_, anies := splitAttrs(args)
-	msg := format
-	if len(anies) > 0 {
-		msg = fmt.Sprintf(format, anies...)
+// Panict logs a message at panic level built from template, then panics.
+// Placeholders are resolved as described in [Logger.Logt].
+func (l *Logger) Panict(template string, args ...any) {
+	msg, logged := l.logt(LevelPanic, template, args)
+	if !logged {
+		msg = interpolate(template, argsToAttrSlice(args))
 	}
+	l.flushBeforeExit()
 	panic(msg)
 }
 
 // Panicj logs a message at panic level with structured key-value pairs from a map, then panics.
 func (l *Logger) Panicj(j map[string]any) {
-	l.logj(LevelPanic, j)
+	l.logj(LevelPanic, "", j)
+	l.flushBeforeExit()
 	panic(j)
 }
 
+// Panicjm logs a message at panic level with structured key-value pairs from a map, then panics.
+func (l *Logger) Panicjm(msg string, j map[string]any) {
+	l.logj(LevelPanic, msg, j)
+	l.flushBeforeExit()
+	panic(msg)
+}
+
 // Fatal logs a message at fatal level with optional structured attributes, then calls os.Exit(1).
 // args can be key-value pairs (string, any, string, any, ...) or Attr values.
 func (l *Logger) Fatal(msg string, args ...any) {
 	l.log(LevelFatal, msg, args)
+	l.flushBeforeExit()
 	OsExiter(1)
 }
 
@@ -283,28 +919,163 @@ func (l *Logger) Fatal(msg string, args ...any) {
 // It supports [fmt.Printf]-style formatting and optional structured attributes.
 func (l *Logger) Fatalf(format string, args ...any) {
 	l.logf(LevelFatal, format, args)
+	l.flushBeforeExit()
+	OsExiter(1)
+}
+
+// Fatalt logs a message at fatal level built from template, then calls os.Exit(1).
+// Placeholders are resolved as described in [Logger.Logt].
+func (l *Logger) Fatalt(template string, args ...any) {
+	l.logt(LevelFatal, template, args)
+	l.flushBeforeExit()
 	OsExiter(1)
 }
 
 // Fatalj logs a message at fatal level with structured key-value pairs from a map, then calls os.Exit(1).
 func (l *Logger) Fatalj(j map[string]any) {
-	l.logj(LevelFatal, j)
+	l.logj(LevelFatal, "", j)
+	l.flushBeforeExit()
+	OsExiter(1)
+}
+
+// Fataljm logs a message at fatal level with structured key-value pairs from a map, then calls os.Exit(1).
+func (l *Logger) Fataljm(msg string, j map[string]any) {
+	l.logj(LevelFatal, msg, j)
+	l.flushBeforeExit()
 	OsExiter(1)
 }
 
+// Flush flushes the handler of the logger and its output, if they buffer
+// records, as described by [Flusher]. It should be called before the
+// program exits; Fatal and Panic call it themselves.
+func (l *Logger) Flush() error {
+	return errors.Join(flush(l.handler), flush(l.output.Output()))
+}
+
+// DrainContext is like Flush but gives up when ctx is done, returning
+// ctx.Err(), so that a shutdown, such as a Kubernetes preStop hook, can
+// bound how long the final flush may take. Handlers implementing
+// [ContextFlusher] stop their writes in flight; the others keep flushing
+// in the background.
+func (l *Logger) DrainContext(ctx context.Context) error {
+	return errors.Join(flushContext(ctx, l.handler), flushContext(ctx, l.output.Output()))
+}
+
+// Compact compacts the handler of the logger and its output, if they keep
+// internal buffers, as described by [Compactor].
+func (l *Logger) Compact() {
+	compact(l.handler)
+	compact(l.output.Output())
+}
+
+// flushBeforeExit flushes the logger, waiting at most the flush timeout,
+// so that the record logged by Fatal or Panic is not lost.
+func (l *Logger) flushBeforeExit() {
+	if l.flushTimeout < 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), l.flushTimeout)
+	defer cancel()
+	if err := l.DrainContext(ctx); errors.Is(err, context.DeadlineExceeded) {
+		FallbackErrorf("unable to flush log messages: timed out after %v", l.flushTimeout)
+	} else if err != nil {
+		FallbackErrorf("unable to flush log messages: %v", err)
+	}
+}
+
+// handle passes r to the handler of the logger, reporting its error with
+// FallbackErrorf, then flushes the output if r is at or above the flush
+// level.
+func (l *Logger) handle(r Record) {
+	if err := l.handleErr(r); err != nil {
+		FallbackErrorf("unable to write log message: %v", err)
+	}
+}
+
+// handleErr is like handle but returns the error of the handler.
+func (l *Logger) handleErr(r Record) error {
+	err := l.safeHandle(r)
+	if l.errorHook != nil && l.ctx != nil && r.Level >= LevelError {
+		l.callErrorHook(r)
+	}
+	if l.flushLevel > 0 && r.Level >= l.flushLevel {
+		if err := flush(l.output.Output()); err != nil {
+			FallbackErrorf("unable to flush log messages: %v", err)
+		}
+	}
+	return err
+}
+
+// callErrorHook passes r to the ErrorHook of the logger with its context,
+// reporting a panic of the hook rather than crashing the program.
+func (l *Logger) callErrorHook(r Record) {
+	defer func() {
+		if p := recover(); p != nil {
+			FallbackErrorf("l4g: error hook panicked: %v", p)
+		}
+	}()
+	l.errorHook(l.ctx, r)
+}
+
+// safeHandle passes r to the handler of the logger, converting a panic,
+// such as one of a faulty LogValuer or ReplaceAttr function, to an error
+// so that it cannot crash the program.
+func (l *Logger) safeHandle(r Record) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("l4g: handler panicked: %v", p)
+		}
+	}()
+	return l.handler.Handle(r)
+}
+
+// argsToAttrs converts args to attributes, applying the BadKeyPolicy of
+// the logger to the arguments not paired with a key.
+func (l *Logger) argsToAttrs(args []any) []Attr {
+	l.lint(args)
+	attrs := argsToAttrSlice(args)
+	if l.badKey == BadKeyKeep {
+		return attrs
+	}
+	return slices.DeleteFunc(attrs, func(a Attr) bool { return !l.keepAttr(a) })
+}
+
+// addArgs adds args to r like argsToAttrs, without building an
+// intermediate slice.
+func (l *Logger) addArgs(r *Record, args []any) {
+	l.lint(args)
+	var a Attr
+	for len(args) > 0 {
+		a, args = argsToAttr(args)
+		if l.keepAttr(a) {
+			r.AddAttrs(a)
+		}
+	}
+}
+
+// keepAttr reports whether a is kept by the BadKeyPolicy of the logger,
+// panicking on an argument not paired with a key under BadKeyPanic.
+func (l *Logger) keepAttr(a Attr) bool {
+	if a.Key != badKey || l.badKey == BadKeyKeep {
+		return true
+	}
+	if l.badKey == BadKeyPanic {
+		panic(fmt.Sprintf("l4g: log argument %v is not paired with a key", a.Value))
+	}
+	return false
+}
+
 // log is the internal implementation for logging with optional structured attributes.
 // It returns early without allocating if the output is disabled or the level is not enabled.
 func (l *Logger) log(level Level, msg string, args []any) {
 	if l.output.Discard() || !l.Enabled(level) {
 		return
 	}
-	r := NewRecord(time.Now(), level, msg)
+	r := l.newRecord(level, msg)
 	if len(args) > 0 {
-		r.AddAttrs(argsToAttrSlice(args)...)
-	}
-	if err := l.handler.Handle(r); err != nil {
-		FallbackErrorf("unable to write log message: %v", err)
+		l.addArgs(&r, args)
 	}
+	l.handle(r)
 }
 
 // logf is the internal implementation for formatted logging with optional structured attributes.
@@ -314,31 +1085,172 @@ func (l *Logger) logf(level Level, format string, args []any) {
 	if l.output.Discard() || !l.Enabled(level) {
 		return
 	}
-	attrs, anies := splitAttrs(args)
-	msg := format
-	if len(anies) > 0 {
-		msg = fmt.Sprintf(format, anies...)
+	r := l.newRecord(level, "")
+	for _, arg := range args {
+		if a, ok := arg.(Attr); ok {
+			r.AddAttrs(a)
+		}
 	}
-	r := NewRecord(time.Now(), level, msg)
-	if len(attrs) > 0 {
-		r.AddAttrs(attrs...)
-	}
-	if err := l.handler.Handle(r); err != nil {
-		FallbackErrorf("unable to write log message: %v", err)
+	r.Message = sprintfAnies(format, nonAttrs(args))
+	l.handle(r)
+}
+
+// sprintf formats the non-Attr values of args according to format.
+func sprintf(format string, args []any) string {
+	return sprintfAnies(format, nonAttrs(args))
+}
+
+// sprintfAnies formats anies according to format, returning format
+// unchanged when there is nothing to format.
+func sprintfAnies(format string, anies []any) string {
+	if len(anies) == 0 {
+		return format
 	}
+	return fmt.Sprintf(format, anies...)
 }
 
 // logj is the internal implementation for logging with structured key-value pairs from a map.
 // It returns early without allocating if the output is disabled or the level is not enabled.
-func (l *Logger) logj(level Level, j map[string]any) {
+func (l *Logger) logj(level Level, msg string, j map[string]any) {
 	if l.output.Discard() || !l.Enabled(level) {
 		return
 	}
-	r := NewRecord(time.Now(), level, "")
-	for key, value := range j {
-		r.Add(key, value)
+	r := l.newRecord(level, msg)
+	r.AddAttrs(mapAttrs(j)...)
+	l.handle(r)
+}
+
+// mapAttrs returns the entries of j as attributes sorted by key, with
+// nested maps as groups.
+func mapAttrs(j map[string]any) []Attr {
+	attrs := make([]Attr, 0, len(j))
+	for _, key := range slices.Sorted(maps.Keys(j)) {
+		if m, ok := j[key].(map[string]any); ok {
+			attrs = append(attrs, Attr{Key: key, Value: slog.GroupValue(mapAttrs(m)...)})
+			continue
+		}
+		attrs = append(attrs, Any(key, j[key]))
+	}
+	return attrs
+}
+
+// logt is the internal implementation for logging with a message template.
+// It returns early without allocating if the output is disabled or the level is not enabled.
+// It returns the message of the record and whether it was logged.
+func (l *Logger) logt(level Level, template string, args []any) (msg string, logged bool) {
+	if l.output.Discard() || !l.Enabled(level) {
+		return "", false
 	}
-	if err := l.handler.Handle(r); err != nil {
-		FallbackErrorf("unable to write log message: %v", err)
+	attrs := l.argsToAttrs(args)
+	msg = interpolate(template, attrs)
+	r := l.newRecord(level, msg)
+	if len(attrs) > 0 {
+		r.AddAttrs(attrs...)
+	}
+	l.handle(r)
+	return msg, true
+}
+
+// newRecord creates the record of a log call made through one of the
+// exported logging methods.
+func (l *Logger) newRecord(level Level, msg string) Record {
+	return l.newRecordAt(level, msg, 3) // newRecord, the internal log function and the exported method
+}
+
+// newRecordAt creates the record of a log call whose source is skip frames
+// above the caller of newRecordAt, 0 being that caller.
+func (l *Logger) newRecordAt(level Level, msg string, skip int) Record {
+	r := NewRecord(l.clock.Now(), level, msg)
+	r.PC = callerPC(skip)
+	r.Tags = l.tags
+	if l.goroutineID {
+		r.AddAttrs(Int64(GoroutineKey, goroutineID()))
+	}
+	if l.stackLevel > 0 && level >= l.stackLevel {
+		r.AddAttrs(String(StackKey, callerStack(skip)))
+	}
+	if l.pushed != nil {
+		l.pushed.addTo(&r)
+	}
+	return r
+}
+
+// callerPC returns the program counter of the frame skip frames above the
+// caller of newRecordAt, skipping [runtime.Callers], callerPC and
+// newRecordAt.
+func callerPC(skip int) uintptr {
+	var pcs [1]uintptr
+	runtime.Callers(3+skip, pcs[:])
+	return pcs[0]
+}
+
+// callerStack returns the stack of the calling goroutine from the frame
+// skip frames above the caller of newRecordAt, as callerPC, with a function
+// and its file and line per frame:
+//
+//	main.run
+//		/src/app/main.go:12
+func callerStack(skip int) string {
+	var pcs [64]uintptr
+	n := runtime.Callers(3+skip, pcs[:])
+	var sb strings.Builder
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		sb.WriteString(f.Function)
+		sb.WriteString("\n\t")
+		sb.WriteString(f.File)
+		sb.WriteByte(':')
+		sb.WriteString(strconv.Itoa(f.Line))
+		if !more {
+			break
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// interpolate replaces each {key} placeholder in template with the value of
+// the first attribute in attrs with that key.
+func interpolate(template string, attrs []Attr) string {
+	if strings.IndexByte(template, '{') < 0 {
+		return template
+	}
+	var sb strings.Builder
+	sb.Grow(len(template))
+	for {
+		i := strings.IndexByte(template, '{')
+		if i < 0 {
+			break
+		}
+		sb.WriteString(template[:i])
+		template = template[i:]
+		if strings.HasPrefix(template, "{{") {
+			sb.WriteByte('{')
+			template = template[2:]
+			continue
+		}
+		j := strings.IndexByte(template, '}')
+		if j < 0 {
+			break
+		}
+		if v, ok := lookupAttr(attrs, template[1:j]); ok {
+			sb.WriteString(v.String())
+		} else {
+			sb.WriteString(template[:j+1])
+		}
+		template = template[j+1:]
+	}
+	sb.WriteString(template)
+	return sb.String()
+}
+
+// lookupAttr returns the resolved value of the first attribute with the given key.
+func lookupAttr(attrs []Attr, key string) (slog.Value, bool) {
+	for _, a := range attrs {
+		if a.Key == key {
+			return a.Value.Resolve(), true
+		}
 	}
+	return slog.Value{}, false
 }
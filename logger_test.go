@@ -1,10 +1,17 @@
 package l4g
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"io"
+	"os"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestNew(t *testing.T) {
@@ -222,6 +229,51 @@ func TestLogger_Infoj(t *testing.T) {
 	}
 }
 
+func TestLogger_Infojm(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, Handler: NewJSONHandler(HandlerOptions{Output: buf})})
+
+	logger.Infojm("request done", map[string]any{
+		"status": 200,
+		"req":    map[string]any{"path": "/users", "user": map[string]any{"id": 7}},
+		"labels": []any{"a", 1},
+		"empty":  map[string]any{},
+	})
+	output := buf.String()
+	for _, want := range []string{
+		`"msg":"request done"`,
+		`"req":{"path":"/users","user":{"id":7}}`,
+		`"labels":["a",1]`,
+		`"status":200`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Logger.Infojm() output = %q, want to contain %s", output, want)
+		}
+	}
+	if strings.Contains(output, "empty") {
+		t.Errorf("Logger.Infojm() output = %q, want empty map omitted", output)
+	}
+}
+
+func TestLogger_Infoj_Sorted(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, Handler: NewJSONHandler(HandlerOptions{Output: buf})})
+
+	j := map[string]any{"d": 4, "b": 2, "a": 1, "e": 5, "c": 3}
+	logger.Infoj(j)
+	first := buf.String()
+	if !strings.Contains(first, `"a":1,"b":2,"c":3,"d":4,"e":5`) {
+		t.Errorf("Logger.Infoj() output = %q, want keys sorted", first)
+	}
+	for range 10 {
+		buf.Reset()
+		logger.Infoj(j)
+		if got, want := buf.String()[strings.Index(buf.String(), `"a"`):], first[strings.Index(first, `"a"`):]; got != want {
+			t.Fatalf("Logger.Infoj() output = %q, want %q", got, want)
+		}
+	}
+}
+
 func TestLogger_Warn(t *testing.T) {
 	buf := &bytes.Buffer{}
 	logger := New(Options{Output: buf})
@@ -406,6 +458,303 @@ func TestLogger_Fatalj(t *testing.T) {
 	}
 }
 
+// flushRecorder is a Handler recording calls to Flush, which blocks until
+// release is closed, if set.
+type flushRecorder struct {
+	Handler
+	flushed chan struct{}
+	release chan struct{}
+}
+
+func (h *flushRecorder) Flush() error {
+	if h.release != nil {
+		<-h.release
+	}
+	close(h.flushed)
+	return nil
+}
+
+// TestLogger_WithGroupAttrsHandlers specifies that attributes added after
+// WithGroup land inside the group, with every built-in handler.
+func TestLogger_WithGroupAttrsHandlers(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(w io.Writer) Handler
+		want    string
+	}{
+		{"simple", func(w io.Writer) Handler {
+			return NewSimpleHandler(HandlerOptions{Output: w, NoColor: true})
+		}, "m req.id=1 req.sub.x=2 req.sub.k=v\n"},
+		{"json", func(w io.Writer) Handler {
+			return NewJSONHandler(HandlerOptions{Output: w})
+		}, `"msg":"m","req":{"id":1,"sub":{"x":2,"k":"v"}}}`},
+		{"flat json", func(w io.Writer) Handler {
+			return NewJSONHandler(HandlerOptions{Output: w, FlattenGroups: true})
+		}, `"msg":"m","req.id":1,"req.sub.x":2,"req.sub.k":"v"}`},
+		{"syslog", func(w io.Writer) Handler {
+			return NewSyslogHandler(SyslogOptions{HandlerOptions: HandlerOptions{Output: w}})
+		}, "m req.id=1 req.sub.x=2 req.sub.k=v\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			l := New(Options{Output: buf, Handler: tt.handler(buf)})
+			l.WithGroup("req").WithAttrs("id", 1).WithGroupAttrs("sub", "x", 2).Info("m", "k", "v")
+			if out := buf.String(); !strings.Contains(out, tt.want) {
+				t.Errorf("output = %q, want %q", out, tt.want)
+			}
+		})
+	}
+
+	ring := NewRing(1)
+	l := New(Options{Output: &bytes.Buffer{}, Handler: NewRingHandler(nil, ring)})
+	l.WithGroupAttrs("req", "id", 1).WithGroup("sub").WithAttrs("x", 2).Info("m", "k", "v")
+	if got, want := string(ring.Entries()[0].Attrs), `{"req":{"id":1,"sub":{"x":2,"k":"v"}}}`; got != want {
+		t.Errorf("ring attrs = %s, want %s", got, want)
+	}
+}
+
+func TestLogger_WithGroupAttrs(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true})
+
+	logger.WithGroupAttrs("req").Info("m1", "k", "v")
+	logger.WithGroupAttrs("", "k", "v").Info("m2")
+	out := buf.String()
+	for _, want := range []string{"m1 req.k=v\n", "m2 k=v\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output = %q, want %q", out, want)
+		}
+	}
+}
+
+func TestLogger_WithOptions(t *testing.T) {
+	buf := &bytes.Buffer{}
+	parent := New(Options{Output: buf, NoColor: true}).
+		WithPrefix("app").WithGroup("req").WithAttrs("id", 1).WithTags("api")
+
+	debug := parent.WithOptions(func(o *Options) {
+		if o.Level != LevelInfo || o.Output == nil {
+			t.Errorf("options = %+v, want the level and output of the parent", o)
+		}
+		o.Level = LevelDebug
+		o.NewHandlerFunc = NewJSONHandler
+	})
+	debug.Debug("details", "k", "v")
+	parent.Debug("hidden")
+	out := buf.String()
+	for _, want := range []string{`"level":"DEBUG"`, `"prefix":"app"`, `"tags":["api"]`, `"req":{"id":1,"k":"v"}`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output = %q, want %s", out, want)
+		}
+	}
+	if strings.Contains(out, "hidden") {
+		t.Errorf("output = %q, want the parent level unchanged", out)
+	}
+
+	// the output is shared unless changed
+	other := &bytes.Buffer{}
+	parent.SetOutput(other)
+	debug.Info("shared")
+	if !strings.Contains(other.String(), "shared") {
+		t.Errorf("output = %q, want the derived logger to follow SetOutput", other.String())
+	}
+	own := &bytes.Buffer{}
+	parent.WithOptions(func(o *Options) { o.Output = own }).Info("own")
+	if !strings.Contains(own.String(), "[app] #api own req.id=1") || strings.Contains(other.String(), "own") {
+		t.Errorf("output = %q, want the record in the new output only", own.String())
+	}
+
+	if Nop().WithOptions(func(*Options) {}) != Nop() {
+		t.Errorf("Nop().WithOptions() did not return Nop()")
+	}
+}
+
+func TestLogger_Write(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true, AddSource: true}).WithPrefix("child")
+
+	_, _, line, _ := runtime.Caller(0)
+	if err := logger.Write(LevelWarn, []byte("{not} %d parsed\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("/logger_test.go:%d {not} %%d parsed\n", line+1)
+	if got := buf.String(); !strings.Contains(got, "WARN [child] ") || !strings.HasSuffix(got, want) {
+		t.Errorf("output = %q, want suffix %q", got, want)
+	}
+
+	buf.Reset()
+	if err := logger.Write(LevelDebug, []byte("hidden")); err != nil || buf.Len() != 0 {
+		t.Errorf("Write(LevelDebug) = %v, output %q, want nothing", err, buf.String())
+	}
+}
+
+func TestLogger_LogString(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true})
+
+	if err := logger.LogString("WARN", "disk low", "free", "1G"); err != nil {
+		t.Errorf("LogString() error = %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "WARN disk low free=1G") {
+		t.Errorf("output = %q, want a warn record", out)
+	}
+	buf.Reset()
+	if err := logger.LogString("debug", "hidden"); err != nil || buf.Len() != 0 {
+		t.Errorf("LogString(debug) = %v, output %q, want nothing logged", err, buf.String())
+	}
+	if err := logger.LogString("loud", "unknown"); err == nil {
+		t.Errorf("LogString(loud) error = nil, want an error")
+	}
+	if out := buf.String(); !strings.Contains(out, "INFO unknown") {
+		t.Errorf("output = %q, want an info record", out)
+	}
+}
+
+func TestLogger_LogfLeveler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true})
+	level := NewLevelVar(LevelError)
+
+	logger.Logf(level, "count %d", 3)
+	logger.Logj(level, map[string]any{"k": "v"})
+	logger.Logt(level, "user {user}", "user", "alice")
+	out := buf.String()
+	for _, want := range []string{"ERROR count 3", "ERROR  k=v", "ERROR user alice"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output = %q, want %q", out, want)
+		}
+	}
+}
+
+func TestLogger_BadKey(t *testing.T) {
+	buf := &bytes.Buffer{}
+	before := BadKeyCount()
+	New(Options{Output: buf, NoColor: true}).Info("keep", "a", 1, 42, "orphan")
+	if out := buf.String(); !strings.Contains(out, "a=1 !BADKEY=42 !BADKEY=orphan") {
+		t.Errorf("output = %q, want the orphans under !BADKEY", out)
+	}
+	if n := BadKeyCount() - before; n != 2 {
+		t.Errorf("BadKeyCount() increased by %d, want 2", n)
+	}
+
+	buf.Reset()
+	logger := New(Options{Output: buf, NoColor: true, BadKey: BadKeyDrop})
+	logger.WithAttrs(true, "k", "v").Info("drop", "a", 1, 42)
+	if out := buf.String(); !strings.Contains(out, "drop k=v a=1\n") {
+		t.Errorf("output = %q, want the orphans dropped", out)
+	}
+	if n := BadKeyCount() - before; n != 4 {
+		t.Errorf("BadKeyCount() increased by %d, want 4", n)
+	}
+
+	logger = New(Options{Output: buf, BadKey: BadKeyPanic})
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "42") {
+			t.Errorf("recover() = %v, want a panic naming the argument", r)
+		}
+	}()
+	logger.Info("panic", 42)
+}
+
+func TestLogger_FatalFlush(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := &flushRecorder{Handler: NewSimpleHandler(HandlerOptions{Output: buf}), flushed: make(chan struct{})}
+	logger := New(Options{Output: buf, Handler: h})
+
+	oldExiter := OsExiter
+	OsExiter = func(code int) {
+		select {
+		case <-h.flushed:
+		default:
+			t.Errorf("Logger.Fatal() exited before flushing the handler")
+		}
+	}
+	defer func() { OsExiter = oldExiter }()
+
+	logger.Fatal("fatal message")
+}
+
+func TestLogger_PanicFlush(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := &flushRecorder{Handler: NewSimpleHandler(HandlerOptions{Output: buf}), flushed: make(chan struct{})}
+	logger := New(Options{Output: buf, Handler: h})
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Logger.Panic() did not panic")
+		}
+		select {
+		case <-h.flushed:
+		default:
+			t.Errorf("Logger.Panic() panicked before flushing the handler")
+		}
+	}()
+	logger.Panic("panic message")
+}
+
+func TestLogger_FatalFlushTimeout(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := &flushRecorder{
+		Handler: NewSimpleHandler(HandlerOptions{Output: buf}),
+		flushed: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	defer close(h.release)
+	logger := New(Options{Output: buf, Handler: h, FlushTimeout: 10 * time.Millisecond})
+
+	exitCalled := false
+	oldExiter := OsExiter
+	OsExiter = func(code int) { exitCalled = true }
+	defer func() { OsExiter = oldExiter }()
+
+	start := time.Now()
+	logger.Fatal("fatal message")
+	if !exitCalled {
+		t.Errorf("Logger.Fatal() did not call os.Exit")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Logger.Fatal() waited %v for a blocked flush", d)
+	}
+}
+
+func TestLogger_FlushLevel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := bufio.NewWriter(buf)
+	logger := New(Options{Output: w, NoColor: true, FlushLevel: LevelError})
+
+	logger.Warn("buffered")
+	if buf.Len() != 0 {
+		t.Errorf("output = %q, want the warning buffered", buf.String())
+	}
+	logger.Error("failed")
+	if out := buf.String(); !strings.Contains(out, "WARN buffered\n") || !strings.HasSuffix(out, "ERROR failed\n") {
+		t.Errorf("output = %q, want both records flushed by the error", out)
+	}
+}
+
+func TestLogger_DrainContext(t *testing.T) {
+	h := &flushRecorder{
+		Handler: NewSimpleHandler(HandlerOptions{Output: &bytes.Buffer{}}),
+		flushed: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	logger := New(Options{Output: &bytes.Buffer{}, Handler: h})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := logger.DrainContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("DrainContext() = %v with a blocked flush, want context.DeadlineExceeded", err)
+	}
+
+	close(h.release)
+	<-h.flushed
+	h.flushed, h.release = make(chan struct{}), nil
+	if err := logger.DrainContext(context.Background()); err != nil {
+		t.Errorf("DrainContext() = %v, want nil", err)
+	}
+}
+
 func TestLogger_Log(t *testing.T) {
 	buf := &bytes.Buffer{}
 	logger := New(Options{Output: buf})
@@ -508,6 +857,23 @@ func TestLogger_WithPrefix(t *testing.T) {
 	}
 }
 
+func TestLogger_OptionsPrefix(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true, Prefix: "svc"})
+
+	logger.Info("plain")
+	db := logger.WithPrefix("db.")
+	db.Info("derived")
+	db.WithOptions(func(o *Options) { o.NewHandlerFunc = NewJSONHandler }).Info("rebuilt")
+
+	out := buf.String()
+	for _, want := range []string{"INFO [svc] plain\n", "INFO [db.svc] derived\n", `"prefix":"db.svc","msg":"rebuilt"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output = %q, want to contain %q", out, want)
+		}
+	}
+}
+
 func TestLogger_WithGroup(t *testing.T) {
 	buf := &bytes.Buffer{}
 	handler := NewSimpleHandler(HandlerOptions{
@@ -653,3 +1019,460 @@ func BenchmarkLogger_Disabled(b *testing.B) {
 		logger.Debug("this should be skipped")
 	}
 }
+
+func TestLogger_Infot(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true})
+
+	logger.Infot("user {user} logged in from {ip}", "user", "alice", String("ip", "10.0.0.1"))
+	output := buf.String()
+
+	if !strings.Contains(output, "user alice logged in from 10.0.0.1 user=alice ip=10.0.0.1") {
+		t.Errorf("Logger.Infot() output = %q, want interpolated message and attributes", output)
+	}
+}
+
+func TestLogger_Panict(t *testing.T) {
+	logger := New(Options{Output: io.Discard})
+
+	defer func() {
+		if r := recover(); r != "job 7 failed" {
+			t.Errorf("Logger.Panict() panicked with %v, want %q", r, "job 7 failed")
+		}
+	}()
+
+	logger.Panict("job {id} failed", "id", 7)
+}
+
+func TestLogger_PanictBadKey(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true})
+	before := BadKeyCount()
+
+	func() {
+		defer func() {
+			if r := recover(); r != "job 7 failed" {
+				t.Errorf("Logger.Panict() panicked with %v, want %q", r, "job 7 failed")
+			}
+		}()
+		logger.Panict("job {id} failed", "id", 7, 42)
+	}()
+	if n := BadKeyCount() - before; n != 1 {
+		t.Errorf("BadKeyCount() increased by %d, want 1", n)
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	attrs := []Attr{String("name", "bob"), Int("n", 3), Group("g", Int("x", 1))}
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"plain", "plain"},
+		{"hi {name}", "hi bob"},
+		{"{name} has {n} items", "bob has 3 items"},
+		{"{missing} stays", "{missing} stays"},
+		{"{{name}", "{name}"},
+		{"unterminated {name", "unterminated {name"},
+		{"group {g}", "group [x=1]"},
+	}
+
+	for _, tt := range tests {
+		if got := interpolate(tt.template, attrs); got != tt.want {
+			t.Errorf("interpolate(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestNewLogLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := NewSimpleHandler(HandlerOptions{Output: buf, NoColor: true, Level: LevelWarn})
+
+	ll := NewLogLogger(h, LevelWarn)
+	ll.Printf("disk %d%% full", 91)
+	if output := buf.String(); !strings.Contains(output, "WARN disk 91% full\n") {
+		t.Errorf("NewLogLogger() output = %q, want a WARN record without a doubled newline", output)
+	}
+
+	buf.Reset()
+	NewLogLogger(h, LevelInfo).Print("dropped")
+	if buf.Len() != 0 {
+		t.Errorf("NewLogLogger() should respect the handler level, got %q", buf.String())
+	}
+}
+
+func TestLogger_If(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf})
+
+	if logger.If(true) != logger {
+		t.Errorf("If(true) should return the receiver")
+	}
+
+	off := logger.If(false)
+	if off != New(Options{Output: buf}).If(false) {
+		t.Errorf("If(false) should return a shared logger")
+	}
+	off.Error("hidden")
+	off.WithAttrs("k", "v").WithGroup("g").WithPrefix("p").Error("hidden")
+	if buf.Len() != 0 {
+		t.Errorf("If(false) logger wrote %q", buf.String())
+	}
+	if off.Enabled(LevelFatal) {
+		t.Errorf("If(false) logger should not be enabled")
+	}
+
+	if n := testing.AllocsPerRun(100, func() { logger.If(false).Info("x", "k", 1) }); n != 0 {
+		t.Errorf("If(false).Info() allocs = %v, want 0", n)
+	}
+}
+
+func TestLogger_Wrap(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true}).Wrap(func(h Handler) Handler {
+		return h.WithAttrs([]Attr{String("wrapped", "yes")})
+	})
+	logger.Info("first")
+	logger.WithOptions(func(o *Options) { o.Level = LevelDebug }).Debug("second")
+	for _, want := range []string{"INFO first wrapped=yes\n", "DEBUG second wrapped=yes\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output = %q, want %q", buf.String(), want)
+		}
+	}
+}
+
+func TestNop(t *testing.T) {
+	logger := Nop()
+	if logger != Nop() {
+		t.Errorf("Nop() should return a shared logger")
+	}
+	if logger.Enabled(LevelFatal) {
+		t.Errorf("Nop() logger should not be enabled")
+	}
+	if n := testing.AllocsPerRun(100, func() { logger.Errorf("x %d", 1) }); n != 0 {
+		t.Errorf("Nop().Errorf() allocs = %v, want 0", n)
+	}
+}
+
+func TestLogger_AddSource(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{
+		Output:     buf,
+		NoColor:    true,
+		AddSource:  true,
+		SourcePath: SourcePathModule,
+		SourceFunc: true,
+	})
+
+	_, _, line, _ := runtime.Caller(0)
+	logger.Info("hello")
+
+	want := fmt.Sprintf("logger_test.go:%d l4g.TestLogger_AddSource hello", line+1)
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("output = %q, want to contain %q", buf.String(), want)
+	}
+}
+
+func TestLogger_AddSourceLevels(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true, Level: LevelTrace, AddSource: true})
+
+	for name, log := range map[string]func(string, ...any){
+		"Trace": logger.Trace,
+		"Debug": logger.Debug,
+		"Info":  logger.Info,
+		"Warn":  logger.Warn,
+		"Error": logger.Error,
+	} {
+		buf.Reset()
+		_, _, line, _ := runtime.Caller(0)
+		log("hello")
+		if want := fmt.Sprintf("logger_test.go:%d hello", line+1); !strings.Contains(buf.String(), want) {
+			t.Errorf("%s output = %q, want to contain %q", name, buf.String(), want)
+		}
+	}
+}
+
+func TestLogger_LogRecordEmitTime(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true, EmitTime: true, TimeFormat: time.RFC3339})
+
+	event := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	r := NewRecord(time.Now(), LevelInfo, "imported").WithTime(event)
+	r.AddAttrs(String("src", "queue"))
+	logger.LogRecord(r)
+	logger.LogRecord(NewRecord(event, LevelDebug, "disabled"))
+
+	out := buf.String()
+	if !strings.HasPrefix(out, "2020-01-02T03:04:05Z INFO imported emit_time=") {
+		t.Errorf("output = %q, want the event time and an emit time", out)
+	}
+	if !strings.HasSuffix(out, " src=queue\n") || strings.Contains(out, "disabled") {
+		t.Errorf("output = %q", out)
+	}
+}
+
+func TestLogger_WithTags(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true, Prefix: "app"})
+
+	billing := logger.WithTags("billing")
+	billing.WithTags("security").Info("refund", "id", 7)
+	billing.Info("charge")
+	logger.Info("plain")
+
+	want := "INFO [app] #billing #security refund id=7\nINFO [app] #billing charge\nINFO [app] plain\n"
+	if got := stripTime(buf.String()); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	if logger.WithTags() != logger {
+		t.Errorf("WithTags() without tags should return the receiver")
+	}
+}
+
+// stripTime removes the leading timestamp of each line of out.
+func stripTime(out string) string {
+	lines := strings.SplitAfter(out, "\n")
+	for i, line := range lines {
+		if j := strings.Index(line, " INFO "); j >= 0 {
+			lines[i] = line[j+1:]
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+func TestNewStdSplit(t *testing.T) {
+	stdout, stderr := tempFile(t), tempFile(t)
+	savedOut, savedErr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdout, stderr
+	l := NewStdSplit(Options{Level: LevelDebug, NoColor: true})
+	os.Stdout, os.Stderr = savedOut, savedErr
+
+	l = l.WithPrefix("app").WithAttrs("k", "v")
+	l.Debug("debug")
+	l.Info("info")
+	l.Warn("warn")
+	l.Error("error")
+
+	out := readFile(t, stdout)
+	if !strings.Contains(out, "[app] debug k=v") || !strings.Contains(out, "[app] info k=v") {
+		t.Errorf("stdout = %q, want the debug and info records", out)
+	}
+	if strings.Contains(out, "warn") || strings.Contains(out, "error") {
+		t.Errorf("stdout = %q, want no warn or error records", out)
+	}
+	errOut := readFile(t, stderr)
+	if !strings.Contains(errOut, "[app] warn k=v") || !strings.Contains(errOut, "[app] error k=v") {
+		t.Errorf("stderr = %q, want the warn and error records", errOut)
+	}
+	if strings.Contains(errOut, "info") {
+		t.Errorf("stderr = %q, want no info records", errOut)
+	}
+
+	l.SetLevel(LevelError)
+	l.Warn("dropped")
+	if errOut := readFile(t, stderr); strings.Contains(errOut, "dropped") {
+		t.Errorf("stderr = %q, want the level shared by both streams", errOut)
+	}
+}
+
+func tempFile(t *testing.T) *os.File {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func readFile(t *testing.T, f *os.File) string {
+	t.Helper()
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestLogger_HandlerPanic(t *testing.T) {
+	errs := tempFile(t)
+	saved := os.Stderr
+	os.Stderr = errs
+	defer func() { os.Stderr = saved }()
+
+	l := New(Options{
+		Output: &bytes.Buffer{},
+		ReplaceAttr: func(_ []string, a Attr) Attr {
+			if a.Key == "bad" {
+				panic("faulty ReplaceAttr")
+			}
+			return a
+		},
+	})
+	l.Info("message", "bad", 1)
+	l.Infof("message %d", 1, String("bad", "x"))
+	if err := l.Write(LevelInfo, []byte("line")); err != nil {
+		t.Errorf("Write without attributes = %v", err)
+	}
+
+	got := readFile(t, errs)
+	if n := strings.Count(got, "handler panicked: faulty ReplaceAttr"); n != 2 {
+		t.Errorf("reported %d panics, want 2:\n%s", n, got)
+	}
+}
+
+func TestLogger_StackLevel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := New(Options{Output: buf})
+	func() {
+		defer func() { recover() }()
+		l.Panic("panic message")
+	}()
+	got := buf.String()
+	if !strings.Contains(got, "stack=") || !strings.Contains(got, "TestLogger_StackLevel") {
+		t.Errorf("Panic record = %q, want the stack of the caller", got)
+	}
+	if strings.Contains(got, "l4g.(*Logger)") {
+		t.Errorf("Panic record = %q, want no frames of the logger", got)
+	}
+
+	buf.Reset()
+	l.Error("error message")
+	if strings.Contains(buf.String(), "stack=") {
+		t.Errorf("Error record = %q, want no stack", buf.String())
+	}
+
+	buf.Reset()
+	l = New(Options{Output: buf, StackLevel: LevelError})
+	l.Error("error message")
+	if !strings.Contains(buf.String(), "stack=") {
+		t.Errorf("Error record with StackLevel LevelError = %q, want a stack", buf.String())
+	}
+
+	buf.Reset()
+	l = l.WithOptions(func(o *Options) { o.StackLevel = LevelFatal + 1 })
+	l.Log(LevelPanic, "panic message")
+	if strings.Contains(buf.String(), "stack=") {
+		t.Errorf("record with StackLevel above LevelFatal = %q, want no stack", buf.String())
+	}
+}
+
+func TestLogger_WithLevelOverride(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := New(Options{Output: buf, NoColor: true})
+	child := l.WithAttrs("k", "v")
+
+	restore := l.WithLevelOverride(LevelDebug)
+	child.Debug("overridden")
+	restore()
+	child.Debug("restored")
+
+	got := buf.String()
+	if !strings.Contains(got, "overridden") {
+		t.Errorf("output = %q, want the debug record logged during the override", got)
+	}
+	if strings.Contains(got, "restored") {
+		t.Errorf("output = %q, want no debug record after restore", got)
+	}
+	if l.Level() != LevelInfo {
+		t.Errorf("Level() = %v after restore, want info", l.Level())
+	}
+}
+
+func TestLogger_WithNamespace(t *testing.T) {
+	buf := &bytes.Buffer{}
+	vendor := NewLevelVar(LevelWarn)
+	l := New(Options{
+		Output:          buf,
+		NoColor:         true,
+		NamespaceLevels: map[string]Leveler{"redis": vendor},
+	})
+	redis := l.WithNamespace("redis")
+
+	redis.Info("connected", "addr", "localhost")
+	if buf.Len() != 0 {
+		t.Errorf("output = %q, want the info record of the namespace dropped", buf.String())
+	}
+	redis.Warn("reconnecting", "addr", "localhost")
+	if got := buf.String(); !strings.Contains(got, "#ns:redis") || !strings.Contains(got, "redis.addr=localhost") {
+		t.Errorf("output = %q, want the namespace tag and group", got)
+	}
+
+	buf.Reset()
+	vendor.Set(LevelInfo)
+	redis.Info("connected")
+	l.WithNamespace("http").Info("request")
+	if got := buf.String(); !strings.Contains(got, "connected") || !strings.Contains(got, "#ns:http") {
+		t.Errorf("output = %q, want both records", got)
+	}
+
+	buf.Reset()
+	quiet := redis.WithOptions(func(o *Options) {
+		o.NamespaceLevels = map[string]Leveler{"redis": LevelError}
+	})
+	quiet.Warn("dropped")
+	if buf.Len() != 0 {
+		t.Errorf("output = %q, want the level of the namespace set by WithOptions", buf.String())
+	}
+}
+
+func TestSetDefaultHandlerFactory(t *testing.T) {
+	oldStd := Default()
+	defer SetDefault(oldStd)
+	buf := &bytes.Buffer{}
+	SetDefault(New(Options{Output: buf}))
+
+	SetDefaultHandlerFactory(NewJSONHandler)
+	defer SetDefaultHandlerFactory(nil)
+
+	Info("default")
+	New(Options{Output: buf}).Info("new")
+	New(Options{Output: buf, NoColor: true, NewHandlerFunc: NewSimpleHandler}).Info("own")
+	got := buf.String()
+	for _, want := range []string{`"msg":"default"`, `"msg":"new"`, "INFO own"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output = %q, want %s", got, want)
+		}
+	}
+
+	buf.Reset()
+	SetDefaultHandlerFactory(nil)
+	New(Options{Output: buf, NoColor: true}).Info("restored")
+	if got := buf.String(); strings.Contains(got, "{") || !strings.Contains(got, "restored") {
+		t.Errorf("output = %q, want the text format restored", got)
+	}
+}
+
+func TestSetDefaultHandlerFactory_Existing(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true})
+	SetDefaultHandlerFactory(NewJSONHandler)
+	defer SetDefaultHandlerFactory(nil)
+
+	logger.Info("before")
+	logger.WithOptions(func(o *Options) { o.Prefix = "p" }).Info("rebuilt")
+	if got := buf.String(); strings.Contains(got, "{") || !strings.Contains(got, "INFO [p] rebuilt") {
+		t.Errorf("output = %q, want the existing logger to keep the text format", got)
+	}
+
+	buf.Reset()
+	logger.WithOptions(func(o *Options) { o.NewHandlerFunc = NewJSONHandler }).Info("own")
+	if got := buf.String(); !strings.Contains(got, `"msg":"own"`) {
+		t.Errorf("output = %q, want the factory set by WithOptions", got)
+	}
+}
+
+func TestPrettyPrint_DefaultHandlerFactory(t *testing.T) {
+	SetDefaultHandlerFactory(NewJSONHandler)
+	defer SetDefaultHandlerFactory(nil)
+
+	var out bytes.Buffer
+	src := strings.NewReader(`{"time":"2024-05-01T12:00:00Z","level":"INFO","msg":"hello"}` + "\n")
+	if err := PrettyPrint(src, &out, WithNoColor()); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); strings.Contains(got, "{") || !strings.Contains(got, "INFO hello") {
+		t.Errorf("output = %q, want the text format", got)
+	}
+}
@@ -0,0 +1,73 @@
+package l4g
+
+import (
+	"fmt"
+	"io"
+	"iter"
+)
+
+// MergeRecords reads the records encoded in each of srcs in the given
+// format, as read by [Replay], and yields them merged into one sequence
+// ordered by time, for investigating an incident across the log files of
+// several instances. Each source must be ordered by time itself; records
+// with equal times are yielded in the order of srcs. Pass the sequence to
+// [ReplayRecords] to write it to a Handler.
+//
+// The sequence ends after the last record of the last source, or with the
+// first malformed record, yielded with an error naming its source.
+func MergeRecords(format ReplayFormat, srcs ...io.Reader) iter.Seq2[Record, error] {
+	return func(yield func(Record, error) bool) {
+		type source struct {
+			next func() (Record, error, bool)
+			head Record
+			n    int // records read
+		}
+		sources := make([]source, len(srcs))
+		active := make([]int, 0, len(srcs)) // indexes of the sources with a head
+		for i, src := range srcs {
+			next, stop := iter.Pull2(readRecords(src, format))
+			defer stop()
+			sources[i].next = next
+			active = append(active, i)
+		}
+
+		// advance reads the next record of the source active[j], removing
+		// it from active at its end, and reports an error as malformed.
+		advance := func(j int) error {
+			s := &sources[active[j]]
+			r, err, ok := s.next()
+			if !ok {
+				active = append(active[:j], active[j+1:]...)
+				return nil
+			}
+			s.n++
+			if err != nil {
+				return fmt.Errorf("source %d record %d: %w", active[j], s.n, err)
+			}
+			s.head = r
+			return nil
+		}
+
+		for j := len(active) - 1; j >= 0; j-- {
+			if err := advance(j); err != nil {
+				yield(Record{}, err)
+				return
+			}
+		}
+		for len(active) > 0 {
+			first := 0
+			for j := 1; j < len(active); j++ {
+				if sources[active[j]].head.Time.Before(sources[active[first]].head.Time) {
+					first = j
+				}
+			}
+			if !yield(sources[active[first]].head, nil) {
+				return
+			}
+			if err := advance(first); err != nil {
+				yield(Record{}, err)
+				return
+			}
+		}
+	}
+}
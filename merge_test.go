@@ -0,0 +1,74 @@
+package l4g
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func encodeRecords(t *testing.T, records ...Record) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, r := range records {
+		b, err := json.Marshal(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Write(append(b, '\n'))
+	}
+	return &buf
+}
+
+func TestMergeRecords(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(ms int, msg string) Record {
+		return NewRecord(t0.Add(time.Duration(ms)*time.Millisecond), LevelInfo, msg)
+	}
+	a := encodeRecords(t, at(0, "a0"), at(20, "a20"), at(40, "a40"))
+	b := encodeRecords(t, at(10, "b10"), at(20, "b20"), at(50, "b50"))
+	empty := encodeRecords(t)
+
+	var got []string
+	for r, err := range MergeRecords(ReplayJSON, a, empty, b) {
+		if err != nil {
+			t.Fatalf("MergeRecords() error = %v", err)
+		}
+		got = append(got, r.Message)
+	}
+	want := "a0 b10 a20 b20 a40 b50"
+	if strings.Join(got, " ") != want {
+		t.Errorf("MergeRecords() = %v, want %s", got, want)
+	}
+}
+
+func TestMergeRecords_Malformed(t *testing.T) {
+	good := encodeRecords(t, NewRecord(time.Now(), LevelInfo, "ok"))
+	bad := strings.NewReader("{not json\n")
+
+	var err error
+	for _, err = range MergeRecords(ReplayJSON, good, bad) {
+		if err != nil {
+			break
+		}
+	}
+	if err == nil || !strings.Contains(err.Error(), "source 1 record 1") {
+		t.Errorf("MergeRecords() error = %v, want one naming the source", err)
+	}
+}
+
+func TestReplayRecords(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	a := encodeRecords(t, NewRecord(t0.Add(time.Second), LevelInfo, "second"))
+	b := encodeRecords(t, NewRecord(t0, LevelInfo, "first"))
+
+	var buf bytes.Buffer
+	h := NewSimpleHandler(HandlerOptions{Output: &buf, NoColor: true})
+	if err := ReplayRecords(MergeRecords(ReplayJSON, a, b), h, ReplayOptions{KeepTime: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); strings.Index(got, "first") > strings.Index(got, "second") {
+		t.Errorf("output not ordered by time:\n%s", got)
+	}
+}
@@ -0,0 +1,46 @@
+package l4g
+
+import (
+	"log/slog"
+	"net/netip"
+)
+
+// IP returns an [Attr] for an IP address. The built-in handlers format it
+// without allocating, unlike a net.IP passed to [Any].
+func IP(key string, addr netip.Addr) Attr {
+	return slog.Any(key, addr)
+}
+
+// NetPrefix returns an [Attr] for an IP network, such as 10.0.0.0/8,
+// formatted as by [IP]. It is not named Prefix, which returns the prefix of
+// the standard logger.
+func NetPrefix(key string, prefix netip.Prefix) Attr {
+	return slog.Any(key, prefix)
+}
+
+// Port returns an [Attr] for a network port.
+func Port(key string, port uint16) Attr {
+	return slog.Uint64(key, uint64(port))
+}
+
+// appendBareText appends the text of v, one of the values formatted
+// without allocating by the built-in handlers, and reports whether the
+// text needs neither quoting nor escaping. If not, b must be discarded and
+// v formatted by its String method.
+func appendBareText(b []byte, v any) ([]byte, bool) {
+	switch v := v.(type) {
+	case idValue:
+		b, _ = v.AppendText(b)
+		return b, true
+	case netip.Addr:
+		// the zone of an IPv6 address may hold any character
+		if v.IsValid() && v.Zone() == "" {
+			return v.AppendTo(b), true
+		}
+	case netip.Prefix:
+		if v.IsValid() {
+			return v.AppendTo(b), true
+		}
+	}
+	return b, false
+}
@@ -0,0 +1,60 @@
+package l4g
+
+import (
+	"bytes"
+	"io"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNetAttrs(t *testing.T) {
+	tests := []struct {
+		name       string
+		attr       Attr
+		text, json string
+	}{
+		{"ipv4", IP("a", netip.MustParseAddr("192.0.2.1")), `a=192.0.2.1`, `"a":"192.0.2.1"`},
+		{"ipv6", IP("a", netip.MustParseAddr("2001:db8::1")), `a=2001:db8::1`, `"a":"2001:db8::1"`},
+		{"zone", IP("a", netip.MustParseAddr("fe80::1%eth 0")), `a="fe80::1%eth 0"`, `"a":"fe80::1%eth 0"`},
+		{"invalid", IP("a", netip.Addr{}), `a="invalid IP"`, `"a":"invalid IP"`},
+		{"prefix", NetPrefix("a", netip.MustParsePrefix("10.0.0.0/8")), `a=10.0.0.0/8`, `"a":"10.0.0.0/8"`},
+		{"port", Port("a", 8080), `a=8080`, `"a":8080`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRecord(time.Time{}, LevelInfo, "m")
+			r.AddAttrs(tt.attr)
+
+			var buf bytes.Buffer
+			NewSimpleHandler(HandlerOptions{Output: &buf, NoColor: true}).Handle(r)
+			if !strings.Contains(buf.String(), " "+tt.text) {
+				t.Errorf("SimpleHandler output = %q, want %s", buf.String(), tt.text)
+			}
+
+			buf.Reset()
+			NewJSONHandler(HandlerOptions{Output: &buf}).Handle(r)
+			if !strings.Contains(buf.String(), tt.json) {
+				t.Errorf("JSONHandler output = %q, want %s", buf.String(), tt.json)
+			}
+		})
+	}
+}
+
+func TestNetAttrs_Allocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+	r := NewRecord(time.Now(), LevelInfo, "m")
+	r.AddAttrs(IP("ip", netip.MustParseAddr("2001:db8::1")), NetPrefix("net", netip.MustParsePrefix("10.0.0.0/8")), Port("port", 443))
+	for _, h := range []Handler{
+		NewSimpleHandler(HandlerOptions{Output: io.Discard}),
+		NewJSONHandler(HandlerOptions{Output: io.Discard}),
+	} {
+		h.Handle(r) // warm up the buffer pool
+		if allocs := testing.AllocsPerRun(100, func() { h.Handle(r) }); allocs != 0 {
+			t.Errorf("%T: allocs = %v, want 0", h, allocs)
+		}
+	}
+}
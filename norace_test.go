@@ -0,0 +1,5 @@
+//go:build !race
+
+package l4g
+
+const raceEnabled = false
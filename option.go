@@ -0,0 +1,76 @@
+package l4g
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// An Option sets a field of [Options]. Options are an alternative to the
+// Options struct for code written against the functional options of
+// earlier versions of this package; see [NewWith]. An Option can also be
+// passed to [Logger.WithOptions].
+type Option func(*Options)
+
+// NewWith creates a new Logger configured by the given options, writing to
+// os.Stderr unless WithOutput is given. It is equivalent to calling New
+// with an Options value modified by each option in turn.
+//
+//	l := l4g.NewWith(l4g.WithLevel(l4g.LevelDebug), l4g.WithOutput(os.Stdout))
+func NewWith(opts ...Option) *Logger {
+	o := Options{Output: os.Stderr}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return New(o)
+}
+
+// WithLevel sets the minimum level of the records logged.
+func WithLevel(level Level) Option {
+	return func(o *Options) { o.Level = level }
+}
+
+// WithOutput sets the destination of the records.
+func WithOutput(w io.Writer) Option {
+	return func(o *Options) { o.Output = w }
+}
+
+// WithHandler sets the handler of the records, overriding the handler
+// created by NewHandlerFunc.
+func WithHandler(h Handler) Option {
+	return func(o *Options) { o.Handler = h }
+}
+
+// WithNewHandlerFunc sets the function creating the handler of the
+// records, such as [NewJSONHandler].
+func WithNewHandlerFunc(f func(HandlerOptions) Handler) Option {
+	return func(o *Options) { o.NewHandlerFunc = f }
+}
+
+// WithTimeFormat sets the format of the record times, as accepted by
+// time.Time.Format.
+func WithTimeFormat(format string) Option {
+	return func(o *Options) { o.TimeFormat = format }
+}
+
+// WithNoColor disables color output.
+func WithNoColor() Option {
+	return func(o *Options) { o.NoColor = true }
+}
+
+// WithReplaceAttr sets the function rewriting attributes before they are
+// logged.
+func WithReplaceAttr(f func(groups []string, attr Attr) Attr) Option {
+	return func(o *Options) { o.ReplaceAttr = f }
+}
+
+// WithSource adds the file and line of the log call to every record.
+func WithSource() Option {
+	return func(o *Options) { o.AddSource = true }
+}
+
+// WithWriteTimeout bounds the time a write to the output may block.
+// See [TimeoutWriter].
+func WithWriteTimeout(d time.Duration) Option {
+	return func(o *Options) { o.WriteTimeout = d }
+}
@@ -0,0 +1,57 @@
+package l4g
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNewWith(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := NewWith(
+		WithOutput(buf),
+		WithLevel(LevelDebug),
+		WithNewHandlerFunc(NewJSONHandler),
+		WithTimeFormat("2006"),
+		WithReplaceAttr(func(groups []string, a Attr) Attr {
+			if a.Key == "secret" {
+				a.Value = slog.StringValue("***")
+			}
+			return a
+		}),
+		WithSource(),
+	)
+	l.Debug("hello", "secret", "pw")
+	out := buf.String()
+	for _, want := range []string{`"level":"DEBUG"`, `"msg":"hello"`, `"secret":"***"`, `"source":{`, `"time":"20`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output = %q, want %s", out, want)
+		}
+	}
+
+	buf.Reset()
+	h := NewSimpleHandler(HandlerOptions{Output: buf, NoColor: true})
+	NewWith(WithOutput(buf), WithHandler(h)).Info("custom")
+	if !strings.Contains(buf.String(), "INFO custom") {
+		t.Errorf("output = %q, want the record from the handler", buf.String())
+	}
+}
+
+func TestNewWithDefaults(t *testing.T) {
+	l := NewWith()
+	if l.Output() != any(os.Stderr) || l.Level() != LevelInfo {
+		t.Errorf("NewWith() output = %v, level = %v, want os.Stderr and info", l.Output(), l.Level())
+	}
+}
+
+func TestOptionWithOptions(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := NewWith(WithOutput(buf), WithNoColor()).WithOptions(WithLevel(LevelWarn))
+	l.Info("hidden")
+	l.Warn("shown")
+	if out := buf.String(); strings.Contains(out, "hidden") || !strings.Contains(out, "WARN shown") {
+		t.Errorf("output = %q, want only the warning", out)
+	}
+}
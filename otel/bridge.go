@@ -0,0 +1,177 @@
+// Package otel bridges the OpenTelemetry Logs API to l4g: the loggers of
+// the [log.LoggerProvider] returned by [NewLoggerProvider] pass the
+// records emitted by instrumentation libraries to an [l4g.Logger], and so
+// to its handlers, with their severity, time and attributes. In the other
+// direction, [SpanEventHook] adds the errors logged with a context to its
+// span as events.
+//
+// It is a module of its own, so that the l4g module keeps no dependency
+// outside the standard library:
+//
+//	provider := otel.NewLoggerProvider(l4g.Default())
+//	global.SetLoggerProvider(provider)
+package otel
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go-slim.dev/l4g"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/embedded"
+)
+
+// Keys of the attributes added by the bridge.
+const (
+	ScopeNameKey    = "otel.scope.name"    // Name of the Logger, when not empty
+	ScopeVersionKey = "otel.scope.version" // Version of the instrumentation, when set
+	EventNameKey    = "event.name"         // Event name of the record, when set
+	SeverityTextKey = "severity_text"      // Severity of the record, when finer than its level
+	BodyKey         = "body"               // Body of the record, when not a string
+)
+
+// LoggerProvider is a [log.LoggerProvider] whose loggers emit to an
+// [l4g.Logger].
+type LoggerProvider struct {
+	embedded.LoggerProvider
+	l *l4g.Logger
+}
+
+// NewLoggerProvider returns a LoggerProvider whose loggers emit to l, or
+// to the default logger if l is nil.
+func NewLoggerProvider(l *l4g.Logger) *LoggerProvider {
+	return &LoggerProvider{l: l}
+}
+
+// Logger returns a [log.Logger] emitting to the logger of p, with the
+// name and the instrumentation version, if any, added as attributes.
+func (p *LoggerProvider) Logger(name string, options ...log.LoggerOption) log.Logger {
+	l := p.l
+	if l == nil {
+		l = l4g.Default()
+	}
+	var args []any
+	if name != "" {
+		args = append(args, l4g.String(ScopeNameKey, name))
+	}
+	if version := log.NewLoggerConfig(options...).InstrumentationVersion(); version != "" {
+		args = append(args, l4g.String(ScopeVersionKey, version))
+	}
+	return &logger{l: l.WithAttrs(args...)}
+}
+
+// logger is a log.Logger emitting to an l4g.Logger.
+type logger struct {
+	embedded.Logger
+	l *l4g.Logger
+}
+
+// Emit passes r to the l4g logger, derived with ctx as by
+// [l4g.Logger.WithContext].
+func (l *logger) Emit(ctx context.Context, r log.Record) {
+	level := Level(r.Severity())
+	if !l.l.Enabled(level) {
+		return
+	}
+	t := r.Timestamp()
+	if t.IsZero() {
+		t = r.ObservedTimestamp()
+	}
+	if t.IsZero() {
+		t = time.Now()
+	}
+	rec := l4g.NewRecord(t, level, "")
+	body := r.Body()
+	if body.Kind() == log.KindString {
+		rec.Message = body.AsString()
+	} else if !body.Empty() {
+		rec.AddAttrs(slog.Attr{Key: BodyKey, Value: Value(body)})
+	}
+	if name := r.EventName(); name != "" {
+		rec.AddAttrs(l4g.String(EventNameKey, name))
+	}
+	if text := severityText(r); text != "" {
+		rec.AddAttrs(l4g.String(SeverityTextKey, text))
+	}
+	r.WalkAttributes(func(kv log.KeyValue) bool {
+		rec.AddAttrs(slog.Attr{Key: kv.Key, Value: Value(kv.Value)})
+		return true
+	})
+	l.l.WithContext(ctx).LogRecord(rec)
+}
+
+// Enabled reports whether the l4g logger logs the records of the given
+// severity. An undefined severity is reported enabled.
+func (l *logger) Enabled(ctx context.Context, param log.EnabledParameters) bool {
+	if param.Severity == log.SeverityUndefined {
+		return true
+	}
+	return l.l.Enabled(Level(param.Severity))
+}
+
+// Level returns the l4g level of an OpenTelemetry severity: each of the
+// six severity ranges maps to the level of the same name, LevelFatal
+// standing for FATAL through FATAL4. An undefined severity maps to
+// LevelInfo.
+func Level(s log.Severity) l4g.Level {
+	switch {
+	case s == log.SeverityUndefined:
+		return l4g.LevelInfo
+	case s < log.SeverityDebug:
+		return l4g.LevelTrace
+	case s < log.SeverityInfo:
+		return l4g.LevelDebug
+	case s < log.SeverityWarn:
+		return l4g.LevelInfo
+	case s < log.SeverityError:
+		return l4g.LevelWarn
+	case s < log.SeverityFatal:
+		return l4g.LevelError
+	}
+	return l4g.LevelFatal
+}
+
+// severityText returns the severity text of r, or the name of its
+// severity if finer than its level, as "ERROR2", so that it is not lost.
+func severityText(r log.Record) string {
+	if text := r.SeverityText(); text != "" {
+		return text
+	}
+	if s := r.Severity(); s > log.SeverityUndefined && (s-log.SeverityTrace1)%4 != 0 {
+		return s.String()
+	}
+	return ""
+}
+
+// Value returns the slog value of an OpenTelemetry value: maps become
+// groups, slices []any and bytes []byte.
+func Value(v log.Value) slog.Value {
+	switch v.Kind() {
+	case log.KindBool:
+		return slog.BoolValue(v.AsBool())
+	case log.KindFloat64:
+		return slog.Float64Value(v.AsFloat64())
+	case log.KindInt64:
+		return slog.Int64Value(v.AsInt64())
+	case log.KindString:
+		return slog.StringValue(v.AsString())
+	case log.KindBytes:
+		return slog.AnyValue(v.AsBytes())
+	case log.KindSlice:
+		vs := v.AsSlice()
+		s := make([]any, len(vs))
+		for i, e := range vs {
+			s[i] = Value(e).Any()
+		}
+		return slog.AnyValue(s)
+	case log.KindMap:
+		kvs := v.AsMap()
+		attrs := make([]slog.Attr, len(kvs))
+		for i, kv := range kvs {
+			attrs[i] = slog.Attr{Key: kv.Key, Value: Value(kv.Value)}
+		}
+		return slog.GroupValue(attrs...)
+	}
+	return slog.AnyValue(nil)
+}
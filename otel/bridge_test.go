@@ -0,0 +1,116 @@
+package otel
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go-slim.dev/l4g"
+	"go.opentelemetry.io/otel/log"
+)
+
+func TestLevel(t *testing.T) {
+	tests := []struct {
+		severity log.Severity
+		want     l4g.Level
+	}{
+		{log.SeverityUndefined, l4g.LevelInfo},
+		{log.SeverityTrace, l4g.LevelTrace},
+		{log.SeverityTrace4, l4g.LevelTrace},
+		{log.SeverityDebug, l4g.LevelDebug},
+		{log.SeverityInfo3, l4g.LevelInfo},
+		{log.SeverityWarn, l4g.LevelWarn},
+		{log.SeverityError2, l4g.LevelError},
+		{log.SeverityFatal4, l4g.LevelFatal},
+	}
+	for _, tt := range tests {
+		if got := Level(tt.severity); got != tt.want {
+			t.Errorf("Level(%v) = %v, want %v", tt.severity, got, tt.want)
+		}
+	}
+}
+
+func TestLogger_Emit(t *testing.T) {
+	buf := &bytes.Buffer{}
+	provider := NewLoggerProvider(l4g.New(l4g.Options{Output: buf, NoColor: true}))
+	logger := provider.Logger("db", log.WithInstrumentationVersion("v1.2.0"))
+
+	var r log.Record
+	r.SetTimestamp(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	r.SetSeverity(log.SeverityError2)
+	r.SetBody(log.StringValue("query failed"))
+	r.SetEventName("db.query")
+	r.AddAttributes(
+		log.Int("rows", 3),
+		log.Map("conn", log.String("host", "db1")),
+		log.Slice("ids", log.Int64Value(1), log.Int64Value(2)),
+	)
+	logger.Emit(context.Background(), r)
+
+	got := buf.String()
+	for _, want := range []string{
+		"ERROR", "query failed", "otel.scope.name=db", "otel.scope.version=v1.2.0",
+		"event.name=db.query", "severity_text=ERROR2", "rows=3", "conn.host=db1", `ids="[1 2]"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestLogger_EmitBody(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewLoggerProvider(l4g.New(l4g.Options{Output: buf, NoColor: true})).Logger("")
+
+	var r log.Record
+	r.SetSeverity(log.SeverityInfo)
+	r.SetBody(log.Int64Value(42))
+	logger.Emit(context.Background(), r)
+
+	got := buf.String()
+	if !strings.Contains(got, "body=42") {
+		t.Errorf("output = %q, want the body attribute", got)
+	}
+	if strings.Contains(got, "otel.scope.name") || strings.Contains(got, "severity_text") {
+		t.Errorf("output = %q, want no scope name nor severity text", got)
+	}
+}
+
+func TestLogger_Enabled(t *testing.T) {
+	l := l4g.New(l4g.Options{Output: &bytes.Buffer{}, Level: l4g.LevelWarn})
+	logger := NewLoggerProvider(l).Logger("test")
+	ctx := context.Background()
+
+	if logger.Enabled(ctx, log.EnabledParameters{Severity: log.SeverityInfo}) {
+		t.Errorf("Enabled(INFO) = true, want false below the logger level")
+	}
+	if !logger.Enabled(ctx, log.EnabledParameters{Severity: log.SeverityWarn}) {
+		t.Errorf("Enabled(WARN) = false, want true")
+	}
+	if !logger.Enabled(ctx, log.EnabledParameters{}) {
+		t.Errorf("Enabled(UNDEFINED) = false, want true")
+	}
+}
+
+func TestLogger_EmitErrorHook(t *testing.T) {
+	var got []string
+	l := l4g.New(l4g.Options{
+		Output: &bytes.Buffer{},
+		ErrorHook: func(ctx context.Context, r l4g.Record) {
+			got = append(got, r.Message)
+		},
+	})
+	logger := NewLoggerProvider(l).Logger("test")
+
+	for _, s := range []log.Severity{log.SeverityInfo, log.SeverityError} {
+		var r log.Record
+		r.SetSeverity(s)
+		r.SetBody(log.StringValue(s.String()))
+		logger.Emit(context.Background(), r)
+	}
+	if len(got) != 1 || got[0] != "ERROR" {
+		t.Errorf("hooked records = %v, want [ERROR]", got)
+	}
+}
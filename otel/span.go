@@ -0,0 +1,84 @@
+package otel
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"go-slim.dev/l4g"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// LevelKey is the key of the level attribute of the span events added by
+// [SpanEventHook].
+const LevelKey = "level"
+
+// SpanEventHook is an [l4g.Options] ErrorHook adding each record at
+// LevelError or above, logged by a logger derived with WithContext, as an
+// event to the span of the context if it is recording, so that traces
+// show the errors inline:
+//
+//	logger := l4g.New(l4g.Options{ErrorHook: otel.SpanEventHook})
+//	logger.WithContext(ctx).Error("payment failed", "order", id)
+//
+// The event is named after the message and holds the level and the
+// attributes of the record, with the keys of attributes within groups
+// dotted, as in "http.status".
+func SpanEventHook(ctx context.Context, r l4g.Record) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	attrs := make([]attribute.KeyValue, 0, 1+r.NumAttrs())
+	attrs = append(attrs, attribute.String(LevelKey, r.Level.String()))
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = appendAttr(attrs, a, "")
+		return true
+	})
+	span.AddEvent(r.Message, trace.WithTimestamp(r.Time), trace.WithAttributes(attrs...))
+}
+
+// appendAttr appends a as span attributes, expanding groups.
+func appendAttr(attrs []attribute.KeyValue, a slog.Attr, group string) []attribute.KeyValue {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return attrs
+	}
+	key := group + a.Key
+	v := a.Value
+	switch v.Kind() {
+	case slog.KindGroup:
+		if a.Key != "" {
+			group = key + "."
+		}
+		for _, ga := range v.Group() {
+			attrs = appendAttr(attrs, ga, group)
+		}
+		return attrs
+	case slog.KindString:
+		return append(attrs, attribute.String(key, v.String()))
+	case slog.KindInt64:
+		return append(attrs, attribute.Int64(key, v.Int64()))
+	case slog.KindUint64:
+		if u := v.Uint64(); u <= math.MaxInt64 {
+			return append(attrs, attribute.Int64(key, int64(u)))
+		}
+	case slog.KindFloat64:
+		return append(attrs, attribute.Float64(key, v.Float64()))
+	case slog.KindBool:
+		return append(attrs, attribute.Bool(key, v.Bool()))
+	case slog.KindTime:
+		return append(attrs, attribute.String(key, v.Time().Format(time.RFC3339Nano)))
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return append(attrs, attribute.String(key, err.Error()))
+		}
+		if s, ok := v.Any().(fmt.Stringer); ok {
+			return append(attrs, attribute.String(key, s.String()))
+		}
+	}
+	return append(attrs, attribute.String(key, v.String()))
+}
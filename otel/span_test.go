@@ -0,0 +1,62 @@
+package otel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-slim.dev/l4g"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSpanEventHook(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "checkout")
+
+	logger := l4g.New(l4g.Options{Output: &bytes.Buffer{}, ErrorHook: SpanEventHook})
+	l := logger.WithContext(ctx)
+	l.Info("not an event")
+	l.Error("payment failed", l4g.Group("order", "id", 42), "err", errors.New("card declined"))
+	logger.Error("no context")
+	span.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("%d spans ended, want 1", len(spans))
+	}
+	events := spans[0].Events()
+	if len(events) != 1 {
+		t.Fatalf("events = %v, want 1 event", events)
+	}
+	if events[0].Name != "payment failed" {
+		t.Errorf("event name = %q, want the message", events[0].Name)
+	}
+	got := map[attribute.Key]string{}
+	for _, kv := range events[0].Attributes {
+		got[kv.Key] = kv.Value.Emit()
+	}
+	want := map[attribute.Key]string{"level": "error", "order.id": "42", "err": "card declined"}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("attribute %s = %q, want %q (attributes: %v)", k, got[k], v, got)
+		}
+	}
+}
+
+func TestSpanEventHook_NotRecording(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder), sdktrace.WithSampler(sdktrace.NeverSample()))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "checkout")
+	r := l4g.NewRecord(time.Now(), l4g.LevelError, "failed")
+	SpanEventHook(ctx, r)
+	SpanEventHook(context.Background(), r)
+	span.End()
+	if spans := recorder.Ended(); len(spans) != 0 {
+		t.Errorf("spans = %v, want none recorded", spans)
+	}
+}
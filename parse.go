@@ -0,0 +1,217 @@
+package l4g
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errTextLine reports a line that is neither written by a SimpleHandler
+// nor made of logfmt pairs with a level.
+var errTextLine = errors.New("l4g: not a text record")
+
+// lineTimeLayouts are the layouts tried for the time starting a line: the
+// default TimeFormat of the SimpleHandler and other common ones.
+var lineTimeLayouts = []string{
+	time.StampMilli,
+	time.RFC3339Nano,
+	time.DateTime,
+	time.Stamp,
+	time.StampMicro,
+	time.StampNano,
+}
+
+// ParseLine parses a line written by a [SimpleHandler] back into a
+// Record, for tools filtering, re-leveling or converting existing text
+// logs. The line holds, as the handler writes them, an optional time, the
+// level, an optional "[prefix]", "#tag" words, an optional "file:line"
+// source, the message and the attributes as key=value pairs, with their
+// values bare or quoted as by [strconv.Quote]. ANSI escape sequences are
+// ignored, and a final newline is allowed.
+//
+// Lines of logfmt pairs, as written by [log/slog.TextHandler] or
+// [Record.MarshalLogfmt], are accepted too: the time, level, msg, prefix
+// and tags keys then give the fields of the record.
+//
+// The keys of groups, joined with dots, are turned back into groups, and
+// bare values into booleans, numbers, times or durations when they read
+// as such. The source is kept as a [SourceKey] string attribute, since
+// the record has no program counter for it. A year-less time, as written
+// by the default time.StampMilli, is taken in the current year, in the
+// local time zone. As the message is written unquoted, its words from the
+// first one that starts the key=value pairs ending the line are parsed as
+// attributes.
+func ParseLine(line []byte) (Record, error) {
+	s := stripAnsi(strings.TrimRight(string(line), "\r\n"))
+	if pairs, err := parseLogfmtPairs(s); err == nil {
+		if r, ok := logfmtRecord(pairs); ok {
+			return r, nil
+		}
+	}
+
+	var r Record
+	rest := s
+	if t, n, ok := parseLineTime(rest); ok {
+		r.Time = t
+		rest = strings.TrimPrefix(rest[n:], " ")
+	}
+	word, rest, _ := strings.Cut(rest, " ")
+	level, ok := parseTextLevel(word)
+	if !ok {
+		return Record{}, errTextLine
+	}
+	r.Level = level
+	if strings.HasPrefix(rest, "[") {
+		if prefix, after, ok := strings.Cut(rest[1:], "] "); ok {
+			r.Prefix, rest = prefix, after
+		} else if strings.HasSuffix(rest, "]") {
+			r.Prefix, rest = rest[1:len(rest)-1], ""
+		}
+	}
+	for strings.HasPrefix(rest, "#") {
+		word, rest, _ = strings.Cut(rest, " ")
+		r.Tags = append(r.Tags, word[1:])
+	}
+	var attrs []Attr
+	if word, after, _ := strings.Cut(rest, " "); isSourceWord(word) {
+		attrs = append(attrs, String(SourceKey, word))
+		rest = after
+	}
+
+	msg, pairs := splitMessage(rest)
+	r.Message = msg
+	group, err := groupPairs(pairs, textValue)
+	if err != nil {
+		return Record{}, err
+	}
+	r.AddAttrs(append(attrs, group...)...)
+	return r, nil
+}
+
+// logfmtRecord returns the record of a line of logfmt pairs, reporting
+// false if it has no valid level.
+func logfmtRecord(pairs []logfmtPair) (Record, bool) {
+	var r Record
+	var hasLevel bool
+	attrs := pairs[:0:0]
+	for _, p := range pairs {
+		switch p.key {
+		case TimeKey:
+			if t, err := time.Parse(time.RFC3339Nano, p.value); err == nil {
+				r.Time = t
+				continue
+			}
+		case LevelKey:
+			if level, ok := parseTextLevel(p.value); ok {
+				r.Level = level
+				hasLevel = true
+				continue
+			}
+		case MessageKey:
+			r.Message = p.value
+			continue
+		case PrefixKey:
+			r.Prefix = p.value
+			continue
+		case TagsKey:
+			r.Tags = append(r.Tags, p.value)
+			continue
+		}
+		attrs = append(attrs, p)
+	}
+	if !hasLevel {
+		return Record{}, false
+	}
+	group, err := groupPairs(attrs, textValue)
+	if err != nil {
+		return Record{}, false
+	}
+	r.AddAttrs(group...)
+	return r, true
+}
+
+// parseLineTime parses the time starting s in one of lineTimeLayouts,
+// returning the number of bytes it takes. As times may hold spaces, the
+// shortest prefix of s ending before a space, or s itself, that parses
+// is taken.
+func parseLineTime(s string) (time.Time, int, bool) {
+	for n, words := 0, 0; n < len(s) && words < 4; words++ {
+		if i := strings.IndexByte(s[n+1:], ' '); i >= 0 {
+			n += 1 + i
+		} else {
+			n = len(s)
+		}
+		for _, layout := range lineTimeLayouts {
+			t, err := time.ParseInLocation(layout, s[:n], time.Local)
+			if err != nil {
+				continue
+			}
+			if t.Year() == 0 {
+				t = t.AddDate(time.Now().Year(), 0, 0)
+			}
+			return t, n, true
+		}
+	}
+	return time.Time{}, 0, false
+}
+
+// parseTextLevel parses a level written by the built-in handlers, with the
+// offset appended by the SimpleHandler to the levels past Trace and Fatal,
+// as "FATAL+2".
+func parseTextLevel(s string) (Level, bool) {
+	if level, ok := parseLevelName(s); ok {
+		return level, true
+	}
+	i := strings.LastIndexAny(s, "+-")
+	if i <= 0 {
+		return 0, false
+	}
+	level, ok := parseLevelName(s[:i])
+	n, err := strconv.Atoi(s[i:])
+	if !ok || err != nil {
+		return 0, false
+	}
+	return level + Level(n), true
+}
+
+// isSourceWord reports whether word reads as a "file:line" source.
+func isSourceWord(word string) bool {
+	file, line, ok := strings.Cut(word, ":")
+	if !ok || file == "" || strings.ContainsAny(file, `="`) {
+		return false
+	}
+	_, err := strconv.Atoi(line)
+	return err == nil && strings.Contains(file, ".")
+}
+
+// splitMessage splits s into the message and the key=value pairs ending
+// the line, which start at the first word beginning a valid sequence of
+// pairs.
+func splitMessage(s string) (string, []logfmtPair) {
+	for p := 0; p < len(s); {
+		if strings.IndexByte(s[p:], '=') < 0 {
+			break
+		}
+		if pairs, err := parseLogfmtPairs(s[p:]); err == nil {
+			return strings.TrimSuffix(s[:p], " "), pairs
+		}
+		i := strings.IndexByte(s[p:], ' ')
+		if i < 0 {
+			break
+		}
+		p += i + 1
+	}
+	return strings.TrimSuffix(s, " "), nil
+}
+
+// textValue returns the value of p, converted as by logfmtValue when it
+// is bare and reads as a boolean, a number, a time or a duration, and as
+// a string otherwise.
+func textValue(p logfmtPair) (slog.Value, error) {
+	if v, err := logfmtValue(p); err == nil {
+		return v, nil
+	}
+	return slog.StringValue(p.value), nil
+}
@@ -0,0 +1,89 @@
+package l4g
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestParseLine(t *testing.T) {
+	at := time.Date(time.Now().Year(), 5, 1, 12, 0, 0, 123e6, time.Local)
+	r := NewRecord(at, LevelWarn, "slow query")
+	r.Prefix = "db"
+	r.Tags = []string{"sql"}
+	r.AddAttrs(
+		Int("rows", 3),
+		String("table", "user accounts"),
+		Group("req", Bool("cached", false), Duration("took", 1500*time.Millisecond), Group("peer", String("ip", "10.0.0.1"))),
+	)
+
+	for _, opts := range []HandlerOptions{
+		{NoColor: true},
+		{NoColor: false},
+		{NoColor: true, TimeFormat: time.RFC3339Nano},
+	} {
+		var buf bytes.Buffer
+		opts.Output = &buf
+		NewSimpleHandler(opts).Handle(r)
+
+		got, err := ParseLine(buf.Bytes())
+		if err != nil {
+			t.Fatalf("ParseLine(%q): %v", buf.String(), err)
+		}
+		if !got.Time.Equal(at) || got.Level != r.Level || got.Prefix != r.Prefix || got.Message != r.Message {
+			t.Errorf("ParseLine(%q) = %v %v %q %q", buf.String(), got.Time, got.Level, got.Prefix, got.Message)
+		}
+		if fmt.Sprint(got.Tags) != "[sql]" {
+			t.Errorf("ParseLine(%q).Tags = %v, want [sql]", buf.String(), got.Tags)
+		}
+		if got, want := recordAttrsString(got), recordAttrsString(r); got != want {
+			t.Errorf("ParseLine(%q) attrs = %s, want %s", buf.String(), got, want)
+		}
+	}
+}
+
+func TestParseLine_Formats(t *testing.T) {
+	tests := []struct {
+		line  string
+		level Level
+		msg   string
+		attrs string
+	}{
+		{"INFO hello world\n", LevelInfo, "hello world", "[]"},
+		{"INFO  a=1", LevelInfo, "", "[a=1]"},
+		{"ERROR main.go:12 done x=1 y=\"a b\"", LevelError, "done", "[source=main.go:12 x=1 y=a b]"},
+		{"FATAL+2 boom", LevelFatal + 2, "boom", "[]"},
+		{"DEBUG-1 at=2024-05-01T12:00:00Z", LevelDebug - 1, "", "[at=2024-05-01 12:00:00 +0000 UTC]"},
+		{`time=2024-05-01T12:00:00Z level=INFO msg="hi there" a.b=1`, LevelInfo, "hi there", "[a=[b=1]]"},
+	}
+	for _, tt := range tests {
+		r, err := ParseLine([]byte(tt.line))
+		if err != nil {
+			t.Errorf("ParseLine(%q): %v", tt.line, err)
+			continue
+		}
+		if r.Level != tt.level || r.Message != tt.msg {
+			t.Errorf("ParseLine(%q) = %v %q, want %v %q", tt.line, r.Level, r.Message, tt.level, tt.msg)
+		}
+		if got := recordAttrsString(r); got != tt.attrs {
+			t.Errorf("ParseLine(%q) attrs = %s, want %s", tt.line, got, tt.attrs)
+		}
+	}
+
+	for _, line := range []string{"", "hello", "a=1 b=2", "May  1 12:00:00.000"} {
+		if _, err := ParseLine([]byte(line)); err == nil {
+			t.Errorf("ParseLine(%q) succeeded, want an error", line)
+		}
+	}
+}
+
+// recordAttrsString returns the attributes of r formatted by fmt.
+func recordAttrsString(r Record) string {
+	var attrs []slog.Attr
+	for a := range r.All() {
+		attrs = append(attrs, a)
+	}
+	return fmt.Sprint(attrs)
+}
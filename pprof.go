@@ -0,0 +1,46 @@
+package l4g
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// SinkLabel is the pprof label naming the sink served by a background
+// goroutine of this package when [ProfileLabels] is set.
+const SinkLabel = "l4g.sink"
+
+// ProfileLabels, if set, makes the background goroutines of this package,
+// such as the flushes of a [GzipWriter] or a [SQLiteHandler] and the
+// [CompactEvery] loop, run under a [SinkLabel] pprof label naming their sink,
+// so that CPU profiles show the cost of logging by sink. It must be set
+// before the sinks are created.
+var ProfileLabels bool
+
+// runLabeled calls f, under a SinkLabel pprof label set to sink if
+// ProfileLabels is set.
+func runLabeled(sink string, f func()) {
+	if !ProfileLabels {
+		f()
+		return
+	}
+	pprof.Do(context.Background(), pprof.Labels(SinkLabel, sink), func(context.Context) { f() })
+}
+
+// ProfileLabelAttrs returns the pprof labels of ctx with the given keys,
+// as set by pprof.Do or pprof.WithLabels, as string attributes to pass to
+// a log call, so that records can be correlated with CPU profiles:
+//
+//	pprof.Do(ctx, pprof.Labels("endpoint", "/api"), func(ctx context.Context) {
+//		l4g.Info("request", l4g.ProfileLabelAttrs(ctx, "endpoint")...)
+//	})
+//
+// Missing labels are skipped.
+func ProfileLabelAttrs(ctx context.Context, keys ...string) []any {
+	var attrs []any
+	for _, key := range keys {
+		if v, ok := pprof.Label(ctx, key); ok {
+			attrs = append(attrs, String(key, v))
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,44 @@
+package l4g
+
+import (
+	"bytes"
+	"context"
+	"runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProfileLabels(t *testing.T) {
+	ProfileLabels = true
+	defer func() { ProfileLabels = false }()
+
+	stop := CompactEvery(time.Hour)
+	defer stop()
+
+	want := `"` + SinkLabel + `":"compaction"`
+	var profile bytes.Buffer
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(profile.String(), want) && time.Now().Before(deadline) {
+		profile.Reset()
+		pprof.Lookup("goroutine").WriteTo(&profile, 1)
+		time.Sleep(time.Millisecond)
+	}
+	if !strings.Contains(profile.String(), want) {
+		t.Errorf("goroutine profile has no label %s", want)
+	}
+}
+
+func TestProfileLabelAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(Options{Output: &buf, NoColor: true})
+	pprof.Do(context.Background(), pprof.Labels("endpoint", "/api", "tenant", "acme"), func(ctx context.Context) {
+		l.Info("request", ProfileLabelAttrs(ctx, "endpoint", "missing")...)
+	})
+	if out := buf.String(); !strings.Contains(out, "request endpoint=/api\n") {
+		t.Errorf("output = %q, want only the endpoint label", out)
+	}
+	if attrs := ProfileLabelAttrs(context.Background(), "endpoint"); attrs != nil {
+		t.Errorf("ProfileLabelAttrs() = %v without labels, want nil", attrs)
+	}
+}
@@ -0,0 +1,222 @@
+package l4g
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PrettyPrint reads the records written by a [JSONHandler], or as logfmt
+// key=value pairs, one per line, and writes them to dst with the layout of
+// the [SimpleHandler], for reading production logs locally. The options
+// configure the handler as they would configure a Logger, as WithNoColor;
+// all the records are written unless WithLevel is given. FieldNames and
+// Severity name the built-in JSON fields read.
+//
+// Lines that are neither JSON objects nor logfmt are copied unchanged.
+// PrettyPrint stops at the end of src, returning nil, or at the first read
+// or write error.
+func PrettyPrint(src io.Reader, dst io.Writer, opts ...Option) error {
+	o := Options{Level: LevelTrace}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.NewHandlerFunc == nil {
+		// the layout of the SimpleHandler, whatever the default factory
+		o.NewHandlerFunc = NewSimpleHandler
+	}
+	h := o.Handler
+	if h == nil {
+		h = o.newHandler(o.Level, dst)
+	}
+	names := o.FieldNames
+	if o.Severity && names.Level == "" {
+		names.Level = SeverityKey
+	}
+	names = names.withDefaults()
+
+	br := bufio.NewReader(src)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			if werr := prettyLine(h, dst, names, line); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// prettyLine writes the record encoded in line to h, or line itself to dst.
+func prettyLine(h Handler, dst io.Writer, names FieldNames, line []byte) error {
+	text := bytes.TrimRight(line, "\r\n")
+	var attrs []Attr
+	var err error
+	if trimmed := bytes.TrimSpace(text); len(trimmed) > 0 && trimmed[0] == '{' {
+		attrs, err = parseJSONObject(trimmed)
+	} else {
+		attrs, err = parseLogfmt(string(text))
+	}
+	if err != nil || len(attrs) == 0 {
+		if len(line) > 0 && line[len(line)-1] != '\n' {
+			line = append(line, '\n')
+		}
+		_, err := dst.Write(line)
+		return err
+	}
+
+	r := NewRecord(time.Time{}, LevelInfo, "")
+	for _, a := range attrs {
+		switch a.Key {
+		case names.Time:
+			if t, err := time.Parse(time.RFC3339Nano, a.Value.String()); err == nil {
+				r.Time = t
+				continue
+			}
+		case names.Level:
+			if level, ok := parseLevelName(a.Value.String()); ok {
+				r.Level = level
+				continue
+			}
+		case names.Message:
+			r.Message = a.Value.String()
+			continue
+		case names.Prefix:
+			r.Prefix = a.Value.String()
+			continue
+		}
+		r.AddAttrs(a)
+	}
+	if !h.Enabled(r.Level) {
+		return nil
+	}
+	return h.Handle(r)
+}
+
+// parseLevelName parses a level name written by the built-in handlers,
+// including the severities written with Severity.
+func parseLevelName(s string) (Level, bool) {
+	var level Level
+	if err := level.parse(s); err == nil {
+		return level, true
+	}
+	switch strings.ToUpper(s) {
+	case "DEFAULT":
+		return LevelInfo, true
+	case "WARNING":
+		return LevelWarn, true
+	case "CRITICAL":
+		return LevelPanic, true
+	}
+	return 0, false
+}
+
+// parseJSONObject returns the members of the JSON object in data as
+// attributes, in order, with nested objects as groups.
+func parseJSONObject(data []byte) ([]Attr, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, errRecordFormat
+	}
+	var attrs []Attr
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		if raw[0] == '{' {
+			group, err := parseJSONObject(raw)
+			if err != nil {
+				return nil, err
+			}
+			attrs = append(attrs, Attr{Key: key, Value: slog.GroupValue(group...)})
+			continue
+		}
+		attrs = append(attrs, jsonValueAttr(key, raw))
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return attrs, nil
+}
+
+// jsonValueAttr returns an attribute holding the JSON value in raw.
+func jsonValueAttr(key string, raw json.RawMessage) Attr {
+	switch raw[0] {
+	case '"':
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			return String(key, s)
+		}
+	case 't', 'f':
+		return Bool(key, raw[0] == 't')
+	case 'n':
+		return Any(key, nil)
+	case '[':
+		var v []any
+		if json.Unmarshal(raw, &v) == nil {
+			return Any(key, v)
+		}
+	default:
+		if n, err := strconv.ParseInt(string(raw), 10, 64); err == nil {
+			return Int64(key, n)
+		}
+		if f, err := strconv.ParseFloat(string(raw), 64); err == nil {
+			return Float(key, f)
+		}
+	}
+	return String(key, string(raw))
+}
+
+// errLogfmt reports a line that is not made of logfmt pairs.
+var errLogfmt = errors.New("l4g: not logfmt")
+
+// parseLogfmt returns the key=value pairs of a logfmt line as string
+// attributes, values being bare or quoted as by strconv.Quote.
+func parseLogfmt(line string) ([]Attr, error) {
+	var attrs []Attr
+	for {
+		line = strings.TrimLeft(line, " ")
+		if line == "" {
+			return attrs, nil
+		}
+		eq := strings.IndexByte(line, '=')
+		if eq <= 0 || strings.ContainsAny(line[:eq], " \"") {
+			return nil, errLogfmt
+		}
+		key := line[:eq]
+		line = line[eq+1:]
+		var value string
+		if strings.HasPrefix(line, `"`) {
+			quoted, err := strconv.QuotedPrefix(line)
+			if err != nil {
+				return nil, errLogfmt
+			}
+			value, _ = strconv.Unquote(quoted)
+			line = line[len(quoted):]
+		} else {
+			end := strings.IndexByte(line, ' ')
+			if end < 0 {
+				end = len(line)
+			}
+			value, line = line[:end], line[end:]
+		}
+		attrs = append(attrs, String(key, value))
+	}
+}
@@ -0,0 +1,47 @@
+package l4g
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPrettyPrint(t *testing.T) {
+	src := &bytes.Buffer{}
+	l := New(Options{Output: src, Level: LevelDebug, NewHandlerFunc: NewJSONHandler}).WithPrefix("app")
+	l.WithGroup("req").Info("hello world", "path", "/a b", "status", 200)
+	l.Debug("details", "ok", true)
+	src.WriteString("level=warn msg=\"disk almost full\" free=5%\n")
+	src.WriteString("plain text line\n")
+
+	dst := &bytes.Buffer{}
+	if err := PrettyPrint(src, dst, WithNoColor(), WithLevel(LevelInfo)); err != nil {
+		t.Fatalf("PrettyPrint() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(dst.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("PrettyPrint() wrote %d lines, want 3:\n%s", len(lines), dst.String())
+	}
+	for i, want := range []string{
+		`INFO [app] hello world req.path="/a b" req.status=200`,
+		`WARN disk almost full free=5%`,
+		`plain text line`,
+	} {
+		if !strings.Contains(lines[i], want) {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want)
+		}
+	}
+}
+
+func TestPrettyPrint_Severity(t *testing.T) {
+	src := &bytes.Buffer{}
+	New(Options{Output: src, NewHandlerFunc: NewJSONHandler, Severity: true}).Warn("careful")
+
+	dst := &bytes.Buffer{}
+	if err := PrettyPrint(src, dst, WithNoColor(), func(o *Options) { o.Severity = true }); err != nil {
+		t.Fatalf("PrettyPrint() error = %v", err)
+	}
+	if got := dst.String(); !strings.Contains(got, "WARN careful") {
+		t.Errorf("PrettyPrint() = %q, want the level read from the severity", got)
+	}
+}
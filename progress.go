@@ -0,0 +1,76 @@
+package l4g
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ProgressInterval is the minimum time between the records logged by
+// [Progress.Step].
+var ProgressInterval = 5 * time.Second
+
+// A Progress logs the progress of a long operation, such as a batch job
+// or a migration, at most once per [ProgressInterval]. It is safe for
+// concurrent use.
+//
+//	p := l4g.StartProgress(logger, "migrating", len(rows))
+//	for _, row := range rows {
+//		migrate(row)
+//		p.Step(1)
+//	}
+//	p.Done()
+type Progress struct {
+	l        *Logger
+	msg      string
+	total    int64
+	start    time.Time
+	count    atomic.Int64
+	last     atomic.Int64 // unix nanoseconds of the last record
+	finished atomic.Bool
+}
+
+// StartProgress returns a Progress logging msg with l, at LevelInfo, for
+// an operation of total steps; total may be zero if unknown. If l is nil,
+// the default logger is used.
+func StartProgress(l *Logger, msg string, total int) *Progress {
+	if l == nil {
+		l = Default()
+	}
+	p := &Progress{l: l, msg: msg, total: int64(total), start: l.clock.Now()}
+	p.last.Store(p.start.UnixNano())
+	return p
+}
+
+// Step adds n steps to the progress and, if ProgressInterval has elapsed
+// since the last record, logs the count of steps, the percentage of
+// total, the rate per second and the estimated time left.
+func (p *Progress) Step(n int) {
+	count := p.count.Add(int64(n))
+	now := p.l.clock.Now()
+	last := p.last.Load()
+	if now.UnixNano()-last < int64(ProgressInterval) || !p.last.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+	elapsed := now.Sub(p.start)
+	rate := float64(count) / elapsed.Seconds()
+	args := []any{Int64("count", count)}
+	if p.total > 0 {
+		args = append(args, Int64("total", p.total), Float("percent", float64(count*1000/p.total)/10))
+	}
+	args = append(args, Float("rate", float64(int64(rate*10))/10))
+	if p.total > count && rate > 0 {
+		eta := time.Duration(float64(p.total-count) / rate * float64(time.Second))
+		args = append(args, Duration("eta", eta.Round(time.Second)))
+	}
+	p.l.Info(p.msg, args...)
+}
+
+// Done logs the completion of the operation with the count of steps and
+// the elapsed time, once however many times it is called.
+func (p *Progress) Done() {
+	if p.finished.Swap(true) {
+		return
+	}
+	elapsed := p.l.clock.Now().Sub(p.start)
+	p.l.Info(p.msg+" done", Int64("count", p.count.Load()), Duration("elapsed", elapsed.Round(time.Millisecond)))
+}
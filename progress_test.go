@@ -0,0 +1,34 @@
+package l4g
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	clock := newFakeClock()
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true, Clock: clock})
+
+	p := StartProgress(logger, "migrating", 4)
+	p.Step(1)
+	if buf.Len() != 0 {
+		t.Errorf("output = %q, want no record before the interval", buf.String())
+	}
+
+	clock.Advance(ProgressInterval)
+	p.Step(1)
+	if got := buf.String(); !strings.Contains(got, "INFO migrating count=2 total=4 percent=50 rate=0.4 eta=5s\n") {
+		t.Errorf("output = %q, want the progress", got)
+	}
+
+	buf.Reset()
+	clock.Advance(time.Second)
+	p.Done()
+	p.Done()
+	if got := buf.String(); !strings.Contains(got, "INFO migrating done count=2 elapsed=6s\n") || strings.Count(got, "done") != 1 {
+		t.Errorf("output = %q, want a single done record", got)
+	}
+}
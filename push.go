@@ -0,0 +1,91 @@
+package l4g
+
+import (
+	"slices"
+	"sync/atomic"
+)
+
+// pushStack holds the attributes pushed on a logger with Push. Each frame
+// is the slice passed to one call; the slice of frames is never modified,
+// Push and pop replace it.
+type pushStack struct {
+	frames atomic.Pointer[[]*pushFrame]
+}
+
+type pushFrame struct {
+	attrs []Attr
+}
+
+// clone returns a copy of l with an empty stack of pushed attributes, for
+// the methods deriving a logger from l.
+func (l *Logger) clone() *Logger {
+	l2 := *l
+	if l.pushed != nil {
+		l2.pushed = new(pushStack)
+	}
+	return &l2
+}
+
+// Push adds attrs to the records subsequently logged by l, until the
+// returned function is called. Unlike [Logger.WithAttrs], it changes l
+// itself rather than allocating a new logger and handler, which suits
+// loops logging about each of their items:
+//
+//	for _, item := range items {
+//		pop := l.Push(l4g.String("item", item.ID))
+//		process(l, item)
+//		pop()
+//	}
+//
+// The attributes come before the arguments of each call, in the groups of
+// l like them. Loggers derived from l do not get them. Push and pop may be called
+// concurrently with logging, which sees the attributes pushed or not but
+// never part of them. Calling pop more than once has no effect.
+func (l *Logger) Push(attrs ...Attr) (pop func()) {
+	if l.pushed == nil || len(attrs) == 0 {
+		return func() {}
+	}
+	f := &pushFrame{attrs: slices.Clone(attrs)}
+	l.pushed.update(func(frames []*pushFrame) []*pushFrame {
+		return append(slices.Clip(frames), f)
+	})
+	var popped atomic.Bool
+	return func() {
+		if popped.Swap(true) {
+			return
+		}
+		l.pushed.update(func(frames []*pushFrame) []*pushFrame {
+			i := slices.Index(frames, f)
+			if i < 0 {
+				return frames
+			}
+			return slices.Delete(slices.Clone(frames), i, i+1)
+		})
+	}
+}
+
+// update replaces the frames of s with the result of f.
+func (s *pushStack) update(f func([]*pushFrame) []*pushFrame) {
+	for {
+		old := s.frames.Load()
+		var frames []*pushFrame
+		if old != nil {
+			frames = *old
+		}
+		frames = f(frames)
+		if s.frames.CompareAndSwap(old, &frames) {
+			return
+		}
+	}
+}
+
+// addTo adds the pushed attributes to r.
+func (s *pushStack) addTo(r *Record) {
+	frames := s.frames.Load()
+	if frames == nil {
+		return
+	}
+	for _, f := range *frames {
+		r.AddAttrs(f.attrs...)
+	}
+}
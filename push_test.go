@@ -0,0 +1,69 @@
+package l4g
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLogger_Push(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true}).WithGroup("g")
+
+	pop := logger.Push(String("item", "a"))
+	derived := logger.WithAttrs("k", 1)
+	logger.Info("first", "n", 1)
+	popInner := logger.Push(Int("try", 2))
+	logger.Info("second")
+	pop()
+	pop()
+	logger.Info("third")
+	popInner()
+	logger.Info("fourth")
+	derived.Info("derived")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := []string{
+		"first g.item=a g.n=1",
+		"second g.item=a g.try=2",
+		"third g.try=2",
+		"fourth",
+		"derived g.k=1",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("output = %q, want %d lines", buf.String(), len(want))
+	}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, " INFO "+want[i]) {
+			t.Errorf("line %d = %q, want suffix %q", i, line, want[i])
+		}
+	}
+}
+
+func TestLogger_PushConcurrent(t *testing.T) {
+	logger := New(Options{Output: &syncBuffer{}})
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				pop := logger.Push(Int("worker", i))
+				logger.Info("step")
+				pop()
+			}
+		}()
+	}
+	wg.Wait()
+	r := NewRecord(logger.clock.Now(), LevelInfo, "")
+	logger.pushed.addTo(&r)
+	if r.NumAttrs() != 0 {
+		t.Errorf("NumAttrs() = %d after all pops, want 0", r.NumAttrs())
+	}
+}
+
+func TestNop_Push(t *testing.T) {
+	pop := Nop().Push(String("k", "v"))
+	pop()
+}
@@ -0,0 +1,187 @@
+package l4g
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// A QuerySpec selects records kept by a [Querier]. Zero fields select
+// all the records.
+type QuerySpec struct {
+	// Since and Until bound the time of the records, Since included and
+	// Until excluded.
+	Since, Until time.Time
+
+	// MinLevel is the lowest level of the records.
+	MinLevel Level
+
+	// Prefix is the beginning of the prefix of the records.
+	Prefix string
+
+	// Attrs are attributes the records must carry, with equal values as
+	// reported by slog.Value.Equal or with the same string form, so that
+	// values given as text match numbers. The keys of attributes in
+	// groups are joined with dots, as in "req.id".
+	Attrs []Attr
+
+	// Limit is the largest number of records returned, the most recent.
+	Limit int
+}
+
+// A Querier searches the records it keeps, such as a [Ring] or the table
+// of a [SQLiteHandler], for embedded debugging pages and tests.
+type Querier interface {
+	// Query returns the records selected by q, oldest first.
+	Query(q QuerySpec) ([]Record, error)
+}
+
+var (
+	_ Querier = (*Ring)(nil)
+	_ Querier = (*SQLiteHandler)(nil)
+)
+
+// Match reports whether q selects r.
+func (q QuerySpec) Match(r Record) bool {
+	if !q.Since.IsZero() && r.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !r.Time.Before(q.Until) {
+		return false
+	}
+	if q.MinLevel != 0 && r.Level < q.MinLevel {
+		return false
+	}
+	if !strings.HasPrefix(r.Prefix, q.Prefix) {
+		return false
+	}
+	for _, want := range q.Attrs {
+		v, ok := findAttr(r, want.Key)
+		if !ok {
+			return false
+		}
+		w := want.Value.Resolve()
+		if !v.Equal(w) && v.String() != w.String() {
+			return false
+		}
+	}
+	return true
+}
+
+// findAttr returns the value of the attribute of r at the dotted path key.
+func findAttr(r Record, key string) (slog.Value, bool) {
+	var v slog.Value
+	found := false
+	r.Attrs(func(a Attr) bool {
+		v, found = attrAtPath(a, key)
+		return !found
+	})
+	return v, found
+}
+
+// attrAtPath returns the value of a, or of the attribute of its group at
+// the dotted path key. Groups with an empty key are inlined.
+func attrAtPath(a Attr, key string) (slog.Value, bool) {
+	v := a.Value.Resolve()
+	if a.Key == key {
+		return v, true
+	}
+	rest, ok := strings.CutPrefix(key, a.Key+".")
+	if a.Key == "" {
+		rest, ok = key, true
+	}
+	if !ok || v.Kind() != slog.KindGroup {
+		return slog.Value{}, false
+	}
+	for _, ga := range v.Group() {
+		if gv, ok := attrAtPath(ga, rest); ok {
+			return gv, true
+		}
+	}
+	return slog.Value{}, false
+}
+
+// query returns the records selected by q, oldest first, out of records.
+func query(q QuerySpec, records func(yield func(Record) bool)) []Record {
+	var out []Record
+	records(func(r Record) bool {
+		if q.Match(r) {
+			out = append(out, r)
+		}
+		return true
+	})
+	if q.Limit > 0 && len(out) > q.Limit {
+		out = out[len(out)-q.Limit:]
+	}
+	return out
+}
+
+// Record returns the record kept in e, its attributes decoded from JSON.
+func (e RingEntry) Record() Record {
+	r := NewRecord(e.Time, e.Level, e.Message)
+	r.Prefix = e.Prefix
+	if attrs, err := parseJSONObject(e.Attrs); err == nil {
+		r.AddAttrs(attrs...)
+	}
+	return r
+}
+
+// Query returns the kept records selected by q, oldest first.
+// The error is always nil.
+func (r *Ring) Query(q QuerySpec) ([]Record, error) {
+	return query(q, func(yield func(Record) bool) {
+		for e := range r.All() {
+			if !yield(e.Record()) {
+				return
+			}
+		}
+	}), nil
+}
+
+// Query inserts the pending records and returns the records of the
+// table selected by q, oldest first. The selection is made by reading
+// the whole table, which suits the small databases of local logs.
+func (h *SQLiteHandler) Query(q QuerySpec) ([]Record, error) {
+	if err := h.Flush(); err != nil {
+		return nil, err
+	}
+	rows, err := h.s.db.Query("SELECT time, level, prefix, msg, attrs FROM " + quoteIdent(h.s.opts.Table) + " ORDER BY rowid")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scanErr error
+	out := query(q, func(yield func(Record) bool) {
+		for rows.Next() {
+			var row sqliteRow
+			if scanErr = rows.Scan(&row.time, &row.level, &row.prefix, &row.msg, &row.attrs); scanErr != nil {
+				return
+			}
+			if !yield(row.record()) {
+				return
+			}
+		}
+	})
+	if scanErr != nil {
+		return nil, scanErr
+	}
+	return out, rows.Err()
+}
+
+// record returns the record stored in row.
+func (row sqliteRow) record() Record {
+	t, _ := time.Parse(time.RFC3339Nano, row.time)
+	level, ok := parseLevelName(row.level)
+	if !ok {
+		level = LevelInfo
+	}
+	return RingEntry{
+		Time:    t,
+		Level:   level,
+		Prefix:  row.prefix,
+		Message: row.msg,
+		Attrs:   json.RawMessage(row.attrs),
+	}.Record()
+}
@@ -0,0 +1,70 @@
+package l4g
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func queryMessages(t *testing.T, qr Querier, q QuerySpec) string {
+	t.Helper()
+	records, err := qr.Query(q)
+	if err != nil {
+		t.Fatalf("Query(%+v) error = %v", q, err)
+	}
+	var msgs []string
+	for _, r := range records {
+		msgs = append(msgs, r.Message)
+	}
+	return strings.Join(msgs, " ")
+}
+
+// logQueryRecords logs the records searched by the query tests to l.
+func logQueryRecords(l *Logger) time.Time {
+	l.WithPrefix("db").Info("connect", "host", "a1")
+	l.WithPrefix("db.pool").WithGroup("req").Warn("slow", "id", 7)
+	mid := time.Now()
+	time.Sleep(time.Millisecond)
+	l.WithPrefix("http").Error("failed", "host", "a2", "status", 500)
+	l.Debug("tick")
+	return mid
+}
+
+func testQuerier(t *testing.T, qr Querier, mid time.Time) {
+	tests := []struct {
+		q    QuerySpec
+		want string
+	}{
+		{QuerySpec{}, "connect slow failed tick"},
+		{QuerySpec{MinLevel: LevelWarn}, "slow failed"},
+		{QuerySpec{Prefix: "db"}, "connect slow"},
+		{QuerySpec{Until: mid}, "connect slow"},
+		{QuerySpec{Since: mid}, "failed tick"},
+		{QuerySpec{Attrs: []Attr{String("host", "a2")}}, "failed"},
+		{QuerySpec{Attrs: []Attr{Int("req.id", 7)}}, "slow"},
+		{QuerySpec{Attrs: []Attr{String("status", "500")}}, "failed"},
+		{QuerySpec{Attrs: []Attr{String("host", "a3")}}, ""},
+		{QuerySpec{Limit: 2}, "failed tick"},
+	}
+	for _, tt := range tests {
+		if got := queryMessages(t, qr, tt.q); got != tt.want {
+			t.Errorf("Query(%+v) = %q, want %q", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestRing_Query(t *testing.T) {
+	ring := NewRing(10)
+	mid := logQueryRecords(New(Options{Output: &strings.Builder{}, Handler: NewRingHandler(nil, ring)}))
+	testQuerier(t, ring, mid)
+}
+
+func TestSQLiteHandler_Query(t *testing.T) {
+	db, _ := openRecording(t)
+	h, err := NewSQLiteHandler(db, SQLiteOptions{Level: LevelTrace, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mid := logQueryRecords(New(Options{Output: &strings.Builder{}, Level: LevelTrace, Handler: h}))
+	testQuerier(t, h, mid)
+}
@@ -0,0 +1,7 @@
+//go:build race
+
+package l4g
+
+// raceEnabled reports whether the tests run under the race detector,
+// whose instrumentation allocates and so defeats the allocation tests.
+const raceEnabled = true
@@ -1,8 +1,11 @@
 package l4g
 
 import (
+	"iter"
 	"log/slog"
+	"runtime"
 	"slices"
+	"sync/atomic"
 	"time"
 )
 
@@ -26,6 +29,19 @@ type Record struct {
 	// The level of the event.
 	Level Level
 
+	// Tags are coarse labels of the event, such as "billing" or "security",
+	// kept apart from the attributes so that handlers can match them
+	// cheaply. The slice may be shared and must not be modified.
+	Tags []string
+
+	// The program counter at the time the record was constructed, as determined
+	// by runtime.Callers. If zero, no program counter is available.
+	//
+	// The only valid use for this value is as an argument to
+	// [runtime.CallersFrames]. In particular, it must not be passed to
+	// [runtime.FuncForPC].
+	PC uintptr
+
 	// Allocation optimization: an inline array sized to hold
 	// the majority of log calls (based on examination of open-source
 	// code). It holds the start of the list of Attrs.
@@ -62,6 +78,29 @@ func (r Record) Clone() Record {
 	return r
 }
 
+// WithTime returns a copy of the record whose Time is t. It is intended for
+// replaying buffered or imported events, whose original time must be kept
+// as the event time; see [HandlerOptions.EmitTime].
+func (r Record) WithTime(t time.Time) Record {
+	r.Time = t
+	return r
+}
+
+// Source returns a new source location for the log event, or nil if the
+// record has no program counter.
+func (r Record) Source() *slog.Source {
+	if r.PC == 0 {
+		return nil
+	}
+	fs := runtime.CallersFrames([]uintptr{r.PC})
+	f, _ := fs.Next()
+	return &slog.Source{
+		Function: f.Function,
+		File:     f.File,
+		Line:     f.Line,
+	}
+}
+
 // NumAttrs returns the number of attributes in the [Record].
 func (r Record) NumAttrs() int {
 	return r.nFront + len(r.back)
@@ -82,6 +121,28 @@ func (r Record) Attrs(f func(Attr) bool) {
 	}
 }
 
+// All returns an iterator over the attributes in the [Record], for use
+// with range:
+//
+//	for a := range r.All() {
+//		...
+//	}
+func (r Record) All() iter.Seq[Attr] {
+	return r.Attrs
+}
+
+// levelOverride returns the level set by the last [OverrideLevel]
+// attribute of the record, if any.
+func (r Record) levelOverride() (level Level, ok bool) {
+	r.Attrs(func(a Attr) bool {
+		if isLevelOverride(a.Value) {
+			level, ok = Level(a.Value.Any().(levelOverride)), true
+		}
+		return true
+	})
+	return level, ok
+}
+
 // AddAttrs appends the given Attrs to the [Record]'s list of Attrs.
 // It omits empty groups.
 func (r *Record) AddAttrs(attrs ...Attr) {
@@ -181,6 +242,32 @@ func isEmptyGroup(v slog.Value) bool {
 // This happens when argsToAttr receives a non-string, non-Attr argument.
 const badKey = "!BADKEY"
 
+// badKeys counts the arguments converted to badKey attributes.
+var badKeys atomic.Int64
+
+// BadKeyCount returns the number of arguments of log calls that were not
+// paired with a key since the program started, whatever the
+// [BadKeyPolicy] of the loggers. A nonzero count, checked by a test or
+// exported as a metric, reveals malformed calls such as
+// Info("msg", "key") or Info("msg", err).
+func BadKeyCount() int64 {
+	return badKeys.Load()
+}
+
+// A BadKeyPolicy controls how a Logger treats the arguments of a log call
+// that are not paired with a key.
+type BadKeyPolicy int
+
+const (
+	// BadKeyKeep logs such arguments under the key "!BADKEY".
+	// This is the default.
+	BadKeyKeep BadKeyPolicy = iota
+	// BadKeyDrop discards such arguments.
+	BadKeyDrop
+	// BadKeyPanic panics, to catch malformed calls during development.
+	BadKeyPanic
+)
+
 // argsToAttr turns a prefix of the nonempty args slice into an Attr
 // and returns the unconsumed portion of the slice.
 // If args[0] is an Attr, it returns it.
@@ -191,6 +278,7 @@ func argsToAttr(args []any) (Attr, []any) {
 	switch x := args[0].(type) {
 	case string:
 		if len(args) == 1 {
+			badKeys.Add(1)
 			return String(badKey, x), nil
 		}
 		return Any(x, args[1]), args[2:]
@@ -199,6 +287,7 @@ func argsToAttr(args []any) (Attr, []any) {
 		return x, args[1:]
 
 	default:
+		badKeys.Add(1)
 		return Any(badKey, x), args[1:]
 	}
 }
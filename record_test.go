@@ -2,6 +2,8 @@ package l4g
 
 import (
 	"log/slog"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -182,6 +184,24 @@ func TestRecord_Attrs_EarlyExit(t *testing.T) {
 	}
 }
 
+func TestRecord_All(t *testing.T) {
+	r := NewRecord(time.Now(), LevelInfo, "test")
+	for i := range nAttrsInline + 2 {
+		r.AddAttrs(Int(strconv.Itoa(i), i))
+	}
+
+	var keys []string
+	for attr := range r.All() {
+		keys = append(keys, attr.Key)
+		if attr.Key == "5" {
+			break
+		}
+	}
+	if got := strings.Join(keys, ","); got != "0,1,2,3,4,5" {
+		t.Errorf("Record.All() keys = %v, want 0,1,2,3,4,5", got)
+	}
+}
+
 func TestRecord_Clone(t *testing.T) {
 	original := NewRecord(time.Now(), LevelInfo, "test")
 	original.AddAttrs(String("a", "1"), Int("b", 2))
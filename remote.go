@@ -0,0 +1,127 @@
+package l4g
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// RemoteLevelsOptions configures PollRemoteLevels.
+type RemoteLevelsOptions struct {
+	// URL is the address of the JSON document holding the levels.
+	URL string
+	// Interval is the time between two requests. (Default: 30s)
+	Interval time.Duration
+	// Client sends the requests. (Default: http.DefaultClient)
+	Client *http.Client
+}
+
+// PollRemoteLevels fetches the JSON document at opts.URL every
+// opts.Interval and sets the levels it holds with [SetChannelLevel],
+// for fleet-wide control of verbosity. The document maps channel name
+// patterns to levels:
+//
+//	{"*": "info", "db.*": "debug", "http": "warn"}
+//
+// The longest patterns are applied last, so that they override the
+// shorter, more general ones. A pattern removed from the document no
+// longer applies to the channels created later. The ETag of the response is sent back in
+// If-None-Match, and the levels are left unchanged when the server
+// replies 304 Not Modified. Errors are reported with [FallbackErrorf],
+// the first request being made at once.
+//
+// stop may be called more than once; it cancels the request in flight and
+// returns once the polling goroutine has exited.
+func PollRemoteLevels(opts RemoteLevelsOptions) (stop func()) {
+	if opts.Interval <= 0 {
+		opts.Interval = 30 * time.Second
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	exited := make(chan struct{})
+
+	go runLabeled("remote-levels", func() {
+		defer close(exited)
+		ticker := time.NewTicker(opts.Interval)
+		defer ticker.Stop()
+		var etag string
+		applied := make(map[string]bool) // the patterns set by the last poll
+		for {
+			var err error
+			etag, err = fetchRemoteLevels(ctx, &opts, etag, applied)
+			if err != nil && ctx.Err() == nil {
+				FallbackErrorf("l4g: remote levels: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+
+	var once sync.Once
+	return func() {
+		once.Do(cancel)
+		<-exited
+	}
+}
+
+// fetchRemoteLevels fetches and applies the levels at opts.URL unless they
+// have the given etag, and returns the etag of the levels applied. It
+// unsets the patterns of applied missing from the levels, and updates
+// applied to the patterns set.
+func fetchRemoteLevels(ctx context.Context, opts *RemoteLevelsOptions, etag string, applied map[string]bool) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, opts.URL, nil)
+	if err != nil {
+		return etag, err
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		return etag, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return etag, nil
+	default:
+		return etag, fmt.Errorf("%s: %s", opts.URL, resp.Status)
+	}
+
+	var levels map[string]Level
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&levels); err != nil {
+		return etag, fmt.Errorf("%s: %w", opts.URL, err)
+	}
+	patterns := slices.SortedFunc(maps.Keys(levels), func(a, b string) int {
+		return cmp.Or(cmp.Compare(len(a), len(b)), cmp.Compare(a, b))
+	})
+	for p := range applied {
+		if _, ok := levels[p]; !ok {
+			unsetChannelLevel(p)
+			delete(applied, p)
+		}
+	}
+	var errs []error
+	for _, p := range patterns {
+		if err := SetChannelLevel(p, levels[p]); err != nil {
+			errs = append(errs, fmt.Errorf("pattern %q: %w", p, err))
+			continue
+		}
+		applied[p] = true
+	}
+	return resp.Header.Get("ETag"), errors.Join(errs...)
+}
@@ -0,0 +1,69 @@
+package l4g
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPollRemoteLevels(t *testing.T) {
+	var requests, notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"test-remote.db": "debug", "test-remote.*": "warn"}`))
+	}))
+	defer srv.Close()
+
+	db := Channel("test-remote.db")
+	stop := PollRemoteLevels(RemoteLevelsOptions{URL: srv.URL, Interval: time.Millisecond})
+	deadline := time.Now().Add(5 * time.Second)
+	for notModified.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	stop()
+	stop()
+
+	if notModified.Load() == 0 {
+		t.Errorf("made %d requests, none with the ETag of the first response", requests.Load())
+	}
+	if db.Level() != LevelDebug {
+		t.Errorf("Level() of the matching channel = %v, want debug", db.Level())
+	}
+	if l := Channel("test-remote.http"); l.Level() != LevelWarn {
+		t.Errorf("Level() of a channel created later = %v, want warn", l.Level())
+	}
+}
+
+func TestPollRemoteLevels_RemovedPattern(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Write([]byte(`{"test-removed.*": "warn", "test-removed.db": "debug"}`))
+			return
+		}
+		w.Write([]byte(`{"test-removed.db": "debug"}`))
+	}))
+	defer srv.Close()
+
+	stop := PollRemoteLevels(RemoteLevelsOptions{URL: srv.URL, Interval: time.Millisecond})
+	deadline := time.Now().Add(5 * time.Second)
+	for requests.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	stop()
+
+	if l := Channel("test-removed.http"); l.Level() == LevelWarn {
+		t.Errorf("Level() of a channel created after the pattern was removed = %v, want the default", l.Level())
+	}
+	if l := Channel("test-removed.db"); l.Level() != LevelDebug {
+		t.Errorf("Level() of a channel of a kept pattern = %v, want debug", l.Level())
+	}
+}
@@ -0,0 +1,102 @@
+// Package replace provides ready-made functions for use as
+// [l4g.HandlerOptions.ReplaceAttr], so that common rewrites such as
+// dropping or renaming keys do not have to be reimplemented.
+//
+// The functions can be combined with [Chain]:
+//
+//	opts := l4g.HandlerOptions{
+//		ReplaceAttr: replace.Chain(
+//			replace.DropKeys("password", "req.headers.authorization"),
+//			replace.RenameKey("msg", "message"),
+//			replace.TruncateStrings(256),
+//		),
+//	}
+package replace
+
+import (
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"go-slim.dev/l4g"
+)
+
+// Func is the signature of [l4g.HandlerOptions.ReplaceAttr].
+type Func = func(groups []string, attr l4g.Attr) l4g.Attr
+
+// Chain returns a Func applying fns in order. The chain stops as soon as a
+// function drops the attribute by returning an empty Attr.
+func Chain(fns ...Func) Func {
+	return func(groups []string, attr l4g.Attr) l4g.Attr {
+		for _, fn := range fns {
+			if fn == nil {
+				continue
+			}
+			attr = fn(groups, attr)
+			if attr.Equal(l4g.Attr{}) {
+				break
+			}
+		}
+		return attr
+	}
+}
+
+// DropKeys returns a Func removing attributes with any of the given keys.
+// A key either matches the attribute key in any group, or, when it
+// contains a dot, the full dotted path of the attribute ("req.password").
+func DropKeys(keys ...string) Func {
+	set := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		set[k] = struct{}{}
+	}
+	return func(groups []string, attr l4g.Attr) l4g.Attr {
+		if _, ok := set[attr.Key]; ok {
+			return l4g.Attr{}
+		}
+		if len(groups) > 0 {
+			if _, ok := set[path(groups, attr.Key)]; ok {
+				return l4g.Attr{}
+			}
+		}
+		return attr
+	}
+}
+
+// RenameKey returns a Func renaming attributes with key from to key to.
+// As for [DropKeys], from may be a dotted path; only the last element
+// of the attribute key is renamed.
+func RenameKey(from, to string) Func {
+	return func(groups []string, attr l4g.Attr) l4g.Attr {
+		if attr.Key == from || len(groups) > 0 && path(groups, attr.Key) == from {
+			attr.Key = to
+		}
+		return attr
+	}
+}
+
+// TruncateStrings returns a Func shortening string values longer than n
+// runes to their first n runes followed by an ellipsis. Handlers pass the
+// message through ReplaceAttr as well, so long messages are truncated too.
+func TruncateStrings(n int) Func {
+	return func(_ []string, attr l4g.Attr) l4g.Attr {
+		if attr.Value.Kind() != slog.KindString {
+			return attr
+		}
+		s := attr.Value.String()
+		if len(s) <= n || utf8.RuneCountInString(s) <= n {
+			return attr
+		}
+		i := 0
+		for range n {
+			_, size := utf8.DecodeRuneInString(s[i:])
+			i += size
+		}
+		attr.Value = slog.StringValue(s[:i] + "…")
+		return attr
+	}
+}
+
+// path joins groups and key with dots.
+func path(groups []string, key string) string {
+	return strings.Join(groups, ".") + "." + key
+}
@@ -0,0 +1,104 @@
+package replace
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"go-slim.dev/l4g"
+)
+
+func TestDropKeys(t *testing.T) {
+	fn := DropKeys("password", "req.token")
+
+	tests := []struct {
+		groups []string
+		key    string
+		drop   bool
+	}{
+		{nil, "password", true},
+		{[]string{"user"}, "password", true},
+		{[]string{"req"}, "token", true},
+		{nil, "token", false},
+		{[]string{"other"}, "token", false},
+		{nil, "user", false},
+	}
+
+	for _, tt := range tests {
+		got := fn(tt.groups, l4g.String(tt.key, "v"))
+		if dropped := got.Equal(l4g.Attr{}); dropped != tt.drop {
+			t.Errorf("DropKeys(%v, %q) dropped = %v, want %v", tt.groups, tt.key, dropped, tt.drop)
+		}
+	}
+}
+
+func TestRenameKey(t *testing.T) {
+	fn := RenameKey("req.ua", "user_agent")
+
+	if got := fn([]string{"req"}, l4g.String("ua", "curl")); got.Key != "user_agent" {
+		t.Errorf("RenameKey() key = %q, want user_agent", got.Key)
+	}
+	if got := fn(nil, l4g.String("ua", "curl")); got.Key != "ua" {
+		t.Errorf("RenameKey() key = %q, want ua", got.Key)
+	}
+}
+
+func TestTruncateStrings(t *testing.T) {
+	fn := TruncateStrings(3)
+
+	tests := []struct {
+		in   l4g.Attr
+		want string
+	}{
+		{l4g.String("s", "abc"), "abc"},
+		{l4g.String("s", "abcdef"), "abc…"},
+		{l4g.String("s", "日本語テキスト"), "日本語…"},
+		{l4g.Int("n", 123456), "123456"},
+	}
+
+	for _, tt := range tests {
+		if got := fn(nil, tt.in).Value.String(); got != tt.want {
+			t.Errorf("TruncateStrings(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	calls := 0
+	count := func(_ []string, a l4g.Attr) l4g.Attr {
+		calls++
+		return a
+	}
+	fn := Chain(RenameKey("pwd", "password"), DropKeys("password"), count)
+
+	if got := fn(nil, l4g.String("pwd", "x")); !got.Equal(l4g.Attr{}) {
+		t.Errorf("Chain() = %v, want the attribute dropped", got)
+	}
+	if calls != 0 {
+		t.Errorf("Chain() should stop after an attribute is dropped")
+	}
+	if got := fn(nil, l4g.String("user", "x")); got.Key != "user" || calls != 1 {
+		t.Errorf("Chain() = %v with %d calls, want user passed through", got, calls)
+	}
+}
+
+func TestChain_Handler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := l4g.NewSimpleHandler(l4g.HandlerOptions{
+		Output:      buf,
+		NoColor:     true,
+		ReplaceAttr: Chain(DropKeys("password"), TruncateStrings(4)),
+	})
+
+	r := l4g.NewRecord(time.Now(), l4g.LevelInfo, "login")
+	r.AddAttrs(l4g.String("user", "alexander"), l4g.String("password", "secret"))
+	if err := h.Handle(r); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	output := buf.String()
+	if strings.Contains(output, "secret") || !strings.Contains(output, "user=alex…") {
+		t.Errorf("output = %q", output)
+	}
+}
@@ -0,0 +1,131 @@
+package l4g
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"time"
+)
+
+// A ReplayFormat selects the encoding of the records read by [Replay].
+type ReplayFormat int
+
+const (
+	// ReplayJSON reads a stream of records encoded by Record.MarshalJSON,
+	// such as one record per line.
+	ReplayJSON ReplayFormat = iota
+	// ReplayBinary reads records encoded by Record.MarshalBinary, each
+	// preceded by its length as a uvarint, as sent to an l4gserver.
+	ReplayBinary
+)
+
+// maxReplayRecord is the size of the largest binary record read by Replay.
+const maxReplayRecord = 1 << 24
+
+// ReplayOptions are options for [Replay].
+type ReplayOptions struct {
+	// Format is the encoding of the records. The default is ReplayJSON.
+	Format ReplayFormat
+
+	// KeepTime keeps the original time of the records.
+	// If false, records are stamped with the time they are replayed.
+	KeepTime bool
+
+	// Realtime paces the replay, waiting between records as long as
+	// between their original times.
+	Realtime bool
+}
+
+// Replay reads the records encoded in src and passes those enabled by h to
+// h.Handle, for testing handlers or reprocessing archives into new sinks.
+// It stops at the end of src, returning nil, or at the first malformed
+// record or handler error.
+func Replay(src io.Reader, h Handler, opts ReplayOptions) error {
+	return ReplayRecords(readRecords(src, opts.Format), h, opts)
+}
+
+// ReplayRecords is like [Replay] but takes the records from a sequence,
+// such as one returned by [MergeRecords]; opts.Format is ignored.
+// It stops at the first error yielded by records.
+func ReplayRecords(records iter.Seq2[Record, error], h Handler, opts ReplayOptions) error {
+	var last time.Time
+	n := 0
+	for r, err := range records {
+		n++
+		if err != nil {
+			return fmt.Errorf("l4g: replay record %d: %w", n, err)
+		}
+		if opts.Realtime {
+			if !last.IsZero() && r.Time.After(last) {
+				time.Sleep(r.Time.Sub(last))
+			}
+			last = r.Time
+		}
+		if !opts.KeepTime {
+			r.Time = time.Now()
+		}
+		if !h.Enabled(r.Level) {
+			continue
+		}
+		if err := h.Handle(r); err != nil {
+			return fmt.Errorf("l4g: replay record %d: %w", n, err)
+		}
+	}
+	return nil
+}
+
+// readRecords returns the records encoded in src, ending with the first
+// error other than io.EOF.
+func readRecords(src io.Reader, format ReplayFormat) iter.Seq2[Record, error] {
+	next := replayJSON(src)
+	if format == ReplayBinary {
+		next = replayBinary(src)
+	}
+	return func(yield func(Record, error) bool) {
+		for {
+			r, err := next()
+			if err == io.EOF {
+				return
+			}
+			if !yield(r, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+func replayJSON(src io.Reader) func() (Record, error) {
+	dec := json.NewDecoder(src)
+	return func() (Record, error) {
+		var r Record
+		err := dec.Decode(&r)
+		return r, err
+	}
+}
+
+func replayBinary(src io.Reader) func() (Record, error) {
+	br := bufio.NewReader(src)
+	return func() (Record, error) {
+		var r Record
+		n, err := binary.ReadUvarint(br)
+		if err != nil {
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				return r, errRecordFormat
+			}
+			return r, err
+		}
+		if n > maxReplayRecord {
+			return r, errRecordFormat
+		}
+		data := make([]byte, n)
+		if _, err := io.ReadFull(br, data); err != nil {
+			return r, errRecordFormat
+		}
+		err = r.UnmarshalBinary(data)
+		return r, err
+	}
+}
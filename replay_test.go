@@ -0,0 +1,89 @@
+package l4g
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func replayRecords() []Record {
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	r1 := NewRecord(t0, LevelInfo, "started")
+	r1.AddAttrs(String("host", "a1"))
+	r2 := NewRecord(t0.Add(30*time.Millisecond), LevelDebug, "tick")
+	r3 := NewRecord(t0.Add(60*time.Millisecond), LevelError, "failed")
+	r3.Prefix = "db"
+	return []Record{r1, r2, r3}
+}
+
+func TestReplayJSON(t *testing.T) {
+	var src bytes.Buffer
+	for _, r := range replayRecords() {
+		b, err := json.Marshal(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		src.Write(append(b, '\n'))
+	}
+
+	var buf bytes.Buffer
+	h := NewJSONHandler(HandlerOptions{Output: &buf, Level: LevelInfo})
+	if err := Replay(&src, h, ReplayOptions{KeepTime: true}); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		`"time":"2024-05-01T12:00:00Z","level":"INFO","msg":"started","host":"a1"`,
+		`"level":"ERROR","prefix":"db","msg":"failed"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %s:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "tick") {
+		t.Errorf("disabled record replayed:\n%s", got)
+	}
+}
+
+func TestReplayBinary(t *testing.T) {
+	var src bytes.Buffer
+	for _, r := range replayRecords() {
+		b, err := r.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		src.Write(binary.AppendUvarint(nil, uint64(len(b))))
+		src.Write(b)
+	}
+
+	var buf bytes.Buffer
+	h := NewJSONHandler(HandlerOptions{Output: &buf, Level: LevelDebug})
+	start := time.Now()
+	if err := Replay(&src, h, ReplayOptions{Format: ReplayBinary, Realtime: true}); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("replay took %v, want at least 60ms", elapsed)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 3 {
+		t.Errorf("replayed %d records, want 3:\n%s", n, buf.String())
+	}
+	if strings.Contains(buf.String(), "2024-05-01") {
+		t.Errorf("original time kept without KeepTime:\n%s", buf.String())
+	}
+}
+
+func TestReplayMalformed(t *testing.T) {
+	h := NewJSONHandler(HandlerOptions{Output: &bytes.Buffer{}})
+	err := Replay(strings.NewReader(`{"msg":"ok"}`+"\n"+`{"msg":`), h, ReplayOptions{})
+	if err == nil || !strings.Contains(err.Error(), "record 2") {
+		t.Errorf("err = %v, want error for record 2", err)
+	}
+	err = Replay(bytes.NewReader([]byte{10, 1, 2}), h, ReplayOptions{Format: ReplayBinary})
+	if err == nil {
+		t.Error("expected error for truncated binary record")
+	}
+}
@@ -0,0 +1,189 @@
+package l4g
+
+import (
+	"encoding/json"
+	"iter"
+	"slices"
+	"sync"
+	"time"
+)
+
+// A RingEntry is a record kept by a [Ring].
+type RingEntry struct {
+	Time    time.Time       `json:"time"`
+	Level   Level           `json:"level"`
+	Prefix  string          `json:"prefix,omitempty"`
+	Message string          `json:"msg"`
+	Attrs   json.RawMessage `json:"attrs"` // JSON object of the attributes
+}
+
+// Ring keeps the most recent records passed to the [RingHandler]s writing
+// to it, for inspection by the program, such as by [DebugHandler].
+// It is safe for concurrent use by multiple goroutines.
+type Ring struct {
+	mu      sync.Mutex
+	entries []RingEntry // ring of len size
+	pos     int         // index of the next entry to write
+	full    bool        // whether the ring has wrapped
+	subs    map[chan RingEntry]struct{}
+}
+
+// DefaultRing is the Ring served by [DebugHandler]. It keeps the last
+// 1000 records.
+var DefaultRing = NewRing(1000)
+
+// NewRing returns a Ring keeping the last size records.
+func NewRing(size int) *Ring {
+	return &Ring{entries: make([]RingEntry, max(size, 1))}
+}
+
+// Entries returns the kept records, oldest first.
+func (r *Ring) Entries() []RingEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]RingEntry(nil), r.entries[:r.pos]...)
+	}
+	entries := make([]RingEntry, 0, len(r.entries))
+	entries = append(entries, r.entries[r.pos:]...)
+	return append(entries, r.entries[:r.pos]...)
+}
+
+// All returns an iterator over the kept records, oldest first. The records
+// are those kept when All is called.
+func (r *Ring) All() iter.Seq[RingEntry] {
+	return slices.Values(r.Entries())
+}
+
+func (r *Ring) add(e RingEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[r.pos] = e
+	r.pos++
+	if r.pos == len(r.entries) {
+		r.pos, r.full = 0, true
+	}
+	for ch := range r.subs {
+		select {
+		case ch <- e:
+		default: // drop entries for slow subscribers
+		}
+	}
+}
+
+// subscribe returns a channel receiving the records added from now on,
+// and a function to stop receiving them.
+func (r *Ring) subscribe() (<-chan RingEntry, func()) {
+	ch := make(chan RingEntry, 64)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.subs == nil {
+		r.subs = make(map[chan RingEntry]struct{})
+	}
+	r.subs[ch] = struct{}{}
+	return ch, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.subs, ch)
+	}
+}
+
+// RingHandler is a Handler that keeps the records it handles in a [Ring]
+// and passes them on to another handler, if any.
+type RingHandler struct {
+	handler Handler // may be nil
+	ring    *Ring
+	json    *JSONHandler // encodes the attributes
+}
+
+var _ Handler = (*RingHandler)(nil)
+
+// NewRingHandler returns a [RingHandler] keeping records in ring and
+// passing them on to h. If h is nil, records are only kept in ring.
+func NewRingHandler(h Handler, ring *Ring) *RingHandler {
+	return &RingHandler{
+		handler: h,
+		ring:    ring,
+		json:    NewJSONHandler(HandlerOptions{Level: LevelTrace}).(*JSONHandler),
+	}
+}
+
+// Enabled reports whether the wrapped handler handles records at the
+// given level. Without a wrapped handler, all levels are enabled.
+func (h *RingHandler) Enabled(level Level) bool {
+	if h.handler == nil {
+		return true
+	}
+	return h.handler.Enabled(level)
+}
+
+// Handle keeps r in the ring and passes it on to the wrapped handler.
+func (h *RingHandler) Handle(r Record) error {
+	e := RingEntry{
+		Time:    r.Time,
+		Level:   r.Level,
+		Prefix:  r.Prefix,
+		Message: r.Message,
+	}
+	if level, ok := r.levelOverride(); ok {
+		e.Level = level
+	}
+	if e.Prefix == "" {
+		e.Prefix = h.json.state.Prefix()
+	}
+	buf := newBuffer()
+	defer buf.Free()
+	buf.WriteByte('{')
+	h.json.appendAttrs(buf, r)
+	closeObject(buf)
+	e.Attrs = json.RawMessage(string(*buf))
+	h.ring.add(e)
+
+	if h.handler == nil {
+		return nil
+	}
+	return h.handler.Handle(r)
+}
+
+// Flush flushes the wrapped handler.
+func (h *RingHandler) Flush() error {
+	return flush(h.handler)
+}
+
+// Compact compacts the wrapped handler.
+func (h *RingHandler) Compact() {
+	compact(h.handler)
+}
+
+// WithAttrs returns a new Handler whose attributes consist of
+// both the receiver's attributes and the arguments.
+func (h *RingHandler) WithAttrs(attrs []Attr) Handler {
+	h2 := *h
+	if h.handler != nil {
+		h2.handler = h.handler.WithAttrs(attrs)
+	}
+	h2.json = h.json.WithAttrs(attrs).(*JSONHandler)
+	return &h2
+}
+
+// WithGroup returns a new Handler with the given group appended to
+// the receiver's existing groups.
+func (h *RingHandler) WithGroup(name string) Handler {
+	h2 := *h
+	if h.handler != nil {
+		h2.handler = h.handler.WithGroup(name)
+	}
+	h2.json = h.json.WithGroup(name).(*JSONHandler)
+	return &h2
+}
+
+// WithPrefix returns a new Handler with the given prefix prepended to
+// the receiver's existing prefix.
+func (h *RingHandler) WithPrefix(prefix string) Handler {
+	h2 := *h
+	if h.handler != nil {
+		h2.handler = h.handler.WithPrefix(prefix)
+	}
+	h2.json = h.json.WithPrefix(prefix).(*JSONHandler)
+	return &h2
+}
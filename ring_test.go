@@ -0,0 +1,69 @@
+package l4g
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRingHandler(t *testing.T) {
+	var buf bytes.Buffer
+	ring := NewRing(3)
+	h := NewRingHandler(NewSimpleHandler(HandlerOptions{Output: &buf, NoColor: true}), ring)
+	l := New(Options{Output: &buf, Handler: h}).WithPrefix("app").WithGroup("g").WithAttrs("k", "v")
+	for i := range 5 {
+		l.Info(fmt.Sprintf("message %d", i), "i", i)
+	}
+
+	if !strings.Contains(buf.String(), "message 4") {
+		t.Errorf("output = %q, want the records passed on", buf.String())
+	}
+	entries := ring.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("len(Entries()) = %d, want 3", len(entries))
+	}
+	for i, e := range entries {
+		if want := fmt.Sprintf("message %d", i+2); e.Message != want {
+			t.Errorf("entries[%d].Message = %q, want %q", i, e.Message, want)
+		}
+	}
+	e := entries[2]
+	if e.Level != LevelInfo || e.Prefix != "app" || e.Time.IsZero() {
+		t.Errorf("entry = %+v, want level, prefix and time", e)
+	}
+	if got, want := string(e.Attrs), `{"g":{"k":"v","i":4}}`; got != want {
+		t.Errorf("Attrs = %s, want %s", got, want)
+	}
+}
+
+func TestRingAll(t *testing.T) {
+	ring := NewRing(10)
+	l := New(Options{Output: &bytes.Buffer{}, Level: LevelDebug, Handler: NewRingHandler(nil, ring)})
+	l.Debug("a")
+	l.Warn("b")
+	l.Error("c")
+
+	var msgs []string
+	for e := range ring.All() {
+		if e.Level >= LevelWarn {
+			msgs = append(msgs, e.Message)
+		}
+	}
+	if got := strings.Join(msgs, ","); got != "b,c" {
+		t.Errorf("messages = %q, want %q", got, "b,c")
+	}
+}
+
+func TestRingHandlerWithoutHandler(t *testing.T) {
+	ring := NewRing(10)
+	h := NewRingHandler(nil, ring)
+	if !h.Enabled(LevelTrace) {
+		t.Errorf("Enabled(LevelTrace) = false, want true")
+	}
+	l := New(Options{Output: &bytes.Buffer{}, Handler: h})
+	l.Warn("kept")
+	if entries := ring.Entries(); len(entries) != 1 || entries[0].Attrs == nil || string(entries[0].Attrs) != "{}" {
+		t.Errorf("Entries() = %+v, want one entry without attributes", entries)
+	}
+}
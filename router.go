@@ -0,0 +1,189 @@
+package l4g
+
+import (
+	"errors"
+	"log/slog"
+	"slices"
+)
+
+// A Route is a rule of a [RouterHandler]: the records for which Match
+// returns true are passed to Handler.
+//
+// Match sees the attributes of the record followed by those added with
+// WithAttrs, without the groups opened by WithGroup, and the prefix set
+// with WithPrefix if the record has none. It must not retain the record.
+type Route struct {
+	Match   func(r Record) bool
+	Handler Handler
+}
+
+// RouterHandler is a Handler passing each record to the handler of the
+// first of its routes that matches it, or to a fallback handler, so that,
+// for example, audit records go to the audit sink, HTTP records to the
+// access log and all the others to the default output:
+//
+//	h := l4g.NewRouterHandler(defaultHandler,
+//		l4g.Route{Match: l4g.MatchAttr("audit", true), Handler: auditHandler},
+//		l4g.Route{Match: l4g.MatchPrefix("HTTP"), Handler: accessHandler},
+//	)
+//
+// A record is passed only to the handler it is routed to, and only if that
+// handler is enabled for its level.
+type RouterHandler struct {
+	routes   []Route
+	fallback Handler
+	attrs    []Attr // attributes from WithAttrs, for Match
+	prefix   string // prefix from WithPrefix, for Match
+}
+
+var _ Handler = (*RouterHandler)(nil)
+
+// NewRouterHandler returns a [RouterHandler] trying routes in order and
+// passing the records that match none of them to fallback. If fallback is
+// nil, those records are dropped.
+func NewRouterHandler(fallback Handler, routes ...Route) *RouterHandler {
+	return &RouterHandler{routes: slices.Clone(routes), fallback: fallback}
+}
+
+// Enabled reports whether the handler of a route, or the fallback handler,
+// is enabled for level.
+func (h *RouterHandler) Enabled(level Level) bool {
+	for _, rt := range h.routes {
+		if rt.Handler.Enabled(level) {
+			return true
+		}
+	}
+	return h.fallback != nil && h.fallback.Enabled(level)
+}
+
+// Handle passes r to the handler it is routed to.
+func (h *RouterHandler) Handle(r Record) error {
+	level := r.Level
+	if l, ok := r.levelOverride(); ok {
+		level = l
+	}
+	target := h.route(r)
+	if target == nil || !target.Enabled(level) {
+		return nil
+	}
+	return target.Handle(r)
+}
+
+// route returns the handler to which r is routed, nil if none.
+func (h *RouterHandler) route(r Record) Handler {
+	if len(h.routes) == 0 {
+		return h.fallback
+	}
+	m := r
+	if len(h.attrs) > 0 {
+		m = r.Clone()
+		m.AddAttrs(h.attrs...)
+	}
+	if m.Prefix == "" {
+		m.Prefix = h.prefix
+	}
+	for _, rt := range h.routes {
+		if rt.Match(m) {
+			return rt.Handler
+		}
+	}
+	return h.fallback
+}
+
+// Flush flushes the handlers of the routes and the fallback handler.
+func (h *RouterHandler) Flush() error {
+	errs := make([]error, 0, len(h.routes)+1)
+	for _, rt := range h.routes {
+		errs = append(errs, flush(rt.Handler))
+	}
+	if h.fallback != nil {
+		errs = append(errs, flush(h.fallback))
+	}
+	return errors.Join(errs...)
+}
+
+// Compact compacts the handlers of the routes and the fallback handler.
+func (h *RouterHandler) Compact() {
+	for _, rt := range h.routes {
+		compact(rt.Handler)
+	}
+	if h.fallback != nil {
+		compact(h.fallback)
+	}
+}
+
+// derive returns a RouterHandler whose handlers are those of h passed
+// through f.
+func (h *RouterHandler) derive(f func(Handler) Handler) *RouterHandler {
+	h2 := &RouterHandler{
+		routes: make([]Route, len(h.routes)),
+		attrs:  h.attrs,
+		prefix: h.prefix,
+	}
+	for i, rt := range h.routes {
+		h2.routes[i] = Route{Match: rt.Match, Handler: f(rt.Handler)}
+	}
+	if h.fallback != nil {
+		h2.fallback = f(h.fallback)
+	}
+	return h2
+}
+
+// WithAttrs returns a RouterHandler whose handlers include attrs.
+func (h *RouterHandler) WithAttrs(attrs []Attr) Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := h.derive(func(h Handler) Handler { return h.WithAttrs(attrs) })
+	h2.attrs = append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...)
+	return h2
+}
+
+// WithGroup returns a RouterHandler whose handlers open the group name.
+func (h *RouterHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return h
+	}
+	return h.derive(func(h Handler) Handler { return h.WithGroup(name) })
+}
+
+// WithPrefix returns a RouterHandler whose handlers have prefix prepended
+// to their prefix.
+func (h *RouterHandler) WithPrefix(prefix string) Handler {
+	if prefix == "" {
+		return h
+	}
+	h2 := h.derive(func(h Handler) Handler { return h.WithPrefix(prefix) })
+	h2.prefix = prefix + h.prefix
+	return h2
+}
+
+// MatchAttr returns a [Route] predicate matching the records holding an
+// attribute with the given key and value, compared as by [slog.Value.Equal].
+func MatchAttr(key string, value any) func(Record) bool {
+	v := slog.AnyValue(value)
+	return func(r Record) bool {
+		found := false
+		r.Attrs(func(a Attr) bool {
+			found = a.Key == key && a.Value.Resolve().Equal(v)
+			return !found
+		})
+		return found
+	}
+}
+
+// MatchPrefix returns a [Route] predicate matching the records with the
+// given prefix.
+func MatchPrefix(prefix string) func(Record) bool {
+	return func(r Record) bool {
+		return r.Prefix == prefix
+	}
+}
+
+// MatchTag returns a [Route] predicate matching the records with the
+// given tag.
+func MatchTag(tag string) func(Record) bool {
+	return func(r Record) bool {
+		return slices.Contains(r.Tags, tag)
+	}
+}
@@ -0,0 +1,42 @@
+package l4g
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRouterHandler(t *testing.T) {
+	var audit, access, other bytes.Buffer
+	h := NewRouterHandler(
+		NewSimpleHandler(HandlerOptions{Output: &other, NoColor: true}),
+		Route{Match: MatchAttr("audit", true), Handler: NewSimpleHandler(HandlerOptions{Output: &audit, NoColor: true})},
+		Route{Match: MatchPrefix("HTTP"), Handler: NewSimpleHandler(HandlerOptions{Output: &access, NoColor: true, Level: LevelWarn})},
+	)
+	logger := New(Options{Output: &other, Handler: h})
+
+	logger.Info("login", "audit", true, "user", "bob")
+	logger.WithAttrs("audit", true).WithGroup("req").Info("logout")
+	logger.WithPrefix("HTTP").Warn("slow request")
+	logger.WithPrefix("HTTP").Info("request")
+	logger.Info("started", "audit", false)
+
+	if got := audit.String(); !strings.Contains(got, "login audit=true user=bob") || !strings.Contains(got, "logout audit=true") {
+		t.Errorf("audit output = %q, want login and logout", got)
+	}
+	if got := access.String(); !strings.Contains(got, "[HTTP] slow request") || strings.Count(got, "\n") != 1 {
+		t.Errorf("access output = %q, want only the slow request", got)
+	}
+	if got := other.String(); !strings.Contains(got, "started audit=false") || strings.Contains(got, "HTTP") || strings.Contains(got, "login") {
+		t.Errorf("default output = %q, want only started", got)
+	}
+}
+
+func TestMatchTag(t *testing.T) {
+	r := NewRecord(time.Now(), LevelInfo, "m")
+	r.Tags = []string{"billing", "security"}
+	if !MatchTag("security")(r) || MatchTag("audit")(r) {
+		t.Errorf("MatchTag() does not match the tags %v", r.Tags)
+	}
+}
@@ -0,0 +1,275 @@
+package l4g
+
+import (
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Keys of the attributes added by a [SamplingHandler].
+const (
+	// SampledKey marks the records kept at a level that is not fully
+	// logged. The associated value is a bool.
+	SampledKey = "sampled"
+	// DroppedKey is the number of records summarized by a head and tail
+	// sampling summary. The associated value is an int.
+	DroppedKey = "dropped"
+	// SampleKeyKey is the sampling key of the records summarized by a head
+	// and tail sampling summary. The associated value is a string.
+	SampleKeyKey = "sample_key"
+)
+
+// droppedMessage is the message of head and tail sampling summaries.
+const droppedMessage = "dropped records"
+
+// SamplingOptions are options for a [SamplingHandler].
+type SamplingOptions struct {
+	// Rates maps levels to the fraction of their records that are kept,
+	// between 0 (drop all) and 1 (keep all). Levels missing from Rates
+	// are kept entirely, e.g.
+	//
+	//	Rates: map[Level]float64{LevelDebug: 0.01, LevelInfo: 0.1}
+	Rates map[Level]float64
+
+	// Window enables head and tail sampling when positive. Records are
+	// grouped by KeyFunc; within a window starting with the first
+	// record of a group, the first Head records are passed on immediately
+	// and the last Tail records when the window ends. The records in
+	// between are summarized by one record at the same level, holding
+	// [DroppedKey] and [SampleKeyKey] attributes. This keeps the
+	// boundaries of bursts, which uniform sampling loses.
+	Window time.Duration
+
+	// Head is the number of records kept at the start of each window.
+	Head int
+
+	// Tail is the number of records kept at the end of each window.
+	// They are held back until the window ends or Flush is called.
+	Tail int
+
+	// KeyFunc returns the key grouping a record with similar records for
+	// head and tail sampling (Default: the level and the message).
+	// See [KeyByAttr] to group records by an attribute such as a route.
+	KeyFunc func(Record) string
+
+	// Clock times the windows and the summaries of the dropped records
+	// (Default: the system clock).
+	Clock Clock
+}
+
+// KeyByAttr returns a [SamplingOptions.KeyFunc] grouping records by the
+// level and the value of the attribute key, such as "route" or
+// "customer_id". Only the attributes of the record are searched, not
+// those added with WithAttrs; records without the attribute share a group.
+func KeyByAttr(key string) func(Record) string {
+	return func(r Record) string {
+		var value string
+		r.Attrs(func(a Attr) bool {
+			if a.Key == key {
+				value = a.Value.String()
+				return false
+			}
+			return true
+		})
+		return r.Level.String() + ":" + value
+	}
+}
+
+// SamplingHandler is a Handler that passes a random fraction of the
+// records of each level to another handler, so that verbose levels can
+// stay enabled in production at a bounded cost. Records kept at a level
+// whose rate is below 1 are marked with a [SampledKey] attribute.
+type SamplingHandler struct {
+	handler Handler
+	opts    *SamplingOptions
+	windows *sampleWindows // shared by the handlers derived from this one
+}
+
+// sampleWindows holds the open head and tail sampling windows by key.
+type sampleWindows struct {
+	mu      sync.Mutex
+	windows map[string]*sampleWindow
+	peak    int // largest number of windows since the map was made
+}
+
+// sampleWindow is the state of a group of records within a window.
+type sampleWindow struct {
+	count   int
+	dropped int
+	level   Level        // level of the last record past the head
+	h       Handler      // handler of the last record past the head
+	tail    []heldRecord // the last Tail records past the head, oldest first
+	stop    func() bool  // cancels the end of the window
+}
+
+// heldRecord is a record held back together with the handler it was
+// passed to, since derived handlers differ in attributes and groups.
+type heldRecord struct {
+	h Handler
+	r Record
+}
+
+var _ Handler = (*SamplingHandler)(nil)
+
+// NewSamplingHandler returns a [SamplingHandler] passing the sampled
+// records to h.
+func NewSamplingHandler(h Handler, opts SamplingOptions) *SamplingHandler {
+	opts.Clock = clockOrSystem(opts.Clock)
+	return &SamplingHandler{
+		handler: h,
+		opts:    &opts,
+		windows: &sampleWindows{windows: make(map[string]*sampleWindow)},
+	}
+}
+
+// rate returns the fraction of the records of level that are kept.
+func (h *SamplingHandler) rate(level Level) float64 {
+	if rate, ok := h.opts.Rates[level]; ok {
+		return rate
+	}
+	return 1
+}
+
+// Enabled reports false for levels whose rate is zero, and otherwise
+// defers to the wrapped handler.
+func (h *SamplingHandler) Enabled(level Level) bool {
+	return h.rate(level) > 0 && h.handler.Enabled(level)
+}
+
+// Handle passes r to the wrapped handler if it is sampled.
+func (h *SamplingHandler) Handle(r Record) error {
+	rate := h.rate(r.Level)
+	if rate < 1 {
+		if rate <= 0 || rand.Float64() >= rate {
+			return nil
+		}
+		r = r.Clone()
+		r.AddAttrs(Bool(SampledKey, true))
+	}
+	if h.opts.Window > 0 {
+		return h.window(r)
+	}
+	return h.handler.Handle(r)
+}
+
+// window applies head and tail sampling to r.
+func (h *SamplingHandler) window(r Record) error {
+	keyFunc := h.opts.KeyFunc
+	if keyFunc == nil {
+		keyFunc = sampleKey
+	}
+	key := keyFunc(r)
+	ws := h.windows
+	ws.mu.Lock()
+	w, ok := ws.windows[key]
+	if !ok {
+		w = &sampleWindow{}
+		w.stop = h.opts.Clock.AfterFunc(h.opts.Window, func() {
+			runLabeled("sampling", func() { ws.flush(key, w, h.opts.Clock) })
+		})
+		ws.windows[key] = w
+		ws.peak = max(ws.peak, len(ws.windows))
+	}
+	w.count++
+	if w.count <= h.opts.Head {
+		ws.mu.Unlock()
+		return h.handler.Handle(r)
+	}
+	w.level, w.h = r.Level, h.handler
+	if h.opts.Tail > 0 {
+		w.tail = append(w.tail, heldRecord{h.handler, r.Clone()})
+	} else {
+		w.dropped++
+	}
+	if len(w.tail) > h.opts.Tail {
+		w.tail[0] = heldRecord{} // release the record
+		w.tail = w.tail[1:]
+		w.dropped++
+	}
+	ws.mu.Unlock()
+	return nil
+}
+
+// Flush ends all open head and tail sampling windows, passing on the
+// records held back and the summaries of the dropped records, then flushes
+// the wrapped handler. It should be called before the program exits.
+func (h *SamplingHandler) Flush() error {
+	ws := h.windows
+	ws.mu.Lock()
+	pending := make(map[string]*sampleWindow, len(ws.windows))
+	for key, w := range ws.windows {
+		pending[key] = w
+	}
+	ws.mu.Unlock()
+
+	var err error
+	for key, w := range pending {
+		w.stop()
+		if e := ws.flush(key, w, h.opts.Clock); e != nil && err == nil {
+			err = e
+		}
+	}
+	return errors.Join(err, flush(h.handler))
+}
+
+// Compact replaces the map of the open windows, which does not shrink as
+// windows end, if it held many more windows than now, then compacts the
+// wrapped handler.
+func (h *SamplingHandler) Compact() {
+	ws := h.windows
+	ws.mu.Lock()
+	if ws.peak > 2*len(ws.windows)+64 {
+		windows := make(map[string]*sampleWindow, len(ws.windows))
+		for key, w := range ws.windows {
+			windows[key] = w
+		}
+		ws.windows, ws.peak = windows, len(windows)
+	}
+	ws.mu.Unlock()
+	compact(h.handler)
+}
+
+// flush ends the window w of key, unless it has been ended already.
+func (ws *sampleWindows) flush(key string, w *sampleWindow, clock Clock) error {
+	ws.mu.Lock()
+	if ws.windows[key] != w {
+		ws.mu.Unlock()
+		return nil
+	}
+	delete(ws.windows, key)
+	ws.mu.Unlock()
+
+	var err error
+	if w.dropped > 0 {
+		r := NewRecord(clock.Now(), w.level, droppedMessage)
+		r.AddAttrs(Int(DroppedKey, w.dropped), String(SampleKeyKey, key))
+		err = w.h.Handle(r)
+	}
+	for _, held := range w.tail {
+		if e := held.h.Handle(held.r); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// sampleKey is the default KeyFunc, grouping records by level and message.
+func sampleKey(r Record) string {
+	return r.Level.String() + ":" + r.Message
+}
+
+// WithAttrs returns a SamplingHandler wrapping h.WithAttrs(attrs).
+func (h *SamplingHandler) WithAttrs(attrs []Attr) Handler {
+	return &SamplingHandler{h.handler.WithAttrs(attrs), h.opts, h.windows}
+}
+
+// WithGroup returns a SamplingHandler wrapping h.WithGroup(name).
+func (h *SamplingHandler) WithGroup(name string) Handler {
+	return &SamplingHandler{h.handler.WithGroup(name), h.opts, h.windows}
+}
+
+// WithPrefix returns a SamplingHandler wrapping h.WithPrefix(prefix).
+func (h *SamplingHandler) WithPrefix(prefix string) Handler {
+	return &SamplingHandler{h.handler.WithPrefix(prefix), h.opts, h.windows}
+}
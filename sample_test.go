@@ -0,0 +1,127 @@
+package l4g
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSamplingHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := NewSamplingHandler(
+		NewSimpleHandler(HandlerOptions{Output: buf, NoColor: true, Level: LevelTrace}),
+		SamplingOptions{Rates: map[Level]float64{LevelTrace: 0, LevelDebug: 0.5}},
+	)
+	logger := New(Options{Output: buf, Handler: h.WithPrefix("p"), Level: LevelTrace})
+
+	if h.Enabled(LevelTrace) {
+		t.Errorf("Enabled(LevelTrace) = true, want false for a zero rate")
+	}
+
+	const n = 2000
+	for range n {
+		logger.Debug("d")
+		logger.Warn("w")
+	}
+	out := buf.String()
+
+	if got := strings.Count(out, "WARN [p] w\n"); got != n {
+		t.Errorf("kept %d warnings, want all %d", got, n)
+	}
+	debug := strings.Count(out, "DEBUG [p] d sampled=true\n")
+	if debug < n/4 || debug > n*3/4 {
+		t.Errorf("kept %d of %d debug records, want about half", debug, n)
+	}
+	if strings.Count(out, "DEBUG") != debug {
+		t.Errorf("some debug records are not marked as sampled")
+	}
+}
+
+func TestSamplingHandler_Window(t *testing.T) {
+	buf := &syncBuffer{}
+	h := NewSamplingHandler(
+		NewSimpleHandler(HandlerOptions{Output: buf, NoColor: true}),
+		SamplingOptions{Window: time.Hour, Head: 2, Tail: 2},
+	)
+	logger := New(Options{Output: buf, Handler: h})
+
+	for i := range 10 {
+		logger.Info("burst", "i", i)
+	}
+	logger.Warn("other")
+	if got := strings.Count(buf.String(), "burst"); got != 2 {
+		t.Errorf("got %d records before the window ended, want the 2 head records", got)
+	}
+
+	if err := h.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	got := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		"INFO burst i=0",
+		"INFO burst i=1",
+		"WARN other",
+		"INFO dropped records dropped=6 sample_key=info:burst",
+		"INFO burst i=8",
+		"INFO burst i=9",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("output = %q, want %d records", buf.String(), len(want))
+	}
+	for i := range want {
+		if !strings.HasSuffix(got[i], want[i]) {
+			t.Errorf("record %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSamplingHandler_WindowTimer(t *testing.T) {
+	clock := newFakeClock()
+	buf := &syncBuffer{}
+	h := NewSamplingHandler(
+		NewSimpleHandler(HandlerOptions{Output: buf, NoColor: true}),
+		SamplingOptions{Window: 10 * time.Second, Head: 1, Clock: clock},
+	)
+	for range 3 {
+		h.Handle(NewRecord(time.Time{}, LevelError, "e"))
+	}
+
+	clock.Advance(9 * time.Second)
+	if strings.Contains(buf.String(), "dropped=") {
+		t.Errorf("output = %q, want no summary before the window ends", buf.String())
+	}
+	clock.Advance(time.Second)
+	if !strings.Contains(buf.String(), "dropped=2") {
+		t.Fatalf("output = %q, want a summary when the window ends", buf.String())
+	}
+
+	h.Handle(NewRecord(time.Time{}, LevelError, "e"))
+	if got := strings.Count(buf.String(), "ERROR e\n"); got != 2 {
+		t.Errorf("got %d head records, want one per window", got)
+	}
+}
+
+func TestSamplingHandler_KeyFunc(t *testing.T) {
+	buf := &syncBuffer{}
+	h := NewSamplingHandler(
+		NewSimpleHandler(HandlerOptions{Output: buf, NoColor: true}),
+		SamplingOptions{Window: time.Hour, Head: 1, KeyFunc: KeyByAttr("route")},
+	)
+	logger := New(Options{Output: buf, Handler: h})
+
+	logger.Info("slow", "route", "/a")
+	logger.Info("timeout", "route", "/a")
+	logger.Info("slow", "route", "/b")
+	h.Flush()
+
+	out := buf.String()
+	for _, want := range []string{"slow route=/a\n", "slow route=/b\n", "dropped=1 sample_key=info:/a\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output = %q, want to contain %q", out, want)
+		}
+	}
+	if strings.Contains(out, "timeout") {
+		t.Errorf("output = %q, want the second /a record dropped", out)
+	}
+}
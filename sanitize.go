@@ -0,0 +1,90 @@
+package l4g
+
+import (
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// sanitizing reports whether opts asks for the lines written by the
+// built-in handlers to be rewritten by sanitize.
+func (opts *HandlerOptions) sanitizing() bool {
+	return opts.ValidUTF8 || opts.StripControl || opts.EscapeNonASCII
+}
+
+// sanitize rewrites the line in buf, ending with a newline, as asked by
+// the ValidUTF8, StripControl and EscapeNonASCII options. buf is left
+// untouched when the line needs no change.
+func sanitize(buf *buffer, opts *HandlerOptions) {
+	line := *buf
+	end := len(line)
+	if end > 0 && line[end-1] == '\n' {
+		end--
+	}
+	i := 0
+	for i < end && line[i] >= 0x20 && line[i] < 0x7f {
+		i++
+	}
+	if i == end {
+		return
+	}
+
+	out := newBuffer()
+	defer out.Free()
+	out.Write(line[:i])
+	for i < end {
+		b := line[i]
+		if b < utf8.RuneSelf {
+			i++
+			switch {
+			case !opts.StripControl || (b >= 0x20 && b != 0x7f):
+				out.WriteByte(b)
+			case b == ansiEsc && i < end && line[i] == '[':
+				// keep the ANSI escape sequences of colors: ESC, '[',
+				// parameters and a final byte
+				j := i + 1
+				for j < end && (line[j] < 0x40 || line[j] > 0x7e) {
+					j++
+				}
+				j = min(j+1, end)
+				out.WriteByte(b)
+				out.Write(line[i:j])
+				i = j
+			case b == '\t' || b == '\n' || b == '\r':
+				out.WriteByte(' ')
+			}
+			continue
+		}
+		r, size := utf8.DecodeRune(line[i:end])
+		invalid := r == utf8.RuneError && size == 1
+		switch {
+		case invalid && !opts.ValidUTF8 && !opts.EscapeNonASCII:
+			out.WriteByte(b)
+		case opts.StripControl && r >= 0x80 && r <= 0x9f:
+		case opts.EscapeNonASCII:
+			appendUnicodeEscape(out, r)
+		case invalid:
+			out.WriteString(string(utf8.RuneError))
+		default:
+			out.Write(line[i : i+size])
+		}
+		i += size
+	}
+	out.Write(line[end:])
+	*buf = append((*buf)[:0], *out...)
+}
+
+// appendUnicodeEscape appends r as \uXXXX, with a surrogate pair for the
+// runes outside the Basic Multilingual Plane, as in JSON strings.
+func appendUnicodeEscape(buf *buffer, r rune) {
+	const hex = "0123456789abcdef"
+	if r > 0xffff {
+		r1, r2 := utf16.EncodeRune(r)
+		appendUnicodeEscape(buf, r1)
+		appendUnicodeEscape(buf, r2)
+		return
+	}
+	buf.WriteString(`\u`)
+	for shift := 12; shift >= 0; shift -= 4 {
+		buf.WriteByte(hex[r>>shift&0xf])
+	}
+}
@@ -0,0 +1,64 @@
+package l4g
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name string
+		opts HandlerOptions
+		in   string
+		want string
+	}{
+		{"unchanged", HandlerOptions{StripControl: true}, "plain line\n", "plain line\n"},
+		{"invalid kept", HandlerOptions{StripControl: true}, "a\xffb\n", "a\xffb\n"},
+		{"valid utf8", HandlerOptions{ValidUTF8: true}, "a\xffb\tc\n", "a�b\tc\n"},
+		{"strip", HandlerOptions{StripControl: true}, "a\x00b\tc\nd\x7f\u0085e\n", "ab c de\n"},
+		{"colors kept", HandlerOptions{StripControl: true}, "\x1b[31mred\x1b[0m \x1bx\n", "\x1b[31mred\x1b[0m x\n"},
+		{"escape", HandlerOptions{EscapeNonASCII: true}, "Zürich 😀 \xff\n", `Z\u00fcrich \ud83d\ude00 \ufffd` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := newBuffer()
+			defer buf.Free()
+			buf.WriteString(tt.in)
+			sanitize(buf, &tt.opts)
+			if got := string(*buf); got != tt.want {
+				t.Errorf("sanitize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlers_Sanitize(t *testing.T) {
+	r := NewRecord(time.Time{}, LevelInfo, "bad\x01 msg\nfor Zoë")
+	r.AddAttrs(String("city", "Zürich"))
+	opts := HandlerOptions{NoColor: true, ValidUTF8: true, StripControl: true, EscapeNonASCII: true}
+
+	var buf bytes.Buffer
+	opts.Output = &buf
+	NewSimpleHandler(opts).Handle(r)
+	if want := "INFO bad msg for Zo\\u00eb city=Z\\u00fcrich\n"; buf.String() != want {
+		t.Errorf("SimpleHandler output = %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	NewJSONHandler(opts).Handle(r)
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("JSONHandler output %q: %v", buf.String(), err)
+	}
+	if m["msg"] != "bad\u0001 msg\nfor Zoë" || m["city"] != "Zürich" {
+		t.Errorf("JSONHandler output = %q, want the strings escaped", buf.String())
+	}
+	for _, b := range buf.Bytes() {
+		if b >= 0x80 {
+			t.Errorf("JSONHandler output = %q, want ASCII", buf.String())
+			break
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package l4g
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"runtime"
+	"sync"
+	"syscall"
+)
+
+// closers are the closers registered with RegisterCloser.
+var closers struct {
+	mu sync.Mutex
+	cs []io.Closer
+}
+
+// RegisterCloser registers c to be closed by [Shutdown], such as a
+// [SyslogHandler], a [SQLiteHandler] or a [GzipWriter] the program logs to.
+func RegisterCloser(c io.Closer) {
+	closers.mu.Lock()
+	defer closers.mu.Unlock()
+	closers.cs = append(closers.cs, c)
+}
+
+// Shutdown flushes the default logger and the channel loggers, as
+// [DrainContext], then closes the closers registered with RegisterCloser,
+// last registered first, and unregisters them. The closers are closed
+// even if ctx is done, so that their last records are not lost.
+func Shutdown(ctx context.Context) error {
+	errs := []error{DrainContext(ctx)}
+	closers.mu.Lock()
+	cs := closers.cs
+	closers.cs = nil
+	closers.mu.Unlock()
+	for i := len(cs) - 1; i >= 0; i-- {
+		errs = append(errs, cs[i].Close())
+	}
+	return errors.Join(errs...)
+}
+
+// HandleSignals catches SIGINT and SIGTERM until ctx is done. On the first
+// of them, it logs the signal with l at LevelWarn, calls [Shutdown] with a
+// context bounded by the FlushTimeout of l, and, if reraise is set, sends
+// the signal again to the process with the default handling restored, so
+// that the program terminates as if the signal had not been caught: with
+// the exit status expected by supervisors. Where the signal cannot be
+// sent, as on Windows, the error is reported with [FallbackErrorf] and
+// the program exits with [OsExiter](1) instead. If l is nil, the default
+// logger is used.
+//
+// The returned channel receives the signal after the shutdown, for
+// programs passing reraise as false to exit in their own way; it is
+// closed, without receiving, when ctx is done first.
+func HandleSignals(ctx context.Context, l *Logger, reraise bool) <-chan os.Signal {
+	if l == nil {
+		l = Default()
+	}
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	handled := make(chan os.Signal, 1)
+	go func() {
+		defer close(handled)
+		select {
+		case <-ctx.Done():
+			signal.Stop(sigs)
+			return
+		case sig := <-sigs:
+			l.Warn("received signal, shutting down", "signal", sig.String())
+			sctx, cancel := context.Background(), func() {}
+			if l.flushTimeout > 0 {
+				sctx, cancel = context.WithTimeout(sctx, l.flushTimeout)
+			}
+			if err := Shutdown(sctx); err != nil {
+				FallbackErrorf("l4g: shutdown: %v", err)
+			}
+			cancel()
+			signal.Stop(sigs)
+			handled <- sig
+			if reraise {
+				signal.Reset(sig)
+				if err := signalSelf(sig); err != nil {
+					FallbackErrorf("l4g: reraise %v: %v", sig, err)
+					OsExiter(1)
+				}
+			}
+		}
+	}()
+	return handled
+}
+
+// signalSelf sends sig to the process. It is a variable for testing.
+var signalSelf = func(sig os.Signal) error {
+	if runtime.GOOS == "windows" {
+		// Sending os.Interrupt is not implemented on Windows.
+		return errors.New("signals cannot be sent on " + runtime.GOOS)
+	}
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		return err
+	}
+	return p.Signal(sig)
+}
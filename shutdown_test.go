@@ -0,0 +1,136 @@
+package l4g
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// recordingCloser records the order in which closers are closed.
+type recordingCloser struct {
+	name  string
+	order *[]string
+}
+
+func (c recordingCloser) Close() error {
+	*c.order = append(*c.order, c.name)
+	if c.name == "bad" {
+		return errors.New("close failed")
+	}
+	return nil
+}
+
+func TestShutdown(t *testing.T) {
+	var order []string
+	RegisterCloser(recordingCloser{"first", &order})
+	RegisterCloser(recordingCloser{"bad", &order})
+	RegisterCloser(recordingCloser{"last", &order})
+
+	err := Shutdown(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "close failed") {
+		t.Errorf("Shutdown() error = %v, want close failed", err)
+	}
+	if got := strings.Join(order, ","); got != "last,bad,first" {
+		t.Errorf("close order = %s, want last,bad,first", got)
+	}
+
+	order = nil
+	if err := Shutdown(context.Background()); err != nil || len(order) != 0 {
+		t.Errorf("second Shutdown() = %v, closed %v, want nothing", err, order)
+	}
+}
+
+func TestHandleSignals(t *testing.T) {
+	buf := &syncBuffer{}
+	logger := New(Options{Output: buf, NoColor: true})
+	var order []string
+	RegisterCloser(recordingCloser{"sink", &order})
+
+	handled := HandleSignals(context.Background(), logger, false)
+	p, _ := os.FindProcess(os.Getpid())
+	if err := p.Signal(syscall.SIGTERM); err != nil {
+		t.Skipf("cannot signal the process: %v", err)
+	}
+	select {
+	case sig := <-handled:
+		if sig != syscall.SIGTERM {
+			t.Errorf("handled %v, want SIGTERM", sig)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("signal not handled")
+	}
+	if !strings.Contains(buf.String(), "received signal") || !strings.Contains(buf.String(), "signal=terminated") {
+		t.Errorf("output = %q, want the signal logged", buf.String())
+	}
+	if len(order) != 1 {
+		t.Errorf("closed %v, want the registered closer", order)
+	}
+}
+
+func TestHandleSignals_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	handled := HandleSignals(ctx, nil, true)
+	cancel()
+	select {
+	case _, ok := <-handled:
+		if ok {
+			t.Error("received a signal, want the channel closed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("channel not closed")
+	}
+}
+
+func TestHandleSignals_ReraiseError(t *testing.T) {
+	exited := make(chan int, 1)
+	oldExiter, oldSignalSelf := OsExiter, signalSelf
+	OsExiter = func(code int) { exited <- code }
+	signalSelf = func(os.Signal) error { return errors.New("not supported") }
+	defer func() { OsExiter, signalSelf = oldExiter, oldSignalSelf }()
+
+	logger := New(Options{Output: &syncBuffer{}, NoColor: true})
+	handled := HandleSignals(context.Background(), logger, true)
+	p, _ := os.FindProcess(os.Getpid())
+	if err := p.Signal(syscall.SIGTERM); err != nil {
+		t.Skipf("cannot signal the process: %v", err)
+	}
+	select {
+	case code := <-exited:
+		if code != 1 {
+			t.Errorf("exit code = %d, want 1", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no exit after the signal could not be reraised")
+	}
+	<-handled
+}
+
+func TestHandleSignals_Concurrent(t *testing.T) {
+	// Two handlers shut down concurrently on the same signal, each
+	// with its own setting, which must not race.
+	buf := &syncBuffer{}
+	logger := New(Options{Output: buf, NoColor: true})
+	first := HandleSignals(context.Background(), logger, false)
+	second := HandleSignals(context.Background(), logger, false)
+	p, _ := os.FindProcess(os.Getpid())
+	if err := p.Signal(syscall.SIGINT); err != nil {
+		t.Skipf("cannot signal the process: %v", err)
+	}
+	for _, handled := range []<-chan os.Signal{first, second} {
+		select {
+		case sig := <-handled:
+			if sig != os.Interrupt {
+				t.Errorf("handled %v, want SIGINT", sig)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("signal not handled")
+		}
+	}
+	if n := strings.Count(buf.String(), "received signal"); n != 2 {
+		t.Errorf("output = %q, want the signal logged twice", buf.String())
+	}
+}
@@ -0,0 +1,102 @@
+package l4g
+
+import (
+	"log/slog"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// SourceKey is the key used by the built-in handlers for the source file
+// and line of the log call, enabled by [HandlerOptions.AddSource].
+// The associated Value is a *[slog.Source].
+const SourceKey = "source"
+
+// A SourcePathMode selects how handlers shorten the file path of a
+// source location.
+type SourcePathMode int
+
+const (
+	// SourcePathShort keeps the last directory and the file name,
+	// as in "db/store.go". This is the default.
+	SourcePathShort SourcePathMode = iota
+	// SourcePathFull keeps the path as recorded by the compiler.
+	SourcePathFull
+	// SourcePathModule makes paths relative to the root of the main
+	// module, as in "internal/db/store.go", using the module path from the
+	// build information of the binary. Files outside the main module
+	// fall back to SourcePathShort.
+	SourcePathModule
+)
+
+// mainModule returns the module path and the main package path of the
+// running binary, or empty strings if the build information is unavailable.
+var mainModule = sync.OnceValues(func() (mod, pkg string) {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "", ""
+	}
+	return bi.Main.Path, bi.Path
+})
+
+// sourceFile returns the file path of src shortened according to opts.
+// SourceRoot is trimmed first; other paths are shortened by SourcePath.
+func sourceFile(src *slog.Source, opts *HandlerOptions) string {
+	if opts.SourceRoot != "" {
+		if rel, ok := strings.CutPrefix(src.File, opts.SourceRoot); ok {
+			return strings.TrimPrefix(rel, "/")
+		}
+	}
+	switch opts.SourcePath {
+	case SourcePathFull:
+		return src.File
+	case SourcePathModule:
+		if rel, ok := moduleRelative(src); ok {
+			return rel
+		}
+	}
+	dir, file := filepath.Split(src.File)
+	return filepath.Join(filepath.Base(dir), file)
+}
+
+// moduleRelative returns the path of src relative to the root of the main
+// module. Binaries built with -trimpath record files under the module path;
+// otherwise the directory is derived from the package of the function.
+func moduleRelative(src *slog.Source) (string, bool) {
+	mod, mainPkg := mainModule()
+	if mod == "" {
+		return "", false
+	}
+	if rel, ok := strings.CutPrefix(src.File, mod+"/"); ok {
+		return rel, true
+	}
+	pkg := funcPackage(src.Function)
+	if pkg == "main" {
+		pkg = mainPkg
+	}
+	file := filepath.Base(src.File)
+	if pkg == mod {
+		return file, true
+	}
+	if dir, ok := strings.CutPrefix(pkg, mod+"/"); ok {
+		return dir + "/" + file, true
+	}
+	return "", false
+}
+
+// funcPackage returns the import path of the package of a fully qualified
+// function name such as "example.com/app/db.(*Store).Query".
+func funcPackage(function string) string {
+	slash := strings.LastIndexByte(function, '/')
+	if dot := strings.IndexByte(function[slash+1:], '.'); dot >= 0 {
+		return function[:slash+1+dot]
+	}
+	return function
+}
+
+// shortFunc returns function without the directories of its package path,
+// as in "db.(*Store).Query".
+func shortFunc(function string) string {
+	return function[strings.LastIndexByte(function, '/')+1:]
+}
@@ -0,0 +1,91 @@
+package l4g
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestSourceFile(t *testing.T) {
+	tests := []struct {
+		name string
+		opts HandlerOptions
+		src  slog.Source
+		want string
+	}{
+		{
+			name: "short",
+			src:  slog.Source{File: "/src/app/internal/db/store.go"},
+			want: "db/store.go",
+		},
+		{
+			name: "full",
+			opts: HandlerOptions{SourcePath: SourcePathFull},
+			src:  slog.Source{File: "/src/app/internal/db/store.go"},
+			want: "/src/app/internal/db/store.go",
+		},
+		{
+			name: "root",
+			opts: HandlerOptions{SourceRoot: "/src/app"},
+			src:  slog.Source{File: "/src/app/internal/db/store.go"},
+			want: "internal/db/store.go",
+		},
+		{
+			name: "outside root",
+			opts: HandlerOptions{SourceRoot: "/src/app"},
+			src:  slog.Source{File: "/go/pkg/mod/x/y.go"},
+			want: "x/y.go",
+		},
+		{
+			name: "module from function",
+			opts: HandlerOptions{SourcePath: SourcePathModule},
+			src:  slog.Source{Function: "go-slim.dev/l4g/replace.Chain", File: "/build/l4g/replace/replace.go"},
+			want: "replace/replace.go",
+		},
+		{
+			name: "module root package",
+			opts: HandlerOptions{SourcePath: SourcePathModule},
+			src:  slog.Source{Function: "go-slim.dev/l4g.(*Logger).Info", File: "/build/l4g/logger.go"},
+			want: "logger.go",
+		},
+		{
+			name: "module trimpath",
+			opts: HandlerOptions{SourcePath: SourcePathModule},
+			src:  slog.Source{Function: "main.main", File: "go-slim.dev/l4g/cmd/x/main.go"},
+			want: "cmd/x/main.go",
+		},
+		{
+			name: "outside module",
+			opts: HandlerOptions{SourcePath: SourcePathModule},
+			src:  slog.Source{Function: "example.com/other.F", File: "/go/pkg/mod/example.com/other/f.go"},
+			want: "other/f.go",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sourceFile(&tt.src, &tt.opts); got != tt.want {
+				t.Errorf("sourceFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuncPackage(t *testing.T) {
+	tests := map[string]string{
+		"go-slim.dev/l4g.(*Logger).Info": "go-slim.dev/l4g",
+		"main.main":                      "main",
+		"example.com/a/b.F.func1":        "example.com/a/b",
+		"example.com/a/b.G[...]":         "example.com/a/b",
+	}
+	for function, want := range tests {
+		if got := funcPackage(function); got != want {
+			t.Errorf("funcPackage(%q) = %q, want %q", function, got, want)
+		}
+	}
+}
+
+func TestShortFunc(t *testing.T) {
+	if got := shortFunc("go-slim.dev/l4g.(*Logger).Info"); got != "l4g.(*Logger).Info" {
+		t.Errorf("shortFunc() = %q", got)
+	}
+}
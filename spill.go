@@ -0,0 +1,159 @@
+package l4g
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// SpillOptions are options for a [SpillHandler].
+type SpillOptions struct {
+	// Output receives the spilled records (Default: os.Stderr).
+	Output io.Writer
+
+	// Level is the lowest level of the records spilled; records below it
+	// are lost while the wrapped handler fails (Default: LevelWarn).
+	Level Level
+
+	// Threshold is the longest a record may take to be handled before it
+	// is spilled. If zero, Handle waits for the wrapped handler and spills
+	// only the records it fails to handle.
+	Threshold time.Duration
+}
+
+// SpillHandler is a Handler that writes a record synchronously to a
+// secondary output, stderr by default, when the wrapped handler fails to
+// handle it or takes longer than a threshold, so that critical messages are
+// not completely lost during an outage of the primary sink. Spilled records
+// are formatted by a SimpleHandler without colors.
+//
+// When the threshold is exceeded, the wrapped handler is left to complete
+// in the background, and the records logged until it returns are spilled
+// without being passed to it. A record spilled after a slow write may
+// therefore appear in both outputs.
+type SpillHandler struct {
+	handler Handler
+	spill   Handler
+	opts    *SpillOptions
+	stall   *spillStall // shared by the handlers derived from this one
+}
+
+// spillStall tracks a call of the wrapped handler that exceeded the
+// threshold.
+type spillStall struct {
+	mu      sync.Mutex
+	pending chan struct{} // closed when the stalled call returns, nil if none
+}
+
+// stalled reports whether a stalled call of the wrapped handler has not
+// returned yet.
+func (s *spillStall) stalled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pending == nil {
+		return false
+	}
+	select {
+	case <-s.pending:
+		s.pending = nil
+		return false
+	default:
+		return true
+	}
+}
+
+var _ Handler = (*SpillHandler)(nil)
+
+// NewSpillHandler returns a [SpillHandler] passing records to h.
+func NewSpillHandler(h Handler, opts SpillOptions) *SpillHandler {
+	if opts.Output == nil {
+		opts.Output = os.Stderr
+	}
+	if opts.Level == 0 {
+		opts.Level = LevelWarn
+	}
+	return &SpillHandler{
+		handler: h,
+		spill:   NewSimpleHandler(HandlerOptions{Output: opts.Output, Level: opts.Level, NoColor: true}),
+		opts:    &opts,
+		stall:   &spillStall{},
+	}
+}
+
+// Enabled defers to the wrapped handler.
+func (h *SpillHandler) Enabled(level Level) bool {
+	return h.handler.Enabled(level)
+}
+
+// Handle passes r to the wrapped handler, spilling it if that fails or
+// exceeds the threshold. It returns the error of the wrapped handler, or
+// nil if r was spilled after exceeding the threshold.
+func (h *SpillHandler) Handle(r Record) error {
+	if h.stall.stalled() {
+		return h.spillRecord(r, nil)
+	}
+	if h.opts.Threshold <= 0 {
+		if err := h.handler.Handle(r); err != nil {
+			return h.spillRecord(r, err)
+		}
+		return nil
+	}
+
+	done := make(chan struct{})
+	var err error
+	r2 := r.Clone()
+	go func() {
+		defer close(done)
+		err = h.handler.Handle(r2)
+	}()
+	timer := time.NewTimer(h.opts.Threshold)
+	defer timer.Stop()
+	select {
+	case <-done:
+		if err != nil {
+			return h.spillRecord(r, err)
+		}
+		return nil
+	case <-timer.C:
+		h.stall.mu.Lock()
+		h.stall.pending = done
+		h.stall.mu.Unlock()
+		return h.spillRecord(r, nil)
+	}
+}
+
+// spillRecord writes r to the spill output, if its level is high enough,
+// and returns err together with the error of the spill output.
+func (h *SpillHandler) spillRecord(r Record, err error) error {
+	if !h.spill.Enabled(r.Level) {
+		return err
+	}
+	return errors.Join(err, h.spill.Handle(r))
+}
+
+// Flush flushes the wrapped handler.
+func (h *SpillHandler) Flush() error {
+	return flush(h.handler)
+}
+
+// Compact compacts the wrapped handler.
+func (h *SpillHandler) Compact() {
+	compact(h.handler)
+}
+
+// WithAttrs returns a SpillHandler wrapping h.WithAttrs(attrs).
+func (h *SpillHandler) WithAttrs(attrs []Attr) Handler {
+	return &SpillHandler{h.handler.WithAttrs(attrs), h.spill.WithAttrs(attrs), h.opts, h.stall}
+}
+
+// WithGroup returns a SpillHandler wrapping h.WithGroup(name).
+func (h *SpillHandler) WithGroup(name string) Handler {
+	return &SpillHandler{h.handler.WithGroup(name), h.spill.WithGroup(name), h.opts, h.stall}
+}
+
+// WithPrefix returns a SpillHandler wrapping h.WithPrefix(prefix).
+func (h *SpillHandler) WithPrefix(prefix string) Handler {
+	return &SpillHandler{h.handler.WithPrefix(prefix), h.spill.WithPrefix(prefix), h.opts, h.stall}
+}
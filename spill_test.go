@@ -0,0 +1,76 @@
+package l4g
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("sink down") }
+
+func TestSpillHandlerOnError(t *testing.T) {
+	var spilled bytes.Buffer
+	h := NewSpillHandler(NewSimpleHandler(HandlerOptions{Output: failingWriter{}, NoColor: true}),
+		SpillOptions{Output: &spilled})
+
+	h2 := h.WithAttrs([]Attr{String("host", "a1")})
+	if err := h2.Handle(NewRecord(time.Now(), LevelError, "write failed")); err == nil {
+		t.Error("Handle did not return the error of the wrapped handler")
+	}
+	if err := h2.Handle(NewRecord(time.Now(), LevelInfo, "not critical")); err == nil {
+		t.Error("Handle did not return the error of the wrapped handler")
+	}
+
+	got := spilled.String()
+	if !strings.Contains(got, "ERROR write failed host=a1") {
+		t.Errorf("record not spilled:\n%s", got)
+	}
+	if strings.Contains(got, "not critical") {
+		t.Errorf("record below the spill level spilled:\n%s", got)
+	}
+}
+
+func TestSpillHandlerThreshold(t *testing.T) {
+	bw := &blockingWriter{release: make(chan struct{})}
+	var spilled bytes.Buffer
+	h := NewSpillHandler(NewSimpleHandler(HandlerOptions{Output: bw, NoColor: true}),
+		SpillOptions{Output: &spilled, Threshold: 20 * time.Millisecond, Level: LevelInfo})
+
+	start := time.Now()
+	if err := h.Handle(NewRecord(time.Now(), LevelWarn, "slow")); err != nil {
+		t.Errorf("Handle = %v, want nil after spilling", err)
+	}
+	if err := h.Handle(NewRecord(time.Now(), LevelWarn, "while stalled")); err != nil {
+		t.Errorf("Handle = %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Handle blocked for %v", d)
+	}
+	close(bw.release)
+
+	got := spilled.String()
+	for _, want := range []string{"WARN slow", "WARN while stalled"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("spilled output missing %q:\n%s", want, got)
+		}
+	}
+
+	// once the stalled write returns, records go to the wrapped handler
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if !h.stall.stalled() {
+			break
+		}
+	}
+	spilled.Reset()
+	if err := h.Handle(NewRecord(time.Now(), LevelWarn, "recovered")); err != nil {
+		t.Errorf("Handle = %v", err)
+	}
+	if spilled.Len() != 0 {
+		t.Errorf("record spilled after recovery:\n%s", spilled.String())
+	}
+}
@@ -0,0 +1,263 @@
+package l4g
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SQLiteOptions are options for a [SQLiteHandler].
+type SQLiteOptions struct {
+	// Level reports the minimum record level that will be logged.
+	// If Level is nil, the handler assumes LevelInfo.
+	Level Leveler
+
+	// Table is the name of the table the records are inserted into
+	// (Default: "logs"). It is created if it does not exist.
+	Table string
+
+	// BatchSize is the number of records inserted in one transaction
+	// (Default: 100).
+	BatchSize int
+
+	// FlushInterval is the longest time a record waits for its batch to
+	// fill before being inserted (Default: 1s).
+	FlushInterval time.Duration
+
+	// FlushLevel is the lowest level of the records inserted at once,
+	// together with the pending ones, so that they are stored before a
+	// crash (Default: none).
+	FlushLevel Level
+}
+
+// SQLiteHandler is a Handler that inserts records into a table of a SQLite
+// database, so that local logs can be queried with SQL. The table has the
+// columns time, level, prefix, msg and attrs, where time is an RFC 3339
+// string in UTC, so that it sorts chronologically, and attrs holds the
+// attributes as a JSON object that can be queried with the JSON functions
+// of SQLite, as in
+//
+//	SELECT time, msg FROM logs WHERE attrs->>'$.user' = 'alice'
+//
+// Records are buffered and inserted in batches, each within a transaction.
+// Call Close to insert the pending records before the program exits.
+type SQLiteHandler struct {
+	s      *sqliteSink
+	json   *JSONHandler // encodes the attributes
+	prefix string       // log prefix from WithPrefix
+}
+
+// sqliteSink holds the state shared by the handlers derived from one another.
+type sqliteSink struct {
+	opts    SQLiteOptions
+	db      *sql.DB
+	insert  string
+	mu      sync.Mutex
+	pending []sqliteRow
+	timer   *time.Timer // pending flush, nil if none
+	err     error       // error of the last background flush
+	closed  bool
+}
+
+type sqliteRow struct {
+	time, level, prefix, msg, attrs string
+}
+
+var _ Handler = (*SQLiteHandler)(nil)
+
+// NewSQLiteHandler returns a [SQLiteHandler] writing to db, creating the
+// table if needed. The database must be opened with a SQLite driver
+// registered by the program, such as modernc.org/sqlite or
+// github.com/mattn/go-sqlite3, which keeps this package free of
+// dependencies.
+func NewSQLiteHandler(db *sql.DB, opts SQLiteOptions) (*SQLiteHandler, error) {
+	if opts.Table == "" {
+		opts.Table = "logs"
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	table := quoteIdent(opts.Table)
+	_, err := db.Exec("CREATE TABLE IF NOT EXISTS " + table +
+		" (time TEXT NOT NULL, level TEXT NOT NULL, prefix TEXT NOT NULL, msg TEXT NOT NULL, attrs TEXT NOT NULL)")
+	if err != nil {
+		return nil, fmt.Errorf("l4g: create table %s: %w", table, err)
+	}
+	s := &sqliteSink{
+		opts:   opts,
+		db:     db,
+		insert: "INSERT INTO " + table + " (time, level, prefix, msg, attrs) VALUES (?, ?, ?, ?, ?)",
+	}
+	return &SQLiteHandler{
+		s:    s,
+		json: NewJSONHandler(HandlerOptions{Level: opts.Level}).(*JSONHandler),
+	}, nil
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *SQLiteHandler) Enabled(level Level) bool {
+	return h.json.Enabled(level)
+}
+
+// Handle buffers r for insertion, inserting the batch once it is full.
+// It returns the error of a previous background insertion, if any.
+func (h *SQLiteHandler) Handle(r Record) error {
+	if level, ok := r.levelOverride(); ok {
+		r.Level = level
+	}
+	prefix := r.Prefix
+	if prefix == "" {
+		prefix = h.prefix
+	}
+	buf := newBuffer()
+	defer buf.Free()
+	buf.WriteByte('{')
+	h.json.appendAttrs(buf, r)
+	closeObject(buf)
+
+	row := sqliteRow{
+		time:   r.Time.UTC().Format(time.RFC3339Nano),
+		level:  levelName(r.Level),
+		prefix: prefix,
+		msg:    r.Message,
+		attrs:  string(*buf),
+	}
+	return h.s.add(row, r.Level)
+}
+
+// Flush inserts the pending records.
+func (h *SQLiteHandler) Flush() error {
+	return h.FlushContext(context.Background())
+}
+
+// FlushContext inserts the pending records, rolling back the transaction
+// when ctx is done. The records are lost then.
+func (h *SQLiteHandler) FlushContext(ctx context.Context) error {
+	h.s.mu.Lock()
+	defer h.s.mu.Unlock()
+	return h.s.flushContext(ctx)
+}
+
+// Close inserts the pending records. It does not close the database.
+func (h *SQLiteHandler) Close() error {
+	h.s.mu.Lock()
+	defer h.s.mu.Unlock()
+	if h.s.closed {
+		return nil
+	}
+	h.s.closed = true
+	return h.s.flush()
+}
+
+// WithAttrs returns a new Handler whose attributes consist of
+// both the receiver's attributes and the arguments.
+func (h *SQLiteHandler) WithAttrs(attrs []Attr) Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.json = h.json.WithAttrs(attrs).(*JSONHandler)
+	return &h2
+}
+
+// WithGroup returns a new Handler with the given group appended to
+// the receiver's existing groups.
+func (h *SQLiteHandler) WithGroup(name string) Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.json = h.json.WithGroup(name).(*JSONHandler)
+	return &h2
+}
+
+// WithPrefix returns a new Handler with the given prefix prepended to
+// the receiver's existing prefix.
+func (h *SQLiteHandler) WithPrefix(prefix string) Handler {
+	if prefix == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix = prefix + h.prefix
+	return &h2
+}
+
+func (s *sqliteSink) add(row sqliteRow, level Level) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrWriterClosed
+	}
+	s.pending = append(s.pending, row)
+	if len(s.pending) >= s.opts.BatchSize || s.opts.FlushLevel > 0 && level >= s.opts.FlushLevel {
+		return s.flush()
+	}
+	if s.timer == nil {
+		s.timer = time.AfterFunc(s.opts.FlushInterval, func() {
+			runLabeled("sqlite", func() {
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				s.timer = nil
+				s.err = s.flush()
+			})
+		})
+	}
+	err := s.err
+	s.err = nil
+	return err
+}
+
+// flush inserts the pending records in one transaction.
+// s.mu must be held.
+func (s *sqliteSink) flush() error {
+	return s.flushContext(context.Background())
+}
+
+// flushContext is like flush but gives up when ctx is done.
+// s.mu must be held.
+func (s *sqliteSink) flushContext(ctx context.Context) error {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if len(s.pending) == 0 {
+		return nil
+	}
+	rows := s.pending
+	s.pending = nil
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := insertRows(ctx, tx, s.insert, rows); err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+	return tx.Commit()
+}
+
+func insertRows(ctx context.Context, tx *sql.Tx, insert string, rows []sqliteRow) error {
+	stmt, err := tx.PrepareContext(ctx, insert)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, row := range rows {
+		if _, err := stmt.ExecContext(ctx, row.time, row.level, row.prefix, row.msg, row.attrs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// quoteIdent quotes name as an SQL identifier.
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,218 @@
+package l4g
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingDriver is a database/sql driver that records the statements
+// executed through it, standing in for a SQLite driver.
+type recordingDriver struct {
+	mu        sync.Mutex
+	stmts     []string
+	committed [][]driver.Value
+	txs       int
+}
+
+func (d *recordingDriver) Open(string) (driver.Conn, error) { return &recordingConn{d: d}, nil }
+
+func (d *recordingDriver) rows() [][]driver.Value {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.committed
+}
+
+type recordingConn struct {
+	d       *recordingDriver
+	pending [][]driver.Value
+}
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return &recordingStmt{c: c, query: query}, nil
+}
+func (c *recordingConn) Close() error              { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error) { return c, nil }
+
+func (c *recordingConn) Commit() error {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	c.d.committed = append(c.d.committed, c.pending...)
+	c.d.txs++
+	c.pending = nil
+	return nil
+}
+
+func (c *recordingConn) Rollback() error {
+	c.pending = nil
+	return nil
+}
+
+type recordingStmt struct {
+	c     *recordingConn
+	query string
+}
+
+func (s *recordingStmt) Close() error  { return nil }
+func (s *recordingStmt) NumInput() int { return -1 }
+
+func (s *recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.c.d.mu.Lock()
+	s.c.d.stmts = append(s.c.d.stmts, s.query)
+	s.c.d.mu.Unlock()
+	if len(args) > 0 {
+		s.c.pending = append(s.c.pending, args)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *recordingStmt) Query([]driver.Value) (driver.Rows, error) {
+	if !strings.HasPrefix(s.query, "SELECT ") {
+		return nil, driver.ErrSkip
+	}
+	return &recordingRows{rows: s.c.d.rows()}, nil
+}
+
+// recordingRows returns the committed rows to a SELECT of all the columns.
+type recordingRows struct {
+	rows [][]driver.Value
+}
+
+func (r *recordingRows) Columns() []string {
+	return []string{"time", "level", "prefix", "msg", "attrs"}
+}
+func (r *recordingRows) Close() error { return nil }
+
+func (r *recordingRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func openRecording(t *testing.T) (*sql.DB, *recordingDriver) {
+	t.Helper()
+	d := &recordingDriver{}
+	name := "l4g-recording-" + t.Name()
+	sql.Register(name, d)
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db, d
+}
+
+func TestSQLiteHandler(t *testing.T) {
+	db, d := openRecording(t)
+	h, err := NewSQLiteHandler(db, SQLiteOptions{BatchSize: 2, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.stmts) != 1 || !strings.HasPrefix(d.stmts[0], `CREATE TABLE IF NOT EXISTS "logs"`) {
+		t.Errorf("statements = %q, want the table created", d.stmts)
+	}
+
+	l := New(Options{Output: &strings.Builder{}, Handler: h}).WithPrefix("db").WithGroup("req").WithAttrs("id", 7)
+	l.Info("first", "user", "alice")
+	if rows := d.rows(); len(rows) != 0 {
+		t.Errorf("rows = %v, want none before the batch is full", rows)
+	}
+	l.Warn("second")
+	rows := d.rows()
+	if len(rows) != 2 || d.txs != 1 {
+		t.Fatalf("rows = %v in %d transactions, want 2 rows in 1", rows, d.txs)
+	}
+	row := rows[0]
+	if _, err := time.Parse(time.RFC3339Nano, row[0].(string)); err != nil {
+		t.Errorf("time = %q: %v", row[0], err)
+	}
+	if row[1] != "INFO" || row[2] != "db" || row[3] != "first" {
+		t.Errorf("row = %v, want level INFO, prefix db and msg first", row)
+	}
+	var attrs map[string]map[string]any
+	if err := json.Unmarshal([]byte(row[4].(string)), &attrs); err != nil {
+		t.Fatalf("attrs = %q: %v", row[4], err)
+	}
+	if attrs["req"]["id"] != 7.0 || attrs["req"]["user"] != "alice" {
+		t.Errorf("attrs = %v, want id and user in group req", attrs)
+	}
+	if rows[1][1] != "WARN" || rows[1][4] != `{"req":{"id":7}}` {
+		t.Errorf("row = %v, want level WARN and the handler attributes", rows[1])
+	}
+
+	l.Info("third")
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if rows := d.rows(); len(rows) != 3 || rows[2][3] != "third" {
+		t.Errorf("rows = %v, want the pending record inserted on Close", rows)
+	}
+	if err := h.Handle(Record{Message: "late"}); err != ErrWriterClosed {
+		t.Errorf("Handle() after Close = %v, want ErrWriterClosed", err)
+	}
+}
+
+func TestSQLiteHandler_FlushLevel(t *testing.T) {
+	db, d := openRecording(t)
+	h, err := NewSQLiteHandler(db, SQLiteOptions{FlushInterval: time.Hour, FlushLevel: LevelError})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Handle(NewRecord(time.Now(), LevelWarn, "pending"))
+	if rows := d.rows(); len(rows) != 0 {
+		t.Errorf("rows = %v, want none below the flush level", rows)
+	}
+	h.Handle(NewRecord(time.Now(), LevelError, "failed"))
+	if rows := d.rows(); len(rows) != 2 || rows[1][3] != "failed" {
+		t.Errorf("rows = %v, want the pending record and the error inserted", rows)
+	}
+}
+
+func TestSQLiteHandlerFlushInterval(t *testing.T) {
+	db, d := openRecording(t)
+	h, err := NewSQLiteHandler(db, SQLiteOptions{Table: "app", FlushInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	h.Handle(Record{Time: time.Now(), Level: LevelInfo, Message: "tick"})
+	deadline := time.Now().Add(time.Second)
+	for len(d.rows()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if rows := d.rows(); len(rows) != 1 || rows[0][3] != "tick" {
+		t.Errorf("rows = %v, want the record inserted after FlushInterval", rows)
+	}
+	if !strings.Contains(d.stmts[len(d.stmts)-1], `INSERT INTO "app"`) {
+		t.Errorf("statements = %q, want inserts into app", d.stmts)
+	}
+}
+
+func TestSQLiteHandler_FlushContext(t *testing.T) {
+	db, d := openRecording(t)
+	h, err := NewSQLiteHandler(db, SQLiteOptions{FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Handle(NewRecord(time.Now(), LevelInfo, "pending"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := h.FlushContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("FlushContext() = %v with a cancelled context, want context.Canceled", err)
+	}
+	if rows := d.rows(); len(rows) != 0 {
+		t.Errorf("rows = %v, want none inserted", rows)
+	}
+}
@@ -0,0 +1,107 @@
+package l4g
+
+import "slices"
+
+// An AttrFormat tells a [HandlerState] how to encode attributes.
+type AttrFormat struct {
+	// AppendAttr appends the encoding of a to b and returns the extended
+	// buffer. groups are the groups enclosing a, for formats that qualify
+	// keys with them.
+	AppendAttr func(b []byte, groups []string, a Attr) []byte
+
+	// OpenGroup, if set, appends the opening of the group name to b, for
+	// formats nesting the attributes of a group, such as JSON. A group is
+	// opened when attributes are first added inside it.
+	OpenGroup func(b []byte, name string) []byte
+}
+
+// HandlerState holds what a handler accumulates through WithAttrs,
+// WithGroup and WithPrefix, with the attributes encoded once, when they are
+// added, rather than for every record. The built-in JSON handler uses it,
+// and third-party handlers can embed it to get the same optimization:
+//
+//	func (h *MyHandler) WithAttrs(attrs []l4g.Attr) l4g.Handler {
+//		h2 := *h
+//		h2.state = h.state.WithAttrs(attrs)
+//		return &h2
+//	}
+//
+// Its Handle method then writes State.Attrs, opens the PendingGroups if
+// the record has attributes, encodes them with the Groups, and closes the
+// groups it opened.
+//
+// A HandlerState is immutable: its methods return a new state.
+type HandlerState struct {
+	format *AttrFormat
+	prefix string
+	groups []string
+	attrs  string
+	nOpen  int
+}
+
+// NewHandlerState returns an empty HandlerState encoding attributes with f.
+func NewHandlerState(f AttrFormat) HandlerState {
+	return HandlerState{format: &f}
+}
+
+// Prefix returns the prefix set by WithPrefix.
+func (s HandlerState) Prefix() string { return s.prefix }
+
+// Groups returns the groups opened by WithGroup, outermost first.
+// The caller must not modify the slice.
+func (s HandlerState) Groups() []string { return s.groups }
+
+// Attrs returns the attributes added by WithAttrs, encoded by the format,
+// including the openings of the groups enclosing them.
+func (s HandlerState) Attrs() string { return s.attrs }
+
+// OpenGroups returns the number of groups opened in Attrs, the first ones
+// of Groups.
+func (s HandlerState) OpenGroups() int { return s.nOpen }
+
+// PendingGroups returns the groups not opened in Attrs yet, which enclose
+// the attributes of the records.
+func (s HandlerState) PendingGroups() []string { return s.groups[s.nOpen:] }
+
+// WithAttrs returns a state holding the attributes of s followed by attrs,
+// added inside the groups of s.
+func (s HandlerState) WithAttrs(attrs []Attr) HandlerState {
+	if len(attrs) == 0 {
+		return s
+	}
+	buf := newBuffer()
+	defer buf.Free()
+	if s.format.OpenGroup != nil {
+		for _, g := range s.PendingGroups() {
+			*buf = s.format.OpenGroup(*buf, g)
+		}
+	}
+	body := len(*buf)
+	for _, a := range attrs {
+		*buf = s.format.AppendAttr(*buf, s.groups, a)
+	}
+	if len(*buf) == body {
+		return s // every attribute was dropped: leave the groups pending
+	}
+	if s.format.OpenGroup != nil {
+		s.nOpen = len(s.groups)
+	}
+	s.attrs += string(*buf)
+	return s
+}
+
+// WithGroup returns a state with the group name appended to the groups of
+// s. It returns s if name is empty.
+func (s HandlerState) WithGroup(name string) HandlerState {
+	if name == "" {
+		return s
+	}
+	s.groups = append(slices.Clip(s.groups), name)
+	return s
+}
+
+// WithPrefix returns a state with prefix prepended to the prefix of s.
+func (s HandlerState) WithPrefix(prefix string) HandlerState {
+	s.prefix = prefix + s.prefix
+	return s
+}
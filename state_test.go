@@ -0,0 +1,136 @@
+package l4g
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// flatFormat encodes attributes as space-separated key=value pairs with
+// dotted keys, counting the attributes encoded.
+func flatFormat(n *int) AttrFormat {
+	return AttrFormat{AppendAttr: func(b []byte, groups []string, a Attr) []byte {
+		*n++
+		for _, g := range groups {
+			b = append(b, g...)
+			b = append(b, '.')
+		}
+		b = append(b, a.Key...)
+		b = append(b, '=')
+		b = append(b, a.Value.String()...)
+		return append(b, ' ')
+	}}
+}
+
+func TestHandlerState(t *testing.T) {
+	var n int
+	s := NewHandlerState(flatFormat(&n)).
+		WithPrefix("db").
+		WithAttrs([]Attr{String("host", "a1")}).
+		WithGroup("req").
+		WithAttrs([]Attr{Int("id", 7)}).
+		WithGroup("user").
+		WithPrefix("app")
+
+	if got, want := s.Attrs(), "host=a1 req.id=7 "; got != want {
+		t.Errorf("Attrs() = %q, want %q", got, want)
+	}
+	if got, want := s.Prefix(), "appdb"; got != want {
+		t.Errorf("Prefix() = %q, want %q", got, want)
+	}
+	if got := strings.Join(s.Groups(), ","); got != "req,user" {
+		t.Errorf("Groups() = %q, want req,user", got)
+	}
+	if s.OpenGroups() != 0 || len(s.PendingGroups()) != 2 {
+		t.Errorf("flat format opened groups: %d open, %v pending", s.OpenGroups(), s.PendingGroups())
+	}
+	if n != 2 {
+		t.Errorf("encoded %d attributes, want 2", n)
+	}
+}
+
+func TestHandlerStateOpenGroup(t *testing.T) {
+	var n int
+	f := flatFormat(&n)
+	f.OpenGroup = func(b []byte, name string) []byte {
+		return append(append(b, name...), '{')
+	}
+	s := NewHandlerState(f).WithGroup("a").WithGroup("b").WithAttrs([]Attr{String("k", "v")}).WithGroup("c")
+
+	if got, want := s.Attrs(), "a{b{a.b.k=v "; got != want {
+		t.Errorf("Attrs() = %q, want %q", got, want)
+	}
+	if s.OpenGroups() != 2 {
+		t.Errorf("OpenGroups() = %d, want 2", s.OpenGroups())
+	}
+	if got := s.PendingGroups(); len(got) != 1 || got[0] != "c" {
+		t.Errorf("PendingGroups() = %v, want [c]", got)
+	}
+}
+
+func TestHandlerStateDroppedAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewJSONHandler(HandlerOptions{
+		Output: &buf,
+		ReplaceAttr: func(groups []string, a Attr) Attr {
+			if a.Key == "secret" {
+				return Attr{}
+			}
+			return a
+		},
+	})
+	h = h.WithGroup("a").WithAttrs([]Attr{String("secret", "x")})
+	if err := h.Handle(NewRecord(time.Now(), LevelInfo, "m")); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), `"a"`) {
+		t.Errorf("output = %s, want the empty group omitted", buf.String())
+	}
+
+	buf.Reset()
+	r := NewRecord(time.Now(), LevelInfo, "m")
+	r.AddAttrs(String("k", "v"))
+	if err := h.Handle(r); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"a":{"k":"v"}`) {
+		t.Errorf("output = %s, want the group opened for the record attributes", buf.String())
+	}
+}
+
+func TestHandlerStateSiblings(t *testing.T) {
+	var n int
+	s := NewHandlerState(flatFormat(&n)).WithGroup("a").WithGroup("b").WithGroup("c")
+	s1 := s.WithGroup("d")
+	s.WithGroup("e")
+	if got := strings.Join(s1.Groups(), ","); got != "a,b,c,d" {
+		t.Errorf("Groups() = %q, want a,b,c,d", got)
+	}
+}
+
+// countingValuer counts its resolutions.
+type countingValuer struct{ n *int }
+
+func (v countingValuer) LogValue() slog.Value {
+	*v.n++
+	return slog.StringValue("v")
+}
+
+func TestJSONHandler_WithAttrsEncodedOnce(t *testing.T) {
+	var buf bytes.Buffer
+	var n int
+	h := NewJSONHandler(HandlerOptions{Output: &buf}).WithAttrs([]Attr{Any("k", countingValuer{&n})})
+	for range 3 {
+		if err := h.Handle(NewRecord(time.Now(), LevelInfo, "m")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n != 1 {
+		t.Errorf("attribute resolved %d times, want 1", n)
+	}
+	if got := strings.Count(buf.String(), `"k":"v"`); got != 3 {
+		t.Errorf("attribute written %d times, want 3:\n%s", got, buf.String())
+	}
+}
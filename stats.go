@@ -0,0 +1,128 @@
+package l4g
+
+import (
+	"log/slog"
+	"runtime"
+	"runtime/metrics"
+	"sync"
+	"time"
+)
+
+// RuntimeKey is the key of the group of runtime statistics added by a
+// [RuntimeStatsHandler].
+const RuntimeKey = "runtime"
+
+// heapMetrics are the runtime/metrics samples that add up to the heap
+// memory in use, as reported by runtime.MemStats.HeapInuse.
+var heapMetrics = []string{
+	"/memory/classes/heap/objects:bytes",
+	"/memory/classes/heap/unused:bytes",
+}
+
+// RuntimeStatsHandler is a Handler that attaches a snapshot of runtime
+// statistics to records at [LevelError] and above, giving each error a
+// small health context for post-mortems. The snapshot is a [RuntimeKey]
+// group holding the number of goroutines and the heap memory in use.
+//
+// The statistics are cached and refreshed at most once per interval, on
+// the first error logged after the cache expired, so the handler does not
+// run a background goroutine.
+type RuntimeStatsHandler struct {
+	handler Handler
+	stats   *runtimeStats // shared by the handlers derived from this one
+}
+
+// runtimeStats caches the last snapshot of the runtime statistics.
+type runtimeStats struct {
+	interval time.Duration
+	mu       sync.Mutex
+	updated  time.Time
+	attr     Attr
+}
+
+var _ Handler = (*RuntimeStatsHandler)(nil)
+
+// NewRuntimeStatsHandler returns a [RuntimeStatsHandler] that passes records
+// to h after enriching errors with statistics at most interval old.
+// A non-positive interval means one second.
+func NewRuntimeStatsHandler(h Handler, interval time.Duration) *RuntimeStatsHandler {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return &RuntimeStatsHandler{
+		handler: h,
+		stats:   &runtimeStats{interval: interval},
+	}
+}
+
+// Enabled reports whether the wrapped handler handles records at the given level.
+func (h *RuntimeStatsHandler) Enabled(level Level) bool {
+	return h.handler.Enabled(level)
+}
+
+// Handle adds the runtime statistics to r if its level is at least
+// LevelError, then passes it to the wrapped handler.
+func (h *RuntimeStatsHandler) Handle(r Record) error {
+	if r.Level >= LevelError {
+		r = r.Clone()
+		r.AddAttrs(h.stats.get())
+	}
+	return h.handler.Handle(r)
+}
+
+// Flush flushes the wrapped handler.
+func (h *RuntimeStatsHandler) Flush() error {
+	return flush(h.handler)
+}
+
+// Compact compacts the wrapped handler.
+func (h *RuntimeStatsHandler) Compact() {
+	compact(h.handler)
+}
+
+// WithAttrs returns a RuntimeStatsHandler wrapping h.WithAttrs(attrs).
+func (h *RuntimeStatsHandler) WithAttrs(attrs []Attr) Handler {
+	return &RuntimeStatsHandler{h.handler.WithAttrs(attrs), h.stats}
+}
+
+// WithGroup returns a RuntimeStatsHandler wrapping h.WithGroup(name).
+func (h *RuntimeStatsHandler) WithGroup(name string) Handler {
+	return &RuntimeStatsHandler{h.handler.WithGroup(name), h.stats}
+}
+
+// WithPrefix returns a RuntimeStatsHandler wrapping h.WithPrefix(prefix).
+func (h *RuntimeStatsHandler) WithPrefix(prefix string) Handler {
+	return &RuntimeStatsHandler{h.handler.WithPrefix(prefix), h.stats}
+}
+
+// get returns the cached snapshot, refreshing it if it has expired.
+func (s *runtimeStats) get() Attr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now := time.Now(); now.Sub(s.updated) >= s.interval {
+		s.attr = readRuntimeStats()
+		s.updated = now
+	}
+	return s.attr
+}
+
+// readRuntimeStats reads the runtime statistics. Unlike
+// runtime.ReadMemStats, reading runtime/metrics does not stop the world.
+func readRuntimeStats() Attr {
+	samples := make([]metrics.Sample, len(heapMetrics))
+	for i, name := range heapMetrics {
+		samples[i].Name = name
+	}
+	metrics.Read(samples)
+
+	var heap uint64
+	for _, s := range samples {
+		if s.Value.Kind() == metrics.KindUint64 {
+			heap += s.Value.Uint64()
+		}
+	}
+	return slog.Group(RuntimeKey,
+		slog.Int("goroutines", runtime.NumGoroutine()),
+		slog.Uint64("heap_inuse", heap),
+	)
+}
@@ -0,0 +1,47 @@
+package l4g
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRuntimeStatsHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := NewRuntimeStatsHandler(NewSimpleHandler(HandlerOptions{Output: buf, NoColor: true}), time.Hour)
+	logger := New(Options{Output: buf, Handler: h.WithPrefix("svc")})
+
+	logger.Info("fine")
+	if strings.Contains(buf.String(), RuntimeKey) {
+		t.Errorf("info output = %q, want no runtime statistics", buf.String())
+	}
+
+	buf.Reset()
+	logger.Error("failed")
+	out := buf.String()
+	for _, want := range []string{"runtime.goroutines=", "runtime.heap_inuse="} {
+		if !strings.Contains(out, want) {
+			t.Errorf("error output = %q, want to contain %q", out, want)
+		}
+	}
+	if strings.Contains(out, "heap_inuse=0 ") {
+		t.Errorf("error output = %q, want a non-zero heap size", out)
+	}
+}
+
+func TestRuntimeStatsHandler_Cache(t *testing.T) {
+	h := NewRuntimeStatsHandler(DiscardHandler, time.Hour)
+
+	first := h.stats.get()
+	go func() {}()
+	if second := h.stats.get(); !second.Equal(first) {
+		t.Errorf("get() = %v, want cached %v", second, first)
+	}
+
+	h.stats.updated = time.Time{}
+	h.stats.get()
+	if h.stats.updated.IsZero() {
+		t.Errorf("get() did not refresh an expired snapshot")
+	}
+}
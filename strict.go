@@ -0,0 +1,372 @@
+package l4g
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// This file implements the strict encodings of a Record, which decode to
+// the record encoded: the same time (as reported by time.Time.Equal),
+// level, prefix, message, tags and attributes, with values of the same
+// kind. Records that cannot be encoded so, such as those with attributes
+// of kind slog.KindAny, are rejected by the encoders, and the decoders
+// accept only the exact bytes the encoders produce, so that decoding then
+// encoding also gives back the input. Both properties are checked by the
+// fuzz tests.
+
+// errStrict is wrapped by the errors of the strict encoders.
+var errStrict = errors.New("l4g: record not representable in strict encoding")
+
+// MarshalStrictJSON is like [Record.MarshalJSON], but fails for records
+// that [Record.UnmarshalStrictJSON] could not restore exactly: records
+// with a level outside LevelTrace through LevelFatal, attributes of kind
+// slog.KindAny, strings that are not valid UTF-8, or times that
+// RFC 3339 cannot represent.
+func (r Record) MarshalStrictJSON() ([]byte, error) {
+	if err := checkStrictRecord(r, true); err != nil {
+		return nil, err
+	}
+	return r.MarshalJSON()
+}
+
+// UnmarshalStrictJSON is like [Record.UnmarshalJSON], but accepts only
+// the encodings produced by [Record.MarshalStrictJSON], byte for byte.
+func (r *Record) UnmarshalStrictJSON(data []byte) error {
+	var rec Record
+	if err := rec.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	if b, err := rec.MarshalStrictJSON(); err != nil || !bytes.Equal(b, data) {
+		return errRecordFormat
+	}
+	*r = rec
+	return nil
+}
+
+// MarshalLogfmt encodes the record as one line of logfmt key=value pairs,
+// without a newline, that [Record.UnmarshalLogfmt] restores exactly. The
+// line holds, in order, the time (omitted if zero), the level, the prefix
+// (omitted if empty), the message, a "tags" pair per tag and the
+// attributes, with the keys of groups joined with dots:
+//
+//	time=2024-05-01T12:00:00Z level=info msg="listening" tags="net" addr.port=8080
+//
+// Strings are always quoted as by [strconv.Quote]. Other values are bare:
+// integers, unsigned integers followed by "u", floats always with a point
+// or an exponent, booleans, times in RFC 3339 and durations as by
+// [time.Duration.String].
+//
+// MarshalLogfmt fails for records with a level outside LevelTrace through
+// LevelFatal, attributes of kind slog.KindAny, times that RFC 3339 cannot
+// represent, and keys that the layout makes ambiguous: empty keys (and so
+// inline groups), keys with a space, a control character, '=', '"' or
+// '.', keys repeated within a group and, at the top level, the keys of
+// the built-in pairs.
+func (r Record) MarshalLogfmt() ([]byte, error) {
+	if err := checkStrictRecord(r, false); err != nil {
+		return nil, err
+	}
+	var b []byte
+	if !r.Time.IsZero() {
+		b = append(b, TimeKey+"="...)
+		b = r.Time.AppendFormat(b, time.RFC3339Nano)
+		b = append(b, ' ')
+	}
+	b = append(b, LevelKey+"="...)
+	b = append(b, r.Level.String()...)
+	if r.Prefix != "" {
+		b = append(b, " "+PrefixKey+"="...)
+		b = strconv.AppendQuote(b, r.Prefix)
+	}
+	b = append(b, " "+MessageKey+"="...)
+	b = strconv.AppendQuote(b, r.Message)
+	for _, tag := range r.Tags {
+		b = append(b, " "+TagsKey+"="...)
+		b = strconv.AppendQuote(b, tag)
+	}
+	var err error
+	seen := map[string]bool{TimeKey: true, LevelKey: true, PrefixKey: true, MessageKey: true, TagsKey: true}
+	r.Attrs(func(a Attr) bool {
+		b, err = appendLogfmtAttr(b, "", a, seen)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// UnmarshalLogfmt decodes a record encoded by [Record.MarshalLogfmt],
+// accepting only the encodings it produces, byte for byte.
+func (r *Record) UnmarshalLogfmt(data []byte) error {
+	pairs, err := parseLogfmtPairs(string(data))
+	if err != nil {
+		return err
+	}
+	var rec Record
+	if len(pairs) > 0 && pairs[0].key == TimeKey {
+		if rec.Time, err = time.Parse(time.RFC3339Nano, pairs[0].value); err != nil {
+			return errRecordFormat
+		}
+		pairs = pairs[1:]
+	}
+	if len(pairs) == 0 || pairs[0].key != LevelKey || rec.Level.parse(pairs[0].value) != nil {
+		return errRecordFormat
+	}
+	pairs = pairs[1:]
+	if len(pairs) > 0 && pairs[0].key == PrefixKey && pairs[0].quoted {
+		rec.Prefix = pairs[0].value
+		pairs = pairs[1:]
+	}
+	if len(pairs) == 0 || pairs[0].key != MessageKey || !pairs[0].quoted {
+		return errRecordFormat
+	}
+	rec.Message = pairs[0].value
+	pairs = pairs[1:]
+	for len(pairs) > 0 && pairs[0].key == TagsKey && pairs[0].quoted {
+		rec.Tags = append(rec.Tags, pairs[0].value)
+		pairs = pairs[1:]
+	}
+
+	attrs, err := groupPairs(pairs, logfmtValue)
+	if err != nil {
+		return err
+	}
+	rec.AddAttrs(attrs...)
+
+	if b, err := rec.MarshalLogfmt(); err != nil || !bytes.Equal(b, data) {
+		return errRecordFormat
+	}
+	*r = rec
+	return nil
+}
+
+// groupPairs returns the attributes of pairs, their values converted by
+// value, rebuilding the groups from the dotted keys: each key closes the
+// groups of the previous one that it leaves and opens those it enters.
+func groupPairs(pairs []logfmtPair, value func(logfmtPair) (slog.Value, error)) ([]Attr, error) {
+	type group struct {
+		key   string
+		attrs []Attr
+	}
+	stack := []group{{}}
+	closeGroup := func() {
+		g := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		top := &stack[len(stack)-1]
+		top.attrs = append(top.attrs, Attr{Key: g.key, Value: slog.GroupValue(g.attrs...)})
+	}
+	for _, p := range pairs {
+		path := strings.Split(p.key, ".")
+		n := 0
+		for n < len(path)-1 && n+1 < len(stack) && stack[n+1].key == path[n] {
+			n++
+		}
+		for len(stack) > n+1 {
+			closeGroup()
+		}
+		for _, key := range path[n : len(path)-1] {
+			stack = append(stack, group{key: key})
+		}
+		v, err := value(p)
+		if err != nil {
+			return nil, err
+		}
+		top := &stack[len(stack)-1]
+		top.attrs = append(top.attrs, Attr{Key: path[len(path)-1], Value: v})
+	}
+	for len(stack) > 1 {
+		closeGroup()
+	}
+	return stack[0].attrs, nil
+}
+
+// checkStrictRecord reports an error if r cannot be encoded by the strict
+// encodings. Strings must be valid UTF-8 if utf8Only is set, as for JSON.
+func checkStrictRecord(r Record, utf8Only bool) error {
+	if r.Level < LevelTrace || r.Level > LevelFatal {
+		return fmt.Errorf("%w: level %d", errStrict, int(r.Level))
+	}
+	if err := checkStrictTime(r.Time); err != nil {
+		return err
+	}
+	if utf8Only {
+		for _, s := range append([]string{r.Prefix, r.Message}, r.Tags...) {
+			if !utf8.ValidString(s) {
+				return fmt.Errorf("%w: invalid UTF-8 %q", errStrict, s)
+			}
+		}
+	}
+	var err error
+	r.Attrs(func(a Attr) bool {
+		err = checkStrictAttr(a, utf8Only)
+		return err == nil
+	})
+	return err
+}
+
+func checkStrictAttr(a Attr, utf8Only bool) error {
+	if utf8Only && !utf8.ValidString(a.Key) {
+		return fmt.Errorf("%w: invalid UTF-8 %q", errStrict, a.Key)
+	}
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		if utf8Only && !utf8.ValidString(v.String()) {
+			return fmt.Errorf("%w: invalid UTF-8 %q", errStrict, v.String())
+		}
+	case slog.KindTime:
+		return checkStrictTime(v.Time())
+	case slog.KindGroup:
+		for _, ga := range v.Group() {
+			if err := checkStrictAttr(ga, utf8Only); err != nil {
+				return err
+			}
+		}
+	case slog.KindAny:
+		return fmt.Errorf("%w: attribute %q of kind Any", errStrict, a.Key)
+	}
+	return nil
+}
+
+// checkStrictTime reports an error if RFC 3339 cannot represent t: years
+// outside 0 through 9999, and zone offsets that are not whole minutes of
+// less than a day.
+func checkStrictTime(t time.Time) error {
+	_, offset := t.Zone()
+	if y := t.Year(); y < 0 || y > 9999 || offset%60 != 0 || offset <= -24*3600 || offset >= 24*3600 {
+		return fmt.Errorf("%w: time %v", errStrict, t)
+	}
+	return nil
+}
+
+// appendLogfmtAttr appends the pairs of a, whose key is joined to those of
+// its groups in prefix, failing for keys in seen.
+func appendLogfmtAttr(b []byte, prefix string, a Attr, seen map[string]bool) ([]byte, error) {
+	if a.Key == "" || strings.ContainsAny(a.Key, "=\".") || strings.ContainsFunc(a.Key, isLogfmtSpace) {
+		return nil, fmt.Errorf("%w: key %q", errStrict, a.Key)
+	}
+	if seen[a.Key] {
+		return nil, fmt.Errorf("%w: repeated key %q", errStrict, prefix+a.Key)
+	}
+	seen[a.Key] = true
+	key := prefix + a.Key
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		seen := map[string]bool{}
+		for _, ga := range v.Group() {
+			var err error
+			if b, err = appendLogfmtAttr(b, key+".", ga, seen); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	}
+	b = append(b, ' ')
+	b = append(b, key...)
+	b = append(b, '=')
+	switch v.Kind() {
+	case slog.KindString:
+		b = strconv.AppendQuote(b, v.String())
+	case slog.KindInt64:
+		b = strconv.AppendInt(b, v.Int64(), 10)
+	case slog.KindUint64:
+		b = strconv.AppendUint(b, v.Uint64(), 10)
+		b = append(b, 'u')
+	case slog.KindFloat64:
+		n := len(b)
+		b = strconv.AppendFloat(b, v.Float64(), 'g', -1, 64)
+		if !bytes.ContainsAny(b[n:], ".eNI") {
+			b = append(b, ".0"...)
+		}
+	case slog.KindBool:
+		b = strconv.AppendBool(b, v.Bool())
+	case slog.KindDuration:
+		b = append(b, v.Duration().String()...)
+	case slog.KindTime:
+		b = v.Time().AppendFormat(b, time.RFC3339Nano)
+	}
+	return b, nil
+}
+
+// isLogfmtSpace reports whether r ends a bare logfmt key or value.
+func isLogfmtSpace(r rune) bool {
+	return r <= ' ' || r == 0x7f || r == utf8.RuneError
+}
+
+// A logfmtPair is a key=value pair of a logfmt line.
+type logfmtPair struct {
+	key, value string
+	quoted     bool
+}
+
+// parseLogfmtPairs returns the pairs of a logfmt line separated by single
+// spaces, their values bare or quoted as by strconv.Quote.
+func parseLogfmtPairs(line string) ([]logfmtPair, error) {
+	var pairs []logfmtPair
+	for line != "" {
+		if len(pairs) > 0 {
+			var ok bool
+			if line, ok = strings.CutPrefix(line, " "); !ok {
+				return nil, errRecordFormat
+			}
+		}
+		key, rest, ok := strings.Cut(line, "=")
+		if !ok || key == "" || strings.ContainsAny(key, "\"") || strings.ContainsFunc(key, isLogfmtSpace) {
+			return nil, errRecordFormat
+		}
+		p := logfmtPair{key: key}
+		if strings.HasPrefix(rest, `"`) {
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return nil, errRecordFormat
+			}
+			p.value, _ = strconv.Unquote(quoted)
+			p.quoted = true
+			line = rest[len(quoted):]
+		} else {
+			end := strings.IndexByte(rest, ' ')
+			if end < 0 {
+				end = len(rest)
+			}
+			p.value, line = rest[:end], rest[end:]
+		}
+		pairs = append(pairs, p)
+	}
+	return pairs, nil
+}
+
+// logfmtValue returns the value of p as encoded by appendLogfmtAttr.
+func logfmtValue(p logfmtPair) (slog.Value, error) {
+	s := p.value
+	if p.quoted {
+		return slog.StringValue(s), nil
+	}
+	if s == "true" || s == "false" {
+		return slog.BoolValue(s == "true"), nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return slog.Int64Value(n), nil
+	}
+	if digits, ok := strings.CutSuffix(s, "u"); ok {
+		if n, err := strconv.ParseUint(digits, 10, 64); err == nil {
+			return slog.Uint64Value(n), nil
+		}
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return slog.Float64Value(f), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return slog.TimeValue(t), nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return slog.DurationValue(d), nil
+	}
+	return slog.Value{}, errRecordFormat
+}
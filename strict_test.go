@@ -0,0 +1,234 @@
+package l4g
+
+import (
+	"errors"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func strictRecord() Record {
+	r := NewRecord(time.Date(2024, 5, 1, 12, 0, 0, 123456789, time.FixedZone("X", 3600)), LevelWarn, "disk \"low\"")
+	r.Prefix = "agent"
+	r.Tags = []string{"ops", "disk"}
+	r.AddAttrs(
+		String("s", "a b\x00"),
+		Int("i", -3),
+		Uint("u", uint64(math.MaxUint64)),
+		Float("f", 2.0),
+		Float("inf", math.Inf(-1)),
+		Float("nan", math.NaN()),
+		Bool("b", true),
+		Duration("d", 1500*time.Millisecond),
+		Time("t", time.Unix(100, 5).UTC()),
+		Group("req", Int("id", 7), Group("user", String("name", "alice"))),
+		String("after", "group"),
+	)
+	return r
+}
+
+// strictEqual reports whether a and b are the same record for the strict
+// encodings.
+func strictEqual(a, b Record) bool {
+	if !a.Time.Equal(b.Time) || a.Level != b.Level || a.Prefix != b.Prefix ||
+		a.Message != b.Message || !slices.Equal(a.Tags, b.Tags) {
+		return false
+	}
+	return slices.EqualFunc(slices.Collect(a.All()), slices.Collect(b.All()), strictAttrEqual)
+}
+
+func strictAttrEqual(a, b Attr) bool {
+	v, w := a.Value.Resolve(), b.Value.Resolve()
+	if a.Key != b.Key || v.Kind() != w.Kind() {
+		return false
+	}
+	switch v.Kind() {
+	case slog.KindFloat64:
+		f, g := v.Float64(), w.Float64()
+		return math.Float64bits(f) == math.Float64bits(g) || math.IsNaN(f) && math.IsNaN(g)
+	case slog.KindGroup:
+		return slices.EqualFunc(v.Group(), w.Group(), strictAttrEqual)
+	}
+	return v.Equal(w)
+}
+
+func TestRecord_MarshalLogfmt(t *testing.T) {
+	b, err := strictRecord().MarshalLogfmt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `time=2024-05-01T12:00:00.123456789+01:00 level=warn prefix="agent" msg="disk \"low\"" tags="ops" tags="disk"` +
+		` s="a b\x00" i=-3 u=18446744073709551615u f=2.0 inf=-Inf nan=NaN b=true d=1.5s t=1970-01-01T00:01:40.000000005Z` +
+		` req.id=7 req.user.name="alice" after="group"`
+	if string(b) != want {
+		t.Errorf("MarshalLogfmt() =\n%s\nwant\n%s", b, want)
+	}
+
+	var got Record
+	if err := got.UnmarshalLogfmt(b); err != nil {
+		t.Fatal(err)
+	}
+	if !strictEqual(got, strictRecord()) {
+		t.Errorf("UnmarshalLogfmt() = %v, want %v", got, strictRecord())
+	}
+}
+
+func TestRecord_MarshalStrictJSON(t *testing.T) {
+	b, err := strictRecord().MarshalStrictJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Record
+	if err := got.UnmarshalStrictJSON(b); err != nil {
+		t.Fatal(err)
+	}
+	if !strictEqual(got, strictRecord()) {
+		t.Errorf("UnmarshalStrictJSON() = %v, want %v", got, strictRecord())
+	}
+
+	// The lenient decoder accepts other spellings of the same record.
+	spaced := strings.Replace(string(b), `"level":"warn"`, `"level": "WARN"`, 1)
+	if err := got.UnmarshalJSON([]byte(spaced)); err != nil {
+		t.Fatal(err)
+	}
+	if err := got.UnmarshalStrictJSON([]byte(spaced)); err == nil {
+		t.Error("UnmarshalStrictJSON() accepted a non-canonical encoding")
+	}
+}
+
+func TestRecord_MarshalStrict_Rejects(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		logfmt bool // whether MarshalLogfmt accepts the record
+		json   bool // whether MarshalStrictJSON accepts the record
+		attrs  []Attr
+		level  Level
+		prefix string
+	}{
+		{name: "any", attrs: []Attr{Any("a", []int{1})}},
+		{name: "level", level: LevelFatal + 1},
+		{name: "year", attrs: []Attr{Time("t", time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC))}},
+		{name: "offset", attrs: []Attr{Time("t", at.In(time.FixedZone("", 30)))}},
+		{name: "invalid UTF-8", prefix: "\xff", logfmt: true},
+		{name: "empty key", attrs: []Attr{String("", "v")}, json: true},
+		{name: "dotted key", attrs: []Attr{String("a.b", "v")}, json: true},
+		{name: "spaced key", attrs: []Attr{String("a b", "v")}, json: true},
+		{name: "reserved key", attrs: []Attr{String(MessageKey, "v")}, json: true},
+		{name: "repeated key", attrs: []Attr{Group("g", Int("a", 1)), Group("g", Int("b", 2))}, json: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level := tt.level
+			if level == 0 {
+				level = LevelInfo
+			}
+			r := NewRecord(at, level, "m")
+			r.Prefix = tt.prefix
+			r.AddAttrs(tt.attrs...)
+			if _, err := r.MarshalLogfmt(); (err == nil) != tt.logfmt || err != nil && !errors.Is(err, errStrict) {
+				t.Errorf("MarshalLogfmt() error = %v", err)
+			}
+			if _, err := r.MarshalStrictJSON(); (err == nil) != tt.json || err != nil && !errors.Is(err, errStrict) {
+				t.Errorf("MarshalStrictJSON() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestRecord_UnmarshalLogfmt_Rejects(t *testing.T) {
+	for _, line := range []string{
+		``,
+		`msg="m"`,
+		`level=info`,
+		`level=info msg=m`,
+		`level=INFO msg="m"`,
+		`level=info  msg="m"`,
+		`level=info msg="m" `,
+		`level=info prefix="" msg="m"`,
+		`level=info msg="m" a=`,
+		`level=info msg="m" a=1.50`,
+		`level=info msg="m" a=+1`,
+		`level=info msg="m" a=x`,
+		`level=info msg="m" g.a=1 b=2 g.c=3`,
+		`level=info msg="m" g=1 g.a=2`,
+		`level=info msg="m" a..b=1`,
+		`time=0001-01-01T00:00:00Z level=info msg="m"`,
+	} {
+		var r Record
+		if err := r.UnmarshalLogfmt([]byte(line)); err == nil {
+			t.Errorf("UnmarshalLogfmt(%q) = %v, want error", line, r)
+		}
+	}
+}
+
+func FuzzRecord_Logfmt(f *testing.F) {
+	f.Add("agent", "msg", "key", "value", int64(-1), uint64(1), 1.5, int64(time.Second), int64(1e18), true)
+	f.Add("", "", "a", "\"\xff", int64(0), uint64(0), math.Inf(1), int64(math.MinInt64), int64(-1e15), false)
+	f.Fuzz(func(t *testing.T, prefix, msg, key, s string, i int64, u uint64, fl float64, d, ns int64, b bool) {
+		r := NewRecord(time.Unix(0, ns).UTC(), LevelInfo, msg)
+		r.Prefix = prefix
+		r.Tags = []string{s}
+		r.AddAttrs(String(key, s), Group("g", Int64("i", i), Uint("u", u), Float("f", fl)),
+			Bool("b", b), Duration("d", time.Duration(d)), Time("t", time.Unix(0, ns).In(time.FixedZone("", 3600))))
+		data, err := r.MarshalLogfmt()
+		if err != nil {
+			if !errors.Is(err, errStrict) {
+				t.Fatalf("MarshalLogfmt() error = %v", err)
+			}
+			return
+		}
+		var got Record
+		if err := got.UnmarshalLogfmt(data); err != nil {
+			t.Fatalf("UnmarshalLogfmt(%q) error = %v", data, err)
+		}
+		if !strictEqual(got, r) {
+			t.Fatalf("UnmarshalLogfmt(%q) = %v, want %v", data, got, r)
+		}
+	})
+}
+
+func FuzzRecord_UnmarshalLogfmt(f *testing.F) {
+	data, _ := strictRecord().MarshalLogfmt()
+	f.Add(data)
+	f.Add([]byte(`level=info msg="m" a.b=1 a.c.d=2.0 e=1s`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var r Record
+		if err := r.UnmarshalLogfmt(data); err != nil {
+			return
+		}
+		got, err := r.MarshalLogfmt()
+		if err != nil || string(got) != string(data) {
+			t.Fatalf("MarshalLogfmt() = %q, %v, want %q", got, err, data)
+		}
+	})
+}
+
+func FuzzRecord_StrictJSON(f *testing.F) {
+	f.Add("agent", "msg", "key", "value", int64(-1), uint64(1), 1.5, int64(time.Second), int64(1e18), true)
+	f.Add("", "", "", "< >", int64(0), uint64(0), math.NaN(), int64(math.MinInt64), int64(-1e15), false)
+	f.Fuzz(func(t *testing.T, prefix, msg, key, s string, i int64, u uint64, fl float64, d, ns int64, b bool) {
+		r := NewRecord(time.Unix(0, ns), LevelError, msg)
+		r.Prefix = prefix
+		r.Tags = []string{s}
+		r.AddAttrs(String(key, s), Group(key, Int64("i", i), Uint("u", u), Float("f", fl)),
+			Bool("b", b), Duration("d", time.Duration(d)), Time("t", time.Unix(0, ns).UTC()))
+		data, err := r.MarshalStrictJSON()
+		if err != nil {
+			if !errors.Is(err, errStrict) {
+				t.Fatalf("MarshalStrictJSON() error = %v", err)
+			}
+			return
+		}
+		var got Record
+		if err := got.UnmarshalStrictJSON(data); err != nil {
+			t.Fatalf("UnmarshalStrictJSON(%s) error = %v", data, err)
+		}
+		if !strictEqual(got, r) {
+			t.Fatalf("UnmarshalStrictJSON(%s) = %v, want %v", data, got, r)
+		}
+	})
+}
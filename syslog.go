@@ -0,0 +1,226 @@
+package l4g
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// A SyslogFormat selects the message format of a [SyslogHandler].
+type SyslogFormat int
+
+const (
+	// SyslogRFC5424 is the format of RFC 5424. This is the default.
+	SyslogRFC5424 SyslogFormat = iota
+	// SyslogRFC3164 is the legacy BSD format of RFC 3164, with a timestamp
+	// without year and a "tag[pid]:" header, for appliances and routers
+	// that cannot parse RFC 5424.
+	SyslogRFC3164
+)
+
+// SyslogOptions are options for a [SyslogHandler].
+type SyslogOptions struct {
+	// Format is the message format (Default: SyslogRFC5424).
+	Format SyslogFormat
+
+	// Facility is the syslog facility code (Default: 1, user-level).
+	Facility int
+
+	// Tag identifies the program, as APP-NAME in RFC 5424 and as TAG in
+	// RFC 3164 (Default: the base name of the executable).
+	Tag string
+
+	// Hostname is the host name written in the header
+	// (Default: the result of os.Hostname).
+	Hostname string
+
+	// Levels maps the levels to syslog severities (Default: SyslogLevels()).
+	Levels LevelMap
+
+	// HandlerOptions configure the message body, which is formatted as by
+	// a [SimpleHandler] without time and level, and without colors.
+	// Output is the destination of the messages, such as a connection to
+	// a syslog daemon; each record is written with a single Write.
+	HandlerOptions
+}
+
+// SyslogHandler is a Handler that writes records as syslog messages. The
+// level is mapped to the syslog severity and the message body holds the
+// prefix, the message and the attributes.
+type SyslogHandler struct {
+	inner *SimpleHandler
+	s     *syslogState
+}
+
+// syslogState holds the state shared by the handlers derived from one another.
+type syslogState struct {
+	opts   SyslogOptions
+	pid    string
+	mu     sync.Mutex
+	output io.Writer
+}
+
+var _ Handler = (*SyslogHandler)(nil)
+
+// NewSyslogHandler returns a [SyslogHandler] writing to opts.Output.
+func NewSyslogHandler(opts SyslogOptions) *SyslogHandler {
+	if opts.Facility == 0 {
+		opts.Facility = 1
+	}
+	if opts.Tag == "" {
+		opts.Tag = filepath.Base(os.Args[0])
+	}
+	if opts.Hostname == "" {
+		opts.Hostname, _ = os.Hostname()
+	}
+	if opts.Levels == nil {
+		opts.Levels = syslogLevels
+	}
+	hopts := opts.HandlerOptions
+	hopts.NoColor = true
+	return &SyslogHandler{
+		inner: NewSimpleHandler(hopts).(*SimpleHandler),
+		s: &syslogState{
+			opts:   opts,
+			pid:    strconv.Itoa(os.Getpid()),
+			output: opts.Output,
+		},
+	}
+}
+
+// syslogSockets are the sockets of the local syslog daemon on Linux, macOS
+// and the BSDs, in probing order.
+var syslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// DialLocalSyslog connects to the socket of the local syslog daemon,
+// probing the paths used on Linux (/dev/log), macOS (/var/run/syslog) and
+// the BSDs (/var/run/log).
+func DialLocalSyslog() (net.Conn, error) {
+	for _, path := range syslogSockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			if conn, err := net.Dial(network, path); err == nil {
+				return conn, nil
+			}
+		}
+	}
+	return nil, errors.New("l4g: no local syslog socket found")
+}
+
+// LocalSyslog returns a [SyslogHandler] sending records to the local
+// syslog daemon, found with [DialLocalSyslog], in the RFC 3164 format
+// understood by every daemon. An empty tag defaults to the base name of
+// the executable. Close the handler to release the connection.
+func LocalSyslog(tag string) (*SyslogHandler, error) {
+	conn, err := DialLocalSyslog()
+	if err != nil {
+		return nil, err
+	}
+	return NewSyslogHandler(SyslogOptions{
+		Format:         SyslogRFC3164,
+		Tag:            tag,
+		HandlerOptions: HandlerOptions{Output: conn},
+	}), nil
+}
+
+// Close closes the output of the handler if it implements io.Closer.
+func (h *SyslogHandler) Close() error {
+	if c, ok := h.s.output.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *SyslogHandler) Enabled(level Level) bool {
+	return h.inner.Enabled(level)
+}
+
+// Handle writes r as one syslog message followed by a newline.
+func (h *SyslogHandler) Handle(rr Record) error {
+	r := h.inner.prepare(rr)
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	buf := newBuffer()
+	defer buf.Free()
+
+	opts := &h.s.opts
+	buf.WriteByte('<')
+	*buf = strconv.AppendInt(*buf, int64(opts.Facility*8+opts.Levels.Code(r.Level)), 10)
+	buf.WriteByte('>')
+	if opts.Format == SyslogRFC3164 {
+		*buf = t.AppendFormat(*buf, time.Stamp)
+		buf.WriteByte(' ')
+		buf.WriteString(syslogHeaderField(opts.Hostname))
+		buf.WriteByte(' ')
+		buf.WriteString(opts.Tag)
+		buf.WriteByte('[')
+		buf.WriteString(h.s.pid)
+		buf.WriteString("]: ")
+	} else {
+		buf.WriteString("1 ")
+		*buf = t.AppendFormat(*buf, "2006-01-02T15:04:05.000000Z07:00")
+		for _, field := range []string{opts.Hostname, opts.Tag, h.s.pid, "-", "-"} {
+			buf.WriteByte(' ')
+			buf.WriteString(syslogHeaderField(field))
+		}
+		buf.WriteByte(' ')
+	}
+
+	h.inner.appendBody(buf, r)
+	if n := len(*buf); (*buf)[n-1] == ' ' {
+		(*buf)[n-1] = '\n'
+	} else {
+		buf.WriteByte('\n')
+	}
+
+	h.s.mu.Lock()
+	defer h.s.mu.Unlock()
+	_, err := h.s.output.Write(*buf)
+	return err
+}
+
+// WithAttrs returns a new Handler whose attributes consist of
+// both the receiver's attributes and the arguments.
+func (h *SyslogHandler) WithAttrs(attrs []Attr) Handler {
+	return &SyslogHandler{h.inner.WithAttrs(attrs).(*SimpleHandler), h.s}
+}
+
+// WithGroup returns a new Handler with the given group appended to
+// the receiver's existing groups.
+func (h *SyslogHandler) WithGroup(name string) Handler {
+	return &SyslogHandler{h.inner.WithGroup(name).(*SimpleHandler), h.s}
+}
+
+// WithPrefix returns a new Handler with the given prefix prepended to
+// the receiver's existing prefix.
+func (h *SyslogHandler) WithPrefix(prefix string) Handler {
+	return &SyslogHandler{h.inner.WithPrefix(prefix).(*SimpleHandler), h.s}
+}
+
+// syslogHeaderField returns s as a header field, which must be printable
+// ASCII without spaces; an empty field is written as "-".
+func syslogHeaderField(s string) string {
+	if s == "" {
+		return "-"
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] <= ' ' || s[i] > '~' {
+			b := []byte(s)
+			for j := i; j < len(b); j++ {
+				if b[j] <= ' ' || b[j] > '~' {
+					b[j] = '_'
+				}
+			}
+			return string(b)
+		}
+	}
+	return s
+}
@@ -0,0 +1,97 @@
+package l4g
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogHandler(t *testing.T) {
+	at := time.Date(2024, 3, 5, 7, 8, 9, 120000000, time.UTC)
+	pid := strconv.Itoa(os.Getpid())
+
+	tests := []struct {
+		name   string
+		format SyslogFormat
+		want   string
+	}{
+		{
+			name:   "rfc5424",
+			format: SyslogRFC5424,
+			want:   "<11>1 2024-03-05T07:08:09.120000Z web-1 billing " + pid + " - - [api] charge failed id=7\n",
+		},
+		{
+			name:   "rfc3164",
+			format: SyslogRFC3164,
+			want:   "<11>Mar  5 07:08:09 web-1 billing[" + pid + "]: [api] charge failed id=7\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			h := NewSyslogHandler(SyslogOptions{
+				Format:         tt.format,
+				Tag:            "billing",
+				Hostname:       "web-1",
+				HandlerOptions: HandlerOptions{Output: buf},
+			})
+
+			r := NewRecord(at, LevelError, "charge failed")
+			r.AddAttrs(Int("id", 7))
+			if err := h.WithPrefix("api").Handle(r); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("output = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestSyslogHandler_Facility(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := NewSyslogHandler(SyslogOptions{Facility: 16, HandlerOptions: HandlerOptions{Output: buf}})
+	h.Handle(NewRecord(time.Now(), LevelInfo, "m"))
+	if got := buf.String()[:6]; got != "<134>1" {
+		t.Errorf("header = %q, want local0.info priority", got)
+	}
+}
+
+func TestSyslogHandler_Levels(t *testing.T) {
+	buf := &bytes.Buffer{}
+	levels := SyslogLevels()
+	levels.Set(LevelWarn, 5, "notice")
+	h := NewSyslogHandler(SyslogOptions{Levels: levels, HandlerOptions: HandlerOptions{Output: buf}})
+	h.Handle(NewRecord(time.Now(), LevelWarn, "m"))
+	if got := buf.String()[:5]; got != "<13>1" {
+		t.Errorf("header = %q, want user.notice priority", got)
+	}
+}
+
+func TestLocalSyslog(t *testing.T) {
+	conn, path := listenJournal(t)
+	defer func(s []string) { syslogSockets = s }(syslogSockets)
+	syslogSockets = []string{filepath.Join(t.TempDir(), "missing"), path}
+
+	h, err := LocalSyslog("app")
+	if err != nil {
+		t.Fatalf("LocalSyslog() error = %v", err)
+	}
+	defer h.Close()
+
+	New(Options{Output: h.s.output, Handler: h}).Warn("disk full")
+	got := readDatagram(t, conn)
+	if !strings.HasPrefix(got, "<12>") || !strings.HasSuffix(got, " app["+strconv.Itoa(os.Getpid())+"]: disk full\n") {
+		t.Errorf("message = %q", got)
+	}
+
+	syslogSockets = []string{filepath.Join(t.TempDir(), "missing")}
+	if _, err := LocalSyslog(""); err == nil {
+		t.Errorf("LocalSyslog() without a socket error = nil")
+	}
+}
@@ -0,0 +1,225 @@
+package l4g
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Keys of the attributes added by a [Tenancy].
+const (
+	// TenantKey names the tenant of the records of the loggers returned
+	// by [Tenancy.Logger]. The associated value is a string.
+	TenantKey = "tenant"
+	// DroppedBytesKey is the approximate size of the records summarized
+	// by a quota summary, besides their number under [DroppedKey]. The
+	// associated value is an int.
+	DroppedBytesKey = "dropped_bytes"
+)
+
+// A Quota bounds the records a tenant may log in each interval of a
+// [Tenancy]. A zero field sets no bound.
+type Quota struct {
+	Records int // most records
+	Bytes   int // most bytes of messages and of the keys and values passed with them
+}
+
+// TenancyOptions are options for a [Tenancy].
+type TenancyOptions struct {
+	// Interval is the length of the windows the quotas apply to
+	// (Default: 1m).
+	Interval time.Duration
+
+	// Quota is the quota of the tenants missing from Quotas.
+	Quota Quota
+
+	// Quotas maps tenants to their own quotas.
+	Quotas map[string]Quota
+
+	// Clock times the windows (Default: the system clock).
+	Clock Clock
+}
+
+// A Tenancy hands out loggers for the tenants of a multi-tenant service,
+// each adding a [TenantKey] attribute to its records and dropping those
+// past the quota of its tenant, so that one noisy tenant cannot drown the
+// logs of the others. The records dropped are summarized, once the window
+// in which they were dropped ends, by one record at LevelWarn holding
+// [DroppedKey] and [DroppedBytesKey] attributes.
+//
+// A Tenancy keeps the state of every tenant it has seen. It is safe for
+// concurrent use by multiple goroutines.
+type Tenancy struct {
+	base    *Logger
+	opts    TenancyOptions
+	mu      sync.Mutex
+	tenants map[string]*tenantLogger
+}
+
+// tenantLogger is the logger of a tenant and the state of its quota.
+type tenantLogger struct {
+	l *Logger
+	q *tenantQuota
+}
+
+// tenantQuota is the usage of a tenant in the current window.
+type tenantQuota struct {
+	quota Quota
+	opts  *TenancyOptions
+
+	mu           sync.Mutex
+	start        time.Time // start of the current window
+	records      int
+	bytes        int
+	dropped      int         // records dropped since the last summary
+	droppedBytes int         // their size
+	h            Handler     // handler of the last record dropped
+	stop         func() bool // cancels the summary, nil if none is due
+}
+
+// NewTenancy returns a Tenancy deriving the loggers of the tenants from
+// l. If l is nil, the default logger is used.
+func NewTenancy(l *Logger, opts TenancyOptions) *Tenancy {
+	if l == nil {
+		l = Default()
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Minute
+	}
+	opts.Clock = clockOrSystem(opts.Clock)
+	return &Tenancy{base: l, opts: opts, tenants: make(map[string]*tenantLogger)}
+}
+
+// Logger returns the logger of tenant. The loggers derived from it share
+// its quota.
+func (t *Tenancy) Logger(tenant string) *Logger {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if tl, ok := t.tenants[tenant]; ok {
+		return tl.l
+	}
+	quota, ok := t.opts.Quotas[tenant]
+	if !ok {
+		quota = t.opts.Quota
+	}
+	q := &tenantQuota{quota: quota, opts: &t.opts}
+	l := t.base.WithAttrs(String(TenantKey, tenant)).with(func(h Handler) Handler {
+		return &quotaHandler{h, q}
+	})
+	t.tenants[tenant] = &tenantLogger{l, q}
+	return l
+}
+
+// Flush logs the summaries of the records dropped so far.
+func (t *Tenancy) Flush() error {
+	t.mu.Lock()
+	quotas := make([]*tenantQuota, 0, len(t.tenants))
+	for _, tl := range t.tenants {
+		quotas = append(quotas, tl.q)
+	}
+	t.mu.Unlock()
+
+	var errs []error
+	for _, q := range quotas {
+		errs = append(errs, q.summarize())
+	}
+	return errors.Join(errs...)
+}
+
+// allow reports whether a record of size bytes handled by h is within the
+// quota, counting it if so and as dropped otherwise.
+func (q *tenantQuota) allow(h Handler, size int) bool {
+	clock := q.opts.Clock
+	now := clock.Now()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if now.Sub(q.start) >= q.opts.Interval {
+		q.start, q.records, q.bytes = now, 0, 0
+	}
+	if (q.quota.Records <= 0 || q.records < q.quota.Records) &&
+		(q.quota.Bytes <= 0 || q.bytes+size <= q.quota.Bytes) {
+		q.records++
+		q.bytes += size
+		return true
+	}
+	q.dropped++
+	q.droppedBytes += size
+	q.h = h
+	if q.stop == nil {
+		q.stop = clock.AfterFunc(q.start.Add(q.opts.Interval).Sub(now), func() {
+			runLabeled("tenancy", func() { q.summarize() })
+		})
+	}
+	return false
+}
+
+// summarize logs the summary of the records dropped since the last one,
+// if any.
+func (q *tenantQuota) summarize() error {
+	q.mu.Lock()
+	if q.stop != nil {
+		q.stop()
+		q.stop = nil
+	}
+	dropped, droppedBytes, h := q.dropped, q.droppedBytes, q.h
+	q.dropped, q.droppedBytes, q.h = 0, 0, nil
+	q.mu.Unlock()
+	if dropped == 0 {
+		return nil
+	}
+	r := NewRecord(q.opts.Clock.Now(), LevelWarn, droppedMessage)
+	r.AddAttrs(Int(DroppedKey, dropped), Int(DroppedBytesKey, droppedBytes))
+	return h.Handle(r)
+}
+
+// recordSize returns the approximate size of r counted by quotas: the
+// length of its message and of the keys and values of its attributes.
+func recordSize(r Record) int {
+	n := len(r.Message)
+	r.Attrs(func(a Attr) bool {
+		n += len(a.Key) + len(a.Value.String())
+		return true
+	})
+	return n
+}
+
+// quotaHandler is the handler of the loggers of a tenant, dropping the
+// records past its quota.
+type quotaHandler struct {
+	handler Handler
+	q       *tenantQuota
+}
+
+func (h *quotaHandler) Enabled(level Level) bool {
+	return h.handler.Enabled(level)
+}
+
+func (h *quotaHandler) Handle(r Record) error {
+	if !h.q.allow(h.handler, recordSize(r)) {
+		return nil
+	}
+	return h.handler.Handle(r)
+}
+
+func (h *quotaHandler) WithAttrs(attrs []Attr) Handler {
+	return &quotaHandler{h.handler.WithAttrs(attrs), h.q}
+}
+
+func (h *quotaHandler) WithGroup(name string) Handler {
+	return &quotaHandler{h.handler.WithGroup(name), h.q}
+}
+
+func (h *quotaHandler) WithPrefix(prefix string) Handler {
+	return &quotaHandler{h.handler.WithPrefix(prefix), h.q}
+}
+
+// Flush logs the summary of the records dropped so far, then flushes the
+// wrapped handler.
+func (h *quotaHandler) Flush() error {
+	return errors.Join(h.q.summarize(), flush(h.handler))
+}
+
+// Compact compacts the wrapped handler.
+func (h *quotaHandler) Compact() {
+	compact(h.handler)
+}
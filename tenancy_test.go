@@ -0,0 +1,79 @@
+package l4g
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTenancy(t *testing.T) {
+	clock := newFakeClock()
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true})
+	tenancy := NewTenancy(logger, TenancyOptions{
+		Interval: time.Minute,
+		Quota:    Quota{Records: 2},
+		Quotas:   map[string]Quota{"acme": {Bytes: 1000}},
+		Clock:    clock,
+	})
+
+	noisy := tenancy.Logger("noisy")
+	if tenancy.Logger("noisy") != noisy {
+		t.Errorf("Logger() returned a new logger for the same tenant")
+	}
+	for range 5 {
+		noisy.WithAttrs("k", "v").Info("spam")
+	}
+	tenancy.Logger("acme").Info("order placed")
+	tenancy.Logger("acme").Info("order shipped")
+	tenancy.Logger("acme").Info("order paid")
+
+	out := buf.String()
+	if got := strings.Count(out, "INFO spam tenant=noisy k=v\n"); got != 2 {
+		t.Errorf("output = %q, want 2 records of the noisy tenant", out)
+	}
+	if got := strings.Count(out, "tenant=acme\n"); got != 3 {
+		t.Errorf("output = %q, want all the records of acme", out)
+	}
+	if strings.Contains(out, "dropped") {
+		t.Errorf("output = %q, want no summary before the window ends", out)
+	}
+
+	clock.Advance(time.Minute)
+	if !strings.Contains(buf.String(), "WARN dropped records tenant=noisy k=v dropped=3 dropped_bytes=12\n") {
+		t.Errorf("output = %q, want the summary of the dropped records", buf.String())
+	}
+
+	buf.Reset()
+	noisy.Info("again")
+	if out := buf.String(); !strings.HasSuffix(out, " INFO again tenant=noisy\n") || strings.Count(out, "\n") != 1 {
+		t.Errorf("output = %q, want the quota renewed", out)
+	}
+}
+
+func TestTenancy_Bytes(t *testing.T) {
+	clock := newFakeClock()
+	buf := &bytes.Buffer{}
+	tenancy := NewTenancy(New(Options{Output: buf, NoColor: true}), TenancyOptions{Quota: Quota{Bytes: 10}, Clock: clock})
+
+	l := tenancy.Logger("t1")
+	l.Info("12345")
+	l.Info("1234567890")
+	l.Info("12345")
+	if err := tenancy.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if strings.Count(out, "INFO 12345 tenant=t1\n") != 2 || strings.Contains(out, "1234567890 ") {
+		t.Errorf("output = %q, want the records within 10 bytes", out)
+	}
+	if !strings.Contains(out, "dropped=1 dropped_bytes=10\n") {
+		t.Errorf("output = %q, want the summary on Flush", out)
+	}
+	clock.Advance(time.Hour)
+	if strings.Count(buf.String(), "dropped=") != 1 {
+		t.Errorf("output = %q, want a single summary", buf.String())
+	}
+}
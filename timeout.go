@@ -0,0 +1,106 @@
+package l4g
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrWriteTimeout is returned by the writers of [TimeoutWriter] when a
+// write does not complete in time.
+var ErrWriteTimeout = errors.New("l4g: write timed out")
+
+// deadlineWriter is implemented by writers supporting write deadlines,
+// such as net.Conn and *os.File for pipes.
+type deadlineWriter interface {
+	io.Writer
+	SetWriteDeadline(t time.Time) error
+}
+
+// timeoutWriter is the io.Writer returned by TimeoutWriter.
+type timeoutWriter struct {
+	w io.Writer
+	d time.Duration
+
+	mu      sync.Mutex
+	pending chan writeResult // result of a write that timed out, nil if none
+}
+
+// writeResult is the result of a Write call made by a watchdog goroutine.
+type writeResult struct {
+	n   int
+	err error
+}
+
+// TimeoutWriter returns an io.Writer writing to w that fails with
+// ErrWriteTimeout rather than blocking for longer than d, so that a
+// stalled destination cannot block the handlers indefinitely.
+//
+// Writers with a SetWriteDeadline method, such as net.Conn, are given a
+// deadline. Writes to other writers are made by a watchdog goroutine,
+// with a copy of the data: after a timeout that goroutine is left
+// blocked, and further writes fail immediately until it returns.
+// If w is an *OutputVar, its current writer is used for every write.
+//
+// If d is not positive, w is returned unchanged.
+func TimeoutWriter(w io.Writer, d time.Duration) io.Writer {
+	if d <= 0 {
+		return w
+	}
+	return &timeoutWriter{w: w, d: d}
+}
+
+// Write writes p to the underlying writer within the timeout.
+func (t *timeoutWriter) Write(p []byte) (int, error) {
+	w := t.w
+	if v, ok := w.(*OutputVar); ok {
+		w = v.Output()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.pending != nil {
+		select {
+		case <-t.pending:
+			t.pending = nil
+		default:
+			return 0, ErrWriteTimeout
+		}
+	}
+
+	if dw, ok := w.(deadlineWriter); ok {
+		if err := dw.SetWriteDeadline(time.Now().Add(t.d)); err == nil {
+			n, err := dw.Write(p)
+			if isTimeout(err) {
+				err = errors.Join(ErrWriteTimeout, err)
+			}
+			return n, err
+		}
+		// deadlines are not supported, as for regular files: fall back
+		// to the watchdog
+	}
+
+	done := make(chan writeResult, 1)
+	data := bytes.Clone(p) // p may be reused once Write returns
+	go func() {
+		n, err := w.Write(data)
+		done <- writeResult{n, err}
+	}()
+	timer := time.NewTimer(t.d)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.n, r.err
+	case <-timer.C:
+		t.pending = done
+		return 0, ErrWriteTimeout
+	}
+}
+
+// isTimeout reports whether err is a timeout, as reported by net.Error.
+func isTimeout(err error) bool {
+	var te interface{ Timeout() bool }
+	return errors.As(err, &te) && te.Timeout()
+}
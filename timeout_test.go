@@ -0,0 +1,107 @@
+package l4g
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// blockingWriter blocks its writes until release is closed.
+type blockingWriter struct {
+	release chan struct{}
+	buf     bytes.Buffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return w.buf.Write(p)
+}
+
+func TestTimeoutWriterWatchdog(t *testing.T) {
+	bw := &blockingWriter{release: make(chan struct{})}
+	w := TimeoutWriter(bw, 20*time.Millisecond)
+
+	p := []byte("first\n")
+	if _, err := w.Write(p); !errors.Is(err, ErrWriteTimeout) {
+		t.Fatalf("Write = %v, want ErrWriteTimeout", err)
+	}
+	copy(p, "XXXXX\n") // the pending write must not see this
+	if _, err := w.Write([]byte("second\n")); !errors.Is(err, ErrWriteTimeout) {
+		t.Errorf("Write while stalled = %v, want ErrWriteTimeout", err)
+	}
+
+	close(bw.release)
+	var err error
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		// fails until the stalled write returns
+		if _, err = w.Write([]byte("third\n")); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		t.Errorf("Write after recovery = %v", err)
+	}
+	if got := bw.buf.String(); got != "first\nthird\n" {
+		t.Errorf("written %q, want %q", got, "first\nthird\n")
+	}
+}
+
+func TestTimeoutWriterDeadline(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+
+	w := TimeoutWriter(c1, 20*time.Millisecond)
+	start := time.Now()
+	_, err := w.Write([]byte("nobody reads\n"))
+	if !errors.Is(err, ErrWriteTimeout) {
+		t.Fatalf("Write = %v, want ErrWriteTimeout", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Write blocked for %v", d)
+	}
+
+	go func() {
+		buf := make([]byte, 64)
+		c2.Read(buf)
+	}()
+	if _, err := w.Write([]byte("read\n")); err != nil {
+		t.Errorf("Write with a reader = %v", err)
+	}
+}
+
+func TestTimeoutWriterDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	if w := TimeoutWriter(&buf, 0); w != &buf {
+		t.Errorf("TimeoutWriter(w, 0) = %T, want w", w)
+	}
+}
+
+func TestOptionsWriteTimeout(t *testing.T) {
+	bw := &blockingWriter{release: make(chan struct{})}
+	defer close(bw.release)
+
+	errs := tempFile(t)
+	saved := os.Stderr
+	os.Stderr = errs
+	defer func() { os.Stderr = saved }()
+
+	l := New(Options{Output: bw, WriteTimeout: 20 * time.Millisecond, NoColor: true})
+	done := make(chan struct{})
+	go func() {
+		l.Info("stalled")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Info blocked on a stalled output")
+	}
+	if got := readFile(t, errs); !strings.Contains(got, ErrWriteTimeout.Error()) {
+		t.Errorf("fallback output = %q, want the timeout reported", got)
+	}
+}
@@ -0,0 +1,86 @@
+package l4g
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Keys of the attributes returned by [ParseTraceparent].
+const (
+	TraceIDKey     = "trace_id"
+	ParentIDKey    = "parent_id"
+	SampledFlagKey = "trace_sampled"
+)
+
+// TraceparentHeader is the W3C Trace Context header carrying the trace
+// of an incoming request.
+const TraceparentHeader = "traceparent"
+
+// errTraceparent is returned for malformed traceparent values.
+var errTraceparent = errors.New("l4g: invalid traceparent")
+
+// ParseTraceparent parses the value of a W3C traceparent header, such as
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", into
+// [TraceIDKey], [ParentIDKey] and [SampledFlagKey] attributes, so that
+// services not using OpenTelemetry still get correlated logs.
+func ParseTraceparent(header string) ([]Attr, error) {
+	header = strings.TrimSpace(header)
+	// version-traceid-parentid-flags, where future versions may append
+	// fields after the flags
+	if len(header) < 55 || len(header) > 55 && (header[:2] == "00" || header[55] != '-') {
+		return nil, errTraceparent
+	}
+	version, traceID, parentID, flags := header[0:2], header[3:35], header[36:52], header[53:55]
+	if header[2] != '-' || header[35] != '-' || header[52] != '-' ||
+		!isLowerHex(version) || version == "ff" ||
+		!isLowerHex(traceID) || isZeros(traceID) ||
+		!isLowerHex(parentID) || isZeros(parentID) ||
+		!isLowerHex(flags) {
+		return nil, errTraceparent
+	}
+	return []Attr{
+		String(TraceIDKey, traceID),
+		String(ParentIDKey, parentID),
+		Bool(SampledFlagKey, unhex(flags[1])&1 == 1),
+	}, nil
+}
+
+// ContextWithTraceparent returns a copy of ctx carrying the attributes
+// parsed from header by [ParseTraceparent], which loggers derived with
+// [Logger.WithContext] add to their records. If header is invalid, ctx is
+// returned unchanged along with the error.
+func ContextWithTraceparent(ctx context.Context, header string) (context.Context, error) {
+	attrs, err := ParseTraceparent(header)
+	if err != nil {
+		return ctx, err
+	}
+	args := make([]any, len(attrs))
+	for i, a := range attrs {
+		args[i] = a
+	}
+	return ContextWithAttrs(ctx, args...), nil
+}
+
+// isLowerHex reports whether s consists of lowercase hex digits only.
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// isZeros reports whether s consists of '0' characters only.
+func isZeros(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
+
+// unhex returns the value of the lowercase hex digit c.
+func unhex(c byte) byte {
+	if c >= 'a' {
+		return c - 'a' + 10
+	}
+	return c - '0'
+}
@@ -0,0 +1,63 @@
+package l4g
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	attrs, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil {
+		t.Fatalf("ParseTraceparent() error = %v", err)
+	}
+	want := []Attr{
+		String(TraceIDKey, "4bf92f3577b34da6a3ce929d0e0e4736"),
+		String(ParentIDKey, "00f067aa0ba902b7"),
+		Bool(SampledFlagKey, true),
+	}
+	for i := range want {
+		if !attrs[i].Equal(want[i]) {
+			t.Errorf("attr %d = %v, want %v", i, attrs[i], want[i])
+		}
+	}
+
+	if _, err := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future"); err != nil {
+		t.Errorf("ParseTraceparent() of a future version error = %v", err)
+	}
+
+	invalid := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00_4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	}
+	for _, h := range invalid {
+		if _, err := ParseTraceparent(h); err == nil {
+			t.Errorf("ParseTraceparent(%q) error = nil, want an error", h)
+		}
+	}
+}
+
+func TestContextWithTraceparent(t *testing.T) {
+	ctx, err := ContextWithTraceparent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	if err != nil {
+		t.Fatalf("ContextWithTraceparent() error = %v", err)
+	}
+	ctx = ContextWithAttrs(ctx, "tenant", "acme")
+
+	buf := &bytes.Buffer{}
+	New(Options{Output: buf, NoColor: true}).WithContext(ctx).Info("m")
+	want := "m trace_id=4bf92f3577b34da6a3ce929d0e0e4736 parent_id=00f067aa0ba902b7 trace_sampled=false tenant=acme\n"
+	if !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("output = %q, want suffix %q", buf.String(), want)
+	}
+
+	if got, err := ContextWithTraceparent(ctx, "bogus"); err == nil || got != ctx {
+		t.Errorf("ContextWithTraceparent() with an invalid header should return ctx and an error")
+	}
+}
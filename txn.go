@@ -0,0 +1,148 @@
+package l4g
+
+import (
+	"sync"
+	"time"
+)
+
+// A Txn groups the records of a unit of work, such as a multi-step
+// operation that usually succeeds, so that its log shows a single summary
+// record unless something went wrong. It is a Logger whose records are
+// held until Commit or Rollback:
+//
+//	txn := logger.Begin("checkout")
+//	txn.Info("cart loaded", "items", n)
+//	txn.Info("payment authorized")
+//	if err != nil {
+//		txn.Error("shipping failed", l4g.Err(err))
+//		txn.Rollback()
+//		return
+//	}
+//	txn.Commit()
+//
+// Loggers derived from a Txn, with WithAttrs for example, hold their
+// records in it as well. Records logged after Commit or Rollback are
+// written at once. A Txn is safe for concurrent use.
+type Txn struct {
+	*Logger
+	name  string
+	base  *Logger
+	start time.Time
+
+	mu      sync.Mutex
+	records []txnRecord
+	done    bool
+}
+
+// txnRecord is a record held by a Txn with the handler it is destined to.
+type txnRecord struct {
+	h Handler
+	r Record
+}
+
+// Begin starts a Txn named name logging with l.
+func (l *Logger) Begin(name string) *Txn {
+	t := &Txn{name: name, base: l, start: time.Now()}
+	t.Logger = l.with(func(h Handler) Handler { return &txnHandler{h, t} })
+	return t
+}
+
+// Commit ends the Txn successfully. If none of its records is at LevelWarn
+// or above, they are discarded and a single "<name> committed" record is
+// logged at LevelInfo with their number and the duration of the Txn;
+// otherwise they are written, followed by that record. Only the first call
+// of Commit or Rollback has an effect.
+func (t *Txn) Commit() {
+	records, ok := t.end()
+	if !ok {
+		return
+	}
+	for _, tr := range records {
+		level := tr.r.Level
+		if l, ok := tr.r.levelOverride(); ok {
+			level = l
+		}
+		if level >= LevelWarn {
+			t.write(records)
+			break
+		}
+	}
+	t.base.log(LevelInfo, t.name+" committed", []any{Int("records", len(records)), Duration("duration", time.Since(t.start))})
+}
+
+// Rollback ends the Txn unsuccessfully: its records are written, followed
+// by a "<name> rolled back" record at LevelWarn with their number and the
+// duration of the Txn. Only the first call of Commit or Rollback has an
+// effect.
+func (t *Txn) Rollback() {
+	records, ok := t.end()
+	if !ok {
+		return
+	}
+	t.write(records)
+	t.base.log(LevelWarn, t.name+" rolled back", []any{Int("records", len(records)), Duration("duration", time.Since(t.start))})
+}
+
+// end marks t as done and returns the records it holds, or false if t
+// was already done.
+func (t *Txn) end() ([]txnRecord, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.done {
+		return nil, false
+	}
+	t.done = true
+	records := t.records
+	t.records = nil
+	return records, true
+}
+
+// write passes the held records to their handlers.
+func (t *Txn) write(records []txnRecord) {
+	for _, tr := range records {
+		if err := tr.h.Handle(tr.r); err != nil {
+			FallbackErrorf("unable to write log message: %v", err)
+		}
+	}
+}
+
+// txnHandler holds the records passed to h in a Txn until it ends.
+type txnHandler struct {
+	h Handler
+	t *Txn
+}
+
+func (h *txnHandler) Enabled(level Level) bool {
+	return h.h.Enabled(level)
+}
+
+func (h *txnHandler) Handle(r Record) error {
+	h.t.mu.Lock()
+	if h.t.done {
+		h.t.mu.Unlock()
+		return h.h.Handle(r)
+	}
+	h.t.records = append(h.t.records, txnRecord{h.h, r.Clone()})
+	h.t.mu.Unlock()
+	return nil
+}
+
+func (h *txnHandler) Flush() error {
+	return flush(h.h)
+}
+
+func (h *txnHandler) Compact() {
+	compact(h.h)
+}
+
+func (h *txnHandler) WithAttrs(attrs []Attr) Handler {
+	return &txnHandler{h.h.WithAttrs(attrs), h.t}
+}
+
+func (h *txnHandler) WithGroup(name string) Handler {
+	return &txnHandler{h.h.WithGroup(name), h.t}
+}
+
+func (h *txnHandler) WithPrefix(prefix string) Handler {
+	return &txnHandler{h.h.WithPrefix(prefix), h.t}
+}
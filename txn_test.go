@@ -0,0 +1,48 @@
+package l4g
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTxn(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(Options{Output: buf, NoColor: true})
+
+	txn := logger.Begin("checkout")
+	txn.Info("cart loaded", "items", 3)
+	txn.WithAttrs("step", 2).Info("payment authorized")
+	if buf.Len() != 0 {
+		t.Errorf("output = %q before Commit, want none", buf.String())
+	}
+	txn.Commit()
+	txn.Rollback()
+	got := buf.String()
+	if strings.Contains(got, "cart loaded") || !strings.Contains(got, "INFO checkout committed records=2 duration=") || strings.Count(got, "\n") != 1 {
+		t.Errorf("output = %q, want only the summary", got)
+	}
+
+	buf.Reset()
+	txn = logger.Begin("checkout")
+	txn.Info("cart loaded")
+	txn.Warn("stock low")
+	txn.Commit()
+	got = buf.String()
+	if !strings.Contains(got, "cart loaded") || !strings.Contains(got, "WARN stock low") || !strings.Contains(got, "checkout committed records=2") {
+		t.Errorf("output = %q, want the records and the summary", got)
+	}
+
+	buf.Reset()
+	txn = logger.Begin("checkout")
+	txn.Info("cart loaded")
+	txn.WithAttrs("step", 2).Error("payment declined")
+	txn.Rollback()
+	txn.Info("after")
+	got = buf.String()
+	for _, want := range []string{"INFO cart loaded\n", "ERROR payment declined step=2\n", "WARN checkout rolled back records=2 duration=", "INFO after\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output = %q, want %q", got, want)
+		}
+	}
+}
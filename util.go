@@ -1,9 +1,11 @@
 package l4g
 
 import (
+	"fmt"
 	"io"
 	"sync"
 	"sync/atomic"
+	"unsafe"
 )
 
 // OutputVar is an atomically updatable io.Writer variable.
@@ -11,8 +13,14 @@ import (
 // It optimizes for the case where the writer is nil or io.Discard
 // by storing a ready flag to avoid unnecessary Write operations.
 type OutputVar struct {
-	ready  atomic.Bool  // true if writer is not nil and not io.Discard
-	writer atomic.Value // holds the io.Writer
+	writer atomic.Pointer[outputWriter] // nil until Set is called
+}
+
+// outputWriter is the writer held by an OutputVar.
+type outputWriter struct {
+	w     io.Writer
+	sw    io.StringWriter // w, if it implements io.StringWriter
+	ready bool            // true if w is not nil and not io.Discard
 }
 
 // NewOutputVar creates a new OutputVar from an io.Writer.
@@ -30,23 +38,26 @@ func NewOutputVar(w io.Writer) *OutputVar {
 // Set atomically sets the output writer.
 // If w is nil or io.Discard, the OutputVar is marked as disabled for optimization.
 func (v *OutputVar) Set(w io.Writer) {
-	v.ready.Store(w != nil && w != io.Discard)
-	v.writer.Store(w)
+	o := &outputWriter{w: w, ready: w != nil && w != io.Discard}
+	o.sw, _ = w.(io.StringWriter)
+	v.writer.Store(o)
 }
 
 // Discard reports whether writes to this OutputVar should be discarded.
 // It returns true if the writer is nil, io.Discard, or not set.
 func (v *OutputVar) Discard() bool {
-	return !v.ready.Load()
+	o := v.writer.Load()
+	return o == nil || !o.ready
 }
 
 // Output returns the current io.Writer.
 // If the writer is nil or marked for discard, it returns io.Discard.
 func (v *OutputVar) Output() io.Writer {
-	if v.Discard() {
+	o := v.writer.Load()
+	if o == nil || !o.ready {
 		return io.Discard
 	}
-	return v.writer.Load().(io.Writer)
+	return o.w
 }
 
 // Write implements io.Writer by writing to the current output writer.
@@ -54,34 +65,123 @@ func (v *OutputVar) Write(p []byte) (int, error) {
 	return v.Output().Write(p)
 }
 
+// WriteString implements io.StringWriter by writing s to the current
+// output writer, without copying it to a byte slice if the writer
+// implements io.StringWriter, as *os.File and *bufio.Writer do.
+func (v *OutputVar) WriteString(s string) (int, error) {
+	o := v.writer.Load()
+	switch {
+	case o == nil || !o.ready:
+		return len(s), nil
+	case o.sw != nil:
+		return o.sw.WriteString(s)
+	}
+	return o.w.Write([]byte(s))
+}
+
+// maxShortWrites is the number of times writeFull writes the rest of a
+// record after a short write before giving up.
+const maxShortWrites = 3
+
+// writeFull writes p to w, writing the remaining bytes again after a short
+// write, up to maxShortWrites times, so that a record is not truncated by
+// a writer that accepts only part of it at once, such as a pipe or a
+// socket under pressure. If the record cannot be written entirely, the
+// error reports the number of bytes lost.
+func writeFull(w io.Writer, p []byte) error {
+	total := len(p)
+	for tries := 0; ; tries++ {
+		n, err := w.Write(p)
+		if n >= len(p) {
+			return err
+		}
+		p = p[max(n, 0):]
+		if err == nil {
+			err = io.ErrShortWrite
+		}
+		if (err != io.ErrShortWrite && n == 0) || tries == maxShortWrites {
+			return fmt.Errorf("l4g: %d of %d bytes lost: %w", len(p), total, err)
+		}
+	}
+}
+
 // buffer is a byte slice used for building log output.
 // It implements efficient Write, WriteByte, and WriteString methods.
 type buffer []byte
 
-// bufPool is a sync.Pool for reusing buffer instances to reduce allocations.
-// Buffers are initially allocated with 1KB capacity.
-var bufPool = sync.Pool{
-	New: func() any {
-		b := make(buffer, 0, 1024)
+// A BufferPool provides the buffers in which the built-in handlers format
+// records. Get returns an empty buffer; Put receives a buffer that is no
+// longer used, emptied, and may drop it. Implementations must be safe for
+// concurrent use.
+type BufferPool interface {
+	Get() *[]byte
+	Put(b *[]byte)
+}
+
+// syncBufferPool is a BufferPool backed by a sync.Pool.
+type syncBufferPool struct {
+	pool    sync.Pool
+	maxSize int
+}
+
+// NewBufferPool returns a BufferPool backed by a sync.Pool, allocating
+// buffers of initialSize bytes and retaining buffers of at most maxSize
+// bytes, so that a rare large record does not keep its buffer alive.
+// The default pool is NewBufferPool(1024, 16<<10).
+func NewBufferPool(initialSize, maxSize int) BufferPool {
+	p := &syncBufferPool{maxSize: maxSize}
+	p.pool.New = func() any {
+		b := make([]byte, 0, initialSize)
 		return &b
-	},
+	}
+	return p
+}
+
+// Get returns a buffer from the pool.
+func (p *syncBufferPool) Get() *[]byte {
+	return p.pool.Get().(*[]byte)
+}
+
+// Put returns b to the pool, unless it is larger than the maximum size.
+func (p *syncBufferPool) Put(b *[]byte) {
+	if cap(*b) <= p.maxSize {
+		p.pool.Put(b)
+	}
+}
+
+var (
+	defaultBufferPool = NewBufferPool(1024, 16<<10)
+	bufferPool        atomic.Pointer[BufferPool]
+)
+
+// SetBufferPool sets the pool of the buffers in which the built-in handlers
+// format records, for example to retain larger buffers in services logging
+// very large records. If p is nil, the default pool is restored.
+func SetBufferPool(p BufferPool) {
+	if p == nil {
+		bufferPool.Store(nil)
+		return
+	}
+	bufferPool.Store(&p)
+}
+
+// currentBufferPool returns the pool set by SetBufferPool, or the default.
+func currentBufferPool() BufferPool {
+	if p := bufferPool.Load(); p != nil {
+		return *p
+	}
+	return defaultBufferPool
 }
 
 // newBuffer gets a buffer from the pool.
 func newBuffer() *buffer {
-	return bufPool.Get().(*buffer)
+	return (*buffer)(currentBufferPool().Get())
 }
 
-// Free returns the buffer to the pool for reuse if it's not too large.
-// Buffers larger than 16KB are discarded to avoid keeping large allocations.
+// Free empties the buffer and returns it to the pool for reuse.
 func (b *buffer) Free() {
-	// To reduce peak allocation, return only
-	// smaller buffers to the pool.
-	const maxBufferSize = 16 << 10
-	if cap(*b) <= maxBufferSize {
-		*b = (*b)[:0]
-		bufPool.Put(b)
-	}
+	*b = (*b)[:0]
+	currentBufferPool().Put((*[]byte)(b))
 }
 
 // Write appends bytes to the buffer.
@@ -90,11 +190,19 @@ func (b *buffer) Write(bytes []byte) {
 }
 
 // WriteByte appends a single byte to the buffer.
-func (b *buffer) WriteByte(char byte) {
+// It implements [io.ByteWriter] and never returns an error.
+func (b *buffer) WriteByte(char byte) error {
 	*b = append(*b, char)
+	return nil
 }
 
 // WriteString appends a string to the buffer.
 func (b *buffer) WriteString(str string) {
 	*b = append(*b, str...)
 }
+
+// view returns the contents of the buffer as a string without copying
+// them. The string must not be used after the buffer is modified or freed.
+func (b *buffer) view() string {
+	return unsafe.String(unsafe.SliceData(*b), len(*b))
+}
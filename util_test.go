@@ -1,10 +1,15 @@
 package l4g
 
 import (
+	"bufio"
 	"bytes"
+	"errors"
 	"io"
+	"os"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestOutputVar_NewOutputVar(t *testing.T) {
@@ -86,24 +91,50 @@ func TestOutputVar_Write(t *testing.T) {
 	}
 }
 
+// plainWriter is an io.Writer that does not implement io.StringWriter.
+type plainWriter struct{ buf bytes.Buffer }
+
+func (w *plainWriter) Write(p []byte) (int, error) { return w.buf.Write(p) }
+
+func TestOutputVar_WriteString(t *testing.T) {
+	buf := &bytes.Buffer{}
+	ov := NewOutputVar(buf)
+	if n, err := ov.WriteString("fast"); err != nil || n != 4 {
+		t.Errorf("WriteString() = %d, %v", n, err)
+	}
+	if buf.String() != "fast" {
+		t.Errorf("wrote %q, want %q", buf.String(), "fast")
+	}
+
+	// a writer of another type, without WriteString
+	pw := &plainWriter{}
+	ov.Set(pw)
+	if n, err := ov.WriteString("slow"); err != nil || n != 4 {
+		t.Errorf("WriteString() = %d, %v", n, err)
+	}
+	if pw.buf.String() != "slow" {
+		t.Errorf("wrote %q, want %q", pw.buf.String(), "slow")
+	}
+
+	ov.Set(nil)
+	if n, err := ov.WriteString("dropped"); err != nil || n != 7 {
+		t.Errorf("WriteString() to nil writer = %d, %v", n, err)
+	}
+}
+
 func TestOutputVar_Discard(t *testing.T) {
 	tests := []struct {
 		name   string
 		writer io.Writer
 		want   bool
-		skip   bool
 	}{
-		{"normal buffer", &bytes.Buffer{}, false, false},
-		{"nil writer", nil, true, true},
-		{"io.Discard", io.Discard, true, false},
+		{"normal buffer", &bytes.Buffer{}, false},
+		{"nil writer", nil, true},
+		{"io.Discard", io.Discard, true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if tt.skip {
-				t.Skip("atomic.Value cannot store nil")
-				return
-			}
 			ov := NewOutputVar(tt.writer)
 			if got := ov.Discard(); got != tt.want {
 				t.Errorf("OutputVar.Discard() = %v, want %v", got, tt.want)
@@ -157,6 +188,47 @@ func TestOutputVar_ConcurrentSet(t *testing.T) {
 	t.Skip("Concurrent Set() is not supported - Set() should only be called from a single goroutine")
 }
 
+// shortWriter accepts at most max bytes per Write, failing with err
+// once fail writes have been made if err is set.
+type shortWriter struct {
+	bytes.Buffer
+	max, fail, writes int
+	err               error
+}
+
+func (w *shortWriter) Write(p []byte) (int, error) {
+	w.writes++
+	if w.err != nil && w.writes > w.fail {
+		return 0, w.err
+	}
+	return w.Buffer.Write(p[:min(len(p), w.max)])
+}
+
+func TestWriteFull(t *testing.T) {
+	w := &shortWriter{max: 4}
+	if err := writeFull(w, []byte("0123456789\n")); err != nil || w.String() != "0123456789\n" {
+		t.Errorf("writeFull() = %v, wrote %q, want the whole record", err, w.String())
+	}
+
+	w = &shortWriter{max: 4, fail: 1, err: io.ErrClosedPipe}
+	err := writeFull(w, []byte("0123456789\n"))
+	if !errors.Is(err, io.ErrClosedPipe) || !strings.Contains(err.Error(), "7 of 11 bytes lost") {
+		t.Errorf("writeFull() = %v, want 7 bytes lost", err)
+	}
+
+	w = &shortWriter{max: 1}
+	err = writeFull(w, []byte("0123456789\n"))
+	if !errors.Is(err, io.ErrShortWrite) || w.writes != maxShortWrites+1 {
+		t.Errorf("writeFull() = %v after %d writes, want a bounded retry", err, w.writes)
+	}
+
+	out := &shortWriter{max: 16}
+	New(Options{Output: out, NoColor: true}).Info("a message longer than one write")
+	if got := out.String(); !strings.HasSuffix(got, "INFO a message longer than one write\n") {
+		t.Errorf("output = %q, want the whole line", got)
+	}
+}
+
 func TestBuffer_NewBuffer(t *testing.T) {
 	buf := newBuffer()
 	if buf == nil {
@@ -248,6 +320,63 @@ func TestBuffer_Free(t *testing.T) {
 	})
 }
 
+// countingPool is a BufferPool counting its calls.
+type countingPool struct {
+	gets, puts int
+}
+
+func (p *countingPool) Get() *[]byte {
+	p.gets++
+	b := make([]byte, 0, 64)
+	return &b
+}
+
+func (p *countingPool) Put(b *[]byte) {
+	p.puts++
+	if len(*b) != 0 {
+		panic("buffer not emptied before Put")
+	}
+}
+
+func TestSetBufferPool(t *testing.T) {
+	p := &countingPool{}
+	SetBufferPool(p)
+	defer SetBufferPool(nil)
+
+	var out bytes.Buffer
+	h := NewSimpleHandler(HandlerOptions{Output: &out, NoColor: true})
+	if err := h.Handle(NewRecord(time.Now(), LevelInfo, "pooled")); err != nil {
+		t.Fatal(err)
+	}
+	if p.gets == 0 || p.gets != p.puts {
+		t.Errorf("pool used for %d gets and %d puts", p.gets, p.puts)
+	}
+	if !strings.Contains(out.String(), "pooled") {
+		t.Errorf("output = %q", out.String())
+	}
+
+	SetBufferPool(nil)
+	gets := p.gets
+	newBuffer().Free()
+	if p.gets != gets {
+		t.Error("SetBufferPool(nil) did not restore the default pool")
+	}
+}
+
+func TestNewBufferPool(t *testing.T) {
+	p := NewBufferPool(32, 64)
+	b := p.Get()
+	if len(*b) != 0 || cap(*b) != 32 {
+		t.Errorf("Get() = len %d cap %d, want len 0 cap 32", len(*b), cap(*b))
+	}
+	*b = append(*b, make([]byte, 100)...)
+	*b = (*b)[:0]
+	p.Put(b) // larger than maxSize: dropped
+	if b2 := p.Get(); cap(*b2) != 32 {
+		t.Errorf("oversized buffer retained: cap %d", cap(*b2))
+	}
+}
+
 func TestBuffer_Growth(t *testing.T) {
 	buf := newBuffer()
 	defer buf.Free()
@@ -287,3 +416,31 @@ func BenchmarkBuffer_WriteByte(b *testing.B) {
 		buf.Free()
 	}
 }
+
+func BenchmarkOutputVar_File(b *testing.B) {
+	f, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Skip(err)
+	}
+	defer f.Close()
+	benchmarkOutputVar(b, f)
+}
+
+func BenchmarkOutputVar_Bufio(b *testing.B) {
+	benchmarkOutputVar(b, bufio.NewWriter(io.Discard))
+}
+
+func benchmarkOutputVar(b *testing.B, w io.Writer) {
+	ov := NewOutputVar(w)
+	s := strings.Repeat("a log line of some length ", 10)
+	b.Run("Write", func(b *testing.B) {
+		for b.Loop() {
+			ov.Write([]byte(s))
+		}
+	})
+	b.Run("WriteString", func(b *testing.B) {
+		for b.Loop() {
+			ov.WriteString(s)
+		}
+	})
+}